@@ -53,6 +53,8 @@ const (
 	ApplyRequest_TYPE_REPLICATION_REPLICATE                  ApplyRequest_Type = 200
 	ApplyRequest_TYPE_REPLICATION_REPLICATE_UPDATE_STATE     ApplyRequest_Type = 201
 	ApplyRequest_TYPE_REPLICATION_REPLICATE_ABORT            ApplyRequest_Type = 202
+	ApplyRequest_TYPE_REPLICATION_REPLICATE_PURGE            ApplyRequest_Type = 203
+	ApplyRequest_TYPE_REPLICATION_REPLICATE_FORCE_STATE      ApplyRequest_Type = 204
 	ApplyRequest_TYPE_REPLICATION_REPLICA_DISABLE            ApplyRequest_Type = 210
 	ApplyRequest_TYPE_REPLICATION_REPLICA_DELETE             ApplyRequest_Type = 211
 	ApplyRequest_TYPE_DISTRIBUTED_TASK_ADD                   ApplyRequest_Type = 300
@@ -90,6 +92,8 @@ var (
 		200: "TYPE_REPLICATION_REPLICATE",
 		201: "TYPE_REPLICATION_REPLICATE_UPDATE_STATE",
 		202: "TYPE_REPLICATION_REPLICATE_ABORT",
+		203: "TYPE_REPLICATION_REPLICATE_PURGE",
+		204: "TYPE_REPLICATION_REPLICATE_FORCE_STATE",
 		210: "TYPE_REPLICATION_REPLICA_DISABLE",
 		211: "TYPE_REPLICATION_REPLICA_DELETE",
 		300: "TYPE_DISTRIBUTED_TASK_ADD",
@@ -124,6 +128,8 @@ var (
 		"TYPE_REPLICATION_REPLICATE":                  200,
 		"TYPE_REPLICATION_REPLICATE_UPDATE_STATE":     201,
 		"TYPE_REPLICATION_REPLICATE_ABORT":            202,
+		"TYPE_REPLICATION_REPLICATE_PURGE":            203,
+		"TYPE_REPLICATION_REPLICATE_FORCE_STATE":      204,
 		"TYPE_REPLICATION_REPLICA_DISABLE":            210,
 		"TYPE_REPLICATION_REPLICA_DELETE":             211,
 		"TYPE_DISTRIBUTED_TASK_ADD":                   300,
@@ -1458,13 +1464,13 @@ const file_api_message_proto_rawDesc = "" +
 	"\x11NotifyPeerRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
 	"\aaddress\x18\x02 \x01(\tR\aaddress\"\x14\n" +
-	"\x12NotifyPeerResponse\"\xd0\b\n" +
+	"\x12NotifyPeerResponse\"\xa4\t\n" +
 	"\fApplyRequest\x12@\n" +
 	"\x04type\x18\x01 \x01(\x0e2,.weaviate.internal.cluster.ApplyRequest.TypeR\x04type\x12\x14\n" +
 	"\x05class\x18\x02 \x01(\tR\x05class\x12\x18\n" +
 	"\aversion\x18\x03 \x01(\x04R\aversion\x12\x1f\n" +
 	"\vsub_command\x18\x04 \x01(\fR\n" +
-	"subCommand\"\xac\a\n" +
+	"subCommand\"\x80\b\n" +
 	"\x04Type\x12\x14\n" +
 	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x12\n" +
 	"\x0eTYPE_ADD_CLASS\x10\x01\x12\x15\n" +
@@ -1493,6 +1499,8 @@ const file_api_message_proto_rawDesc = "" +
 	"\x1aTYPE_REPLICATION_REPLICATE\x10\xc8\x01\x12,\n" +
 	"'TYPE_REPLICATION_REPLICATE_UPDATE_STATE\x10\xc9\x01\x12%\n" +
 	" TYPE_REPLICATION_REPLICATE_ABORT\x10\xca\x01\x12%\n" +
+	" TYPE_REPLICATION_REPLICATE_PURGE\x10\xcb\x01\x12+\n" +
+	"&TYPE_REPLICATION_REPLICATE_FORCE_STATE\x10\xcc\x01\x12%\n" +
 	" TYPE_REPLICATION_REPLICA_DISABLE\x10\xd2\x01\x12$\n" +
 	"\x1fTYPE_REPLICATION_REPLICA_DELETE\x10\xd3\x01\x12\x1e\n" +
 	"\x19TYPE_DISTRIBUTED_TASK_ADD\x10\xac\x02\x12!\n" +
@@ -45,6 +45,14 @@ type AddReplicaToShardRequest struct {
 	SchemaVersion         uint64
 }
 
+type DeleteReplicaFromShardRequest struct {
+	Class, Shard, Replica string
+	SchemaVersion         uint64
+	// MinReadyReplicas is the minimum number of READY replicas the shard must keep once the replica is removed.
+	// It is set by the proposer so that every node applying the request decides the same way.
+	MinReadyReplicas int
+}
+
 type QueryReadOnlyClassesRequest struct {
 	Classes []string
 }
@@ -11,6 +11,8 @@
 
 package api
 
+import "time"
+
 const (
 	ReplicationCommandVersionV0 = iota
 )
@@ -39,6 +41,20 @@ type ReplicationReplicateShardRequest struct {
 	SourceShard      string
 
 	TargetNode string
+
+	// CostCenter optionally tags the operation to attribute its replication cost to a team or namespace
+	CostCenter string `json:",omitempty"`
+
+	// CampaignID optionally groups the operation with the other operations submitted together, e.g. for a rebalance
+	CampaignID string `json:",omitempty"`
+
+	// Priority optionally orders the operation relative to the others, higher priority operations being emitted for
+	// replication first
+	Priority int `json:",omitempty"`
+
+	// Deadline optionally sets the time after which the operation is not started anymore, as read on the node
+	// submitting it
+	Deadline time.Time `json:",omitzero"`
 }
 
 type ReplicationReplicateShardReponse struct{}
@@ -48,6 +64,10 @@ type ReplicationUpdateOpStateRequest struct {
 
 	Id    uint64
 	State ShardReplicationState
+
+	// CommittedBatches optionally records the number of object batches a batched copy already committed to the
+	// target replica, so that the copy resumes from there. It is kept by updates not setting it.
+	CommittedBatches *int `json:",omitempty"`
 }
 
 type ReplicationUpdateOpStateResponse struct{}
@@ -60,6 +80,27 @@ type ReplicationDeleteOpRequest struct {
 
 type ReplicationDeleteOpResponse struct{}
 
+type ReplicationPurgeOpsRequest struct {
+	Version int
+
+	// Ids are the terminal ops to purge, ops not READY or ABORTED anymore being kept
+	Ids []uint64
+}
+
+type ReplicationPurgeOpsResponse struct{}
+
+type ReplicationForceOpStateRequest struct {
+	Version int
+
+	Id    uint64
+	State ShardReplicationState
+
+	// Reason explains why the state is forced, recorded with the state until the next transition of the op
+	Reason string
+}
+
+type ReplicationForceOpStateResponse struct{}
+
 type ReplicationDetailsRequest struct {
 	Id uint64
 }
@@ -13,10 +13,13 @@ package cluster
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	cmd "github.com/weaviate/weaviate/cluster/proto/api"
+	"github.com/weaviate/weaviate/cluster/replication/types"
 	"github.com/weaviate/weaviate/cluster/router"
 	"github.com/weaviate/weaviate/cluster/schema"
 	"github.com/weaviate/weaviate/usecases/cluster"
@@ -76,6 +79,16 @@ func (s *Raft) SchemaReader() schema.SchemaReader {
 	return s.store.SchemaReader()
 }
 
+// ShardReplicas returns the nodes currently holding a replica of the given shard, see schema.SchemaReader.ShardReplicas.
+// The error returned when the shard or its class does not exist wraps types.ErrShardNotFound.
+func (s *Raft) ShardReplicas(class, shard string) ([]string, error) {
+	replicas, err := s.store.SchemaReader().ShardReplicas(class, shard)
+	if errors.Is(err, schema.ErrShardNotFound) || errors.Is(err, schema.ErrClassNotFound) {
+		return nil, fmt.Errorf("%w: %w", types.ErrShardNotFound, err)
+	}
+	return replicas, err
+}
+
 func (s *Raft) NewRouter(logger *logrus.Logger) *router.Router {
 	return router.New(logger, s.store.cfg.NodeSelector, s.store.SchemaReader(), s.store.replicationManager.GetReplicationFSM())
 }
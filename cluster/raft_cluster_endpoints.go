@@ -13,6 +13,7 @@ package cluster
 
 import (
 	"context"
+	"fmt"
 	"slices"
 
 	"github.com/sirupsen/logrus"
@@ -26,6 +27,23 @@ func (s *Raft) LeaderWithID() (string, string) {
 	return string(addr), string(id)
 }
 
+// Ping implements replication types.LeaderPinger by sending the leader a cheap query, counting the collections, so
+// that an unreachable leader is reported as well. It returns an error decorating types.ErrLeaderNotFound if there is
+// no known leader.
+func (s *Raft) Ping(ctx context.Context) error {
+	if s.store.IsLeader() {
+		return nil
+	}
+	leader := s.store.Leader()
+	if leader == "" {
+		return s.leaderErr()
+	}
+	if _, err := s.cl.Query(ctx, leader, &cmd.QueryRequest{Type: cmd.QueryRequest_TYPE_GET_COLLECTIONS_COUNT}); err != nil {
+		return fmt.Errorf("ping leader %s: %w", leader, err)
+	}
+	return nil
+}
+
 // StorageCandidates return the nodes in the raft configuration or memberlist storage nodes
 // based on the current configuration of the cluster if it does have  MetadataVoterOnly nodes.
 func (s *Raft) StorageCandidates() []string {
@@ -14,11 +14,13 @@ package cluster
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/weaviate/weaviate/cluster/proto/api"
 	"github.com/weaviate/weaviate/cluster/replication"
 	replicationTypes "github.com/weaviate/weaviate/cluster/replication/types"
+	"github.com/weaviate/weaviate/usecases/config/runtime"
 )
 
 func (s *Raft) ReplicationReplicateReplica(sourceNode string, sourceCollection string, sourceShard string, targetNode string) error {
@@ -52,8 +54,37 @@ func (s *Raft) ReplicationDisableReplica(node string, collection string, shard s
 	return fmt.Errorf("not implemented")
 }
 
+// ReplicationDeleteReplica removes the replica of the shard on node from the sharding state. The removal is rejected,
+// with an error wrapping replication.ErrNotEnoughReadyReplicas, while it would leave the shard with fewer READY
+// replicas than the configured minimum. The minimum is carried by the request and checked again when the request is
+// applied, so that every node decides the same way.
 func (s *Raft) ReplicationDeleteReplica(node string, collection string, shard string) error {
-	return fmt.Errorf("not implemented")
+	req := &api.DeleteReplicaFromShardRequest{
+		Class:            collection,
+		Shard:            shard,
+		Replica:          node,
+		MinReadyReplicas: runtime.GetOverrides(s.store.cfg.ReplicationMinReadyReplicas, s.store.cfg.ReplicationMinReadyReplicasFn),
+	}
+
+	subCommand, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	command := &api.ApplyRequest{
+		Type:       api.ApplyRequest_TYPE_REPLICATION_REPLICA_DELETE,
+		Class:      collection,
+		SubCommand: subCommand,
+	}
+	if err := s.store.replicationManager.ValidateDeleteReplica(command); err != nil {
+		if errors.Is(err, replication.ErrNotEnoughReadyReplicas) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", replicationTypes.ErrInvalidRequest, err)
+	}
+	if _, err := s.Execute(context.Background(), command); err != nil {
+		return err
+	}
+	return nil
 }
 
 func (s *Raft) ReplicationUpdateReplicaOpStatus(id uint64, state api.ShardReplicationState) error {
@@ -76,3 +107,73 @@ func (s *Raft) ReplicationUpdateReplicaOpStatus(id uint64, state api.ShardReplic
 	}
 	return nil
 }
+
+// ReplicationForceReplicaOpState implements types.OpStateForcer by setting the state of the op on every node, bypassing
+// the transition guards, and recording the reason it was forced.
+func (s *Raft) ReplicationForceReplicaOpState(id uint64, state api.ShardReplicationState, reason string) error {
+	req := &api.ReplicationForceOpStateRequest{
+		Version: api.ReplicationCommandVersionV0,
+		Id:      id,
+		State:   state,
+		Reason:  reason,
+	}
+
+	subCommand, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	command := &api.ApplyRequest{
+		Type:       api.ApplyRequest_TYPE_REPLICATION_REPLICATE_FORCE_STATE,
+		SubCommand: subCommand,
+	}
+	if _, err := s.Execute(context.Background(), command); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReplicationStoreOpCheckpoint implements types.OpCheckpointStore by recording the number of object batches the
+// batched copy of the op committed, while the op stays HYDRATING.
+func (s *Raft) ReplicationStoreOpCheckpoint(id uint64, committedBatches int) error {
+	req := &api.ReplicationUpdateOpStateRequest{
+		Version:          api.ReplicationCommandVersionV0,
+		Id:               id,
+		State:            api.HYDRATING,
+		CommittedBatches: &committedBatches,
+	}
+
+	subCommand, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	command := &api.ApplyRequest{
+		Type:       api.ApplyRequest_TYPE_REPLICATION_REPLICATE_UPDATE_STATE,
+		SubCommand: subCommand,
+	}
+	if _, err := s.Execute(context.Background(), command); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReplicationPurgeOps implements types.OpPurger by deleting the given ops from the FSM of every node, as long as they
+// are still READY or ABORTED when the purge is applied.
+func (s *Raft) ReplicationPurgeOps(ids []uint64) error {
+	req := &api.ReplicationPurgeOpsRequest{
+		Version: api.ReplicationCommandVersionV0,
+		Ids:     ids,
+	}
+
+	subCommand, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	command := &api.ApplyRequest{
+		Type:       api.ApplyRequest_TYPE_REPLICATION_REPLICATE_PURGE,
+		SubCommand: subCommand,
+	}
+	if _, err := s.Execute(context.Background(), command); err != nil {
+		return err
+	}
+	return nil
+}
@@ -60,6 +60,7 @@ func TestRaftEndpoints(t *testing.T) {
 	assert.ErrorIs(t, err, types.ErrLeaderNotFound)
 	assert.ErrorIs(t, srv.Join(ctx, m.store.cfg.NodeID, addr, true), types.ErrLeaderNotFound)
 	assert.ErrorIs(t, srv.Remove(ctx, m.store.cfg.NodeID), types.ErrLeaderNotFound)
+	assert.ErrorIs(t, srv.Ping(ctx), types.ErrLeaderNotFound)
 
 	// Deadline exceeded while waiting for DB to be restored
 	func() {
@@ -83,6 +84,7 @@ func TestRaftEndpoints(t *testing.T) {
 	assert.True(t, tryNTimesWithWait(10, time.Millisecond*200, srv.Ready))
 	tryNTimesWithWait(20, time.Millisecond*100, srv.store.IsLeader)
 	assert.True(t, srv.store.IsLeader())
+	assert.NoError(t, srv.Ping(ctx))
 	schemaReader := srv.SchemaReader()
 	assert.Equal(t, schemaReader.Len(), 0)
 
@@ -13,18 +13,58 @@ package replication
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/sirupsen/logrus"
 	"github.com/weaviate/weaviate/cluster/proto/api"
 	"github.com/weaviate/weaviate/cluster/replication/types"
 	enterrors "github.com/weaviate/weaviate/entities/errors"
 )
 
+var (
+	// ErrConsumerDeadlineExceeded is returned by CopyOpConsumer.Consume when it stops because its context deadline
+	// expired. The returned error also wraps context.DeadlineExceeded.
+	ErrConsumerDeadlineExceeded = errors.New("replication consumer deadline exceeded")
+	// ErrConsumerCanceled is returned by CopyOpConsumer.Consume when it stops because its context was canceled.
+	// The returned error also wraps context.Canceled.
+	ErrConsumerCanceled = errors.New("replication consumer canceled")
+	// ErrOpDeadlineExceeded is returned when an operation is not started because its deadline has passed.
+	ErrOpDeadlineExceeded = errors.New("replication operation deadline exceeded")
+	// ErrObjectCountMismatch is returned when the target replica of a completed copy does not hold as many objects
+	// as the source replica.
+	ErrObjectCountMismatch = errors.New("replica object count mismatch")
+	// ErrEncryptionUnsupported is returned when encrypted copies are required but the replica copier does not
+	// implement types.EncryptedReplicaCopier.
+	ErrEncryptionUnsupported = errors.New("replica copier does not support encrypted transport")
+	// ErrQuorumAckUnsupported is returned when a quorum acknowledgment of new replicas is required but the leader FSM
+	// updater does not implement types.ReplicaQuorumWaiter.
+	ErrQuorumAckUnsupported = errors.New("FSM updater does not support waiting for a replica quorum")
+	// ErrStagedCopyUnsupported is returned when staged copies are required but the replica copier does not implement
+	// types.StagedReplicaCopier, or the copy is encrypted.
+	ErrStagedCopyUnsupported = errors.New("replica copier does not support staged copies")
+	// ErrCopyVerificationUnsupported is returned when copies must be verified but the replica copier does not
+	// implement types.ObjectCountingReplicaCopier.
+	ErrCopyVerificationUnsupported = errors.New("replica copier does not support verifying copies")
+	// errShardDeleted aborts the copy of an operation whose shard was deleted from the sharding state. It is returned
+	// by processReplicationOp once the operation is ABORTED.
+	errShardDeleted = errors.New("replicated shard deleted")
+)
+
+// Transport label values of the replica copy attempts metric
+const (
+	transportEncrypted = "encrypted"
+	transportPlaintext = "plaintext"
+)
+
 // OpConsumer is an interface for consuming replication operations.
 type OpConsumer interface {
 	// Consume starts consuming operations from the provided channel.
@@ -49,27 +89,264 @@ type CopyOpConsumer struct {
 	// It abstracts the mechanics of data replication and file copying.
 	replicaCopier types.ReplicaCopier
 
-	// backoffPolicy defines the retry mechanism for failed operations.
-	// It allows the consumer to retry replication operations using a backoff strategy in case of failure.
-	backoffPolicy backoff.BackOff
+	// newBackoffPolicy creates the retry mechanism for failed operations.
+	// It allows the consumer to retry replication operations using a backoff strategy in case of failure. A new
+	// policy is created for each operation, so that concurrent operations do not share the state of their policy.
+	newBackoffPolicy func() backoff.BackOff
+
+	// categoryBackoffs creates, per error category, the retry mechanism for failed copies whose last error falls in
+	// the category. Copies failing with an error of another category are retried using newBackoffPolicy.
+	categoryBackoffs map[ErrorCategory]func() backoff.BackOff
+
+	// newShardingUpdateBackoff creates the retry mechanism for the sharding state update committed once the copy
+	// succeeded, together with the surrounding FINALIZING and READY status updates. When nil, newBackoffPolicy is
+	// used.
+	newShardingUpdateBackoff func() backoff.BackOff
 
 	// maxWorkers sets the maximum number of concurrent workers that will be used to process replication operations.
 	// It controls the level of parallelism in the replication process allowing multiple replication operations to
-	// run concurrently.
-	maxWorkers int
+	// run concurrently. It can be changed at runtime with SetMaxWorkers.
+	maxWorkers atomic.Int32
 
 	// opTimeout defines the timeout duration for each replication operation.
 	// It ensures that operations do not hang indefinitely and are retried or terminated after the timeout period.
 	opTimeout time.Duration
 
+	// maxQueueWait, when greater than zero, is the maximum time an operation may wait in the engine queue. Operations
+	// dequeued after waiting longer are canceled rather than processed.
+	maxQueueWait time.Duration
+
 	// timeProvider abstracts time operations, allowing for easier testing and mocking of time-related functions.
 	timeProvider TimeProvider
 
-	// tokens controls the maximum number of concurrently running consumers
+	// shardUpdateLocks, when set, serializes the status updates of operations replicating the same shard.
+	shardUpdateLocks *shardLocks
+
+	// asyncStatusUpdate makes the consumer start copying a replica while the HYDRATING status update is in flight.
+	asyncStatusUpdate bool
+
+	// tlsConfig, when set, requires replicas to be copied over a transport encrypted with this configuration.
+	tlsConfig *tls.Config
+
+	// stagedCopy makes the consumer copy replicas into a staging area, promoted to the live replica once verified.
+	stagedCopy bool
+
+	// batchCommit makes the consumer copy replicas in batches of objects committed one at a time, when the replica
+	// copier supports it.
+	batchCommit bool
+
+	// outcomeWriter, when set, receives a JSON record of every operation reaching a terminal outcome.
+	outcomeWriter *opOutcomeWriter
+
+	// fsmWrites, when set, holds a token for every FSM write in flight, capping how many are issued concurrently.
+	fsmWrites chan struct{}
+	// opSlots, when set, holds a slot for every operation held by a worker or waiting for a worker token, enforcing
+	// the global concurrency cap set with WithMaxConcurrentOps.
+	opSlots chan struct{}
+
+	// webhook, when set, receives a JSON record of every operation reaching a terminal outcome.
+	webhook *opCompletionWebhook
+
+	// clockSkewTolerance is the clock skew between nodes allowed for by time-based checks, see elapsedSince.
+	clockSkewTolerance time.Duration
+
+	// tokens controls the maximum number of concurrently running consumers. Its capacity is at least
+	// maxWorkersCapacity, the tokens beyond the worker limit being held as reserved tokens.
 	tokens chan struct{}
 
 	// nodeId uniquely identifies the node on which this consumer instance is running.
 	nodeId string
+
+	// clusterLoadProvider reports the cluster-wide number of in-flight replication operations. When set, the
+	// consumer delays starting new operations while the cluster load is at or above maxClusterInFlightOps.
+	clusterLoadProvider types.ClusterLoadProvider
+
+	// maxClusterInFlightOps is the cluster-wide number of in-flight operations above which the cluster is
+	// considered saturated.
+	maxClusterInFlightOps int
+
+	// clusterLoadPollInterval is how long the consumer waits before checking the cluster load again while the
+	// cluster is saturated.
+	clusterLoadPollInterval time.Duration
+
+	// sourceQueryLoadProvider reports the query load of the source shard replicas. When set, the consumer delays
+	// operations reading from a source replica whose query load is above maxSourceQueryLoad, checking it again every
+	// sourceQueryLoadPollInterval.
+	sourceQueryLoadProvider     types.SourceQueryLoadProvider
+	maxSourceQueryLoad          float64
+	sourceQueryLoadPollInterval time.Duration
+
+	// nodeScheduleProvider reports the maintenance windows of the nodes. When set, the consumer defers operations
+	// targeting a node under maintenance until the window ends, checking the schedule again at least every
+	// nodeSchedulePollInterval.
+	nodeScheduleProvider     types.NodeScheduleProvider
+	nodeSchedulePollInterval time.Duration
+
+	// pausedOps tracks the paused operations, consulted when dequeuing and when retrying operations.
+	pausedOps *pausedOps
+
+	// inFlightOps tracks the operations currently held by a worker.
+	inFlightOps *inFlightOps
+
+	// healingOps tracks the operations whose finalization is currently being healed, see WithFinalizationHealing.
+	healingOps *inFlightOps
+
+	// outcomes tracks the success rate of the most recent operations, when requested by the engine.
+	outcomes *opOutcomes
+
+	// allowedCollections restricts the processed operations to these collections, when not empty.
+	allowedCollections map[string]struct{}
+
+	// strictTargetNode skips the operations whose target node is not the node of the consumer.
+	strictTargetNode bool
+
+	// copyTimeout and bookkeepingTimeout bound the copy phase and the bookkeeping phase finalizing the operation, in
+	// addition to opTimeout, when greater than zero. They are measured using timer.
+	copyTimeout        time.Duration
+	bookkeepingTimeout time.Duration
+	timer              Timer
+
+	// requireQuorumAck makes operations wait, up to quorumAckTimeout per attempt, for a quorum of the shard replicas to
+	// acknowledge the new replica before being marked READY.
+	requireQuorumAck bool
+	quorumAckTimeout time.Duration
+
+	// verifyCopies makes the consumer verify copies by comparing object counts, see WithCopyVerification, and
+	// verificationSampleRate is the fraction of operations whose copy is verified, see WithVerificationSampleRate.
+	verifyCopies           bool
+	verificationSampleRate float64
+
+	// completions and pending measure the consumer throughput and backlog, see EstimatedDrainTime.
+	completions opCompletions
+
+	// copiedBytes measures the bytes copied over the recent throughput window, see CurrentThroughput.
+	copiedBytes *copiedBytesWindow
+	pending     atomic.Int64
+
+	// quarantine holds the operations whose processing panicked, when enabled with WithPanicQuarantine.
+	quarantine *opQuarantine
+
+	// failedOps keeps the most recently failed operations, when enabled with WithFailedOpsHistory.
+	failedOps *failedOps
+
+	// errorLogs limits the logging of identical errors of the same operation, when enabled with WithErrorLogInterval.
+	errorLogs *opErrorLogLimiter
+
+	// blockedOps tracks why received operations are not progressing, see OpBlockReason.
+	blockedOps *opBlockReasons
+
+	// resumedOps holds the operations resumed after being held while paused, waiting to be dispatched again.
+	resumedOps     []ShardReplicationOp
+	resumedOpsLock sync.Mutex
+
+	// resumeSignal notifies the consume loop that resumed operations are waiting to be dispatched.
+	resumeSignal chan struct{}
+
+	// registerer is used to register the consumer metrics. When nil, metrics are still collected but not registered.
+	registerer prometheus.Registerer
+
+	// bytesCopied counts the bytes copied by successful replica copies, labeled by the operation cost center.
+	bytesCopied *prometheus.CounterVec
+
+	// copiesByTransport counts the replica copy attempts, labeled by whether their transport is encrypted.
+	copiesByTransport *prometheus.CounterVec
+
+	// sourceReadConcurrency tracks the number of copies concurrently reading from each source shard replica, counted
+	// in sourceReads so that the series of a replica no longer read from is deleted.
+	sourceReadConcurrency *prometheus.GaugeVec
+	sourceReadsLock       sync.Mutex
+	sourceReads           map[shardFQDN]int
+
+	// queueWaitExceeded counts the operations canceled because they waited longer than maxQueueWait in the queue.
+	queueWaitExceeded prometheus.Counter
+
+	// scalingPolicy, when set, makes the consumer scale the number of workers between minWorkers and maxWorkers
+	// based on the queue depth, re-evaluated on every tick received from scalingTicks.
+	scalingPolicy WorkerScalingPolicy
+	minWorkers    int
+	scalingTicks  <-chan time.Time
+
+	// rampUp, when set, raises the worker limit gradually to maxWorkers every time the consumer starts.
+	rampUp *workerRampUp
+
+	// queueDepth returns the number of operations queued in the engine running the consumer, if any.
+	queueDepth func() int
+
+	// onOpEnd, when set by the engine running the consumer, is called once the consumer is done with an operation.
+	onOpEnd func(id uint64)
+
+	// checkTransition, when set by the engine running the consumer, is called before proposing a status update of an
+	// operation and vetoes the update if it returns an error, see ShardReplicationFSM.AddTransitionGuard.
+	checkTransition func(id uint64, to api.ShardReplicationState) error
+
+	// onOpResume, when set by the engine running the consumer, is called before dispatching an operation resumed after
+	// being held while paused. The operation is not dispatched if it returns an error.
+	onOpResume func(op ShardReplicationOp) error
+
+	// onOpSucceeded, when set by the engine running the consumer, is called once an operation is successfully
+	// processed.
+	onOpSucceeded func(op ShardReplicationOp)
+
+	// onOpOutcome, when set by the engine running the consumer, is called once an operation is processed, with the
+	// error it failed with, if any.
+	onOpOutcome func(op ShardReplicationOp, err error)
+
+	// opCompleteCallbacks are invoked once an operation is marked READY, see OnOpComplete.
+	opCompleteCallbacks opCompleteCallbacks
+
+	// completedOps tracks the completed operations so that each operation is completed exactly once, and
+	// onDuplicateCompletion, when set with WithDuplicateCompletionHandler, is called with every suppressed completion.
+	completedOps          *completedOps
+	onDuplicateCompletion func(op ShardReplicationOp)
+
+	// reservedTokens is the number of worker tokens held to lower the worker limit below the token capacity.
+	reservedTokens atomic.Int32
+
+	// pendingShrink is the number of worker tokens still held by running operations that are held as reserved
+	// tokens once released, lowering the worker limit as the operations complete.
+	pendingShrink atomic.Int32
+
+	// workersLock serializes the changes of the worker limit.
+	workersLock sync.Mutex
+
+	// goroutines is the number of goroutines currently run by the consumer to process operations.
+	goroutines atomic.Int64
+
+	// tokenObserver, when set, is notified whenever a worker token is acquired or released.
+	tokenObserver TokenObserver
+
+	// timeline records the consumer events of each operation. It is nil unless set by the engine.
+	timeline *opTimeline
+
+	// compactOpLogs makes the consumer log the fields describing an operation only once, when the operation starts,
+	// and only the operation ID on the following non-error log lines. Error log lines always include every field.
+	compactOpLogs bool
+}
+
+// opLoggers holds the loggers used while processing a single replication operation.
+type opLoggers struct {
+	// full includes every field describing the operation. It is always used to log errors.
+	full *logrus.Entry
+	// brief is used for the remaining log lines. It only includes the operation ID when op logs are compacted.
+	brief *logrus.Entry
+}
+
+// newOpLoggers returns the loggers used while processing the given replication operation.
+func (c *CopyOpConsumer) newOpLoggers(op ShardReplicationOp) opLoggers {
+	full := c.logger.WithFields(logrus.Fields{
+		"consumer":          c,
+		"op":                op.ID,
+		"source_node":       op.sourceShard.nodeId,
+		"target_node":       op.targetShard.nodeId,
+		"source_shard":      op.sourceShard.shardId,
+		"target_shard":      op.targetShard.shardId,
+		"source_collection": op.sourceShard.collectionId,
+		"target_collection": op.targetShard.collectionId,
+	})
+	if !c.compactOpLogs {
+		return opLoggers{full: full, brief: full}
+	}
+	return opLoggers{full: full, brief: c.logger.WithFields(logrus.Fields{"consumer": c, "op": op.ID})}
 }
 
 // String returns a string representation of the CopyOpConsumer,
@@ -84,174 +361,871 @@ func (c *CopyOpConsumer) String() string {
 // NewCopyOpConsumer creates a new CopyOpConsumer instance responsible for executing
 // replication operations using a configurable worker pool.
 //
-// It uses a ReplicaCopier to perform the actual data copy.
+// It uses a ReplicaCopier to perform the actual data copy, retrying failed operations with a backoff policy created
+// by newBackoffPolicy for each operation.
+//
+// Additional configuration can be applied using optional CopyOpConsumerOption functions.
 func NewCopyOpConsumer(
 	logger *logrus.Logger,
 	leaderClient types.FSMUpdater,
 	replicaCopier types.ReplicaCopier,
 	timeProvider TimeProvider,
 	nodeId string,
-	backoffPolicy backoff.BackOff,
+	newBackoffPolicy func() backoff.BackOff,
 	opTimeout time.Duration,
 	maxWorkers int,
+	opts ...CopyOpConsumerOption,
 ) *CopyOpConsumer {
 	c := &CopyOpConsumer{
-		logger:        logger.WithFields(logrus.Fields{"component": "replication_consumer", "action": replicationEngineLogAction, "node": nodeId, "workers": maxWorkers, "timeout": opTimeout}),
-		leaderClient:  leaderClient,
-		replicaCopier: replicaCopier,
-		backoffPolicy: backoffPolicy,
-		opTimeout:     opTimeout,
-		maxWorkers:    maxWorkers,
-		nodeId:        nodeId,
-		timeProvider:  timeProvider,
-		tokens:        make(chan struct{}, maxWorkers),
+		logger:           logger.WithFields(logrus.Fields{"component": "replication_consumer", "action": replicationEngineLogAction, "node": nodeId, "workers": maxWorkers, "timeout": opTimeout}),
+		leaderClient:     leaderClient,
+		replicaCopier:    replicaCopier,
+		newBackoffPolicy: newBackoffPolicy,
+		opTimeout:        opTimeout,
+		nodeId:           nodeId,
+		timeProvider:     timeProvider,
+		tokens:           make(chan struct{}, max(maxWorkers, maxWorkersCapacity)),
+		pausedOps:        newPausedOps(),
+		inFlightOps:      newInFlightOps(),
+		healingOps:       newInFlightOps(),
+		blockedOps:       newOpBlockReasons(),
+		resumeSignal:     make(chan struct{}, 1),
+		copiedBytes:      newCopiedBytesWindow(defaultThroughputWindow),
+		timer:            RealTimer{},
+
+		verificationSampleRate: 1,
+		completedOps:           newCompletedOps(defaultCompletedOpsRetention),
+		sourceReads:            make(map[shardFQDN]int),
+	}
+	c.maxWorkers.Store(int32(maxWorkers))
+	for _, opt := range opts {
+		opt(c)
+	}
+	// The tokens beyond maxWorkers are held, to be released when raising the limit with SetMaxWorkers
+	c.reserveIdleTokens(maxWorkers)
+	if c.scalingPolicy != nil {
+		c.minWorkers = max(1, min(c.minWorkers, maxWorkers))
+		c.reserveIdleTokens(c.minWorkers)
+	}
+	if c.rampUp != nil {
+		c.rampUp.initialWorkers = max(1, min(c.rampUp.initialWorkers, maxWorkers))
 	}
+
+	c.bytesCopied = promauto.With(c.registerer).NewCounterVec(prometheus.CounterOpts{
+		Namespace: "weaviate",
+		Name:      "replication_bytes_copied_total",
+		Help:      "Number of bytes copied by replication operations, attributed to the operation cost center",
+	}, []string{"cost_center"})
+	c.sourceReadConcurrency = promauto.With(c.registerer).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "weaviate",
+		Name:      "replication_source_read_concurrency",
+		Help:      "Number of replica copies concurrently reading from a source shard replica",
+	}, []string{"node", "collection", "shard"})
+	c.queueWaitExceeded = promauto.With(c.registerer).NewCounter(prometheus.CounterOpts{
+		Namespace: "weaviate",
+		Name:      "replication_queue_wait_exceeded_total",
+		Help:      "Number of replication operations canceled because they waited too long in the queue",
+	})
+	c.copiesByTransport = promauto.With(c.registerer).NewCounterVec(prometheus.CounterOpts{
+		Namespace: "weaviate",
+		Name:      "replication_copy_attempts_total",
+		Help:      "Number of replica copy attempts, labeled by transport (encrypted or plaintext)",
+	}, []string{"transport"})
+
 	return c
 }
 
 // Consume processes replication operations from the input channel, ensuring that only a limited number of consumers
 // are active concurrently based on the maxWorkers value.
+//
+// It returns nil once the input channel is closed and all pending operations completed. When the context is done,
+// it returns an error wrapping ErrConsumerDeadlineExceeded or ErrConsumerCanceled depending on the reason.
 func (c *CopyOpConsumer) Consume(ctx context.Context, in <-chan ShardReplicationOp) error {
 	c.logger.Info("starting replication operation consumer")
 
 	workerCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	c.startRampUp()
+	defer c.stopRampUp()
+
 	var wg sync.WaitGroup
+	c.webhook.bind(workerCtx, &wg)
 
 	for {
 		select {
 		case <-ctx.Done():
-			c.logger.WithFields(logrus.Fields{"consumer": c, "reason": ctx.Err()}).Info("context canceled, shutting down consumer")
 			wg.Wait() // Waiting for pending operations before terminating
-			return ctx.Err()
+			return c.shutdownError(ctx)
 
-		case op, ok := <-in:
+		case operation, ok := <-in:
 			if !ok {
 				c.logger.WithFields(logrus.Fields{"consumer": c}).Info("operation channel closed, shutting down consumer")
 				wg.Wait() // Waiting for pending operations before terminating
 				return nil
 			}
 
-			select {
-			// The 'tokens' channel limits the number of concurrent workers (`maxWorkers`).
-			// Each worker acquires a token before processing an operation. If no tokens are available,
-			// the worker blocks until one is released. After completing the task, the worker releases the token,
-			// allowing another worker to proceed. This ensures only a limited number of workers is concurrently
-			// running replication operations and avoids overloading the system.
-			case c.tokens <- struct{}{}:
-
-				wg.Add(1)
-
-				// Here we capture the op argument used by the func below as the enterrors.GoWrapper requires calling
-				// a function without arguments.
-				operation := op
-
-				enterrors.GoWrapper(func() {
-					defer func() {
-						<-c.tokens // Release token when completed
-						wg.Done()
-					}()
-
-					opLogger := c.logger.WithFields(logrus.Fields{
-						"consumer":          c,
-						"op":                operation.ID,
-						"source_node":       operation.sourceShard.nodeId,
-						"target_node":       operation.targetShard.nodeId,
-						"source_shard":      operation.sourceShard.shardId,
-						"target_shard":      operation.targetShard.shardId,
-						"source_collection": operation.sourceShard.collectionId,
-						"target_collection": operation.targetShard.collectionId,
-					})
-
-					opLogger.Info("worker processing replication operation")
-
-					// Start a replication operation with a timeout for completion to prevent replication operations
-					// from running indefinitely
-					opCtx, opCancel := context.WithTimeout(workerCtx, c.opTimeout)
-					defer opCancel()
-
-					err := c.processReplicationOp(opCtx, operation.ID, operation)
-					if err != nil && errors.Is(err, context.DeadlineExceeded) {
-						opLogger.WithError(err).Error("replication operation timed out")
-					} else if err != nil {
-						opLogger.WithError(err).Error("replication operation failed")
-					}
-				}, c.logger)
+			c.timeline.record(operation.ID, TimelineDequeued, "", "")
+			if c.pausedOps.park(operation) {
+				c.logger.WithFields(logrus.Fields{"consumer": c, "op": operation.ID}).Info("replication operation paused, holding it until resumed")
+				// The engine is done with the operation until it is resumed, see resumeOp
+				c.endOp(operation.ID)
+				continue
+			}
+			if !c.isCollectionAllowed(operation) {
+				c.logger.WithFields(logrus.Fields{"consumer": c, "op": operation.ID, "collection": operation.Collection()}).Debug("replication operation collection not allow-listed, deferring it")
+				c.endOp(operation.ID)
+				continue
+			}
+			if c.isMisrouted(operation) {
+				c.logger.WithFields(logrus.Fields{"consumer": c, "op": operation.ID, "target_node": operation.targetShard.nodeId}).Warn("replication operation targets another node, skipping it")
+				c.endOp(operation.ID)
+				continue
+			}
+			if c.healingOps.contains(operation.ID) {
+				c.logger.WithFields(logrus.Fields{"consumer": c, "op": operation.ID}).Debug("replication operation finalization being healed, skipping it")
+				c.endOp(operation.ID)
+				continue
+			}
+			if c.quarantine.isQuarantined(operation.ID, c.timeProvider.Now()) {
+				c.logger.WithFields(logrus.Fields{"consumer": c, "op": operation.ID}).Warn("replication operation quarantined after panicking, skipping it")
+				c.endOp(operation.ID)
+				continue
+			}
+			if c.queueWaitExceededBy(operation) {
+				c.cancelQueuedOp(ctx, operation)
+				c.endOp(operation.ID)
+				continue
+			}
+			if err := c.dispatchOp(ctx, workerCtx, &wg, in, operation); err != nil {
+				wg.Wait() // Waiting for pending operations before terminating
+				return c.shutdownError(ctx)
+			}
+
+		case <-c.scalingTicks:
+			c.scaleWorkers(c.currentQueueDepth(in))
+
+		case <-c.rampUpSignal():
+			c.stepRampUp()
 
-			case <-ctx.Done():
-				return ctx.Err()
+		case <-c.resumeSignal:
+			for _, operation := range c.takeResumedOps() {
+				if !c.resumeOp(operation) {
+					continue
+				}
+				if err := c.dispatchOp(ctx, workerCtx, &wg, in, operation); err != nil {
+					wg.Wait() // Waiting for pending operations before terminating
+					return c.shutdownError(ctx)
+				}
 			}
 		}
 	}
 }
 
+// shutdownError returns the error reported when the consumer shuts down because its context is done, logging the
+// reason. A consumer-level deadline is distinguished from a cancellation: the returned error wraps either
+// ErrConsumerDeadlineExceeded or ErrConsumerCanceled, together with the underlying context error.
+func (c *CopyOpConsumer) shutdownError(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		c.logger.WithFields(logrus.Fields{"consumer": c, "reason": err}).Warn("context deadline exceeded, shutting down consumer")
+		return fmt.Errorf("%w: %w", ErrConsumerDeadlineExceeded, err)
+	}
+	c.logger.WithFields(logrus.Fields{"consumer": c, "reason": err}).Info("context canceled, shutting down consumer")
+	return fmt.Errorf("%w: %w", ErrConsumerCanceled, err)
+}
+
+// dispatchOp waits for a worker token, within the global concurrency cap, and then runs the given replication
+// operation in a new worker goroutine. It returns an error only if the context is canceled while waiting for a token.
+// While waiting, the number of workers keeps being scaled based on the queue depth, if adaptive worker scaling is
+// enabled.
+func (c *CopyOpConsumer) dispatchOp(ctx context.Context, workerCtx context.Context, wg *sync.WaitGroup, in <-chan ShardReplicationOp, op ShardReplicationOp) error {
+	c.pending.Add(1)
+	if err := c.waitForClusterCapacity(ctx, op); err != nil {
+		c.pending.Add(-1)
+		return err
+	}
+	if err := c.acquireOpSlot(ctx, op); err != nil {
+		c.pending.Add(-1)
+		return err
+	}
+
+	c.blockedOps.set(op.ID, opBlockedWorker)
+
+	for {
+		select {
+		// The 'tokens' channel limits the number of concurrent workers (`maxWorkers`).
+		// Each worker acquires a token before processing an operation. If no tokens are available,
+		// the worker blocks until one is released. After completing the task, the worker releases the token,
+		// allowing another worker to proceed. This ensures only a limited number of workers is concurrently
+		// running replication operations and avoids overloading the system.
+		case c.tokens <- struct{}{}:
+			c.blockedOps.clear(op.ID)
+			c.notifyTokenAcquired(op.ID)
+
+			wg.Add(1)
+
+			// Here we capture the op argument used by the func below as the enterrors.GoWrapper requires calling
+			// a function without arguments.
+			operation := op
+
+			c.inFlightOps.add(operation.ID)
+			c.goroutines.Add(1)
+			enterrors.GoWrapper(func() {
+				// completed is set, together with the processing duration, once the operation is marked READY
+				var completed bool
+				var duration time.Duration
+				defer func() {
+					c.pending.Add(-1)
+					c.inFlightOps.remove(operation.ID)
+					c.endOp(operation.ID)
+					c.blockedOps.clear(operation.ID)
+					c.releaseToken() // Release token when completed
+					c.notifyTokenReleased(operation.ID)
+					c.releaseOpSlot()
+					if completed {
+						// Invoked once the token is released so that callbacks do not hold back other operations
+						c.notifyOpComplete(operation, duration)
+					}
+					c.goroutines.Add(-1)
+					wg.Done()
+				}()
+
+				opLogger := c.newOpLoggers(operation).full
+
+				if err := c.waitForSourceQueryLoad(workerCtx, operation); err != nil {
+					opLogger.WithError(err).Info("consumer stopped while waiting for the source replica query load to drop")
+					return
+				}
+				if err := c.waitForTargetMaintenance(workerCtx, operation); err != nil {
+					opLogger.WithError(err).Info("consumer stopped while waiting for the maintenance window of the target node to end")
+					return
+				}
+
+				opLogger.Info("worker processing replication operation")
+
+				// Start a replication operation with a timeout for completion to prevent replication operations
+				// from running indefinitely
+				opCtx, opCancel := context.WithTimeout(workerCtx, c.opTimeout)
+				defer opCancel()
+
+				startTime := c.timeProvider.Now()
+				err := c.processReplicationOp(opCtx, operation.ID, operation)
+				if errors.Is(err, ErrOpAlreadyCompleted) {
+					c.handleDuplicateCompletion(operation)
+					return
+				}
+				if errors.Is(err, errShardDeleted) {
+					// Aborted because the shard is gone, which is neither a completion nor a failure of the consumer
+					return
+				}
+				if err == nil {
+					completed = true
+					duration = c.timeProvider.Now().Sub(startTime)
+				}
+				if err == nil && c.onOpSucceeded != nil {
+					c.onOpSucceeded(operation)
+				}
+				if err != nil {
+					c.timeline.record(operation.ID, TimelineFailed, "", err.Error())
+				}
+				if err != nil && !errors.Is(err, ErrOpPaused) {
+					c.failedOps.record(operation)
+				}
+				if !errors.Is(err, ErrOpPaused) && !errors.Is(err, context.Canceled) {
+					c.outcomes.record(err == nil)
+					c.completions.record(c.timeProvider.Now())
+					if c.onOpOutcome != nil {
+						c.onOpOutcome(operation, err)
+					}
+				}
+				if err != nil && errors.Is(err, context.DeadlineExceeded) {
+					opLogger.WithError(err).Error("replication operation timed out")
+				} else if err != nil {
+					opLogger.WithError(err).Error("replication operation failed")
+				}
+			}, c.logger)
+			return nil
+
+		case <-c.scalingTicks:
+			c.scaleWorkers(c.currentQueueDepth(in))
+
+		case <-c.rampUpSignal():
+			c.stepRampUp()
+
+		case <-ctx.Done():
+			c.blockedOps.clear(op.ID)
+			c.releaseOpSlot()
+			c.pending.Add(-1)
+			return ctx.Err()
+		}
+	}
+}
+
+// isCollectionAllowed reports whether the operation replicates a shard of an allow-listed collection, which is always
+// the case without allow list.
+func (c *CopyOpConsumer) isCollectionAllowed(op ShardReplicationOp) bool {
+	if len(c.allowedCollections) == 0 {
+		return true
+	}
+	_, ok := c.allowedCollections[op.targetShard.collectionId]
+	return ok
+}
+
+// isMisrouted reports whether the operation targets another node than the node of the consumer, which is never the
+// case unless strict target node checking is enabled.
+func (c *CopyOpConsumer) isMisrouted(op ShardReplicationOp) bool {
+	return c.strictTargetNode && op.targetShard.nodeId != c.nodeId
+}
+
+// waitForClusterCapacity blocks while the cluster-wide replication load reported by the cluster load provider is at
+// or above the configured maximum, throttling the rate at which this node starts new operations. It returns an
+// error only if the context is canceled while waiting.
+func (c *CopyOpConsumer) waitForClusterCapacity(ctx context.Context, op ShardReplicationOp) error {
+	if c.clusterLoadProvider == nil {
+		return nil
+	}
+	defer c.blockedOps.clear(op.ID)
+
+	for {
+		load := c.clusterLoadProvider.ClusterInFlightOps()
+		if load < c.maxClusterInFlightOps {
+			return nil
+		}
+
+		c.logger.WithFields(logrus.Fields{
+			"consumer":         c,
+			"op":               op.ID,
+			"cluster_load":     load,
+			"max_cluster_load": c.maxClusterInFlightOps,
+		}).Debug("cluster replication load saturated, delaying replication operation")
+		c.blockedOps.set(op.ID, opBlockedClusterLoad)
+
+		recheck := make(chan struct{})
+		timer := c.timer.AfterFunc(c.clusterLoadPollInterval, func() { close(recheck) })
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-recheck:
+		}
+	}
+}
+
 // processReplicationOp performs the full replication flow for a single operation.
 //
 // It performs of the following steps:
-//  1. Updates the operation status to HYDRATING using the leader FSM updater.
-//  2. Initiates the copy of replica data from the source node to the target shard.
-//  3. Once the copy succeeds, updates the sharding state to reflect the added replica.
+//  1. Updates the operation status to HYDRATING using the leader FSM updater, concurrently with the next step if
+//     asynchronous status updates are enabled.
+//  2. Initiates the copy of replica data from the source node to the target shard, verifying the object count of
+//     the copy if enabled with WithCopyVerification, and promoting it to the live replica if the copy is staged.
+//  3. Once the copy succeeds, updates the operation status to FINALIZING.
+//  4. Updates the sharding state to reflect the added replica.
+//  5. Updates the operation status to READY.
 //
-// If any step fails, the operation is retried using the configured backoff policy.
-// Errors are logged and wrapped using the structured error group wrapper.
-func (c *CopyOpConsumer) processReplicationOp(ctx context.Context, workerId uint64, op ShardReplicationOp) error {
-	logger := c.logger.WithFields(logrus.Fields{
-		"consumer":          c,
-		"op":                op.ID,
-		"source_node":       op.sourceShard.nodeId,
-		"target_node":       op.targetShard.nodeId,
-		"source_shard":      op.sourceShard.shardId,
-		"target_shard":      op.targetShard.shardId,
-		"source_collection": op.sourceShard.collectionId,
-		"target_collection": op.targetShard.collectionId,
-	})
+// If the first two steps fail, they are retried using the configured backoff policy. The remaining steps are
+// retried independently using the sharding update backoff policy, if configured, so that a failure while
+// finalizing the operation never results in copying the replica again.
+//
+// Operations restarted while in the FINALIZING state already completed their copy, hence they skip the copy
+// and directly retry finalizing the operation. Operations whose target node already holds a replica of the shard,
+// according to the live sharding state, are obsolete and directly marked READY. Operations whose shard is deleted
+// while being copied are marked ABORTED and errShardDeleted is returned.
+//
+// A panic raised while processing the operation fails it with an error wrapping ErrOpPanicked.
+func (c *CopyOpConsumer) processReplicationOp(ctx context.Context, workerId uint64, op ShardReplicationOp) (err error) {
+	loggers := c.newOpLoggers(op)
 
 	startTime := c.timeProvider.Now()
 
-	return backoff.Retry(func() error {
+	var result opResult
+	retries := &opRetries{}
+	defer func() {
+		c.reportOpOutcome(op, startTime, result, err)
+	}()
+	defer c.logSuppressedOpErrors(loggers, op)
+	defer func() {
+		if r := recover(); r != nil {
+			err = c.recoverOpPanic(loggers, op, r)
+		}
+	}()
+
+	if !op.Deadline.IsZero() && c.elapsedSince(op.Deadline) > 0 {
+		return fmt.Errorf("%w: deadline %s", ErrOpDeadlineExceeded, op.Deadline.Format(time.RFC3339))
+	}
+
+	if c.isObsolete(loggers, op) {
+		loggers.brief.Info("target node already holds a replica of the shard, skipping obsolete replication operation")
+		if err = c.runPhase(ctx, phaseBookkeeping, c.bookkeepingTimeout, func(ctx context.Context) error {
+			return c.completeObsoleteOp(ctx, loggers, op, retries)
+		}); err != nil {
+			return err
+		}
+		result.endState, result.obsolete = api.READY, true
+		c.timeline.record(op.ID, TimelineCompleted, "", "obsolete")
+		return nil
+	}
+
+	if op.startState == api.FINALIZING {
+		loggers.brief.Info("resuming replication operation with completed copy, skipping copy")
+	} else {
+		err = c.runPhase(ctx, phaseCopy, c.copyTimeout, func(ctx context.Context) (err error) {
+			result.copiedBytes, err = c.copyReplica(ctx, loggers, op, retries)
+			return err
+		})
+		if errors.Is(err, errShardDeleted) {
+			loggers.brief.Info("shard deleted while being replicated, aborting replication operation")
+			if err = c.runPhase(ctx, phaseBookkeeping, c.bookkeepingTimeout, func(ctx context.Context) error {
+				return c.abortDeletedShardOp(ctx, loggers, op, retries)
+			}); err != nil {
+				return err
+			}
+			c.timeline.record(op.ID, TimelineFailed, "", "shard deleted")
+			return errShardDeleted
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if err = c.runPhase(ctx, phaseBookkeeping, c.bookkeepingTimeout, func(ctx context.Context) error {
+		return c.finalizeReplicationOp(ctx, loggers, op, retries)
+	}); err != nil {
+		return err
+	}
+	result.endState = api.READY
+
+	c.timeline.record(op.ID, TimelineCompleted, "", "")
+	c.logCompletedReplicationOp(loggers, workerId, startTime, c.timeProvider.Now(), op, result.copiedBytes, retries)
+	return nil
+}
+
+// copyReplica updates the operation status to HYDRATING and copies the replica from the source node, retrying
+// using the main backoff policy, or the policy configured for the category of the last error with
+// WithErrorCategoryBackoff. It returns the number of bytes copied by the successful attempt, if the replica
+// copier is able to report it. With copy verification enabled, a copy whose object count does not match the source
+// replica is failed and retried.
+//
+// With staged copies enabled, each attempt copies the replica into a staging area, promoted to the live replica once
+// verified. The staged replica is discarded whenever the attempt fails, including when the promotion fails.
+//
+// When a failed copy attempt reports having copied some bytes or committed some batches, the backoff policy of the
+// operation is reset so that the next attempt is retried after the initial interval rather than an ever growing one.
+// The policies of the other operations are left untouched.
+//
+// Each attempt first checks that the replicated shard still exists, and the copy is aborted with errShardDeleted,
+// without further retries, once it was deleted.
+func (c *CopyOpConsumer) copyReplica(ctx context.Context, loggers opLoggers, op ShardReplicationOp, retries *opRetries) (int64, error) {
+	if c.tlsConfig != nil {
+		if _, ok := c.replicaCopier.(types.EncryptedReplicaCopier); !ok {
+			loggers.full.Error("encrypted transport required but not supported by the replica copier, failing replication operation")
+			return 0, fmt.Errorf("%w: %T", ErrEncryptionUnsupported, c.replicaCopier)
+		}
+	}
+	if c.stagedCopy {
+		if c.tlsConfig != nil {
+			loggers.full.Error("staged copy required but not supported over an encrypted transport, failing replication operation")
+			return 0, fmt.Errorf("%w: over an encrypted transport", ErrStagedCopyUnsupported)
+		}
+		if _, ok := c.replicaCopier.(types.StagedReplicaCopier); !ok {
+			loggers.full.Error("staged copy required but not supported by the replica copier, failing replication operation")
+			return 0, fmt.Errorf("%w: %T", ErrStagedCopyUnsupported, c.replicaCopier)
+		}
+	}
+	if c.verifyCopies {
+		if _, ok := c.replicaCopier.(types.ObjectCountingReplicaCopier); !ok {
+			loggers.full.Error("copy verification required but not supported by the replica copier, failing replication operation")
+			return 0, fmt.Errorf("%w: %T", ErrCopyVerificationUnsupported, c.replicaCopier)
+		}
+	}
+
+	attempt := 0
+	var copiedBytes int64
+	committedBatches := op.committedBatches
+	policy := c.copyBackoffPolicy()
+	err := backoff.RetryNotify(func() (err error) {
+		defer func() {
+			if err != nil {
+				policy.observe(err)
+			}
+		}()
+
 		if ctx.Err() != nil {
-			logger.WithField("consumer", c).WithError(ctx.Err()).Error("error while processing replication operation, shutting down")
+			loggers.full.WithError(ctx.Err()).Error("error while processing replication operation, shutting down")
 			return backoff.Permanent(ctx.Err())
 		}
+		if attempt > 0 && c.pausedOps.isPaused(op.ID) {
+			loggers.brief.Info("replication operation paused, not retrying")
+			return backoff.Permanent(ErrOpPaused)
+		}
+		if c.isShardDeleted(loggers, op) {
+			return backoff.Permanent(errShardDeleted)
+		}
+		if err := c.checkNotCompleted(op); err != nil {
+			return err
+		}
+		attempt++
+		c.blockedOps.clear(op.ID)
+		if c.isCopyStaged() {
+			defer func() {
+				if err != nil {
+					c.discardStagedReplica(ctx, loggers, op)
+				}
+			}()
+		}
+
+		copyCtx, cancelCopy := context.WithCancel(ctx)
+		defer cancelCopy()
 
-		if err := c.leaderClient.ReplicationUpdateReplicaOpStatus(op.ID, api.HYDRATING); err != nil {
-			logger.WithField("consumer", c).WithError(err).Error("failed to update replica status to 'HYDRATING'")
+		var hydratingCommitted <-chan error
+		if c.asyncStatusUpdate {
+			hydratingCommitted = c.updateStatusAsync(ctx, op, api.HYDRATING, cancelCopy)
+		} else if err := c.updateOpStatus(ctx, op, api.HYDRATING); err != nil {
+			loggers.full.WithError(err).Error("failed to update replica status to 'HYDRATING'")
 			return err
 		}
 
-		logger.WithField("consumer", c).Info("starting replication copy operation")
+		loggers.brief.Info("starting replication copy operation")
+
+		batchesBefore := committedBatches
+		n, err := c.copyReplicaData(copyCtx, loggers, op, &committedBatches)
+		if hydratingCommitted != nil {
+			// The copy may complete before the HYDRATING status is committed, in which case the op waits for the
+			// commit before moving on. A failed commit fails the attempt even if the copy succeeded.
+			if statusErr := <-hydratingCommitted; statusErr != nil {
+				loggers.full.WithError(statusErr).Error("failed to update replica status to 'HYDRATING'")
+				return statusErr
+			}
+		}
+		if err != nil {
+			c.logOpError(loggers.full.WithFields(logrus.Fields{"bytes_copied": n, "committed_batches": committedBatches}), op, err, "failure while copying replica shard")
+			if n > 0 || committedBatches > batchesBefore {
+				// The failed attempt made progress, which is kept by the next attempt, hence the failure is not
+				// considered persistent and the retry interval starts over instead of growing further.
+				loggers.brief.WithField("bytes_copied", n).Info("replica copy made progress before failing, resetting backoff")
+				policy.Reset()
+			}
+			return err
+		}
+		if err := c.verifyObjectCount(ctx, op); err != nil {
+			c.logOpError(loggers.full, op, err, "failure while verifying replica copy")
+			return err
+		}
+		if err := c.promoteStagedReplica(ctx, loggers, op); err != nil {
+			c.logOpError(loggers.full, op, err, "failure while promoting staged replica")
+			return err
+		}
+		copiedBytes = n
+		c.bytesCopied.WithLabelValues(op.CostCenter).Add(float64(n))
+		c.copiedBytes.record(c.timeProvider.Now(), n)
+		return nil
+	}, policy, c.recordRetry(op, retries))
+	return copiedBytes, err
+}
+
+// copyReplicaData copies the replica data using the replica copier, returning the number of bytes copied when the
+// copier implements types.SizedReplicaCopier and zero otherwise. The copy is accounted for in the read concurrency
+// of the source shard replica while in progress.
+//
+// With encrypted transport required, the copy goes through types.EncryptedReplicaCopier, which neither reports the
+// number of bytes copied nor commits batches. Otherwise, with staged copies enabled, the copy goes through
+// types.StagedReplicaCopier into the staging area. Otherwise, with batch commits enabled and a copier implementing
+// types.BatchReplicaCopier, the copy resumes from the given
+// number of committed batches, which is advanced as batches are committed.
+func (c *CopyOpConsumer) copyReplicaData(ctx context.Context, loggers opLoggers, op ShardReplicationOp, committedBatches *int) (int64, error) {
+	c.startSourceRead(op.sourceShard)
+	defer c.endSourceRead(op.sourceShard)
+
+	if c.tlsConfig != nil {
+		c.copiesByTransport.WithLabelValues(transportEncrypted).Inc()
+		return 0, c.replicaCopier.(types.EncryptedReplicaCopier).CopyReplicaEncrypted(ctx, op.sourceShard.nodeId,
+			op.sourceShard.collectionId, op.targetShard.shardId, c.tlsConfig)
+	}
+	c.copiesByTransport.WithLabelValues(transportPlaintext).Inc()
+
+	if c.isCopyStaged() {
+		return 0, c.replicaCopier.(types.StagedReplicaCopier).StageReplica(ctx, op.sourceShard.nodeId,
+			op.sourceShard.collectionId, op.targetShard.shardId)
+	}
+	if batchCopier, ok := c.batchReplicaCopier(); ok {
+		return 0, c.copyReplicaBatches(ctx, loggers, op, batchCopier, committedBatches)
+	}
+
+	if sizedCopier, ok := c.replicaCopier.(types.SizedReplicaCopier); ok {
+		return sizedCopier.CopyReplicaWithSize(ctx, op.sourceShard.nodeId, op.sourceShard.collectionId, op.targetShard.shardId)
+	}
+	return 0, c.replicaCopier.CopyReplica(ctx, op.sourceShard.nodeId, op.sourceShard.collectionId, op.targetShard.shardId)
+}
+
+// isObsolete reports whether the operation no longer needs to be processed because the target node already holds a
+// replica of the shard. The sharding state is only checked if the leader FSM updater implements
+// types.ShardingStateReader; failing to read it is logged and the operation is processed.
+func (c *CopyOpConsumer) isObsolete(loggers opLoggers, op ShardReplicationOp) bool {
+	reader, ok := c.leaderClient.(types.ShardingStateReader)
+	if !ok {
+		return false
+	}
+
+	replicas, err := reader.ShardReplicas(op.targetShard.collectionId, op.targetShard.shardId)
+	if err != nil {
+		loggers.full.WithError(err).Warn("failed to read sharding state, not checking whether the replication operation is obsolete")
+		return false
+	}
+	return slices.Contains(replicas, op.targetShard.nodeId)
+}
+
+// isShardDeleted reports whether the shard replicated by the operation no longer exists in the live sharding state,
+// e.g. because its deletion was committed while the operation was being processed. The sharding state is only checked
+// if the leader FSM updater implements types.ShardingStateReader; failing to read it is logged and the shard is
+// assumed to exist.
+func (c *CopyOpConsumer) isShardDeleted(loggers opLoggers, op ShardReplicationOp) bool {
+	reader, ok := c.leaderClient.(types.ShardingStateReader)
+	if !ok {
+		return false
+	}
+	_, err := reader.ShardReplicas(op.targetShard.collectionId, op.targetShard.shardId)
+	if err != nil && !errors.Is(err, types.ErrShardNotFound) {
+		loggers.full.WithError(err).Warn("failed to read sharding state, not checking whether the replicated shard was deleted")
+	}
+	return errors.Is(err, types.ErrShardNotFound)
+}
+
+// completeObsoleteOp marks an obsolete operation as READY without copying the replica nor updating the sharding
+// state, retrying using the sharding update backoff policy.
+func (c *CopyOpConsumer) completeObsoleteOp(ctx context.Context, loggers opLoggers, op ShardReplicationOp, retries *opRetries) error {
+	return backoff.RetryNotify(func() error {
+		if ctx.Err() != nil {
+			return backoff.Permanent(ctx.Err())
+		}
+		if err := c.markOpReady(ctx, op); err != nil {
+			loggers.full.WithError(err).Error("failed to update replica status to 'READY'")
+			return err
+		}
+		return nil
+	}, c.shardingUpdateBackoffPolicy(), c.recordRetry(op, retries))
+}
 
-		if err := c.replicaCopier.CopyReplica(ctx, op.sourceShard.nodeId, op.sourceShard.collectionId, op.targetShard.shardId); err != nil {
-			logger.WithField("consumer", c).WithError(err).Error("failure while copying replica shard")
+// abortDeletedShardOp marks an operation whose shard was deleted while being replicated as ABORTED, retrying using
+// the sharding update backoff policy. The operation is not completed, hence no completion is reported for it.
+func (c *CopyOpConsumer) abortDeletedShardOp(ctx context.Context, loggers opLoggers, op ShardReplicationOp, retries *opRetries) error {
+	return backoff.RetryNotify(func() error {
+		if ctx.Err() != nil {
+			return backoff.Permanent(ctx.Err())
+		}
+		if err := c.markOpTerminal(ctx, op, api.ABORTED); err != nil {
+			loggers.full.WithError(err).Error("failed to update replica status to 'ABORTED'")
 			return err
 		}
+		return nil
+	}, c.shardingUpdateBackoffPolicy(), c.recordRetry(op, retries))
+}
+
+// updateStatusAsync issues the status update of the given operation in a new goroutine and returns a channel
+// receiving its outcome. If the update fails, onFailure is called before the error is sent.
+func (c *CopyOpConsumer) updateStatusAsync(ctx context.Context, op ShardReplicationOp, state api.ShardReplicationState, onFailure func()) <-chan error {
+	committed := make(chan error, 1)
+	c.goroutines.Add(1)
+	enterrors.GoWrapper(func() {
+		defer c.goroutines.Add(-1)
+		err := c.updateOpStatus(ctx, op, state)
+		if err != nil {
+			onFailure()
+		}
+		committed <- err
+	}, c.logger)
+	return committed
+}
+
+// startSourceRead records a copy starting to read from the given source shard replica.
+func (c *CopyOpConsumer) startSourceRead(source shardFQDN) {
+	c.sourceReadsLock.Lock()
+	defer c.sourceReadsLock.Unlock()
+	c.sourceReads[source]++
+	c.sourceReadConcurrency.WithLabelValues(source.nodeId, source.collectionId, source.shardId).Set(float64(c.sourceReads[source]))
+}
+
+// endSourceRead records a copy done reading from the given source shard replica, deleting its series once no copy
+// reads from it anymore.
+func (c *CopyOpConsumer) endSourceRead(source shardFQDN) {
+	c.sourceReadsLock.Lock()
+	defer c.sourceReadsLock.Unlock()
+	c.sourceReads[source]--
+	if c.sourceReads[source] > 0 {
+		c.sourceReadConcurrency.WithLabelValues(source.nodeId, source.collectionId, source.shardId).Set(float64(c.sourceReads[source]))
+		return
+	}
+	delete(c.sourceReads, source)
+	c.sourceReadConcurrency.DeleteLabelValues(source.nodeId, source.collectionId, source.shardId)
+}
+
+// verifyObjectCount compares the number of objects held by the source and target replicas once the copy completed,
+// returning an error wrapping ErrObjectCountMismatch if they differ. The copy is not verified unless copy
+// verification is enabled and the operation is sampled for verification. The replica copier is checked to implement
+// types.ObjectCountingReplicaCopier before copying.
+func (c *CopyOpConsumer) verifyObjectCount(ctx context.Context, op ShardReplicationOp) error {
+	if !c.verifyCopies || !c.isSampledForVerification(op) {
+		return nil
+	}
+	counter := c.replicaCopier.(types.ObjectCountingReplicaCopier)
+
+	sourceCount, err := counter.CountObjects(ctx, op.sourceShard.nodeId, op.sourceShard.collectionId, op.sourceShard.shardId)
+	if err != nil {
+		return fmt.Errorf("counting objects on source node %s: %w", op.sourceShard.nodeId, err)
+	}
+	targetCount, err := counter.CountObjects(ctx, op.targetShard.nodeId, op.targetShard.collectionId, op.targetShard.shardId)
+	if err != nil {
+		return fmt.Errorf("counting objects on target node %s: %w", op.targetShard.nodeId, err)
+	}
+	if sourceCount != targetCount {
+		return fmt.Errorf("%w: source node %s has %d objects, target node %s has %d", ErrObjectCountMismatch,
+			op.sourceShard.nodeId, sourceCount, op.targetShard.nodeId, targetCount)
+	}
+	return nil
+}
+
+// waitForReplicaQuorum waits until a quorum of the replicas of the shard acknowledged the new replica, up to the quorum
+// acknowledgment timeout, if required with WithQuorumAck. It fails permanently if the leader FSM updater does not
+// implement types.ReplicaQuorumWaiter, and fails once the context is done, so that the wait is not retried.
+func (c *CopyOpConsumer) waitForReplicaQuorum(ctx context.Context, op ShardReplicationOp) error {
+	if !c.requireQuorumAck {
+		return nil
+	}
+	waiter, ok := c.leaderClient.(types.ReplicaQuorumWaiter)
+	if !ok {
+		return backoff.Permanent(fmt.Errorf("%w: %T", ErrQuorumAckUnsupported, c.leaderClient))
+	}
+	if ctx.Err() != nil {
+		return backoff.Permanent(ctx.Err())
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, c.quorumAckTimeout)
+	defer cancel()
+	return waiter.WaitForReplicaQuorum(waitCtx, op.targetShard.collectionId, op.targetShard.shardId, op.targetShard.nodeId)
+}
+
+// isSampledForVerification reports whether the copy of the operation is verified given the verification sample rate.
+// The decision only depends on the operation ID, so that it is the same on every attempt and every node.
+func (c *CopyOpConsumer) isSampledForVerification(op ShardReplicationOp) bool {
+	if c.verificationSampleRate >= 1 {
+		return true
+	}
+	if c.verificationSampleRate <= 0 {
+		return false
+	}
+	// splitmix64 finalizer, spreading sequential operation IDs uniformly over [0, 1)
+	h := op.ID + 0x9e3779b97f4a7c15
+	h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+	h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+	h ^= h >> 31
+	return float64(h>>11)/(1<<53) < c.verificationSampleRate
+}
 
-		if _, err := c.leaderClient.AddReplicaToShard(ctx, op.targetShard.collectionId, op.targetShard.shardId, op.targetShard.nodeId); err != nil {
-			logger.WithField("consumer", c).WithError(err).Error("failure while updating sharding state")
+// finalizeReplicationOp moves an operation with a completed copy to FINALIZING, adds the new replica to the
+// sharding state and finally marks the operation as READY. Steps already completed are not repeated on retry.
+//
+// The consumer may be stopped while an operation is being finalized. The outcome is then defined as follows:
+//   - The first attempt is always made, even if the context is already done, so that a completed copy is recorded
+//     by moving the operation to FINALIZING and is not repeated when the operation is restarted.
+//   - Once the sharding state update committed, the operation is marked READY regardless of the context, as the
+//     replica is already part of the sharding state. With a quorum acknowledgment required, the operation is only
+//     marked READY once a quorum of the shard replicas acknowledged the new replica, which is waited for until the
+//     quorum acknowledgment timeout and retried, and no further attempt is made once the context is done.
+//   - A sharding state update interrupted by the context leaves the operation in FINALIZING, and no further attempt
+//     is made once the context is done. The restarted operation resumes by updating the sharding state again.
+func (c *CopyOpConsumer) finalizeReplicationOp(ctx context.Context, loggers opLoggers, op ShardReplicationOp, retries *opRetries) error {
+	finalizing := op.startState == api.FINALIZING
+	replicaAdded := false
+	attempt := 0
+
+	return backoff.RetryNotify(func() error {
+		if attempt > 0 && ctx.Err() != nil {
+			loggers.full.WithError(ctx.Err()).Error("error while updating sharding state, shutting down")
+			return backoff.Permanent(ctx.Err())
+		}
+		if attempt > 0 && c.pausedOps.isPaused(op.ID) {
+			loggers.brief.Info("replication operation paused, not retrying")
+			return backoff.Permanent(ErrOpPaused)
+		}
+		if err := c.checkNotCompleted(op); err != nil {
 			return err
 		}
+		attempt++
+		c.blockedOps.clear(op.ID)
 
-		c.logCompletedReplicationOp(workerId, startTime, c.timeProvider.Now(), op)
+		if !finalizing {
+			if err := c.updateOpStatus(ctx, op, api.FINALIZING); err != nil {
+				loggers.full.WithError(err).Error("failed to update replica status to 'FINALIZING'")
+				return err
+			}
+			finalizing = true
+		}
+
+		if !replicaAdded {
+			if err := c.addReplicaToShard(ctx, op); err != nil {
+				loggers.full.WithError(err).Error("failure while updating sharding state")
+				return err
+			}
+			replicaAdded = true
+		}
 
+		if err := c.waitForReplicaQuorum(ctx, op); err != nil {
+			loggers.full.WithError(err).Error("failure while waiting for a quorum of replicas to acknowledge the new replica")
+			return err
+		}
+
+		if err := c.markOpReady(ctx, op); err != nil {
+			loggers.full.WithError(err).Error("failed to update replica status to 'READY'")
+			return err
+		}
 		return nil
-	}, c.backoffPolicy)
+	}, c.shardingUpdateBackoffPolicy(), c.recordRetry(op, retries))
+}
+
+// opRetries tallies the retries of an operation being processed, across every step of its processing.
+type opRetries struct {
+	count        int
+	totalBackoff time.Duration
+}
+
+// recordRetry returns a backoff notification recording the retries of the given operation in its timeline and in
+// retries, and the reason the operation is blocked until the next attempt.
+func (c *CopyOpConsumer) recordRetry(op ShardReplicationOp, retries *opRetries) backoff.Notify {
+	return func(err error, delay time.Duration) {
+		retries.count++
+		retries.totalBackoff += delay
+		c.timeline.record(op.ID, TimelineRetried, "", err.Error())
+		c.blockedOps.set(op.ID, opBlockedRetry+err.Error())
+	}
+}
+
+// elapsedSince returns the time elapsed since t, as measured by the consumer clock, minus the clock skew tolerance.
+// It is negative while t is in the future or within the tolerance, as t may have been set using the clock of another
+// node running ahead of this one.
+func (c *CopyOpConsumer) elapsedSince(t time.Time) time.Duration {
+	return c.timeProvider.Now().Sub(t) - c.clockSkewTolerance
+}
+
+// shardingUpdateBackoffPolicy returns a new backoff policy used to retry finalizing an operation, falling back to the
+// main backoff policy when no dedicated one is configured.
+func (c *CopyOpConsumer) shardingUpdateBackoffPolicy() backoff.BackOff {
+	if c.newShardingUpdateBackoff != nil {
+		return c.newShardingUpdateBackoff()
+	}
+	return c.newBackoffPolicy()
 }
 
-func (c *CopyOpConsumer) logCompletedReplicationOp(workerId uint64, startTime time.Time, endTime time.Time, op ShardReplicationOp) {
+// logCompletedReplicationOp logs the completion of an operation, including the number of attempts it took, i.e.
+// one more than its number of retries, and the total time spent waiting before retrying.
+func (c *CopyOpConsumer) logCompletedReplicationOp(loggers opLoggers, workerId uint64, startTime time.Time, endTime time.Time, op ShardReplicationOp, copiedBytes int64, retries *opRetries) {
 	duration := endTime.Sub(startTime)
 
-	c.logger.WithFields(logrus.Fields{
-		"worker":            workerId,
-		"op":                op.ID,
-		"duration":          duration.String(),
-		"start_time":        startTime.Format(time.RFC1123),
-		"completed_since":   c.timeProvider.Now().Sub(endTime),
-		"source_node":       op.sourceShard.nodeId,
-		"target_node":       op.targetShard.nodeId,
-		"source_shard":      op.sourceShard.shardId,
-		"target_shard":      op.targetShard.shardId,
-		"source_collection": op.sourceShard.collectionId,
-		"target_collection": op.targetShard.collectionId,
+	loggers.brief.WithFields(logrus.Fields{
+		"worker":          workerId,
+		"duration":        duration.String(),
+		"start_time":      startTime.Format(time.RFC1123),
+		"completed_since": c.timeProvider.Now().Sub(endTime),
+		"cost_center":     op.CostCenter,
+		"bytes_copied":    copiedBytes,
+		"attempts":        retries.count + 1,
+		"total_backoff":   retries.totalBackoff,
 	}).Info("Replication operation completed successfully")
 }
@@ -0,0 +1,73 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/weaviate/weaviate/cluster/replication/types"
+)
+
+// batchReplicaCopier returns the replica copier as a types.BatchReplicaCopier if batch commits are enabled and the
+// copier supports them.
+func (c *CopyOpConsumer) batchReplicaCopier() (types.BatchReplicaCopier, bool) {
+	if !c.batchCommit {
+		return nil, false
+	}
+	copier, ok := c.replicaCopier.(types.BatchReplicaCopier)
+	return copier, ok
+}
+
+// copyReplicaBatches copies the replica one batch of objects at a time, starting from the first batch not committed
+// yet, until the copier reports the last batch. The number of committed batches is advanced, and persisted as a
+// checkpoint, after each batch so that a failed copy resumes from the last committed batch.
+func (c *CopyOpConsumer) copyReplicaBatches(ctx context.Context, loggers opLoggers, op ShardReplicationOp, copier types.BatchReplicaCopier, committedBatches *int) error {
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		batch := *committedBatches
+		last, err := copier.CopyReplicaBatch(ctx, op.sourceShard.nodeId, op.sourceShard.collectionId, op.targetShard.shardId, batch)
+		if err != nil {
+			return fmt.Errorf("copying batch %d: %w", batch, err)
+		}
+		if err := c.storeCheckpoint(ctx, op, batch+1); err != nil {
+			return fmt.Errorf("storing checkpoint of batch %d: %w", batch, err)
+		}
+		*committedBatches = batch + 1
+		loggers.brief.WithField("committed_batches", *committedBatches).Debug("committed replica copy batch")
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// storeCheckpoint persists the number of batches committed by the copy of the operation to the FSM, if the leader
+// FSM updater implements types.OpCheckpointStore. Otherwise, the progress is only kept until the consumer restarts.
+func (c *CopyOpConsumer) storeCheckpoint(ctx context.Context, op ShardReplicationOp, committedBatches int) error {
+	store, ok := c.leaderClient.(types.OpCheckpointStore)
+	if !ok {
+		return nil
+	}
+	if c.shardUpdateLocks != nil {
+		defer c.shardUpdateLocks.lock(op)()
+	}
+	release, err := c.acquireFSMWrite(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return store.ReplicationStoreOpCheckpoint(op.ID, committedBatches)
+}
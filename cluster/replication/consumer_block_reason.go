@@ -0,0 +1,74 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import "sync"
+
+// Human-readable reasons for which a replication operation is not progressing, reported by OpBlockReason.
+const (
+	opBlockedQueued      = "waiting in the replication engine queue"
+	opBlockedPaused      = "paused, held until resumed"
+	opBlockedClusterLoad = "waiting for the cluster-wide replication load to drop below the configured maximum"
+	opBlockedSourceLoad  = "waiting for the source replica query load to drop below the configured maximum"
+	opBlockedMaintenance = "waiting for the maintenance window of the target node to end"
+	opBlockedConcurrency = "waiting for the number of concurrent operations to drop below the global concurrency cap"
+	opBlockedWorker      = "waiting for a free worker"
+	opBlockedRetry       = "waiting to retry after failure: "
+)
+
+// opBlockReasons is a concurrent map from operation IDs to the reason they are currently blocked for.
+type opBlockReasons struct {
+	mu      sync.Mutex
+	reasons map[uint64]string
+}
+
+func newOpBlockReasons() *opBlockReasons {
+	return &opBlockReasons{reasons: make(map[uint64]string)}
+}
+
+func (b *opBlockReasons) set(id uint64, reason string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reasons[id] = reason
+}
+
+func (b *opBlockReasons) clear(id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.reasons, id)
+}
+
+func (b *opBlockReasons) get(id uint64) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	reason, ok := b.reasons[id]
+	return reason, ok
+}
+
+// isParked reports whether the operation is held by the consumer because it is paused.
+func (p *pausedOps) isParked(id uint64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.parked[id]
+	return ok
+}
+
+// OpBlockReason returns a human-readable reason for which the replication operation with the given ID is received
+// by the consumer but not progressing: it is paused, throttled by the cluster-wide replication load, waiting for a
+// free worker or waiting to be retried. It reports false if the operation is being processed or unknown to the
+// consumer.
+func (c *CopyOpConsumer) OpBlockReason(id uint64) (string, bool) {
+	if c.pausedOps.isParked(id) {
+		return opBlockedPaused, true
+	}
+	return c.blockedOps.get(id)
+}
@@ -0,0 +1,132 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// ErrOpAlreadyCompleted is returned when a replication operation is not marked READY because it was already completed
+// by the consumer, e.g. by another worker processing the same operation emitted twice.
+var ErrOpAlreadyCompleted = errors.New("replication operation already completed")
+
+// defaultCompletedOpsRetention is the number of completed operations remembered by the consumer to suppress duplicate
+// completions.
+const defaultCompletedOpsRetention = 10000
+
+// completedOps tracks the operations completed by the consumer, and the ones being marked READY, so that each
+// operation is completed exactly once. Only the most recently completed operations are remembered.
+type completedOps struct {
+	mu        sync.Mutex
+	claimed   map[uint64]struct{}
+	completed map[uint64]struct{}
+	// order holds the completed operations in completion order, the oldest being forgotten first
+	order     []uint64
+	retention int
+}
+
+func newCompletedOps(retention int) *completedOps {
+	return &completedOps{
+		claimed:   make(map[uint64]struct{}),
+		completed: make(map[uint64]struct{}),
+		retention: retention,
+	}
+}
+
+// claim reserves the completion of the operation and reports whether it succeeded, which is not the case if the
+// operation is already completed or being completed.
+func (c *completedOps) claim(id uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.completed[id]; ok {
+		return false
+	}
+	if _, ok := c.claimed[id]; ok {
+		return false
+	}
+	c.claimed[id] = struct{}{}
+	return true
+}
+
+// release gives up a claimed completion which failed.
+func (c *completedOps) release(id uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.claimed, id)
+}
+
+// complete records a claimed completion as done.
+func (c *completedOps) complete(id uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.claimed, id)
+	c.completed[id] = struct{}{}
+	c.order = append(c.order, id)
+	if len(c.order) > c.retention {
+		delete(c.completed, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// isClaimed reports whether the operation is completed or being completed.
+func (c *completedOps) isClaimed(id uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, completed := c.completed[id]
+	_, claimed := c.claimed[id]
+	return completed || claimed
+}
+
+// markOpReady updates the status of the operation to READY unless it was already completed, in which case it fails
+// permanently with ErrOpAlreadyCompleted, so that the terminal transition of an operation is issued exactly once even
+// if several workers race to complete it.
+func (c *CopyOpConsumer) markOpReady(ctx context.Context, op ShardReplicationOp) error {
+	return c.markOpTerminal(ctx, op, api.READY)
+}
+
+// markOpTerminal updates the status of the operation to the given terminal state, READY or ABORTED, with the same
+// exactly once guarantee as markOpReady.
+func (c *CopyOpConsumer) markOpTerminal(ctx context.Context, op ShardReplicationOp, state api.ShardReplicationState) error {
+	if !c.completedOps.claim(op.ID) {
+		return backoff.Permanent(ErrOpAlreadyCompleted)
+	}
+	if err := c.updateOpStatus(ctx, op, state); err != nil {
+		c.completedOps.release(op.ID)
+		return err
+	}
+	c.completedOps.complete(op.ID)
+	return nil
+}
+
+// checkNotCompleted fails permanently with ErrOpAlreadyCompleted if the operation is already completed or being
+// marked READY, so that a late attempt does not move a completed operation back to an earlier state.
+func (c *CopyOpConsumer) checkNotCompleted(op ShardReplicationOp) error {
+	if c.completedOps.isClaimed(op.ID) {
+		return backoff.Permanent(ErrOpAlreadyCompleted)
+	}
+	return nil
+}
+
+// handleDuplicateCompletion reports a completion of the operation suppressed because it was already completed.
+func (c *CopyOpConsumer) handleDuplicateCompletion(op ShardReplicationOp) {
+	c.logger.WithFields(logrus.Fields{"consumer": c, "op": op.ID}).Info("replication operation already completed, ignoring duplicate completion")
+	if c.onDuplicateCompletion != nil {
+		c.onDuplicateCompletion(op)
+	}
+}
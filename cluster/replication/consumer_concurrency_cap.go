@@ -0,0 +1,43 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import "context"
+
+// acquireOpSlot blocks until the number of operations held by the consumer is below the global concurrency cap, if
+// any, and accounts for the operation. It returns an error only if the context is canceled while waiting.
+func (c *CopyOpConsumer) acquireOpSlot(ctx context.Context, op ShardReplicationOp) error {
+	if c.opSlots == nil {
+		return nil
+	}
+	select {
+	case c.opSlots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	c.blockedOps.set(op.ID, opBlockedConcurrency)
+	defer c.blockedOps.clear(op.ID)
+	select {
+	case c.opSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// releaseOpSlot releases the slot of an operation acquired with acquireOpSlot.
+func (c *CopyOpConsumer) releaseOpSlot() {
+	if c.opSlots != nil {
+		<-c.opSlots
+	}
+}
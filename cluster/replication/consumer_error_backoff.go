@@ -0,0 +1,99 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// ErrorCategory classifies the errors failing a replication copy attempt by their likely cause.
+type ErrorCategory string
+
+const (
+	// ErrorCategoryNetwork covers transient network failures, such as refused or reset connections and timeouts.
+	ErrorCategoryNetwork ErrorCategory = "network"
+	// ErrorCategoryDisk covers storage failures, such as a full disk or an I/O error, which take longer to resolve.
+	ErrorCategoryDisk ErrorCategory = "disk"
+	// ErrorCategoryOther covers every other error.
+	ErrorCategoryOther ErrorCategory = "other"
+)
+
+// ClassifyError returns the category of the given error, looking at the whole chain of wrapped errors.
+func ClassifyError(err error) ErrorCategory {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ENOSPC), errors.Is(err, syscall.EDQUOT), errors.Is(err, syscall.EIO), errors.Is(err, syscall.EROFS):
+		return ErrorCategoryDisk
+	case errors.As(err, &netErr), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.EPIPE), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorCategoryNetwork
+	default:
+		return ErrorCategoryOther
+	}
+}
+
+// categoryBackOff is a backoff policy delegating to the policy configured for the category of the last observed
+// error, and to the fallback policy for errors of other categories.
+type categoryBackOff struct {
+	fallback backoff.BackOff
+	policies map[ErrorCategory]backoff.BackOff
+
+	mu       sync.Mutex
+	category ErrorCategory
+}
+
+// observe records the error of the last failed attempt, selecting the policy used for the next retry.
+func (b *categoryBackOff) observe(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.category = ClassifyError(err)
+}
+
+func (b *categoryBackOff) policy() backoff.BackOff {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if policy, ok := b.policies[b.category]; ok {
+		return policy
+	}
+	return b.fallback
+}
+
+// NextBackOff implements backoff.BackOff.
+func (b *categoryBackOff) NextBackOff() time.Duration {
+	return b.policy().NextBackOff()
+}
+
+// Reset implements backoff.BackOff, resetting the fallback policy and every category policy.
+func (b *categoryBackOff) Reset() {
+	b.fallback.Reset()
+	for _, policy := range b.policies {
+		policy.Reset()
+	}
+}
+
+// copyBackoffPolicy returns a new backoff policy used to retry copying the replica of a single operation, selecting
+// the policy configured with WithErrorCategoryBackoff for the category of the last error, if any, and the main backoff
+// policy otherwise. The returned policy must observe the errors of the failed attempts.
+func (c *CopyOpConsumer) copyBackoffPolicy() *categoryBackOff {
+	policies := make(map[ErrorCategory]backoff.BackOff, len(c.categoryBackoffs))
+	for category, newPolicy := range c.categoryBackoffs {
+		policies[category] = newPolicy()
+	}
+	return &categoryBackOff{fallback: c.newBackoffPolicy(), policies: policies}
+}
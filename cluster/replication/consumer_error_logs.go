@@ -0,0 +1,110 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// opErrorKey identifies an error of a replication operation by its message.
+type opErrorKey struct {
+	id      uint64
+	message string
+}
+
+// loggedOpError records when an error of an operation was last logged and how many times it was not logged since.
+type loggedOpError struct {
+	lastLogged time.Time
+	suppressed int
+}
+
+// opErrorLogLimiter limits the logging of identical errors of the same operation, e.g. of a copy failing repeatedly
+// with the same error, to once per interval.
+type opErrorLogLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	errors   map[opErrorKey]loggedOpError
+}
+
+func newOpErrorLogLimiter(interval time.Duration) *opErrorLogLimiter {
+	return &opErrorLogLimiter{interval: interval, errors: make(map[opErrorKey]loggedOpError)}
+}
+
+// allow reports whether the error of the operation may be logged at the given time, and if so how many identical
+// errors were not logged since it was last logged. Every error is allowed on a nil limiter.
+func (l *opErrorLogLimiter) allow(id uint64, err error, now time.Time) (int, bool) {
+	if l == nil {
+		return 0, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := opErrorKey{id: id, message: err.Error()}
+	logged, ok := l.errors[key]
+	if ok && now.Sub(logged.lastLogged) < l.interval {
+		logged.suppressed++
+		l.errors[key] = logged
+		return 0, false
+	}
+	l.errors[key] = loggedOpError{lastLogged: now}
+	return logged.suppressed, true
+}
+
+// forget removes the errors recorded for the operation and returns, by message, how many identical errors were not
+// logged since they were last logged. It is a no-op on a nil limiter.
+func (l *opErrorLogLimiter) forget(id uint64) map[string]int {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var suppressed map[string]int
+	for key, logged := range l.errors {
+		if key.id != id {
+			continue
+		}
+		delete(l.errors, key)
+		if logged.suppressed > 0 {
+			if suppressed == nil {
+				suppressed = make(map[string]int)
+			}
+			suppressed[key.message] = logged.suppressed
+		}
+	}
+	return suppressed
+}
+
+// logOpError logs an error of the operation at the error level, unless an identical error of the operation was
+// logged less than the configured interval ago. The log line reports how many identical errors were not logged since
+// the previous one.
+func (c *CopyOpConsumer) logOpError(entry *logrus.Entry, op ShardReplicationOp, err error, msg string) {
+	suppressed, ok := c.errorLogs.allow(op.ID, err, c.timeProvider.Now())
+	if !ok {
+		return
+	}
+	entry = entry.WithError(err)
+	if suppressed > 0 {
+		entry = entry.WithField("suppressed", suppressed)
+	}
+	entry.Error(msg)
+}
+
+// logSuppressedOpErrors logs a summary of the errors of the operation that were not logged since they were last
+// logged, once the operation processing ends.
+func (c *CopyOpConsumer) logSuppressedOpErrors(loggers opLoggers, op ShardReplicationOp) {
+	for message, suppressed := range c.errorLogs.forget(op.ID) {
+		loggers.full.WithFields(logrus.Fields{"error": message, "suppressed": suppressed}).
+			Warn("identical replication operation errors were not logged")
+	}
+}
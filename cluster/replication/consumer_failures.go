@@ -0,0 +1,57 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"slices"
+	"sync"
+)
+
+// failedOps keeps the most recently failed operations, up to a maximum size, in the order they failed.
+type failedOps struct {
+	mu      sync.Mutex
+	maxSize int
+	ops     []ShardReplicationOp
+}
+
+func newFailedOps(maxSize int) *failedOps {
+	return &failedOps{maxSize: maxSize}
+}
+
+// record appends the operation as the most recent failure, replacing a previous failure of the same operation and
+// evicting the oldest failure beyond the maximum size. It is a no-op on a nil store.
+func (f *failedOps) record(op ShardReplicationOp) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ops = slices.DeleteFunc(f.ops, func(failed ShardReplicationOp) bool { return failed.ID == op.ID })
+	f.ops = append(f.ops, op)
+	if len(f.ops) > f.maxSize {
+		f.ops = slices.Delete(f.ops, 0, len(f.ops)-f.maxSize)
+	}
+}
+
+// takeRecent removes and returns up to n of the most recent failures, most recent first.
+func (f *failedOps) takeRecent(n int) []ShardReplicationOp {
+	if f == nil || n <= 0 {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n = min(n, len(f.ops))
+	recent := slices.Clone(f.ops[len(f.ops)-n:])
+	f.ops = f.ops[:len(f.ops)-n]
+	slices.Reverse(recent)
+	return recent
+}
@@ -0,0 +1,46 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import "context"
+
+// healFinalization makes a single attempt to add the replica of an operation with a completed copy to the sharding
+// state and to mark the operation READY. The operation is skipped, and false returned, if it is currently held by a
+// worker, paused or quarantined. While the attempt is made, the operation is not dispatched to a worker.
+func (c *CopyOpConsumer) healFinalization(ctx context.Context, op ShardReplicationOp) (bool, error) {
+	if c.inFlightOps.contains(op.ID) || c.pausedOps.isPaused(op.ID) || c.quarantine.isQuarantined(op.ID, c.timeProvider.Now()) {
+		return false, nil
+	}
+	if !c.healingOps.tryAdd(op.ID) {
+		return false, nil
+	}
+	defer c.healingOps.remove(op.ID)
+
+	loggers := c.newOpLoggers(op)
+	if !c.isObsolete(loggers, op) {
+		if err := c.addReplicaToShard(ctx, op); err != nil {
+			loggers.full.WithError(err).Warn("failed to heal replication operation finalization while updating sharding state")
+			return true, err
+		}
+	}
+	if err := c.waitForReplicaQuorum(ctx, op); err != nil {
+		loggers.full.WithError(err).Warn("failed to heal replication operation finalization while waiting for a quorum of replicas to acknowledge the replica")
+		return true, err
+	}
+	if err := c.markOpReady(ctx, op); err != nil {
+		loggers.full.WithError(err).Warn("failed to heal replication operation finalization while updating replica status to 'READY'")
+		return true, err
+	}
+	c.timeline.record(op.ID, TimelineCompleted, "", "finalization healed")
+	loggers.brief.Info("healed replication operation finalization")
+	return true, nil
+}
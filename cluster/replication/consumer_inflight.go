@@ -0,0 +1,76 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"slices"
+	"sync"
+)
+
+// inFlightOps is a concurrent set of the IDs of the operations currently held by a worker.
+type inFlightOps struct {
+	mu  sync.Mutex
+	ids map[uint64]struct{}
+}
+
+func newInFlightOps() *inFlightOps {
+	return &inFlightOps{ids: make(map[uint64]struct{})}
+}
+
+func (f *inFlightOps) add(id uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ids[id] = struct{}{}
+}
+
+func (f *inFlightOps) remove(id uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.ids, id)
+}
+
+// tryAdd adds the ID to the set unless it is already there, and reports whether it was added.
+func (f *inFlightOps) tryAdd(id uint64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.ids[id]; ok {
+		return false
+	}
+	f.ids[id] = struct{}{}
+	return true
+}
+
+func (f *inFlightOps) contains(id uint64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.ids[id]
+	return ok
+}
+
+// list returns the IDs in the set in ascending order.
+func (f *inFlightOps) list() []uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := make([]uint64, 0, len(f.ids))
+	for id := range f.ids {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// InFlightOps returns, in ascending order, the IDs of the replication operations currently held by a worker.
+// Unlike the FSM state, which shows an operation as HYDRATING even while it waits for a retry or a restart, an
+// operation is only listed while a worker is actively processing it.
+func (c *CopyOpConsumer) InFlightOps() []uint64 {
+	return c.inFlightOps.list()
+}
@@ -0,0 +1,63 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultNodeSchedulePollInterval is how often the schedule of a node under maintenance is checked again when no
+// poll interval is configured.
+const defaultNodeSchedulePollInterval = 10 * time.Second
+
+// waitForTargetMaintenance blocks while the target node of the operation is under maintenance, as reported by the
+// node schedule provider, until the maintenance window ends. The schedule is checked again at least every poll
+// interval, so that windows ending early or being extended are taken into account. It returns an error only if the
+// context is canceled while waiting.
+func (c *CopyOpConsumer) waitForTargetMaintenance(ctx context.Context, op ShardReplicationOp) error {
+	if c.nodeScheduleProvider == nil {
+		return nil
+	}
+	defer c.blockedOps.clear(op.ID)
+
+	for {
+		end, inMaintenance := c.nodeScheduleProvider.MaintenanceWindow(op.targetShard.nodeId)
+		if !inMaintenance {
+			return nil
+		}
+
+		wait := c.nodeSchedulePollInterval
+		if now := c.timeProvider.Now(); end.After(now) {
+			wait = min(wait, end.Sub(now))
+		}
+		c.logger.WithFields(logrus.Fields{
+			"consumer":            c,
+			"op":                  op.ID,
+			"target_node":         op.targetShard.nodeId,
+			"maintenance_end":     end,
+			"maintenance_recheck": wait,
+		}).Debug("target node under maintenance, deferring replication operation")
+		c.blockedOps.set(op.ID, opBlockedMaintenance)
+
+		recheck := make(chan struct{})
+		timer := c.timer.AfterFunc(wait, func() { close(recheck) })
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-recheck:
+		}
+	}
+}
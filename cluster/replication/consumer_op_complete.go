@@ -0,0 +1,66 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OpCompleteCallback is invoked once a replication operation completed and was marked READY, with the time it took
+// to process it.
+type OpCompleteCallback func(op ShardReplicationOp, duration time.Duration)
+
+// opCompleteCallbacks holds the callbacks registered with CopyOpConsumer.OnOpComplete.
+type opCompleteCallbacks struct {
+	mu        sync.RWMutex
+	callbacks []OpCompleteCallback
+}
+
+// OnOpComplete registers a callback invoked every time an operation completes, once its final FSM update marking it
+// READY succeeded, e.g. to warm up indexes or invalidate caches of the new replica. Operations completed without
+// copying because they are obsolete are also reported. Each operation is reported once, even if several workers race
+// to complete it, see WithDuplicateCompletionHandler.
+//
+// Callbacks are invoked synchronously by the worker which processed the operation, in registration order, once it
+// released its worker token, hence a slow callback does not hold back other operations. A panic raised by a callback
+// is recovered and logged without affecting the worker nor the other callbacks.
+func (c *CopyOpConsumer) OnOpComplete(callback OpCompleteCallback) {
+	c.opCompleteCallbacks.mu.Lock()
+	defer c.opCompleteCallbacks.mu.Unlock()
+	c.opCompleteCallbacks.callbacks = append(c.opCompleteCallbacks.callbacks, callback)
+}
+
+// notifyOpComplete invokes the registered completion callbacks for the given completed operation.
+func (c *CopyOpConsumer) notifyOpComplete(op ShardReplicationOp, duration time.Duration) {
+	c.opCompleteCallbacks.mu.RLock()
+	callbacks := c.opCompleteCallbacks.callbacks
+	c.opCompleteCallbacks.mu.RUnlock()
+
+	for _, callback := range callbacks {
+		c.invokeOpCompleteCallback(callback, op, duration)
+	}
+}
+
+// invokeOpCompleteCallback invokes a completion callback, recovering from a panic it raises.
+func (c *CopyOpConsumer) invokeOpCompleteCallback(callback OpCompleteCallback, op ShardReplicationOp, duration time.Duration) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.WithFields(logrus.Fields{"consumer": c, "op": op.ID, "panic": r, "stack": string(debug.Stack())}).
+				Error("recovered from panic in replication operation completion callback")
+		}
+	}()
+	callback(op, duration)
+}
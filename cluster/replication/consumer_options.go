@@ -0,0 +1,398 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"crypto/tls"
+	"io"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/weaviate/weaviate/cluster/replication/types"
+)
+
+// CopyOpConsumerOption configures optional behavior of a CopyOpConsumer.
+type CopyOpConsumerOption func(*CopyOpConsumer)
+
+// WithShardingUpdateBackoff sets a dedicated backoff policy for retrying the sharding state update
+// (AddReplicaToShard) committed after a successful copy, so that commit retries can be tuned independently
+// of copy retries. newPolicy is called to create a new policy every time an operation is finalized, as policies are
+// stateful and operations are finalized concurrently by the workers.
+func WithShardingUpdateBackoff(newPolicy func() backoff.BackOff) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.newShardingUpdateBackoff = newPolicy
+	}
+}
+
+// WithClusterLoadThrottling makes the consumer consult the given provider before starting each operation. While the
+// cluster-wide number of in-flight operations is at or above maxInFlightOps, the consumer waits pollInterval before
+// checking again, so that a node reduces its own op start rate when the cluster is globally saturated.
+func WithClusterLoadThrottling(provider types.ClusterLoadProvider, maxInFlightOps int, pollInterval time.Duration) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.clusterLoadProvider = provider
+		c.maxClusterInFlightOps = maxInFlightOps
+		c.clusterLoadPollInterval = pollInterval
+	}
+}
+
+// WithConsumerRegisterer sets the prometheus registerer used to register the consumer metrics.
+func WithConsumerRegisterer(reg prometheus.Registerer) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.registerer = reg
+	}
+}
+
+// WithCompactOpLogs reduces the consumer log volume by logging the fields describing an operation (source and target
+// nodes, collections and shards) only once when the operation starts, and only the operation ID on the following
+// log lines. Error log lines still include every field.
+func WithCompactOpLogs() CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.compactOpLogs = true
+	}
+}
+
+// WithAdaptiveWorkers makes the consumer scale the number of concurrently running operations between minWorkers and
+// the consumer maxWorkers, starting from minWorkers. On every tick received from ticks, typically the channel of a
+// time.Ticker owned by the caller, the policy is given the number of operations waiting to be consumed, queued in
+// the replication engine or buffered in the consumer input channel, to decide whether to grow or shrink the number of
+// workers.
+func WithAdaptiveWorkers(minWorkers int, policy WorkerScalingPolicy, ticks <-chan time.Time) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.minWorkers = minWorkers
+		c.scalingPolicy = policy
+		c.scalingTicks = ticks
+	}
+}
+
+// WithWorkerRampUp makes the consumer start with initialWorkers workers every time it starts, raising the number of
+// concurrently running operations linearly to maxWorkers over the given duration, as measured by the clock and the
+// timer of the consumer, so that starting with many queued operations does not spike the load. With adaptive worker
+// scaling, the ramp-up caps the number of workers decided by the scaling policy. A duration lower than or equal to
+// zero disables the ramp-up.
+func WithWorkerRampUp(initialWorkers int, duration time.Duration) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		if duration <= 0 {
+			c.rampUp = nil
+			return
+		}
+		c.rampUp = &workerRampUp{initialWorkers: initialWorkers, duration: duration, signal: make(chan struct{}, 1)}
+	}
+}
+
+// WithClockSkewTolerance sets the maximum clock skew tolerated between the node running the consumer and the nodes
+// setting the timestamps it compares against, such as operation deadlines. Time-based checks only consider a point in
+// time as passed once it is older than the tolerance, so that a node whose clock runs ahead does not act prematurely.
+func WithClockSkewTolerance(tolerance time.Duration) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.clockSkewTolerance = tolerance
+	}
+}
+
+// WithOpOutcomeWriter makes the consumer write an OpOutcomeRecord as a JSON line to w every time an operation
+// completes or fails, independently of the logger format. Records are written one at a time.
+func WithOpOutcomeWriter(w io.Writer) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.outcomeWriter = newOpOutcomeWriter(w)
+	}
+}
+
+// WithMaxConcurrentFSMWrites caps the number of FSM writes, such as operation status updates and sharding state
+// updates, issued concurrently by the workers, independently of the number of workers, so that many workers do not
+// overwhelm the Raft leader. Workers wait for a write to complete before issuing theirs while the cap is reached,
+// failing the write with the context error if the context of the operation is done first. A cap lower than or equal
+// to zero disables it.
+func WithMaxConcurrentFSMWrites(maxWrites int) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.fsmWrites = nil
+		if maxWrites > 0 {
+			c.fsmWrites = make(chan struct{}, maxWrites)
+		}
+	}
+}
+
+// WithMaxConcurrentOps sets a global cap on the number of operations processed concurrently by the consumer. A cap
+// lower than or equal to zero disables it.
+//
+// The operations received by the consumer go through the following gates, in this order, before being processed:
+//
+//  1. the cluster-wide replication load, see WithClusterLoadThrottling;
+//  2. the global concurrency cap;
+//  3. a worker token, limited by maxWorkers and adjusted at runtime by SetMaxWorkers, WithAdaptiveWorkers and
+//     WithWorkerRampUp;
+//  4. the source replica query load and the maintenance window of the target node, see
+//     WithSourceQueryLoadThrottling and WithNodeMaintenanceDeferral, checked by the worker holding the operation.
+//
+// The global concurrency cap is authoritative: an operation holds its slot from before acquiring a worker token until
+// it is done, hence no other gate, whatever its configuration, lets more operations be processed concurrently.
+func WithMaxConcurrentOps(maxOps int) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.opSlots = nil
+		if maxOps > 0 {
+			c.opSlots = make(chan struct{}, maxOps)
+		}
+	}
+}
+
+// WithOpCompletionWebhook makes the consumer POST an OpOutcomeRecord as JSON to url every time an operation completes
+// or fails, e.g. to feed external dashboards. Each attempt times out after timeout and failed attempts, including
+// responses with a non-2xx status code, are retried up to maxRetries times with an exponential backoff.
+//
+// Records are posted asynchronously so that a slow webhook never delays processing operations. Records produced while
+// too many are being posted are dropped and logged. Pending posts are aborted once the consumer context is done, and
+// Consume waits for them before returning.
+func WithOpCompletionWebhook(url string, timeout time.Duration, maxRetries int) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.webhook = newOpCompletionWebhook(url, timeout, maxRetries)
+	}
+}
+
+// WithAsyncStatusUpdate makes the consumer issue the HYDRATING status update of an operation asynchronously and start
+// copying the replica while the update is being committed, rather than after. The copy is canceled if the update
+// fails, and an operation whose copy completes first waits for the update to be committed before being finalized.
+// Either way, a failed update fails the copy attempt, which is retried like any other failure.
+func WithAsyncStatusUpdate() CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.asyncStatusUpdate = true
+	}
+}
+
+// WithFailedOpsHistory makes the consumer keep the size most recently failed operations, which can be replayed with
+// ShardReplicationEngine.ReplayRecentFailures. Operations not retried because they are paused are not kept.
+func WithFailedOpsHistory(size int) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.failedOps = newFailedOps(size)
+	}
+}
+
+// WithSerializedShardUpdates makes the consumer apply the status updates of operations replicating the same shard
+// one at a time, so that the transitions of concurrently processed operations on a shard are never interleaved and
+// are applied in the order they are issued by the workers.
+func WithSerializedShardUpdates() CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.shardUpdateLocks = newShardLocks()
+	}
+}
+
+// WithBatchCommit makes the consumer copy replicas incrementally when the replica copier implements
+// types.BatchReplicaCopier, committing one batch of objects at a time. The number of committed batches is persisted
+// as a checkpoint of the operation in the FSM after each batch, if the FSM updater implements types.OpCheckpointStore,
+// so that a copy failing midway, or interrupted by a restart, resumes from the last committed batch instead of from
+// scratch.
+func WithBatchCommit() CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.batchCommit = true
+	}
+}
+
+// WithPanicQuarantine quarantines the operations whose processing panicked, as a deterministic panic would otherwise
+// be raised again every time the producer emits the operation. A panicked operation is not processed again until
+// gracePeriod passed since its last panic, and never again once it panicked maxPanics times. A maxPanics lower than or
+// equal to zero never quarantines operations permanently.
+func WithPanicQuarantine(gracePeriod time.Duration, maxPanics int) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.quarantine = newOpQuarantine(gracePeriod, maxPanics)
+	}
+}
+
+// WithEncryptedTransport requires replicas to be copied over a transport encrypted using tlsConfig, e.g. when copies
+// cross untrusted networks. The replica copier must implement types.EncryptedReplicaCopier, otherwise operations fail
+// with an error wrapping ErrEncryptionUnsupported rather than falling back to a plaintext copy.
+func WithEncryptedTransport(tlsConfig *tls.Config) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.tlsConfig = tlsConfig
+	}
+}
+
+// WithStagedCopy makes the consumer copy replicas into a staging area of the target node rather than into the live
+// replica, so that a partially copied replica is never served. Once the copy completed and was verified, the staged
+// replica is atomically promoted to the live replica, while it is discarded if the copy attempt fails. The replica
+// copier must implement types.StagedReplicaCopier, otherwise operations fail with an error wrapping
+// ErrStagedCopyUnsupported. Staged copies over an encrypted transport, see WithEncryptedTransport, are not supported
+// and fail with the same error.
+func WithStagedCopy() CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.stagedCopy = true
+	}
+}
+
+// WithQuorumAck requires a quorum of the replicas of a shard to acknowledge the new replica, once added to the sharding
+// state, before marking the operation READY, for strong durability. The acknowledgment is waited for up to timeout per
+// attempt and retried using the sharding update backoff policy. The leader FSM updater must implement
+// types.ReplicaQuorumWaiter, otherwise operations fail with an error wrapping ErrQuorumAckUnsupported.
+func WithQuorumAck(timeout time.Duration) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.requireQuorumAck = true
+		c.quorumAckTimeout = timeout
+	}
+}
+
+// WithCopyTimeout bounds the copy phase of each operation, including its retries, independently of the timeout of the
+// whole operation, which must cover both the copy and the bookkeeping phases. An operation whose copy phase times out
+// fails with an error wrapping ErrPhaseTimeout. A timeout lower than or equal to zero disables it.
+func WithCopyTimeout(timeout time.Duration) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.copyTimeout = timeout
+	}
+}
+
+// WithBookkeepingTimeout bounds the bookkeeping phase of each operation, updating the sharding state and the status of
+// the operation once the replica is copied, including its retries, so that it is not given the budget of the copy
+// phase. An operation whose bookkeeping phase times out fails with an error wrapping ErrPhaseTimeout. A timeout lower
+// than or equal to zero disables it.
+func WithBookkeepingTimeout(timeout time.Duration) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.bookkeepingTimeout = timeout
+	}
+}
+
+// WithConsumerTimer sets the timer used to enforce the phase timeouts and to schedule the consumer rechecks, such as
+// polling the cluster load, e.g. a fake clock in tests.
+func WithConsumerTimer(timer Timer) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.timer = timer
+	}
+}
+
+// WithCopyVerification makes the consumer verify each copy by comparing the object counts of the source and target
+// replicas once the copy completed. A copy whose object count does not match is failed with an error wrapping
+// ErrObjectCountMismatch and retried. The replica copier must implement types.ObjectCountingReplicaCopier, otherwise
+// operations fail with an error wrapping ErrCopyVerificationUnsupported.
+func WithCopyVerification() CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.verifyCopies = true
+	}
+}
+
+// WithVerificationSampleRate sets the fraction, between 0 and 1, of operations whose copy is verified as with
+// WithCopyVerification, as verifying every copy is expensive. Operations are sampled deterministically from their ID,
+// hence an operation is either verified on every attempt or never. It defaults to 1, verifying every copy. A positive
+// rate enables copy verification, hence every operation fails with an error wrapping ErrCopyVerificationUnsupported
+// if the replica copier does not implement types.ObjectCountingReplicaCopier, whether it is sampled or not.
+func WithVerificationSampleRate(rate float64) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.verificationSampleRate = rate
+		if rate > 0 {
+			c.verifyCopies = true
+		}
+	}
+}
+
+// WithThroughputWindow sets the rolling window over which the bytes copied per second are measured, see
+// ShardReplicationEngine.CurrentThroughput. It defaults to one minute. A window lower than or equal to zero keeps the
+// default.
+func WithThroughputWindow(window time.Duration) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		if window > 0 {
+			c.copiedBytes = newCopiedBytesWindow(window)
+		}
+	}
+}
+
+// WithStrictTargetNode makes the consumer skip, without processing nor updating them, the operations whose target
+// node is not the node the consumer runs on, guarding against misrouted operations. Skipped operations are logged.
+func WithStrictTargetNode() CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.strictTargetNode = true
+	}
+}
+
+// WithCollectionAllowList restricts the consumer to the operations replicating shards of the given collections, e.g.
+// to roll out replication collection by collection. Operations of other collections are skipped without being
+// processed nor updated, hence they stay registered in the FSM and are emitted again by the producer until their
+// collection is allowed. An empty list allows every collection.
+func WithCollectionAllowList(collections ...string) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		if len(collections) == 0 {
+			c.allowedCollections = nil
+			return
+		}
+		c.allowedCollections = make(map[string]struct{}, len(collections))
+		for _, collection := range collections {
+			c.allowedCollections[collection] = struct{}{}
+		}
+	}
+}
+
+// WithErrorCategoryBackoff sets the backoff policies used to retry failed replica copies depending on the category of
+// the error of the last attempt, see ClassifyError, e.g. so that network blips are retried quickly while a full disk
+// is retried slowly. Copies failing with an error of a category without policy are retried using the main backoff
+// policy. Like the main backoff policy, a new policy is created for each operation.
+func WithErrorCategoryBackoff(policies map[ErrorCategory]func() backoff.BackOff) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.categoryBackoffs = policies
+	}
+}
+
+// WithMaxQueueWait makes the consumer cancel the operations dequeued after waiting longer than maxQueueWait in the
+// queue of the replication engine, as this indicates the engine is overwhelmed. Canceled operations are marked
+// ABORTED in the FSM without being started, failed with an error wrapping ErrOpQueueWaitExceeded, and counted by a
+// metric. A wait lower than or equal to zero disables the cancellation.
+func WithMaxQueueWait(maxQueueWait time.Duration) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.maxQueueWait = maxQueueWait
+	}
+}
+
+// WithErrorLogInterval limits the logging of identical copy errors of the same operation, e.g. of a copy failing
+// repeatedly with the same error, to once per interval. Each logged error reports how many identical errors were not
+// logged since the previous one, and a summary of the errors not logged is logged once the operation processing ends.
+func WithErrorLogInterval(interval time.Duration) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.errorLogs = newOpErrorLogLimiter(interval)
+	}
+}
+
+// WithSourceQueryLoadThrottling makes the consumer consult the given provider before starting each operation. While
+// the query load of the source shard replica of the operation is above maxLoad, the operation waits pollInterval
+// before checking again, so that copies do not degrade the latency of the queries served by busy replicas. A delayed
+// operation holds its worker, while operations reading from other replicas proceed on the other workers.
+func WithSourceQueryLoadThrottling(provider types.SourceQueryLoadProvider, maxLoad float64, pollInterval time.Duration) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.sourceQueryLoadProvider = provider
+		c.maxSourceQueryLoad = maxLoad
+		c.sourceQueryLoadPollInterval = pollInterval
+	}
+}
+
+// WithNodeMaintenanceDeferral makes the consumer consult the given provider before starting each operation. While
+// the target node of the operation is under maintenance, the operation is deferred until the maintenance window ends
+// instead of failing, the schedule being checked again at least every pollInterval. A deferred operation holds its
+// worker, while operations targeting other nodes proceed on the other workers. A pollInterval lower than or equal to
+// zero defaults to 10 seconds.
+func WithNodeMaintenanceDeferral(provider types.NodeScheduleProvider, pollInterval time.Duration) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		if pollInterval <= 0 {
+			pollInterval = defaultNodeSchedulePollInterval
+		}
+		c.nodeScheduleProvider = provider
+		c.nodeSchedulePollInterval = pollInterval
+	}
+}
+
+// WithDuplicateCompletionHandler sets a handler called every time a worker completes an operation already completed,
+// e.g. by another worker processing the same operation emitted twice. The consumer completes each operation exactly
+// once, hence such a duplicate completion neither marks the operation READY again nor invokes the completion
+// callbacks, see OnOpComplete, and is only reported to the handler, e.g. to count them.
+func WithDuplicateCompletionHandler(handler func(op ShardReplicationOp)) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.onDuplicateCompletion = handler
+	}
+}
+
+// WithTokenObserver sets an observer notified whenever a worker token is acquired to process an operation and
+// released once done, e.g. to implement custom worker pool diagnostics.
+func WithTokenObserver(observer TokenObserver) CopyOpConsumerOption {
+	return func(c *CopyOpConsumer) {
+		c.tokenObserver = observer
+	}
+}
@@ -0,0 +1,121 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// OpOutcomeRecord is the machine-readable record of a replication operation reaching a terminal outcome in the
+// consumer, written as a single JSON line by consumers configured with WithOpOutcomeWriter and posted to the webhook
+// configured with WithOpCompletionWebhook.
+type OpOutcomeRecord struct {
+	OpID       uint64 `json:"op_id"`
+	SourceNode string `json:"source_node"`
+	TargetNode string `json:"target_node"`
+	Collection string `json:"collection"`
+	Shard      string `json:"shard"`
+
+	// Outcome is either "completed" or "failed".
+	Outcome string `json:"outcome"`
+
+	// StartState is the state the operation was in when dequeued. EndState is the state the consumer moved the
+	// operation to, only set for completed operations.
+	StartState api.ShardReplicationState `json:"start_state"`
+	EndState   api.ShardReplicationState `json:"end_state,omitempty"`
+
+	// Obsolete is set for operations completed without copying the replica, as the target node already held it.
+	Obsolete bool `json:"obsolete,omitempty"`
+
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	DurationMs int64     `json:"duration_ms"`
+
+	// BytesCopied is the number of bytes copied, if reported by the replica copier.
+	BytesCopied int64 `json:"bytes_copied"`
+
+	// Error is the error the operation failed with, if any.
+	Error string `json:"error,omitempty"`
+}
+
+const (
+	opOutcomeCompleted = "completed"
+	opOutcomeFailed    = "failed"
+)
+
+// opResult is what processing an operation achieved, as reported in its outcome record.
+type opResult struct {
+	// copiedBytes is the number of bytes copied, if reported by the replica copier.
+	copiedBytes int64
+	// endState is the state the operation was moved to once processed, if any.
+	endState api.ShardReplicationState
+	// obsolete is set if the operation was completed without copying the replica.
+	obsolete bool
+}
+
+// opOutcomeWriter serializes OpOutcomeRecord lines written concurrently by the consumer workers.
+type opOutcomeWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newOpOutcomeWriter(w io.Writer) *opOutcomeWriter {
+	return &opOutcomeWriter{enc: json.NewEncoder(w)}
+}
+
+// reportOpOutcome writes the outcome record of the given operation, if an outcome writer is configured, and posts it
+// to the completion webhook, if any. Duplicate completions of an already completed operation are not reported.
+func (c *CopyOpConsumer) reportOpOutcome(op ShardReplicationOp, startTime time.Time, result opResult, err error) {
+	if (c.outcomeWriter == nil && c.webhook == nil) || errors.Is(err, ErrOpAlreadyCompleted) || errors.Is(err, errShardDeleted) {
+		return
+	}
+
+	endTime := c.timeProvider.Now()
+	record := OpOutcomeRecord{
+		OpID:        op.ID,
+		SourceNode:  op.sourceShard.nodeId,
+		TargetNode:  op.targetShard.nodeId,
+		Collection:  op.sourceShard.collectionId,
+		Shard:       op.sourceShard.shardId,
+		Outcome:     opOutcomeCompleted,
+		StartState:  op.startState,
+		EndState:    result.endState,
+		Obsolete:    result.obsolete,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		DurationMs:  endTime.Sub(startTime).Milliseconds(),
+		BytesCopied: result.copiedBytes,
+	}
+	if err != nil {
+		record.Outcome = opOutcomeFailed
+		record.EndState = ""
+		record.Error = err.Error()
+	}
+
+	c.postOpOutcome(record)
+	if c.outcomeWriter == nil {
+		return
+	}
+
+	c.outcomeWriter.mu.Lock()
+	defer c.outcomeWriter.mu.Unlock()
+	if err := c.outcomeWriter.enc.Encode(record); err != nil {
+		c.logger.WithFields(logrus.Fields{"consumer": c, "op": op.ID}).WithError(err).Warn("failed to write replication operation outcome")
+	}
+}
@@ -0,0 +1,141 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrOpPaused is returned when a failed replication operation is not retried because it has been paused.
+var ErrOpPaused = errors.New("replication operation paused")
+
+// pausedOps is a concurrent set of paused operation IDs, together with the operations dequeued while paused and
+// held until they are resumed.
+type pausedOps struct {
+	mu     sync.Mutex
+	ids    map[uint64]struct{}
+	parked map[uint64]ShardReplicationOp
+}
+
+func newPausedOps() *pausedOps {
+	return &pausedOps{
+		ids:    make(map[uint64]struct{}),
+		parked: make(map[uint64]ShardReplicationOp),
+	}
+}
+
+func (p *pausedOps) pause(id uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ids[id] = struct{}{}
+}
+
+// resume removes the operation from the paused set and returns the operation held while paused, if any.
+func (p *pausedOps) resume(id uint64) (ShardReplicationOp, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.ids, id)
+	op, ok := p.parked[id]
+	delete(p.parked, id)
+	return op, ok
+}
+
+func (p *pausedOps) isPaused(id uint64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.ids[id]
+	return ok
+}
+
+// forget removes the operation from the paused set, dropping the operation held while paused, if any.
+func (p *pausedOps) forget(id uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.ids, id)
+	delete(p.parked, id)
+}
+
+// park holds the operation if it is paused and reports whether it did so.
+func (p *pausedOps) park(op ShardReplicationOp) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.ids[op.ID]; !ok {
+		return false
+	}
+	p.parked[op.ID] = op
+	return true
+}
+
+// PauseOp pauses the replication operation with the given ID. A queued operation is held instead of being
+// processed until it is resumed, while an operation already being processed is allowed to finish but is not
+// retried on failure. The engine running the consumer is notified that the consumer is done with a held operation,
+// releasing the resources reserved for it, until it is resumed.
+func (c *CopyOpConsumer) PauseOp(id uint64) {
+	c.pausedOps.pause(id)
+	c.logger.WithFields(logrus.Fields{"consumer": c, "op": id}).Info("replication operation paused")
+}
+
+// ResumeOp resumes a paused replication operation. An operation held while paused is handed back to the consumer
+// to be processed.
+func (c *CopyOpConsumer) ResumeOp(id uint64) {
+	op, ok := c.pausedOps.resume(id)
+	c.logger.WithFields(logrus.Fields{"consumer": c, "op": id}).Info("replication operation resumed")
+	if !ok {
+		return
+	}
+
+	c.resumedOpsLock.Lock()
+	c.resumedOps = append(c.resumedOps, op)
+	c.resumedOpsLock.Unlock()
+
+	select {
+	case c.resumeSignal <- struct{}{}:
+	default:
+		// A resume signal is already pending and will also pick up this operation
+	}
+}
+
+// IsOpPaused reports whether the replication operation with the given ID is paused.
+func (c *CopyOpConsumer) IsOpPaused(id uint64) bool {
+	return c.pausedOps.isPaused(id)
+}
+
+// takeResumedOps returns and clears the operations resumed since the last call.
+func (c *CopyOpConsumer) takeResumedOps() []ShardReplicationOp {
+	c.resumedOpsLock.Lock()
+	defer c.resumedOpsLock.Unlock()
+	ops := c.resumedOps
+	c.resumedOps = nil
+	return ops
+}
+
+// resumeOp notifies the engine running the consumer, if any, that an operation held while paused is resumed and
+// reports whether it can be dispatched again.
+func (c *CopyOpConsumer) resumeOp(op ShardReplicationOp) bool {
+	if c.onOpResume == nil {
+		return true
+	}
+	if err := c.onOpResume(op); err != nil {
+		c.logger.WithFields(logrus.Fields{"consumer": c, "op": op.ID}).WithError(err).Warn("resumed replication operation rejected by the engine, not dispatching it")
+		return false
+	}
+	return true
+}
+
+// forgetPausedOp drops the pause state of the replication operation with the given ID, including the operation held while
+// paused, if any. It is called once the operation is removed from the replication FSM or aborted.
+func (c *CopyOpConsumer) forgetPausedOp(id uint64) {
+	c.pausedOps.forget(id)
+}
@@ -0,0 +1,53 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPhaseTimeout is wrapped by the error of an operation whose copy or bookkeeping phase exceeded the timeout set
+// with WithCopyTimeout or WithBookkeepingTimeout.
+var ErrPhaseTimeout = errors.New("replication operation phase timed out")
+
+const (
+	phaseCopy        = "copy"
+	phaseBookkeeping = "bookkeeping"
+)
+
+// runPhase runs a phase of an operation with a context canceled once the timeout elapsed, as measured by the timer of
+// the consumer, and returns an error wrapping ErrPhaseTimeout if the phase failed after timing out. A timeout lower
+// than or equal to zero only bounds the phase by the operation timeout.
+func (c *CopyOpConsumer) runPhase(ctx context.Context, phase string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	phaseCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var timedOut atomic.Bool
+	timer := c.timer.AfterFunc(timeout, func() {
+		timedOut.Store(true)
+		cancel()
+	})
+	defer timer.Stop()
+
+	err := fn(phaseCtx)
+	if err != nil && timedOut.Load() {
+		return fmt.Errorf("%w: %s phase exceeded %s: %w", ErrPhaseTimeout, phase, timeout, err)
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrOpPanicked is returned when processing a replication operation panicked.
+var ErrOpPanicked = errors.New("replication operation panicked")
+
+// panickedOp records the panics of a replication operation.
+type panickedOp struct {
+	panics    int
+	lastPanic time.Time
+}
+
+// opQuarantine holds the operations whose processing panicked, so that they are not processed again until a grace
+// period passed, and never again after maxPanics panics.
+type opQuarantine struct {
+	mu          sync.Mutex
+	gracePeriod time.Duration
+	maxPanics   int
+	ops         map[uint64]panickedOp
+}
+
+func newOpQuarantine(gracePeriod time.Duration, maxPanics int) *opQuarantine {
+	return &opQuarantine{
+		gracePeriod: gracePeriod,
+		maxPanics:   maxPanics,
+		ops:         make(map[uint64]panickedOp),
+	}
+}
+
+// recordPanic records a panic of the operation at the given time and returns the number of panics so far. It is a
+// no-op on a nil instance.
+func (q *opQuarantine) recordPanic(id uint64, at time.Time) int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	op := q.ops[id]
+	op.panics++
+	op.lastPanic = at
+	q.ops[id] = op
+	return op.panics
+}
+
+// isQuarantined reports whether the operation must not be processed at the given time, either because its grace
+// period has not passed yet or because it permanently panicked. It is false on a nil instance.
+func (q *opQuarantine) isQuarantined(id uint64, now time.Time) bool {
+	if q == nil {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	op, ok := q.ops[id]
+	if !ok {
+		return false
+	}
+	return q.isPermanent(op) || now.Sub(op.lastPanic) < q.gracePeriod
+}
+
+// isPermanentlyQuarantined reports whether the operation panicked maxPanics times and is never processed again.
+func (q *opQuarantine) isPermanentlyQuarantined(id uint64) bool {
+	if q == nil {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	op, ok := q.ops[id]
+	return ok && q.isPermanent(op)
+}
+
+func (q *opQuarantine) isPermanent(op panickedOp) bool {
+	return q.maxPanics > 0 && op.panics >= q.maxPanics
+}
+
+// recoverOpPanic turns a panic raised while processing the operation into an error wrapping ErrOpPanicked, so that
+// the operation is failed like any other, and quarantines the operation if enabled.
+func (c *CopyOpConsumer) recoverOpPanic(loggers opLoggers, op ShardReplicationOp, recovered any) error {
+	panics := c.quarantine.recordPanic(op.ID, c.timeProvider.Now())
+	loggers.full.WithFields(logrus.Fields{"panic": recovered, "panics": panics, "stack": string(debug.Stack())}).
+		Error("recovered from panic while processing replication operation")
+	if c.quarantine.isPermanentlyQuarantined(op.ID) {
+		loggers.full.WithField("panics", panics).Error("replication operation panicked too many times, quarantining it permanently")
+	}
+	return fmt.Errorf("%w: %v", ErrOpPanicked, recovered)
+}
+
+// IsOpQuarantined reports whether the replication operation with the given ID is currently quarantined after
+// panicking, see WithPanicQuarantine.
+func (c *CopyOpConsumer) IsOpQuarantined(id uint64) bool {
+	return c.quarantine.isQuarantined(id, c.timeProvider.Now())
+}
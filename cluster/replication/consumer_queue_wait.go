@@ -0,0 +1,52 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// ErrOpQueueWaitExceeded is the reason an operation is canceled when it waited longer than the maximum queue wait
+// configured with WithMaxQueueWait.
+var ErrOpQueueWaitExceeded = errors.New("queue wait exceeded")
+
+// queueWaitExceededBy reports whether the operation waited longer than the maximum queue wait since it was queued by
+// the engine. Operations not queued by an engine never exceed it.
+func (c *CopyOpConsumer) queueWaitExceededBy(op ShardReplicationOp) bool {
+	if c.maxQueueWait <= 0 || op.queuedAt.IsZero() {
+		return false
+	}
+	return c.timeProvider.Now().Sub(op.queuedAt) > c.maxQueueWait
+}
+
+// cancelQueuedOp fails an operation that waited too long in the queue without starting it, marking it ABORTED.
+func (c *CopyOpConsumer) cancelQueuedOp(ctx context.Context, op ShardReplicationOp) {
+	queueWait := c.timeProvider.Now().Sub(op.queuedAt)
+	logger := c.logger.WithFields(logrus.Fields{"consumer": c, "op": op.ID, "queue_wait": queueWait})
+	if err := c.markOpTerminal(ctx, op, api.ABORTED); err != nil {
+		logger.WithError(err).Warn("failed to cancel replication operation waiting too long in the queue")
+		return
+	}
+
+	err := fmt.Errorf("%w: waited %s, maximum %s", ErrOpQueueWaitExceeded, queueWait.Round(time.Millisecond), c.maxQueueWait)
+	c.queueWaitExceeded.Inc()
+	c.timeline.record(op.ID, TimelineFailed, "", err.Error())
+	c.failedOps.record(op)
+	logger.WithError(err).Warn("replication operation waited too long in the queue, canceled it")
+}
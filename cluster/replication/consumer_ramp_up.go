@@ -0,0 +1,123 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// workerRampUp raises the worker limit of the consumer from an initial number of workers to maxWorkers over a
+// duration, every time the consumer starts, so that a consumer started with many queued operations does not
+// immediately run all its workers.
+type workerRampUp struct {
+	initialWorkers int
+	duration       time.Duration
+
+	// start is the time the ramp-up started, as measured by the clock of the consumer.
+	start time.Time
+	// signal receives a value every time the worker limit should be raised.
+	signal chan struct{}
+	// timer schedules the next raise of the worker limit.
+	timer *time.Timer
+}
+
+// startRampUp lowers the worker limit to the initial number of workers of the ramp-up, if any, and schedules its
+// first raise. It is called when the consumer starts, while no worker is running.
+func (c *CopyOpConsumer) startRampUp() {
+	if c.rampUp == nil {
+		return
+	}
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+
+	// Dropping a raise signaled while the consumer was last running
+	select {
+	case <-c.rampUp.signal:
+	default:
+	}
+	c.rampUp.start = c.timeProvider.Now()
+	c.resizeWorkers(min(c.Workers(), c.rampUp.initialWorkers))
+	c.logger.WithFields(logrus.Fields{
+		"consumer":        c,
+		"initial_workers": c.Workers(),
+		"max_workers":     c.maxWorkers.Load(),
+		"ramp_up":         c.rampUp.duration,
+	}).Info("ramping up replication workers")
+	c.scheduleRampUpStep()
+}
+
+// stopRampUp cancels the next raise of the worker limit, if any.
+func (c *CopyOpConsumer) stopRampUp() {
+	if c.rampUp != nil && c.rampUp.timer != nil {
+		c.rampUp.timer.Stop()
+	}
+}
+
+// rampUpSignal returns the channel receiving a value every time the worker limit should be raised, or nil without
+// ramp-up.
+func (c *CopyOpConsumer) rampUpSignal() <-chan struct{} {
+	if c.rampUp == nil {
+		return nil
+	}
+	return c.rampUp.signal
+}
+
+// rampUpLimit returns the current worker limit of the ramp-up, growing linearly from the initial number of workers to
+// maxWorkers over the ramp-up duration. It is maxWorkers without ramp-up or once it is over. It is called with the
+// workers lock held.
+func (c *CopyOpConsumer) rampUpLimit() int {
+	maxWorkers := int(c.maxWorkers.Load())
+	if c.rampUp == nil {
+		return maxWorkers
+	}
+	elapsed := c.timeProvider.Now().Sub(c.rampUp.start)
+	if elapsed >= c.rampUp.duration || c.rampUp.initialWorkers >= maxWorkers {
+		return maxWorkers
+	}
+	added := int(int64(maxWorkers-c.rampUp.initialWorkers) * int64(elapsed) / int64(c.rampUp.duration))
+	return c.rampUp.initialWorkers + added
+}
+
+// stepRampUp raises the worker limit to the current limit of the ramp-up and schedules the next raise until the
+// ramp-up is over. With adaptive worker scaling, the ramp-up only caps the number of workers decided by the scaling
+// policy.
+func (c *CopyOpConsumer) stepRampUp() {
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+
+	limit := c.rampUpLimit()
+	if c.scalingPolicy == nil && c.Workers() < limit {
+		c.resizeWorkers(limit)
+		c.logger.WithFields(logrus.Fields{"consumer": c, "workers": c.Workers()}).Debug("ramped up replication workers")
+	}
+	if limit < int(c.maxWorkers.Load()) {
+		c.scheduleRampUpStep()
+	}
+}
+
+// scheduleRampUpStep schedules the next raise of the worker limit by one worker, using the timer of the consumer.
+func (c *CopyOpConsumer) scheduleRampUpStep() {
+	steps := int(c.maxWorkers.Load()) - c.rampUp.initialWorkers
+	if steps <= 0 {
+		return
+	}
+	interval := max(time.Nanosecond, c.rampUp.duration/time.Duration(steps))
+	signal := c.rampUp.signal
+	c.rampUp.timer = c.timer.AfterFunc(interval, func() {
+		select {
+		case signal <- struct{}{}:
+		default:
+		}
+	})
+}
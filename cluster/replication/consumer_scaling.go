@@ -0,0 +1,189 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WorkerScalingPolicy decides how many workers the consumer should run given the number of operations waiting in
+// its queue. The returned value is clamped by the consumer between its minimum and maximum number of workers.
+type WorkerScalingPolicy interface {
+	DesiredWorkers(queueDepth int, currentWorkers int) int
+}
+
+// QueueDepthScalingPolicy grows the number of workers by one while more than GrowAboveDepth operations are waiting
+// and shrinks it by one while the queue is empty.
+type QueueDepthScalingPolicy struct {
+	GrowAboveDepth int
+}
+
+// DesiredWorkers implements WorkerScalingPolicy.
+func (p QueueDepthScalingPolicy) DesiredWorkers(queueDepth int, currentWorkers int) int {
+	switch {
+	case queueDepth > p.GrowAboveDepth:
+		return currentWorkers + 1
+	case queueDepth == 0:
+		return currentWorkers - 1
+	default:
+		return currentWorkers
+	}
+}
+
+// maxWorkersCapacity is the minimum capacity of the worker token pool, bounding the number of workers SetMaxWorkers
+// can raise the limit to.
+const maxWorkersCapacity = 1024
+
+// ErrInvalidMaxWorkers is returned by SetMaxWorkers when given a number of workers it cannot apply.
+var ErrInvalidMaxWorkers = errors.New("invalid maximum number of replication workers")
+
+// Workers returns the number of operations the consumer currently runs concurrently at most. Without adaptive
+// worker scaling nor ramp-up it is always maxWorkers, once the running operations exceeding a lowered maximum
+// completed.
+func (c *CopyOpConsumer) Workers() int {
+	return cap(c.tokens) - int(c.reservedTokens.Load()) - int(c.pendingShrink.Load())
+}
+
+// BusyWorkers returns the number of worker tokens currently held by running operations, excluding the tokens reserved
+// to lower the worker limit.
+func (c *CopyOpConsumer) BusyWorkers() int {
+	return len(c.tokens) - int(c.reservedTokens.Load())
+}
+
+// SetMaxWorkers changes the maximum number of operations the consumer runs concurrently, without interrupting the
+// running operations. Raising the maximum lets more operations start right away, whereas lowering it below the
+// number of running operations lets them complete while no other operation starts until the number of running
+// operations drops below the new maximum. With adaptive worker scaling or a worker ramp-up, the maximum caps the
+// number of workers they decide.
+//
+// It returns an error wrapping ErrInvalidMaxWorkers if n is lower than or equal to zero, or greater than the
+// capacity of the worker token pool, which is the greater of the initial maxWorkers and 1024.
+func (c *CopyOpConsumer) SetMaxWorkers(n int) error {
+	if n <= 0 || n > cap(c.tokens) {
+		return fmt.Errorf("%w: %d, expected between 1 and %d", ErrInvalidMaxWorkers, n, cap(c.tokens))
+	}
+
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+
+	previous := int(c.maxWorkers.Swap(int32(n)))
+	desired := c.rampUpLimit()
+	if c.scalingPolicy != nil {
+		c.minWorkers = min(c.minWorkers, n)
+		desired = max(c.minWorkers, min(desired, c.Workers()))
+	}
+	c.resizeWorkers(desired)
+
+	c.logger.WithFields(logrus.Fields{
+		"consumer":         c,
+		"from_max_workers": previous,
+		"to_max_workers":   n,
+		"workers":          c.Workers(),
+	}).Info("changed maximum number of replication workers")
+	return nil
+}
+
+// reserveIdleTokens lowers the worker limit to the given limit by holding worker tokens. It is called while no
+// worker is running yet.
+func (c *CopyOpConsumer) reserveIdleTokens(limit int) {
+	for c.Workers() > limit {
+		c.tokens <- struct{}{}
+		c.reservedTokens.Add(1)
+	}
+}
+
+// scaleWorkers adjusts the worker limit according to the scaling policy and the given queue depth, without exceeding
+// the limit of the worker ramp-up, if any.
+func (c *CopyOpConsumer) scaleWorkers(queueDepth int) {
+	if c.scalingPolicy == nil {
+		return
+	}
+
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+
+	current := c.Workers()
+	desired := min(int(c.maxWorkers.Load()), max(c.minWorkers, c.scalingPolicy.DesiredWorkers(queueDepth, current)))
+	c.resizeWorkers(min(desired, c.rampUpLimit()))
+
+	if workers := c.Workers(); workers != current {
+		c.logger.WithFields(logrus.Fields{
+			"consumer":     c,
+			"queue_depth":  queueDepth,
+			"from_workers": current,
+			"to_workers":   workers,
+		}).Debug("scaled replication workers")
+	}
+}
+
+// resizeWorkers adjusts the worker limit to desired. It is called with the workers lock held.
+//
+// The worker limit is enforced by holding some of the worker tokens: releasing a held token lets one more worker
+// run, while holding an additional token prevents one. Growing takes effect immediately, whereas shrinking holds the
+// tokens not currently used by a running worker right away and the other ones as the running operations release
+// them, see releaseToken.
+func (c *CopyOpConsumer) resizeWorkers(desired int) {
+	for c.Workers() < desired {
+		if c.pendingShrink.Load() > 0 {
+			c.pendingShrink.Add(-1)
+			continue
+		}
+		// Held tokens are always in the channel, hence receiving never blocks here
+		<-c.tokens
+		c.reservedTokens.Add(-1)
+	}
+
+	for c.Workers() > desired {
+		select {
+		case c.tokens <- struct{}{}:
+			c.reservedTokens.Add(1)
+		default:
+			c.pendingShrink.Add(int32(c.Workers() - desired))
+			return
+		}
+	}
+}
+
+// releaseToken releases the worker token of a completed operation, or holds it if the worker limit was lowered while
+// the operation was running.
+func (c *CopyOpConsumer) releaseToken() {
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+
+	if c.pendingShrink.Load() > 0 {
+		c.pendingShrink.Add(-1)
+		c.reservedTokens.Add(1)
+		return
+	}
+	<-c.tokens
+}
+
+// queueDepthObserver is implemented by consumers relying on the number of operations queued in the engine.
+type queueDepthObserver interface {
+	observeQueueDepth(queueDepth func() int)
+}
+
+func (c *CopyOpConsumer) observeQueueDepth(queueDepth func() int) {
+	c.queueDepth = queueDepth
+}
+
+// currentQueueDepth returns the number of operations waiting to be consumed: the operations queued in the engine if
+// the consumer is run by one, otherwise the operations buffered in the input channel.
+func (c *CopyOpConsumer) currentQueueDepth(in <-chan ShardReplicationOp) int {
+	if c.queueDepth != nil {
+		return c.queueDepth()
+	}
+	return len(in)
+}
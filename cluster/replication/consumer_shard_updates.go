@@ -0,0 +1,116 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"sync"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// shardLocks holds one mutex per shard, identified by its collection and shard name regardless of the node. The mutex
+// of a shard is only held while in use, so that locks of shards not updated anymore do not pile up.
+type shardLocks struct {
+	mu    sync.Mutex
+	locks map[string]*shardLock
+}
+
+// shardLock is the mutex of a shard together with the number of callers holding or waiting for it.
+type shardLock struct {
+	sync.Mutex
+	users int
+}
+
+func newShardLocks() *shardLocks {
+	return &shardLocks{locks: make(map[string]*shardLock)}
+}
+
+// lock locks the mutex of the shard replicated by the operation and returns the function unlocking it.
+func (s *shardLocks) lock(op ShardReplicationOp) func() {
+	key := op.targetShard.collectionId + "/" + op.targetShard.shardId
+
+	s.mu.Lock()
+	entry, ok := s.locks[key]
+	if !ok {
+		entry = &shardLock{}
+		s.locks[key] = entry
+	}
+	entry.users++
+	s.mu.Unlock()
+
+	entry.Lock()
+	return func() {
+		entry.Unlock()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		entry.users--
+		if entry.users == 0 {
+			delete(s.locks, key)
+		}
+	}
+}
+
+// acquireFSMWrite waits until the number of FSM writes in flight is below the cap set with
+// WithMaxConcurrentFSMWrites, if any, and returns the function to call once the write completed. It returns an error
+// only if the context is canceled while waiting.
+func (c *CopyOpConsumer) acquireFSMWrite(ctx context.Context) (func(), error) {
+	if c.fsmWrites == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.fsmWrites <- struct{}{}:
+		return func() { <-c.fsmWrites }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// updateOpStatus updates the status of the operation using the leader FSM updater. With serialized shard updates
+// enabled, updates of operations replicating the same shard are applied one at a time, in the order they are issued.
+func (c *CopyOpConsumer) updateOpStatus(ctx context.Context, op ShardReplicationOp, state api.ShardReplicationState) error {
+	if c.shardUpdateLocks != nil {
+		defer c.shardUpdateLocks.lock(op)()
+	}
+	if c.checkTransition != nil {
+		if err := c.checkTransition(op.ID, state); err != nil {
+			return err
+		}
+	}
+	release, err := c.acquireFSMWrite(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return c.leaderClient.ReplicationUpdateReplicaOpStatus(op.ID, state)
+}
+
+// transitionChecker is implemented by consumers able to check the transitions of operations before proposing them.
+type transitionChecker interface {
+	checkTransitionsWith(check func(id uint64, to api.ShardReplicationState) error)
+}
+
+func (c *CopyOpConsumer) checkTransitionsWith(check func(id uint64, to api.ShardReplicationState) error) {
+	c.checkTransition = check
+}
+
+// addReplicaToShard adds the target replica of the operation to the sharding state using the leader FSM updater.
+func (c *CopyOpConsumer) addReplicaToShard(ctx context.Context, op ShardReplicationOp) error {
+	release, err := c.acquireFSMWrite(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	_, err = c.leaderClient.AddReplicaToShard(ctx, op.targetShard.collectionId, op.targetShard.shardId, op.targetShard.nodeId)
+	return err
+}
@@ -0,0 +1,51 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// waitForSourceQueryLoad blocks while the query load of the source shard replica of the operation, as reported by
+// the source query load provider, is above the configured maximum. It returns an error only if the context is
+// canceled while waiting.
+func (c *CopyOpConsumer) waitForSourceQueryLoad(ctx context.Context, op ShardReplicationOp) error {
+	if c.sourceQueryLoadProvider == nil {
+		return nil
+	}
+	defer c.blockedOps.clear(op.ID)
+
+	for {
+		load := c.sourceQueryLoadProvider.ShardQueryLoad(op.sourceShard.nodeId, op.sourceShard.collectionId, op.sourceShard.shardId)
+		if load <= c.maxSourceQueryLoad {
+			return nil
+		}
+
+		c.logger.WithFields(logrus.Fields{
+			"consumer":          c,
+			"op":                op.ID,
+			"source_shard":      op.sourceShard.String(),
+			"source_query_load": load,
+			"max_query_load":    c.maxSourceQueryLoad,
+		}).Debug("source replica busy serving queries, delaying replication operation")
+		c.blockedOps.set(op.ID, opBlockedSourceLoad)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.sourceQueryLoadPollInterval):
+		}
+	}
+}
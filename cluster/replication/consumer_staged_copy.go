@@ -0,0 +1,47 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+
+	"github.com/weaviate/weaviate/cluster/replication/types"
+)
+
+// isCopyStaged reports whether replicas are copied into a staging area, as enabled with WithStagedCopy.
+func (c *CopyOpConsumer) isCopyStaged() bool {
+	return c.stagedCopy
+}
+
+// promoteStagedReplica atomically swaps the replica staged by a verified copy in as the live replica of the target
+// node, if the copy is staged.
+func (c *CopyOpConsumer) promoteStagedReplica(ctx context.Context, loggers opLoggers, op ShardReplicationOp) error {
+	if !c.isCopyStaged() {
+		return nil
+	}
+	if err := c.replicaCopier.(types.StagedReplicaCopier).PromoteStagedReplica(ctx, op.targetShard.collectionId, op.targetShard.shardId); err != nil {
+		return err
+	}
+	loggers.brief.Info("staged replica promoted to live replica")
+	return nil
+}
+
+// discardStagedReplica cleans up the staging area after a failed copy attempt. The cleanup is not interrupted if the
+// operation is canceled, and failing to clean up is only logged as the next attempt replaces the staged replica.
+func (c *CopyOpConsumer) discardStagedReplica(ctx context.Context, loggers opLoggers, op ShardReplicationOp) {
+	err := c.replicaCopier.(types.StagedReplicaCopier).DiscardStagedReplica(context.WithoutCancel(ctx), op.targetShard.collectionId, op.targetShard.shardId)
+	if err != nil {
+		loggers.full.WithError(err).Warn("failed to discard staged replica")
+		return
+	}
+	loggers.brief.Debug("staged replica discarded")
+}
@@ -0,0 +1,3178 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+	"github.com/weaviate/weaviate/cluster/replication"
+	"github.com/weaviate/weaviate/cluster/replication/replicationtest"
+	"github.com/weaviate/weaviate/cluster/replication/types"
+)
+
+func TestCopyOpConsumer(t *testing.T) {
+	t.Run("ops are processed in arrival order", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything).Return(nil)
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+
+		var mu sync.Mutex
+		var copiedFrom []string
+		mockReplicaCopier.On("CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				mu.Lock()
+				defer mu.Unlock()
+				copiedFrom = append(copiedFrom, args.String(1))
+			}).Return(nil)
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node3",
+			func() backoff.BackOff { return &backoff.StopBackOff{} },
+			time.Minute,
+			1,
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, 4)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node3", "TestCollection", "shard1")
+		opsChan <- replication.NewShardReplicationOp(2, "node2", "node3", "TestCollection", "shard2")
+		opsChan <- replication.NewShardReplicationOp(3, "node1", "node3", "TestCollection", "shard3")
+		opsChan <- replication.NewShardReplicationOp(4, "node2", "node3", "TestCollection", "shard4")
+		close(opsChan)
+
+		// WHEN
+		err := consumer.Consume(context.Background(), opsChan)
+
+		// THEN
+		require.NoError(t, err)
+		require.Equal(t, []string{"node1", "node2", "node1", "node2"}, copiedFrom)
+	})
+
+	t.Run("sharding update uses dedicated backoff policy", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything).Return(nil)
+		mockReplicaCopier.On("CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(errors.New("copy failure")).Once()
+		mockReplicaCopier.On("CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil).Once()
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(uint64(0), errors.New("sharding update failure")).Times(2)
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(uint64(0), nil).Once()
+
+		copyBackoff := &countingBackOff{BackOff: &backoff.ZeroBackOff{}}
+		shardingUpdateBackoff := &countingBackOff{BackOff: &backoff.ZeroBackOff{}}
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return copyBackoff },
+			time.Minute,
+			1,
+			replication.WithShardingUpdateBackoff(func() backoff.BackOff { return shardingUpdateBackoff }),
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		err := consumer.Consume(context.Background(), opsChan)
+
+		// THEN
+		require.NoError(t, err)
+		require.Equal(t, 1, copyBackoff.Retries(), "copy failure should be retried with the main policy")
+		require.Equal(t, 2, shardingUpdateBackoff.Retries(), "sharding update failures should be retried with the dedicated policy")
+		mockReplicaCopier.AssertNumberOfCalls(t, "CopyReplica", 2)
+		mockFSMUpdater.AssertNumberOfCalls(t, "AddReplicaToShard", 3)
+	})
+
+	t.Run("every op is finalized with its own sharding update backoff policy", func(t *testing.T) {
+		// GIVEN a consumer whose sharding updates fail once per shard
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		var failed sync.Map
+		fsmUpdater.AddReplicaFunc = func(ctx context.Context, collection, shard, node string) error {
+			if _, loaded := failed.LoadOrStore(shard, true); !loaded {
+				return errors.New("sharding update failure")
+			}
+			return nil
+		}
+		var policiesLock sync.Mutex
+		var policies []*countingBackOff
+		newPolicy := func() backoff.BackOff {
+			policy := &countingBackOff{BackOff: &backoff.ZeroBackOff{}}
+			policiesLock.Lock()
+			defer policiesLock.Unlock()
+			policies = append(policies, policy)
+			return policy
+		}
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, replicationtest.NewFakeCopier(),
+			replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 4,
+			replication.WithShardingUpdateBackoff(newPolicy))
+
+		const ops = 8
+		opsChan := make(chan replication.ShardReplicationOp, ops)
+		for id := uint64(1); id <= ops; id++ {
+			opsChan <- replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))
+		}
+		close(opsChan)
+
+		// WHEN the ops are finalized concurrently
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN every op retried its failure with a policy of its own
+		for id := uint64(1); id <= ops; id++ {
+			state, _ := fsmUpdater.State(id)
+			require.Equal(t, api.READY, state)
+		}
+		require.Len(t, policies, ops)
+		for _, policy := range policies {
+			require.Equal(t, 1, policy.Retries())
+		}
+	})
+
+	t.Run("high cluster load delays op start", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+		copyStarted := make(chan struct{}, 1)
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything).Return(nil)
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+		mockReplicaCopier.On("CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { copyStarted <- struct{}{} }).Return(nil)
+
+		loadProvider := &fakeClusterLoadProvider{}
+		loadProvider.load.Store(10)
+		clock := replicationtest.NewFakeClock(time.Now())
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} },
+			time.Minute,
+			2,
+			replication.WithClusterLoadThrottling(loadProvider, 5, 10*time.Millisecond),
+			replication.WithConsumerTimer(clock),
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		consumeErrChan := make(chan error, 1)
+		go func() {
+			consumeErrChan <- consumer.Consume(context.Background(), opsChan)
+		}()
+
+		// WHEN the cluster is saturated
+		require.Eventually(t, func() bool { return clock.Waiters() == 1 }, 5*time.Second, time.Millisecond)
+		require.Equal(t, int64(1), loadProvider.queries.Load())
+		clock.Advance(10 * time.Millisecond)
+		require.Eventually(t, func() bool { return clock.Waiters() == 1 }, 5*time.Second, time.Millisecond)
+
+		// THEN the cluster load is polled every interval, without starting the op
+		require.Equal(t, int64(2), loadProvider.queries.Load(), "cluster load should be polled while saturated")
+		select {
+		case <-copyStarted:
+			require.Fail(t, "op should not start while the cluster is saturated")
+		default:
+		}
+
+		// WHEN the cluster load drops
+		loadProvider.load.Store(1)
+		clock.Advance(10 * time.Millisecond)
+
+		// THEN
+		select {
+		case <-copyStarted:
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "op should start once the cluster load drops")
+		}
+		require.NoError(t, <-consumeErrChan)
+	})
+
+	t.Run("op restarted in FINALIZING skips copy and retries sharding update", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+		fsm := newTestFSM(t)
+		require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+		require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.FINALIZING}))
+
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, "TestCollection", "shard1", "node2").
+			Return(uint64(0), errors.New("leader unavailable")).Once()
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, "TestCollection", "shard1", "node2").
+			Return(uint64(0), nil).Once()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), api.READY).Return(nil).Once()
+
+		// The op is emitted by the FSM producer as it would be after an engine restart
+		producer := replication.NewFSMOpProducer(logger, fsm, 10*time.Millisecond, "node2")
+		producerCtx, producerCancel := context.WithCancel(context.Background())
+		producedChan := make(chan replication.ShardReplicationOp, 1)
+		go func() { _ = producer.Produce(producerCtx, producedChan) }()
+		op := <-producedChan
+		producerCancel()
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return &backoff.ZeroBackOff{} },
+			time.Minute,
+			1,
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- op
+		close(opsChan)
+
+		// WHEN
+		err := consumer.Consume(context.Background(), opsChan)
+
+		// THEN
+		require.NoError(t, err)
+		mockReplicaCopier.AssertNotCalled(t, "CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockFSMUpdater.AssertNumberOfCalls(t, "AddReplicaToShard", 2)
+		mockFSMUpdater.AssertNotCalled(t, "ReplicationUpdateReplicaOpStatus", uint64(1), api.HYDRATING)
+	})
+
+	t.Run("bytes copied are attributed to the op cost center", func(t *testing.T) {
+		// GIVEN
+		const metricName = "weaviate_replication_bytes_copied_total"
+		reg := prometheus.NewPedanticRegistry()
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything).Return(nil)
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+
+		copier := &fakeSizedReplicaCopier{sizes: map[string]int64{"shard1": 100, "shard2": 50, "shard3": 25}}
+
+		fsm := newTestFSM(t)
+		requests := []*api.ReplicationReplicateShardRequest{
+			replicateRequest("node1", "node2", "TestCollection", "shard1"),
+			replicateRequest("node1", "node2", "TestCollection", "shard2"),
+			replicateRequest("node1", "node2", "TestCollection", "shard3"),
+		}
+		requests[0].CostCenter = "team-a"
+		requests[1].CostCenter = "team-b"
+		requests[2].CostCenter = "team-a"
+		for i, req := range requests {
+			require.NoError(t, fsm.Replicate(uint64(i+1), req))
+		}
+
+		producer := replication.NewFSMOpProducer(logger, fsm, 10*time.Millisecond, "node2")
+		producerCtx, producerCancel := context.WithCancel(context.Background())
+		producedChan := make(chan replication.ShardReplicationOp, len(requests))
+		go func() { _ = producer.Produce(producerCtx, producedChan) }()
+
+		opsChan := make(chan replication.ShardReplicationOp, len(requests))
+		for range requests {
+			opsChan <- <-producedChan
+		}
+		producerCancel()
+		close(opsChan)
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			copier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} },
+			time.Minute,
+			2,
+			replication.WithConsumerRegisterer(reg),
+		)
+
+		// WHEN
+		err := consumer.Consume(context.Background(), opsChan)
+
+		// THEN
+		require.NoError(t, err)
+		err = testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+# HELP %s Number of bytes copied by replication operations, attributed to the operation cost center
+# TYPE %s counter
+%s{cost_center="team-a"} 125
+%s{cost_center="team-b"} 50
+`, metricName, metricName, metricName, metricName)), metricName)
+		require.NoError(t, err)
+	})
+
+	t.Run("consumer deadline and cancellation are distinguishable", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			ctx         func() (context.Context, context.CancelFunc)
+			expectedErr error
+			contextErr  error
+			logLevel    logrus.Level
+		}{
+			{
+				name: "deadline exceeded",
+				ctx: func() (context.Context, context.CancelFunc) {
+					return context.WithTimeout(context.Background(), 50*time.Millisecond)
+				},
+				expectedErr: replication.ErrConsumerDeadlineExceeded,
+				contextErr:  context.DeadlineExceeded,
+				logLevel:    logrus.WarnLevel,
+			},
+			{
+				name: "canceled",
+				ctx: func() (context.Context, context.CancelFunc) {
+					ctx, cancel := context.WithCancel(context.Background())
+					time.AfterFunc(50*time.Millisecond, cancel)
+					return ctx, cancel
+				},
+				expectedErr: replication.ErrConsumerCanceled,
+				contextErr:  context.Canceled,
+				logLevel:    logrus.InfoLevel,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN
+				logger, hook := logrustest.NewNullLogger()
+				consumer := replication.NewCopyOpConsumer(
+					logger,
+					types.NewMockFSMUpdater(t),
+					types.NewMockReplicaCopier(t),
+					replication.NewMockTimeProvider(t),
+					"node2",
+					func() backoff.BackOff { return &backoff.StopBackOff{} },
+					time.Minute,
+					1,
+				)
+				ctx, cancel := tt.ctx()
+				defer cancel()
+
+				// WHEN
+				err := consumer.Consume(ctx, make(chan replication.ShardReplicationOp))
+
+				// THEN
+				require.ErrorIs(t, err, tt.expectedErr)
+				require.ErrorIs(t, err, tt.contextErr)
+				for _, other := range []error{replication.ErrConsumerDeadlineExceeded, replication.ErrConsumerCanceled} {
+					if other != tt.expectedErr {
+						require.NotErrorIs(t, err, other)
+					}
+				}
+				require.Equal(t, tt.logLevel, hook.LastEntry().Level)
+				require.Equal(t, tt.contextErr, hook.LastEntry().Data["reason"])
+			})
+		}
+	})
+
+	t.Run("compact op logs include the op fields only at op start and on errors", func(t *testing.T) {
+		// GIVEN
+		logger, hook := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything).Return(nil)
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+		mockReplicaCopier.On("CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(errors.New("copy failed")).Once()
+		mockReplicaCopier.On("CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil).Once()
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 1) },
+			time.Minute,
+			1,
+			replication.WithCompactOpLogs(),
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN
+		var opEntries, verboseEntries, errorEntries int
+		for _, entry := range hook.AllEntries() {
+			if entry.Data["op"] != uint64(1) {
+				continue
+			}
+			opEntries++
+			_, verbose := entry.Data["source_node"]
+			switch {
+			case entry.Level == logrus.ErrorLevel:
+				errorEntries++
+				require.True(t, verbose, "error log line should include every op field: %q", entry.Message)
+			case entry.Message == "worker processing replication operation":
+				verboseEntries++
+				require.True(t, verbose, "op start log line should include every op field")
+			default:
+				require.False(t, verbose, "log line after op start should only include the op ID: %q", entry.Message)
+			}
+		}
+		require.Equal(t, 1, verboseEntries, "op fields should be logged once at op start")
+		require.Equal(t, 1, errorEntries, "the failed copy should be logged as an error")
+		require.Greater(t, opEntries, verboseEntries+errorEntries, "op should log lines besides op start and errors")
+	})
+
+	t.Run("adaptive workers scale with queue depth within bounds", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything).Return(nil)
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+
+		release := make(chan struct{})
+		var running atomic.Int32
+		mockReplicaCopier.On("CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				running.Add(1)
+				defer running.Add(-1)
+				<-release
+			}).Return(nil)
+
+		// Ticks are sent by the test to drive the scaling evaluations as a fake clock would
+		ticks := make(chan time.Time)
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} },
+			time.Minute,
+			4,
+			replication.WithAdaptiveWorkers(1, replication.QueueDepthScalingPolicy{GrowAboveDepth: 2}, ticks),
+		)
+		require.Equal(t, 1, consumer.Workers(), "consumer should start with the minimum number of workers")
+
+		opsChan := make(chan replication.ShardReplicationOp, 10)
+		for i := 1; i <= 10; i++ {
+			opsChan <- replication.NewShardReplicationOp(uint64(i), "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", i))
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		consumeErr := make(chan error, 1)
+		go func() {
+			consumeErr <- consumer.Consume(ctx, opsChan)
+		}()
+		require.Eventually(t, func() bool { return running.Load() == 1 }, 5*time.Second, time.Millisecond)
+
+		// WHEN the backlog is large
+		for _, expected := range []int{2, 3, 4, 4} {
+			ticks <- time.Now()
+
+			// THEN workers grow up to the maximum
+			require.Eventually(t, func() bool { return consumer.Workers() == expected }, 5*time.Second, time.Millisecond)
+			require.Eventually(t, func() bool { return int(running.Load()) == expected }, 5*time.Second, time.Millisecond,
+				"running ops should follow the number of workers")
+		}
+
+		// WHEN the backlog is drained and the consumer is idle
+		close(release)
+		require.Eventually(t, func() bool { return len(opsChan) == 0 && running.Load() == 0 }, 5*time.Second, time.Millisecond)
+
+		// THEN workers shrink down to the minimum, as workers complete their ops
+		previous := consumer.Workers()
+		require.Eventually(t, func() bool {
+			ticks <- time.Now()
+			workers := consumer.Workers()
+			require.LessOrEqual(t, workers, previous, "workers should not grow while idle")
+			require.GreaterOrEqual(t, workers, 1, "workers should not shrink below the minimum")
+			previous = workers
+			return workers == 1
+		}, 5*time.Second, time.Millisecond)
+		for i := 0; i < 3; i++ {
+			ticks <- time.Now()
+		}
+		require.Equal(t, 1, consumer.Workers(), "workers should not shrink below the minimum")
+
+		cancel()
+		require.ErrorIs(t, <-consumeErr, replication.ErrConsumerCanceled)
+	})
+
+	t.Run("backoff resets after a failed copy making progress", func(t *testing.T) {
+		tests := []struct {
+			name              string
+			failedCopyBytes   int64
+			expectedIntervals []time.Duration
+		}{
+			{
+				name:              "progressing failures retry after the initial interval",
+				failedCopyBytes:   1024,
+				expectedIntervals: []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond},
+			},
+			{
+				name:              "zero progress failures retry after growing intervals",
+				failedCopyBytes:   0,
+				expectedIntervals: []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN
+				logger, _ := logrustest.NewNullLogger()
+				mockTimeProvider := replication.NewMockTimeProvider(t)
+				mockFSMUpdater := types.NewMockFSMUpdater(t)
+
+				mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+				mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything).Return(nil)
+				mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+
+				copier := &scriptedSizedReplicaCopier{results: []copyResult{
+					{bytes: tt.failedCopyBytes, err: errors.New("connection reset")},
+					{bytes: tt.failedCopyBytes, err: errors.New("connection reset")},
+					{bytes: tt.failedCopyBytes, err: errors.New("connection reset")},
+					{bytes: 2048},
+				}}
+
+				exponential := backoff.NewExponentialBackOff()
+				exponential.InitialInterval = time.Millisecond
+				exponential.Multiplier = 2
+				exponential.RandomizationFactor = 0
+				exponential.MaxElapsedTime = 0
+				backoffPolicy := &recordingBackOff{BackOff: exponential}
+
+				consumer := replication.NewCopyOpConsumer(
+					logger,
+					mockFSMUpdater,
+					copier,
+					mockTimeProvider,
+					"node2",
+					func() backoff.BackOff { return backoffPolicy },
+					time.Minute,
+					1,
+				)
+
+				opsChan := make(chan replication.ShardReplicationOp, 1)
+				opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+				close(opsChan)
+
+				// WHEN
+				err := consumer.Consume(context.Background(), opsChan)
+
+				// THEN
+				require.NoError(t, err)
+				require.Equal(t, 4, copier.calls, "copy should be retried until it succeeds")
+				require.Equal(t, tt.expectedIntervals, backoffPolicy.Intervals())
+			})
+		}
+	})
+
+	t.Run("backoff reset by an op making progress leaves concurrent ops untouched", func(t *testing.T) {
+		// GIVEN two ops copied concurrently, the first one making progress before failing and the second one not
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		progressing := copyResult{bytes: 1024, err: errors.New("connection reset")}
+		stalled := copyResult{err: errors.New("connection reset")}
+		copier := newShardScriptedReplicaCopier(map[string][]copyResult{
+			"shard1": {progressing, progressing, progressing, {bytes: 2048}},
+			"shard2": {stalled, stalled, stalled, {bytes: 2048}},
+		})
+
+		var lock sync.Mutex
+		var policies []*recordingBackOff
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff {
+				exponential := backoff.NewExponentialBackOff()
+				exponential.InitialInterval = time.Millisecond
+				exponential.Multiplier = 2
+				exponential.RandomizationFactor = 0
+				exponential.MaxElapsedTime = 0
+
+				lock.Lock()
+				defer lock.Unlock()
+				policy := &recordingBackOff{BackOff: exponential}
+				policies = append(policies, policy)
+				return policy
+			}, time.Minute, 2)
+
+		opsChan := make(chan replication.ShardReplicationOp, 2)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		opsChan <- replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2")
+		close(opsChan)
+
+		// WHEN
+		err := consumer.Consume(context.Background(), opsChan)
+
+		// THEN the progressing op retries after the initial interval while the stalled op keeps backing off
+		require.NoError(t, err)
+		var intervals [][]time.Duration
+		for _, policy := range policies {
+			if policyIntervals := policy.Intervals(); len(policyIntervals) > 0 {
+				intervals = append(intervals, policyIntervals)
+			}
+		}
+		require.ElementsMatch(t, [][]time.Duration{
+			{time.Millisecond, time.Millisecond, time.Millisecond},
+			{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond},
+		}, intervals)
+	})
+
+	t.Run("op deadlines allow for the clock skew tolerance", func(t *testing.T) {
+		now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		tests := []struct {
+			name          string
+			deadline      time.Time
+			skewTolerance time.Duration
+			wantStarted   bool
+		}{
+			{name: "deadline in the future", deadline: now.Add(time.Minute), wantStarted: true},
+			{name: "passed deadline without tolerance", deadline: now.Add(-time.Minute), wantStarted: false},
+			{name: "passed deadline within the tolerance", deadline: now.Add(-time.Minute), skewTolerance: 2 * time.Minute, wantStarted: true},
+			{name: "passed deadline beyond the tolerance", deadline: now.Add(-time.Minute), skewTolerance: 30 * time.Second, wantStarted: false},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN
+				logger, _ := logrustest.NewNullLogger()
+				mockTimeProvider := replication.NewMockTimeProvider(t)
+				mockFSMUpdater := types.NewMockFSMUpdater(t)
+				mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+				mockTimeProvider.On("Now").Return(now)
+				if tt.wantStarted {
+					mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), mock.Anything).Return(nil)
+					mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+					mockReplicaCopier.On("CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				}
+
+				consumer := replication.NewCopyOpConsumer(
+					logger,
+					mockFSMUpdater,
+					mockReplicaCopier,
+					mockTimeProvider,
+					"node2",
+					func() backoff.BackOff { return &backoff.StopBackOff{} },
+					time.Minute,
+					1,
+					replication.WithClockSkewTolerance(tt.skewTolerance),
+				)
+
+				op := replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+				op.Deadline = tt.deadline
+				opsChan := make(chan replication.ShardReplicationOp, 1)
+				opsChan <- op
+				close(opsChan)
+
+				// WHEN
+				err := consumer.Consume(context.Background(), opsChan)
+
+				// THEN
+				require.NoError(t, err)
+				if tt.wantStarted {
+					mockReplicaCopier.AssertNumberOfCalls(t, "CopyReplica", 1)
+					mockFSMUpdater.AssertCalled(t, "ReplicationUpdateReplicaOpStatus", uint64(1), api.READY)
+				} else {
+					mockReplicaCopier.AssertNotCalled(t, "CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+					mockFSMUpdater.AssertNotCalled(t, "ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything)
+				}
+			})
+		}
+	})
+
+	t.Run("copy is verified by comparing source and target object counts", func(t *testing.T) {
+		tests := []struct {
+			name         string
+			targetCounts []int64
+			wantCopies   int
+		}{
+			{name: "matching count completes the op", targetCounts: []int64{10}, wantCopies: 1},
+			{name: "mismatched count retries the copy", targetCounts: []int64{7, 10}, wantCopies: 2},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN
+				logger, _ := logrustest.NewNullLogger()
+				mockTimeProvider := replication.NewMockTimeProvider(t)
+				mockFSMUpdater := types.NewMockFSMUpdater(t)
+
+				mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+				mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), mock.Anything).Return(nil)
+				mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+
+				copier := &objectCountingReplicaCopier{
+					counts: map[string][]int64{"node1": {10}, "node2": tt.targetCounts},
+				}
+				consumer := replication.NewCopyOpConsumer(
+					logger,
+					mockFSMUpdater,
+					copier,
+					mockTimeProvider,
+					"node2",
+					func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3) },
+					time.Minute,
+					1,
+					replication.WithCopyVerification(),
+				)
+
+				opsChan := make(chan replication.ShardReplicationOp, 1)
+				opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+				close(opsChan)
+
+				// WHEN
+				err := consumer.Consume(context.Background(), opsChan)
+
+				// THEN
+				require.NoError(t, err)
+				require.Equal(t, tt.wantCopies, copier.copies, "the copy should be retried until the counts match")
+				mockFSMUpdater.AssertCalled(t, "ReplicationUpdateReplicaOpStatus", uint64(1), api.READY)
+			})
+		}
+	})
+
+	t.Run("copy verification not supported by the replica copier", func(t *testing.T) {
+		// GIVEN a consumer verifying copies with a replica copier unable to count objects
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		copier := replicationtest.NewFakeCopier()
+		var outcome bytes.Buffer
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3) }, time.Minute, 1,
+			replication.WithCopyVerification(), replication.WithOpOutcomeWriter(&outcome))
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN the op fails without copying an unverifiable replica
+		require.Empty(t, copier.Calls())
+		var record replication.OpOutcomeRecord
+		require.NoError(t, json.Unmarshal(outcome.Bytes(), &record))
+		require.Contains(t, record.Error, replication.ErrCopyVerificationUnsupported.Error())
+	})
+
+	t.Run("op outcomes are written as JSON lines", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+		now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		mockTimeProvider.On("Now").Return(now)
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything).Return(nil)
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+		mockReplicaCopier.On("CopyReplica", mock.Anything, "node1", "TestCollection", "shard1").Return(nil)
+		mockReplicaCopier.On("CopyReplica", mock.Anything, "node1", "TestCollection", "shard2").Return(errors.New("source unreachable"))
+
+		var out bytes.Buffer
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} },
+			time.Minute,
+			1,
+			replication.WithOpOutcomeWriter(&out),
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, 2)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		opsChan <- replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2")
+		close(opsChan)
+
+		// WHEN
+		err := consumer.Consume(context.Background(), opsChan)
+
+		// THEN
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 2, "one record per op should be written")
+
+		records := make(map[float64]map[string]any, len(lines))
+		for _, line := range lines {
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &record), "record should be valid JSON: %s", line)
+			records[record["op_id"].(float64)] = record
+		}
+
+		completed := records[1]
+		require.Equal(t, "completed", completed["outcome"])
+		require.Equal(t, "node1", completed["source_node"])
+		require.Equal(t, "node2", completed["target_node"])
+		require.Equal(t, "TestCollection", completed["collection"])
+		require.Equal(t, "shard1", completed["shard"])
+		require.Equal(t, string(api.READY), completed["end_state"])
+		require.Equal(t, now.Format(time.RFC3339), completed["start_time"])
+		require.Equal(t, now.Format(time.RFC3339), completed["end_time"])
+		require.Contains(t, completed, "duration_ms")
+		require.Contains(t, completed, "bytes_copied")
+		require.NotContains(t, completed, "error")
+		require.NotContains(t, completed, "obsolete", "an op copied should not be reported obsolete")
+
+		failed := records[2]
+		require.Equal(t, "failed", failed["outcome"])
+		require.Equal(t, "shard2", failed["shard"])
+		require.Equal(t, "source unreachable", failed["error"])
+		require.NotContains(t, failed, "end_state")
+	})
+
+	t.Run("async HYDRATING update lets the copy start while the update is committed", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+		copyStarted := make(chan struct{})
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), api.HYDRATING).Run(func(mock.Arguments) {
+			// The commit only completes once the copy started, which deadlocks unless both run concurrently
+			<-copyStarted
+		}).Return(nil)
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), api.FINALIZING).Return(nil)
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), api.READY).Return(nil)
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+		mockReplicaCopier.On("CopyReplica", mock.Anything, "node1", "TestCollection", "shard1").Run(func(mock.Arguments) {
+			close(copyStarted)
+		}).Return(nil)
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} },
+			time.Minute,
+			1,
+			replication.WithAsyncStatusUpdate(),
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := consumer.Consume(ctx, opsChan)
+
+		// THEN
+		require.NoError(t, err)
+		mockFSMUpdater.AssertCalled(t, "ReplicationUpdateReplicaOpStatus", uint64(1), api.READY)
+	})
+
+	t.Run("async HYDRATING update failing after the copy completed fails the attempt", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+		copyDone := make(chan struct{})
+		var commits atomic.Int32
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), api.HYDRATING).Return(func(uint64, api.ShardReplicationState) error {
+			if commits.Add(1) == 1 {
+				// The first commit fails only once the copy completed
+				<-copyDone
+				return errors.New("leader changed")
+			}
+			return nil
+		})
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), api.FINALIZING).Return(nil)
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), api.READY).Return(nil)
+		var finalizedBeforeCommit atomic.Bool
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+			finalizedBeforeCommit.Store(commits.Load() < 2)
+		}).Return(uint64(0), nil)
+		var copies atomic.Int32
+		mockReplicaCopier.On("CopyReplica", mock.Anything, "node1", "TestCollection", "shard1").Run(func(mock.Arguments) {
+			if copies.Add(1) == 1 {
+				close(copyDone)
+			}
+		}).Return(nil)
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 1) },
+			time.Minute,
+			1,
+			replication.WithAsyncStatusUpdate(),
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := consumer.Consume(ctx, opsChan)
+
+		// THEN the failed commit fails the first attempt and the retry copies the replica again
+		require.NoError(t, err)
+		require.Equal(t, int32(2), commits.Load())
+		require.Equal(t, int32(2), copies.Load(), "the copy completed before the failed commit should be retried")
+		require.False(t, finalizedBeforeCommit.Load(), "the op should not be finalized before HYDRATING is committed")
+		mockFSMUpdater.AssertCalled(t, "ReplicationUpdateReplicaOpStatus", uint64(1), api.READY)
+	})
+
+	t.Run("source read concurrency reflects the copies sharing a source shard", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+		reg := prometheus.NewPedanticRegistry()
+
+		var started sync.WaitGroup
+		started.Add(3)
+		release := make(chan struct{})
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything).Return(nil)
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+		mockReplicaCopier.On("CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+			started.Done()
+			<-release
+		}).Return(nil)
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} },
+			time.Minute,
+			3,
+			replication.WithConsumerRegisterer(reg),
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, 3)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		opsChan <- replication.NewShardReplicationOp(2, "node1", "node4", "TestCollection", "shard1")
+		opsChan <- replication.NewShardReplicationOp(3, "node3", "node2", "TestCollection", "shard2")
+		close(opsChan)
+
+		// WHEN
+		consumeErr := make(chan error, 1)
+		go func() {
+			consumeErr <- consumer.Consume(context.Background(), opsChan)
+		}()
+		started.Wait()
+
+		// THEN
+		expected := `
+				# HELP weaviate_replication_source_read_concurrency Number of replica copies concurrently reading from a source shard replica
+				# TYPE weaviate_replication_source_read_concurrency gauge
+				weaviate_replication_source_read_concurrency{collection="TestCollection",node="node1",shard="shard1"} 2
+				weaviate_replication_source_read_concurrency{collection="TestCollection",node="node3",shard="shard2"} 1
+			`
+		require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "weaviate_replication_source_read_concurrency"))
+
+		// WHEN
+		close(release)
+
+		// THEN the series of source replicas no longer read from are deleted
+		require.NoError(t, <-consumeErr)
+		count, err := testutil.GatherAndCount(reg, "weaviate_replication_source_read_concurrency")
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+
+	t.Run("serialized shard updates never interleave status updates of the same shard", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+		const opsCount = 8
+		var inProgress, overlaps atomic.Int32
+		var mu sync.Mutex
+		applied := make(map[uint64][]api.ShardReplicationState)
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			if inProgress.Add(1) > 1 {
+				overlaps.Add(1)
+			}
+			defer inProgress.Add(-1)
+			// Widening the window in which concurrent updates would overlap
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			id := args.Get(0).(uint64)
+			applied[id] = append(applied[id], args.Get(1).(api.ShardReplicationState))
+		}).Return(nil)
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+		mockReplicaCopier.On("CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node0",
+			func() backoff.BackOff { return &backoff.StopBackOff{} },
+			time.Minute,
+			opsCount,
+			replication.WithSerializedShardUpdates(),
+			replication.WithAsyncStatusUpdate(),
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, opsCount)
+		for i := 1; i <= opsCount; i++ {
+			opsChan <- replication.NewShardReplicationOp(uint64(i), "node0", fmt.Sprintf("node%d", i), "TestCollection", "shard1")
+		}
+		close(opsChan)
+
+		// WHEN
+		err := consumer.Consume(context.Background(), opsChan)
+
+		// THEN
+		require.NoError(t, err)
+		require.Zero(t, overlaps.Load(), "status updates of the same shard should never overlap")
+		for i := uint64(1); i <= opsCount; i++ {
+			require.Equal(t, []api.ShardReplicationState{api.HYDRATING, api.FINALIZING, api.READY}, applied[i],
+				"op %d transitions should be applied in causal order", i)
+		}
+	})
+
+	t.Run("op whose target already holds a replica is skipped as obsolete", func(t *testing.T) {
+		tests := []struct {
+			name         string
+			replicas     []string
+			wantObsolete bool
+		}{
+			{name: "target already a replica", replicas: []string{"node1", "node2"}, wantObsolete: true},
+			{name: "target not a replica yet", replicas: []string{"node1"}, wantObsolete: false},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN
+				logger, _ := logrustest.NewNullLogger()
+				mockTimeProvider := replication.NewMockTimeProvider(t)
+				mockFSMUpdater := types.NewMockFSMUpdater(t)
+				mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+				mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+				mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), api.READY).Return(nil)
+				if !tt.wantObsolete {
+					mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), mock.Anything).Return(nil)
+					mockFSMUpdater.On("AddReplicaToShard", mock.Anything, "TestCollection", "shard1", "node2").Return(uint64(0), nil)
+					mockReplicaCopier.On("CopyReplica", mock.Anything, "node1", "TestCollection", "shard1").Return(nil)
+				}
+				leaderClient := &shardingStateFSMUpdater{
+					MockFSMUpdater: mockFSMUpdater,
+					replicas:       map[string][]string{"TestCollection/shard1": tt.replicas},
+				}
+
+				var outcome bytes.Buffer
+				consumer := replication.NewCopyOpConsumer(
+					logger,
+					leaderClient,
+					mockReplicaCopier,
+					mockTimeProvider,
+					"node2",
+					func() backoff.BackOff { return &backoff.StopBackOff{} },
+					time.Minute,
+					1,
+					replication.WithOpOutcomeWriter(&outcome),
+				)
+
+				opsChan := make(chan replication.ShardReplicationOp, 1)
+				opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+				close(opsChan)
+
+				// WHEN
+				err := consumer.Consume(context.Background(), opsChan)
+
+				// THEN
+				require.NoError(t, err)
+				mockFSMUpdater.AssertCalled(t, "ReplicationUpdateReplicaOpStatus", uint64(1), api.READY)
+				if tt.wantObsolete {
+					mockReplicaCopier.AssertNotCalled(t, "CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+					mockFSMUpdater.AssertNotCalled(t, "ReplicationUpdateReplicaOpStatus", uint64(1), api.HYDRATING)
+					mockFSMUpdater.AssertNotCalled(t, "AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				} else {
+					mockReplicaCopier.AssertNumberOfCalls(t, "CopyReplica", 1)
+				}
+				var record replication.OpOutcomeRecord
+				require.NoError(t, json.Unmarshal(outcome.Bytes(), &record))
+				require.Equal(t, "completed", record.Outcome)
+				require.Equal(t, api.READY, record.EndState)
+				require.Equal(t, tt.wantObsolete, record.Obsolete, "the outcome should tell whether the op was skipped as obsolete")
+			})
+		}
+	})
+
+	t.Run("batch commit checkpoints every batch and resumes a failed copy from the last committed batch", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		leaderClient := &checkpointFSMUpdater{MockFSMUpdater: mockFSMUpdater}
+		copier := &fakeBatchReplicaCopier{batches: 5, failures: map[int]int{2: 1}}
+
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), mock.Anything).Return(nil)
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, "TestCollection", "shard1", "node2").Return(uint64(0), nil)
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			leaderClient,
+			copier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3) },
+			time.Minute,
+			1,
+			replication.WithBatchCommit(),
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		err := consumer.Consume(context.Background(), opsChan)
+
+		// THEN the checkpoint advances per batch and the failed batch is copied again, without the committed ones
+		require.NoError(t, err)
+		require.Equal(t, []int{0, 1, 2, 2, 3, 4}, copier.copiedBatches())
+		require.Equal(t, []int{1, 2, 3, 4, 5}, leaderClient.checkpoints())
+		mockFSMUpdater.AssertNumberOfCalls(t, "ReplicationUpdateReplicaOpStatus", 4)
+		mockFSMUpdater.AssertCalled(t, "ReplicationUpdateReplicaOpStatus", uint64(1), api.READY)
+	})
+
+	t.Run("batch commit is ignored for copiers not supporting batches", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+		leaderClient := &checkpointFSMUpdater{MockFSMUpdater: mockFSMUpdater}
+
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), mock.Anything).Return(nil)
+		mockFSMUpdater.On("AddReplicaToShard", mock.Anything, "TestCollection", "shard1", "node2").Return(uint64(0), nil)
+		mockReplicaCopier.On("CopyReplica", mock.Anything, "node1", "TestCollection", "shard1").Return(nil).Once()
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			leaderClient,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} },
+			time.Minute,
+			1,
+			replication.WithBatchCommit(),
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		err := consumer.Consume(context.Background(), opsChan)
+
+		// THEN
+		require.NoError(t, err)
+		require.Empty(t, leaderClient.checkpoints())
+		mockFSMUpdater.AssertCalled(t, "ReplicationUpdateReplicaOpStatus", uint64(1), api.READY)
+	})
+
+	t.Run("op panicking deterministically is quarantined instead of looping", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+		var nowLock sync.Mutex
+		now := time.Now()
+		advance := func(d time.Duration) {
+			nowLock.Lock()
+			defer nowLock.Unlock()
+			now = now.Add(d)
+		}
+		mockTimeProvider.EXPECT().Now().RunAndReturn(func() time.Time {
+			nowLock.Lock()
+			defer nowLock.Unlock()
+			return now
+		}).Maybe()
+		mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), api.HYDRATING).Return(nil)
+		mockReplicaCopier.On("CopyReplica", mock.Anything, "node1", "TestCollection", "shard1").Run(func(mock.Arguments) {
+			panic("nil pointer dereference in shard metadata")
+		})
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} },
+			time.Minute,
+			1,
+			replication.WithPanicQuarantine(time.Minute, 3),
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp)
+		consumerDone := make(chan error, 1)
+		go func() {
+			consumerDone <- consumer.Consume(context.Background(), opsChan)
+		}()
+		// A paused op is only parked by the consumer, hence receiving it guarantees the previous op was handled
+		consumer.PauseOp(2)
+		barrier := replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2")
+		// emit mimics the producer re-emitting the op, waiting for the consumer to be done with it
+		emit := func() {
+			opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+			opsChan <- barrier
+			require.Eventually(t, func() bool {
+				return len(consumer.InFlightOps()) == 0
+			}, 5*time.Second, time.Millisecond)
+		}
+
+		// WHEN the op panics and is re-emitted right away
+		emit()
+		emit()
+
+		// THEN it is not processed again during the grace period
+		require.True(t, consumer.IsOpQuarantined(1))
+		mockReplicaCopier.AssertNumberOfCalls(t, "CopyReplica", 1)
+
+		// WHEN the op keeps panicking once the grace periods pass
+		advance(2 * time.Minute)
+		emit()
+		advance(2 * time.Minute)
+		emit()
+
+		// THEN it is quarantined permanently
+		advance(time.Hour)
+		emit()
+		emit()
+		require.True(t, consumer.IsOpQuarantined(1))
+		mockReplicaCopier.AssertNumberOfCalls(t, "CopyReplica", 3)
+
+		close(opsChan)
+		require.NoError(t, <-consumerDone)
+	})
+
+	t.Run("encrypted transport is requested from the copier when configured", func(t *testing.T) {
+		tests := []struct {
+			name              string
+			encrypt           bool
+			expectedTransport string
+		}{
+			{name: "encrypted", encrypt: true, expectedTransport: "encrypted"},
+			{name: "plaintext", encrypt: false, expectedTransport: "plaintext"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN
+				logger, _ := logrustest.NewNullLogger()
+				reg := prometheus.NewPedanticRegistry()
+				mockTimeProvider := replication.NewMockTimeProvider(t)
+				mockFSMUpdater := types.NewMockFSMUpdater(t)
+				copier := &encryptedReplicaCopier{}
+
+				mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+				mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), mock.Anything).Return(nil)
+				mockFSMUpdater.On("AddReplicaToShard", mock.Anything, "TestCollection", "shard1", "node2").Return(uint64(0), nil)
+
+				tlsConfig := &tls.Config{MinVersion: tls.VersionTLS13}
+				opts := []replication.CopyOpConsumerOption{replication.WithConsumerRegisterer(reg)}
+				if tt.encrypt {
+					opts = append(opts, replication.WithEncryptedTransport(tlsConfig))
+				}
+				consumer := replication.NewCopyOpConsumer(logger, mockFSMUpdater, copier, mockTimeProvider, "node2",
+					func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 1, opts...)
+
+				opsChan := make(chan replication.ShardReplicationOp, 1)
+				opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+				close(opsChan)
+
+				// WHEN
+				err := consumer.Consume(context.Background(), opsChan)
+
+				// THEN
+				require.NoError(t, err)
+				mockFSMUpdater.AssertCalled(t, "ReplicationUpdateReplicaOpStatus", uint64(1), api.READY)
+				if tt.encrypt {
+					require.Equal(t, []*tls.Config{tlsConfig}, copier.encryptedCopies)
+					require.Zero(t, copier.plaintextCopies)
+				} else {
+					require.Empty(t, copier.encryptedCopies)
+					require.Equal(t, 1, copier.plaintextCopies)
+				}
+				metricName := "weaviate_replication_copy_attempts_total"
+				require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+# HELP %s Number of replica copy attempts, labeled by transport (encrypted or plaintext)
+# TYPE %s counter
+%s{transport="%s"} 1
+`, metricName, metricName, metricName, tt.expectedTransport)), metricName))
+			})
+		}
+	})
+
+	t.Run("encrypted transport required but unsupported by the copier fails the op", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockTimeProvider := replication.NewMockTimeProvider(t)
+		mockFSMUpdater := types.NewMockFSMUpdater(t)
+		mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+		mockTimeProvider.On("Now").Return(time.Now()).Maybe()
+		var outcome bytes.Buffer
+
+		consumer := replication.NewCopyOpConsumer(
+			logger,
+			mockFSMUpdater,
+			mockReplicaCopier,
+			mockTimeProvider,
+			"node2",
+			func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3) },
+			time.Minute,
+			1,
+			replication.WithEncryptedTransport(&tls.Config{MinVersion: tls.VersionTLS13}),
+			replication.WithOpOutcomeWriter(&outcome),
+		)
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		err := consumer.Consume(context.Background(), opsChan)
+
+		// THEN the op fails without any copy nor status update
+		require.NoError(t, err)
+		var record replication.OpOutcomeRecord
+		require.NoError(t, json.Unmarshal(outcome.Bytes(), &record))
+		require.Contains(t, record.Error, replication.ErrEncryptionUnsupported.Error())
+		mockReplicaCopier.AssertNotCalled(t, "CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockFSMUpdater.AssertNotCalled(t, "ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("op completing while the consumer stops leaves a deterministic FSM state", func(t *testing.T) {
+		tests := []struct {
+			name string
+			// copyFunc and addReplicaFunc are given the function stopping the consumer, to stop it at a precise step
+			copyFunc       func(stop context.CancelFunc) func(ctx context.Context, sourceNode, collection, shard string) error
+			addReplicaFunc func(stop context.CancelFunc) func(ctx context.Context, collection, shard, node string) error
+			expected       []api.ShardReplicationState
+			replicaAdded   bool
+		}{
+			{
+				name: "sharding update committing before the stop is observed is fully recorded",
+				addReplicaFunc: func(stop context.CancelFunc) func(context.Context, string, string, string) error {
+					return func(context.Context, string, string, string) error {
+						stop()
+						return nil
+					}
+				},
+				expected:     []api.ShardReplicationState{api.HYDRATING, api.FINALIZING, api.READY},
+				replicaAdded: true,
+			},
+			{
+				name: "copy completing as the consumer stops is recorded and left restartable",
+				copyFunc: func(stop context.CancelFunc) func(context.Context, string, string, string) error {
+					return func(context.Context, string, string, string) error {
+						stop()
+						return nil
+					}
+				},
+				addReplicaFunc: func(context.CancelFunc) func(context.Context, string, string, string) error {
+					return func(ctx context.Context, _, _, _ string) error {
+						return ctx.Err()
+					}
+				},
+				expected: []api.ShardReplicationState{api.HYDRATING, api.FINALIZING},
+			},
+			{
+				name: "sharding update interrupted mid-commit is left restartable",
+				addReplicaFunc: func(stop context.CancelFunc) func(context.Context, string, string, string) error {
+					return func(context.Context, string, string, string) error {
+						stop()
+						return context.Canceled
+					}
+				},
+				expected: []api.ShardReplicationState{api.HYDRATING, api.FINALIZING},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN
+				logger, _ := logrustest.NewNullLogger()
+				ctx, stop := context.WithCancel(context.Background())
+				defer stop()
+				fsmUpdater := replicationtest.NewFakeFSMUpdater()
+				copier := replicationtest.NewFakeCopier()
+				if tt.copyFunc != nil {
+					copier.CopyFunc = tt.copyFunc(stop)
+				}
+				fsmUpdater.AddReplicaFunc = tt.addReplicaFunc(stop)
+
+				consumer := replication.NewCopyOpConsumer(
+					logger,
+					fsmUpdater,
+					copier,
+					replication.RealTimeProvider{},
+					"node2",
+					func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3) },
+					time.Minute,
+					1,
+				)
+
+				opsChan := make(chan replication.ShardReplicationOp, 1)
+				opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+
+				// WHEN
+				err := consumer.Consume(ctx, opsChan)
+
+				// THEN
+				require.ErrorIs(t, err, replication.ErrConsumerCanceled)
+				require.Equal(t, tt.expected, fsmUpdater.StateHistory(1))
+				if tt.replicaAdded {
+					require.Len(t, fsmUpdater.AddedReplicas(), 1)
+				} else {
+					require.Empty(t, fsmUpdater.AddedReplicas())
+				}
+			})
+		}
+	})
+
+	t.Run("copy failures are retried with the backoff policy of their error category", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			copyErr        error
+			expectFast     int
+			expectSlow     int
+			expectFallback int
+		}{
+			{
+				name:       "network error",
+				copyErr:    fmt.Errorf("copy shard: %w", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}),
+				expectFast: 1,
+			},
+			{
+				name:       "disk error",
+				copyErr:    fmt.Errorf("write segment: %w", &os.PathError{Op: "write", Path: "/data/segment", Err: syscall.ENOSPC}),
+				expectSlow: 1,
+			},
+			{
+				name:           "other error",
+				copyErr:        errors.New("copy failure"),
+				expectFallback: 1,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN
+				logger, _ := logrustest.NewNullLogger()
+				mockFSMUpdater := types.NewMockFSMUpdater(t)
+				mockReplicaCopier := types.NewMockReplicaCopier(t)
+
+				mockFSMUpdater.On("ReplicationUpdateReplicaOpStatus", mock.Anything, mock.Anything).Return(nil)
+				mockFSMUpdater.On("AddReplicaToShard", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil)
+				mockReplicaCopier.On("CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(tt.copyErr).Once()
+				mockReplicaCopier.On("CopyReplica", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil).Once()
+
+				fallbackBackoff := &countingBackOff{BackOff: &backoff.ZeroBackOff{}}
+				fastBackoff := &countingBackOff{BackOff: &backoff.ZeroBackOff{}}
+				slowBackoff := &countingBackOff{BackOff: &backoff.ZeroBackOff{}}
+
+				consumer := replication.NewCopyOpConsumer(
+					logger,
+					mockFSMUpdater,
+					mockReplicaCopier,
+					replication.RealTimeProvider{},
+					"node2",
+					func() backoff.BackOff { return fallbackBackoff },
+					time.Minute,
+					1,
+					replication.WithErrorCategoryBackoff(map[replication.ErrorCategory]func() backoff.BackOff{
+						replication.ErrorCategoryNetwork: func() backoff.BackOff { return fastBackoff },
+						replication.ErrorCategoryDisk:    func() backoff.BackOff { return slowBackoff },
+					}),
+				)
+
+				opsChan := make(chan replication.ShardReplicationOp, 1)
+				opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+				close(opsChan)
+
+				// WHEN
+				err := consumer.Consume(context.Background(), opsChan)
+
+				// THEN
+				require.NoError(t, err)
+				require.Equal(t, tt.expectFast, fastBackoff.Retries(), "retries with the network policy")
+				require.Equal(t, tt.expectSlow, slowBackoff.Retries(), "retries with the disk policy")
+				require.Equal(t, tt.expectFallback, fallbackBackoff.Retries(), "retries with the main policy")
+				mockReplicaCopier.AssertNumberOfCalls(t, "CopyReplica", 2)
+			})
+		}
+	})
+
+	t.Run("each operation retries with its own backoff policy", func(t *testing.T) {
+		// GIVEN two ops copied concurrently, each failing twice before succeeding
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		copier := newShardScriptedReplicaCopier(map[string][]copyResult{
+			"shard1": {{err: errors.New("connection reset")}, {err: errors.New("connection reset")}, {bytes: 1024}},
+			"shard2": {{err: errors.New("connection reset")}, {err: errors.New("connection reset")}, {bytes: 1024}},
+		})
+
+		var lock sync.Mutex
+		var policies []*countingBackOff
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff {
+				lock.Lock()
+				defer lock.Unlock()
+				policy := &countingBackOff{BackOff: backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)}
+				policies = append(policies, policy)
+				return policy
+			}, time.Minute, 2)
+
+		opsChan := make(chan replication.ShardReplicationOp, 2)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		opsChan <- replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2")
+		close(opsChan)
+
+		// WHEN
+		err := consumer.Consume(context.Background(), opsChan)
+
+		// THEN both ops complete within their own retry budget
+		require.NoError(t, err)
+		for _, id := range []uint64{1, 2} {
+			state, _ := fsmUpdater.State(id)
+			require.Equal(t, api.READY, state, "op %d should complete", id)
+		}
+		var retries []int
+		for _, policy := range policies {
+			retries = append(retries, policy.Retries())
+		}
+		require.ElementsMatch(t, []int{2, 2, 0, 0}, retries,
+			"a copy policy retried twice and an unused sharding update policy should be created for each op")
+	})
+
+	t.Run("completion log reports the attempts and the accumulated backoff", func(t *testing.T) {
+		// GIVEN a copier failing twice then succeeding, with a constant retry interval
+		logger, hook := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		copier := replicationtest.NewFakeCopier()
+		var copies atomic.Int32
+		copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+			if copies.Add(1) <= 2 {
+				return errors.New("copy failed")
+			}
+			return nil
+		}
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff {
+				return backoff.WithMaxRetries(backoff.NewConstantBackOff(5*time.Millisecond), 3)
+			}, time.Minute, 1)
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN
+		var completed *logrus.Entry
+		for _, entry := range hook.AllEntries() {
+			if entry.Message == "Replication operation completed successfully" {
+				completed = entry
+			}
+		}
+		require.NotNil(t, completed)
+		require.Equal(t, 3, completed.Data["attempts"])
+		require.Equal(t, 10*time.Millisecond, completed.Data["total_backoff"])
+	})
+
+	t.Run("identical copy errors are logged once per interval", func(t *testing.T) {
+		// GIVEN a copier failing five times with the same error then succeeding, retried at once
+		logger, hook := logrustest.NewNullLogger()
+		copier := replicationtest.NewFakeCopier()
+		var copies atomic.Int32
+		copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+			if copies.Add(1) <= 5 {
+				return errors.New("connection refused")
+			}
+			return nil
+		}
+		consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), copier,
+			replicationtest.NewFakeClock(time.Now()), "node2", func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 5) }, time.Minute, 1,
+			replication.WithErrorLogInterval(time.Minute))
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN the error is logged once, and the identical errors not logged are summarized
+		var copyFailures, summaries []*logrus.Entry
+		for _, entry := range hook.AllEntries() {
+			switch entry.Message {
+			case "failure while copying replica shard":
+				copyFailures = append(copyFailures, entry)
+			case "identical replication operation errors were not logged":
+				summaries = append(summaries, entry)
+			}
+		}
+		require.Equal(t, 5, int(copies.Load())-1)
+		require.Len(t, copyFailures, 1)
+		require.Len(t, summaries, 1)
+		require.Equal(t, "connection refused", summaries[0].Data["error"])
+		require.Equal(t, 4, summaries[0].Data["suppressed"])
+	})
+
+	t.Run("ops reading from a source shard with a high query load are delayed", func(t *testing.T) {
+		// GIVEN a source shard busy serving queries
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		copier := replicationtest.NewFakeCopier()
+		loadProvider := &fakeSourceQueryLoadProvider{busyShard: "busy"}
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 2,
+			replication.WithSourceQueryLoadThrottling(loadProvider, 100, time.Millisecond))
+
+		opsChan := make(chan replication.ShardReplicationOp, 2)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "busy")
+		opsChan <- replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "idle")
+		close(opsChan)
+
+		// WHEN
+		consumeErr := make(chan error, 1)
+		go func() {
+			consumeErr <- consumer.Consume(context.Background(), opsChan)
+		}()
+
+		// THEN the op reading from the idle shard proceeds while the other one is delayed
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(2, api.READY)))
+		require.Eventually(t, func() bool {
+			reason, _ := consumer.OpBlockReason(1)
+			return strings.Contains(reason, "source replica query load")
+		}, 5*time.Second, time.Millisecond)
+		_, started := fsmUpdater.State(1)
+		require.False(t, started, "the op reading from the busy shard should not be started")
+		require.Equal(t, []replicationtest.CopyCall{{SourceNode: "node1", Collection: "TestCollection", Shard: "idle"}}, copier.Calls())
+
+		// THEN the delayed op proceeds once the query load drops
+		loadProvider.cooled.Store(true)
+		require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(1, api.READY)))
+		require.NoError(t, <-consumeErr)
+	})
+
+	t.Run("token observer is notified of balanced token acquisitions and releases", func(t *testing.T) {
+		// GIVEN a consumer with two workers and a copier blocking until released
+		logger, _ := logrustest.NewNullLogger()
+		copier := replicationtest.NewFakeCopier()
+		release := make(chan struct{})
+		copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+			<-release
+			return nil
+		}
+		observer := &recordingTokenObserver{}
+		consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), copier,
+			replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 2,
+			replication.WithTokenObserver(observer))
+
+		opsChan := make(chan replication.ShardReplicationOp, 3)
+		for id := uint64(1); id <= 3; id++ {
+			opsChan <- replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))
+		}
+		close(opsChan)
+
+		// WHEN
+		consumeErr := make(chan error, 1)
+		go func() {
+			consumeErr <- consumer.Consume(context.Background(), opsChan)
+		}()
+
+		// THEN both workers hold a token while the copies are blocked
+		require.Eventually(t, func() bool {
+			return len(observer.snapshot()) == 2
+		}, 5*time.Second, time.Millisecond)
+		events := observer.snapshot()
+		require.Equal(t, replication.TokenPoolUtilization{InUse: 2, Limit: 2}, events[1].utilization)
+		require.True(t, events[0].acquired && events[1].acquired)
+
+		// THEN every acquisition is followed by a release of the same op once the copies complete
+		close(release)
+		require.NoError(t, <-consumeErr)
+		events = observer.snapshot()
+		require.Len(t, events, 6)
+		held := map[uint64]bool{}
+		inUse := 0
+		for _, event := range events {
+			require.Equal(t, 2, event.utilization.Limit)
+			if event.acquired {
+				require.False(t, held[event.opID], "token acquired twice for op %d", event.opID)
+				held[event.opID] = true
+				inUse++
+			} else {
+				require.True(t, held[event.opID], "token released without being acquired for op %d", event.opID)
+				delete(held, event.opID)
+				inUse--
+			}
+			require.LessOrEqual(t, event.utilization.InUse, 2)
+			require.GreaterOrEqual(t, event.utilization.InUse, 0)
+		}
+		require.Empty(t, held)
+		require.Zero(t, inUse)
+		require.Equal(t, replication.TokenPoolUtilization{InUse: 0, Limit: 2}, events[len(events)-1].utilization)
+	})
+
+	t.Run("completion webhook receives the outcome of completed and failed ops", func(t *testing.T) {
+		// GIVEN a webhook failing its first request, and a copier failing the copies of shard2
+		var (
+			mu       sync.Mutex
+			requests int
+			records  = map[uint64]replication.OpOutcomeRecord{}
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			requests++
+			if requests == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			var record replication.OpOutcomeRecord
+			if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" ||
+				json.NewDecoder(r.Body).Decode(&record) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			records[record.OpID] = record
+		}))
+		defer server.Close()
+
+		logger, _ := logrustest.NewNullLogger()
+		copier := replicationtest.NewFakeCopier()
+		copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+			if shard == "shard2" {
+				return errors.New("disk full")
+			}
+			return nil
+		}
+		consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), copier,
+			replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 1,
+			replication.WithOpCompletionWebhook(server.URL, time.Second, 3))
+
+		opsChan := make(chan replication.ShardReplicationOp, 2)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		opsChan <- replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2")
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN both outcomes are posted before the consumer returns, despite the failed request being retried
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, records, 2)
+		require.Equal(t, 3, requests, "only the failed request should be retried")
+
+		completed := records[1]
+		require.Equal(t, "completed", completed.Outcome)
+		require.Equal(t, api.READY, completed.EndState)
+		require.Equal(t, "shard1", completed.Shard)
+		require.Empty(t, completed.Error)
+		require.False(t, completed.StartTime.IsZero())
+		require.False(t, completed.EndTime.Before(completed.StartTime))
+
+		failed := records[2]
+		require.Equal(t, "failed", failed.Outcome)
+		require.Empty(t, failed.EndState)
+		require.Equal(t, "shard2", failed.Shard)
+		require.Contains(t, failed.Error, "disk full")
+		require.False(t, failed.StartTime.IsZero())
+	})
+
+	t.Run("completion webhook posts are aborted once the consumer stops", func(t *testing.T) {
+		// GIVEN a webhook never responding
+		received := make(chan struct{}, 1)
+		aborted := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The connection is only watched for the client going away once the body is read
+			_, _ = io.Copy(io.Discard, r.Body)
+			received <- struct{}{}
+			<-r.Context().Done()
+			close(aborted)
+		}))
+		defer server.Close()
+
+		logger, _ := logrustest.NewNullLogger()
+		consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), replicationtest.NewFakeCopier(),
+			replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 1,
+			replication.WithOpCompletionWebhook(server.URL, time.Minute, 3))
+		engine := replication.NewShardReplicationEngine(logger, "node2", replicationtest.NewFakeProducer(1), consumer, 1, 1, time.Minute)
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		consumed := make(chan error, 1)
+		go func() { consumed <- consumer.Consume(ctx, opsChan) }()
+		<-received
+
+		// WHEN
+		cancel()
+
+		// THEN the pending post is aborted and the consumer no longer holds its goroutine once returned
+		select {
+		case err := <-consumed:
+			require.ErrorIs(t, err, replication.ErrConsumerCanceled)
+		case <-time.After(5 * time.Second):
+			t.Fatal("consumer did not return once its context was canceled")
+		}
+		require.Zero(t, engine.Diagnostics().Goroutines)
+		select {
+		case <-aborted:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the webhook request was not aborted")
+		}
+	})
+
+	t.Run("concurrent FSM writes are capped independently of the workers", func(t *testing.T) {
+		// GIVEN many workers and an FSM updater whose writes take a while and track how many are in flight
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		var inFlight, maxInFlight, writes atomic.Int32
+		write := func() {
+			writes.Add(1)
+			n := inFlight.Add(1)
+			for {
+				current := maxInFlight.Load()
+				if n <= current || maxInFlight.CompareAndSwap(current, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			inFlight.Add(-1)
+		}
+		fsmUpdater.UpdateStatusFunc = func(id uint64, state api.ShardReplicationState) error {
+			write()
+			return nil
+		}
+		fsmUpdater.AddReplicaFunc = func(ctx context.Context, collection, shard, node string) error {
+			write()
+			return nil
+		}
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, replicationtest.NewFakeCopier(),
+			replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 8,
+			replication.WithMaxConcurrentFSMWrites(2))
+
+		const ops = 16
+		opsChan := make(chan replication.ShardReplicationOp, ops)
+		for id := uint64(1); id <= ops; id++ {
+			opsChan <- replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))
+		}
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN every op is completed without exceeding the cap
+		for id := uint64(1); id <= ops; id++ {
+			state, _ := fsmUpdater.State(id)
+			require.Equal(t, api.READY, state)
+		}
+		require.Equal(t, int32(4*ops), writes.Load(), "each op should issue three status updates and one sharding update")
+		require.Equal(t, int32(2), maxInFlight.Load(), "no more than the cap of FSM writes should be in flight")
+	})
+
+	t.Run("ops waiting for an FSM write stop waiting once the context is canceled", func(t *testing.T) {
+		// GIVEN a consumer allowing a single FSM write whose slot is held by the stalled first write of an op
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		var stalledID atomic.Uint64
+		stalled := make(chan struct{})
+		release := make(chan struct{})
+		fsmUpdater.UpdateStatusFunc = func(id uint64, state api.ShardReplicationState) error {
+			if stalledID.CompareAndSwap(0, id) {
+				close(stalled)
+			}
+			if stalledID.Load() == id {
+				<-release
+			}
+			return nil
+		}
+		outcomes := make(chan []byte, 2)
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, replicationtest.NewFakeCopier(),
+			replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 2,
+			replication.WithMaxConcurrentFSMWrites(1), replication.WithOpOutcomeWriter(outcomeChanWriter(outcomes)))
+
+		opsChan := make(chan replication.ShardReplicationOp, 2)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		opsChan <- replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2")
+		close(opsChan)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		consumeErr := make(chan error, 1)
+		go func() {
+			consumeErr <- consumer.Consume(ctx, opsChan)
+		}()
+		<-stalled
+		waitingID := 3 - stalledID.Load()
+		require.Eventually(t, func() bool {
+			return slices.Contains(consumer.InFlightOps(), waitingID)
+		}, 5*time.Second, time.Millisecond)
+
+		// WHEN the context is canceled while the write holding the slot is still stalled
+		cancel()
+
+		// THEN the other op fails with the context error instead of waiting for the slot
+		select {
+		case line := <-outcomes:
+			var record replication.OpOutcomeRecord
+			require.NoError(t, json.Unmarshal(line, &record))
+			require.Equal(t, waitingID, record.OpID)
+			require.Contains(t, record.Error, context.Canceled.Error())
+		case <-time.After(5 * time.Second):
+			t.Fatal("op kept waiting for an FSM write slot after the context was canceled")
+		}
+		_, updated := fsmUpdater.State(waitingID)
+		require.False(t, updated, "the waiting op should never issue its FSM write")
+
+		// THEN the consumer returns once the stalled write completes, as every op was already dequeued
+		close(release)
+		require.NoError(t, <-consumeErr)
+	})
+
+	t.Run("ops targeting another node are skipped in strict mode only", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			opts          []replication.CopyOpConsumerOption
+			wantProcessed bool
+		}{
+			{name: "strict", opts: []replication.CopyOpConsumerOption{replication.WithStrictTargetNode()}, wantProcessed: false},
+			{name: "lenient", wantProcessed: true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN a consumer on node2 and an op targeting node3
+				logger, _ := logrustest.NewNullLogger()
+				fsmUpdater := replicationtest.NewFakeFSMUpdater()
+				copier := replicationtest.NewFakeCopier()
+				consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier,
+					replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 1, tt.opts...)
+
+				opsChan := make(chan replication.ShardReplicationOp, 2)
+				opsChan <- replication.NewShardReplicationOp(1, "node1", "node3", "TestCollection", "shard1")
+				opsChan <- replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2")
+				close(opsChan)
+
+				// WHEN
+				require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+				// THEN the op targeting the node of the consumer is always processed
+				state, _ := fsmUpdater.State(2)
+				require.Equal(t, api.READY, state)
+
+				// THEN the misrouted op is only processed in lenient mode
+				_, updated := fsmUpdater.State(1)
+				require.Equal(t, tt.wantProcessed, updated)
+				wantCalls := []replicationtest.CopyCall{{SourceNode: "node1", Collection: "TestCollection", Shard: "shard2"}}
+				if tt.wantProcessed {
+					wantCalls = append([]replicationtest.CopyCall{{SourceNode: "node1", Collection: "TestCollection", Shard: "shard1"}}, wantCalls...)
+				}
+				require.Equal(t, wantCalls, copier.Calls())
+			})
+		}
+	})
+
+	t.Run("only a deterministic sample of ops is verified", func(t *testing.T) {
+		// GIVEN many ops and a consumer verifying 10% of them
+		const ops = 1000
+		verifiedOps := func() map[string]bool {
+			logger, _ := logrustest.NewNullLogger()
+			copier := &verificationRecordingCopier{verified: map[string]bool{}}
+			consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), copier,
+				replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 8,
+				replication.WithVerificationSampleRate(0.1))
+
+			opsChan := make(chan replication.ShardReplicationOp, ops)
+			for id := uint64(1); id <= ops; id++ {
+				opsChan <- replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))
+			}
+			close(opsChan)
+			require.NoError(t, consumer.Consume(context.Background(), opsChan))
+			return copier.verifiedShards()
+		}
+
+		// WHEN
+		first := verifiedOps()
+		second := verifiedOps()
+
+		// THEN roughly the configured fraction of ops is verified, and the same ones every time
+		require.InDelta(t, 0.1*ops, len(first), 0.03*ops)
+		require.Equal(t, first, second, "the sampling should be deterministic per op ID")
+	})
+
+	t.Run("sampled verification not supported by the replica copier", func(t *testing.T) {
+		// GIVEN a consumer verifying a sample of copies with a replica copier unable to count objects
+		logger, _ := logrustest.NewNullLogger()
+		copier := replicationtest.NewFakeCopier()
+		var outcome bytes.Buffer
+		consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), copier,
+			replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 1,
+			replication.WithVerificationSampleRate(0.1), replication.WithOpOutcomeWriter(&outcome))
+
+		const ops = 10
+		opsChan := make(chan replication.ShardReplicationOp, ops)
+		for id := uint64(1); id <= ops; id++ {
+			opsChan <- replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))
+		}
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN every op fails, including those not sampled for verification
+		require.Empty(t, copier.Calls())
+		decoder := json.NewDecoder(&outcome)
+		for range ops {
+			var record replication.OpOutcomeRecord
+			require.NoError(t, decoder.Decode(&record))
+			require.Contains(t, record.Error, replication.ErrCopyVerificationUnsupported.Error())
+		}
+	})
+
+	t.Run("op whose shard is deleted mid-copy is aborted", func(t *testing.T) {
+		// GIVEN a copy failing once, while the shard is deleted, with plenty of retries left
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := &shardDeletingFSMUpdater{FakeFSMUpdater: replicationtest.NewFakeFSMUpdater()}
+		copier := replicationtest.NewFakeCopier()
+		copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+			fsmUpdater.deleted.Store(true)
+			return errors.New("shard is being deleted")
+		}
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier,
+			replicationtest.NewFakeClock(time.Now()), "node2", func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 10) },
+			time.Minute, 1)
+		var completions atomic.Int32
+		consumer.OnOpComplete(func(replication.ShardReplicationOp, time.Duration) { completions.Add(1) })
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN the op is aborted after the next attempt found the shard deleted, without being reported as completed
+		require.Len(t, copier.Calls(), 1, "the copy should not be retried once the shard is deleted")
+		require.Equal(t, []api.ShardReplicationState{api.HYDRATING, api.ABORTED}, fsmUpdater.StateHistory(1))
+		require.Empty(t, fsmUpdater.AddedReplicas(), "no replica should be added to a deleted shard")
+		require.Zero(t, completions.Load(), "an aborted op should not invoke completion callbacks")
+	})
+
+	t.Run("copy and bookkeeping phases are bounded by their own timeouts", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			stallCopy bool
+			timeout   time.Duration
+			wantPhase string
+		}{
+			{name: "copy", stallCopy: true, timeout: 10 * time.Minute, wantPhase: "copy phase exceeded 10m0s"},
+			{name: "bookkeeping", timeout: time.Second, wantPhase: "bookkeeping phase exceeded 1s"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN a consumer with a large copy timeout and a small bookkeeping timeout, and a phase stalling
+				// until canceled
+				logger, _ := logrustest.NewNullLogger()
+				clock := replicationtest.NewFakeClock(time.Now())
+				fsmUpdater := replicationtest.NewFakeFSMUpdater()
+				copier := replicationtest.NewFakeCopier()
+				stalled := make(chan struct{})
+				stall := func(ctx context.Context) error {
+					close(stalled)
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				if tt.stallCopy {
+					copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+						return stall(ctx)
+					}
+				} else {
+					fsmUpdater.AddReplicaFunc = func(ctx context.Context, collection, shard, node string) error {
+						return stall(ctx)
+					}
+				}
+				var outcomes bytes.Buffer
+				consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, clock, "node2",
+					func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Hour, 1,
+					replication.WithCopyTimeout(10*time.Minute), replication.WithBookkeepingTimeout(time.Second),
+					replication.WithConsumerTimer(clock), replication.WithOpOutcomeWriter(&outcomes))
+
+				opsChan := make(chan replication.ShardReplicationOp, 1)
+				opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+				close(opsChan)
+
+				consumeErr := make(chan error, 1)
+				go func() {
+					consumeErr <- consumer.Consume(context.Background(), opsChan)
+				}()
+				<-stalled
+
+				// WHEN the clock advances just below the timeout of the stalled phase
+				clock.Advance(tt.timeout - time.Millisecond)
+
+				// THEN the phase is still running
+				select {
+				case <-consumeErr:
+					t.Fatal("the phase should not time out before its timeout")
+				case <-time.After(20 * time.Millisecond):
+				}
+
+				// WHEN the clock reaches the timeout of the stalled phase
+				clock.Advance(time.Millisecond)
+
+				// THEN the op fails with a phase timeout
+				require.NoError(t, <-consumeErr)
+				var record replication.OpOutcomeRecord
+				require.NoError(t, json.Unmarshal(outcomes.Bytes(), &record))
+				require.Equal(t, "failed", record.Outcome)
+				require.Contains(t, record.Error, replication.ErrPhaseTimeout.Error())
+				require.Contains(t, record.Error, tt.wantPhase)
+			})
+		}
+	})
+
+	t.Run("op is marked READY once a quorum of replicas acknowledged the new replica", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			timeoutsBefore int
+		}{
+			{name: "quorum reached", timeoutsBefore: 0},
+			{name: "quorum timeout retried", timeoutsBefore: 2},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN a quorum acknowledging the new replica after timing out a few times
+				logger, _ := logrustest.NewNullLogger()
+				fsmUpdater := &quorumFSMUpdater{FakeFSMUpdater: replicationtest.NewFakeFSMUpdater(), timeouts: tt.timeoutsBefore}
+				consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, replicationtest.NewFakeCopier(),
+					replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 5) }, time.Minute, 1,
+					replication.WithQuorumAck(10*time.Millisecond))
+
+				opsChan := make(chan replication.ShardReplicationOp, 1)
+				opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+				close(opsChan)
+
+				// WHEN
+				require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+				// THEN the op is marked READY after the quorum acknowledged the replica, added only once
+				require.Equal(t, []api.ShardReplicationState{api.HYDRATING, api.FINALIZING, api.READY}, fsmUpdater.StateHistory(1))
+				require.Len(t, fsmUpdater.AddedReplicas(), 1)
+				require.Equal(t, tt.timeoutsBefore+1, fsmUpdater.waitCount())
+				for _, state := range fsmUpdater.statesWhileWaiting() {
+					require.Equal(t, api.FINALIZING, state, "the op should not be READY before the quorum acknowledged the replica")
+				}
+			})
+		}
+	})
+
+	t.Run("op fails when quorum acknowledgment is required but unsupported", func(t *testing.T) {
+		// GIVEN an FSM updater unable to wait for a replica quorum
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		var outcomes bytes.Buffer
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, replicationtest.NewFakeCopier(),
+			replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 5) }, time.Minute, 1,
+			replication.WithQuorumAck(time.Second), replication.WithOpOutcomeWriter(&outcomes))
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN the op is left FINALIZING without retrying
+		require.Equal(t, []api.ShardReplicationState{api.HYDRATING, api.FINALIZING}, fsmUpdater.StateHistory(1))
+		var record replication.OpOutcomeRecord
+		require.NoError(t, json.Unmarshal(outcomes.Bytes(), &record))
+		require.Contains(t, record.Error, replication.ErrQuorumAckUnsupported.Error())
+	})
+
+	t.Run("ops targeting a node under maintenance are deferred until the window ends", func(t *testing.T) {
+		// GIVEN node3 under maintenance for an hour
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		copier := replicationtest.NewFakeCopier()
+		clock := replicationtest.NewFakeClock(time.Now())
+		schedule := &fakeNodeScheduleProvider{clock: clock, node: "node3", end: clock.Now().Add(time.Hour)}
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, clock, "node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 2,
+			replication.WithConsumerTimer(clock), replication.WithNodeMaintenanceDeferral(schedule, 2*time.Hour))
+
+		opsChan := make(chan replication.ShardReplicationOp, 2)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node3", "TestCollection", "shard1")
+		opsChan <- replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2")
+		close(opsChan)
+
+		// WHEN
+		consumeErr := make(chan error, 1)
+		go func() {
+			consumeErr <- consumer.Consume(context.Background(), opsChan)
+		}()
+
+		// THEN the op targeting the available node proceeds while the other one is deferred
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(2, api.READY)))
+		require.Eventually(t, func() bool {
+			reason, _ := consumer.OpBlockReason(1)
+			return strings.Contains(reason, "maintenance window") && clock.Waiters() == 1
+		}, 5*time.Second, time.Millisecond)
+		_, started := fsmUpdater.State(1)
+		require.False(t, started, "the op targeting the node under maintenance should not be started")
+		require.Equal(t, []replicationtest.CopyCall{{SourceNode: "node1", Collection: "TestCollection", Shard: "shard2"}}, copier.Calls())
+
+		// THEN the deferred op proceeds once the maintenance window ends
+		clock.Advance(time.Hour)
+		require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(1, api.READY)))
+		require.NoError(t, <-consumeErr)
+	})
+
+	t.Run("workers ramp up gradually to the maximum", func(t *testing.T) {
+		// GIVEN a consumer with four workers ramping up from one over three seconds, and a large backlog
+		logger, _ := logrustest.NewNullLogger()
+		copier := replicationtest.NewFakeCopier()
+		var running atomic.Int32
+		release := make(chan struct{})
+		copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+			running.Add(1)
+			defer running.Add(-1)
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			return nil
+		}
+		clock := replicationtest.NewFakeClock(time.Now())
+		consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), copier, clock, "node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 4,
+			replication.WithConsumerTimer(clock), replication.WithWorkerRampUp(1, 3*time.Second))
+
+		opsChan := make(chan replication.ShardReplicationOp, 10)
+		for i := 1; i <= 10; i++ {
+			opsChan <- replication.NewShardReplicationOp(uint64(i), "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", i))
+		}
+
+		// WHEN
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		consumeErr := make(chan error, 1)
+		go func() {
+			consumeErr <- consumer.Consume(ctx, opsChan)
+		}()
+
+		// THEN the consumer starts with a single worker
+		require.Eventually(t, func() bool {
+			return running.Load() == 1 && clock.Waiters() == 1
+		}, 5*time.Second, time.Millisecond)
+		require.Equal(t, 1, consumer.Workers())
+
+		// THEN workers are added one at a time as time passes, up to the maximum
+		for _, expected := range []int{2, 3, 4} {
+			clock.Advance(time.Second)
+			require.Eventually(t, func() bool {
+				return consumer.Workers() == expected && int(running.Load()) == expected
+			}, 5*time.Second, time.Millisecond, "running ops should follow the ramp-up")
+		}
+		require.Eventually(t, func() bool { return clock.Waiters() == 0 }, 5*time.Second, time.Millisecond,
+			"no more raise should be scheduled once the ramp-up is over")
+		clock.Advance(time.Minute)
+		require.Equal(t, 4, consumer.Workers())
+		require.Equal(t, int32(4), running.Load())
+
+		close(release)
+		cancel()
+		require.ErrorIs(t, <-consumeErr, replication.ErrConsumerCanceled)
+	})
+
+	t.Run("max workers changed while ops are flowing", func(t *testing.T) {
+		// GIVEN a consumer with two workers and a backlog of ops whose copies each wait to be released
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		copier := replicationtest.NewFakeCopier()
+		var running atomic.Int32
+		release := make(chan struct{})
+		copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+			running.Add(1)
+			defer running.Add(-1)
+			<-release
+			return nil
+		}
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 2)
+
+		const opsCount = 20
+		opsChan := make(chan replication.ShardReplicationOp, opsCount)
+		for i := 1; i <= opsCount; i++ {
+			opsChan <- replication.NewShardReplicationOp(uint64(i), "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", i))
+		}
+		close(opsChan)
+		consumeErr := make(chan error, 1)
+		go func() {
+			consumeErr <- consumer.Consume(context.Background(), opsChan)
+		}()
+		require.Eventually(t, func() bool { return running.Load() == 2 }, 5*time.Second, time.Millisecond)
+
+		// WHEN the maximum is raised
+		require.NoError(t, consumer.SetMaxWorkers(4))
+
+		// THEN more ops start right away
+		require.Equal(t, 4, consumer.Workers())
+		require.Eventually(t, func() bool { return running.Load() == 4 }, 5*time.Second, time.Millisecond)
+
+		// WHEN the maximum is lowered below the number of running ops
+		require.NoError(t, consumer.SetMaxWorkers(1))
+
+		// THEN the running ops keep running, and no op starts until a single one is left running
+		require.Equal(t, 1, consumer.Workers())
+		require.Equal(t, int32(4), running.Load())
+		for i := 0; i < 3; i++ {
+			release <- struct{}{}
+		}
+		require.Eventually(t, func() bool { return running.Load() == 1 && consumer.BusyWorkers() == 1 }, 5*time.Second, time.Millisecond)
+		require.Never(t, func() bool { return running.Load() > 1 }, 100*time.Millisecond, time.Millisecond)
+
+		// THEN invalid maximums are rejected
+		for _, n := range []int{0, -1, 1025} {
+			require.ErrorIs(t, consumer.SetMaxWorkers(n), replication.ErrInvalidMaxWorkers)
+		}
+		require.Equal(t, 1, consumer.Workers())
+
+		// THEN every op completes, none being dropped by the changes
+		close(release)
+		require.NoError(t, <-consumeErr)
+		require.Len(t, copier.Calls(), opsCount)
+		for id := uint64(1); id <= opsCount; id++ {
+			state, _ := fsmUpdater.State(id)
+			require.Equal(t, api.READY, state)
+		}
+	})
+
+	t.Run("global concurrency cap is never exceeded", func(t *testing.T) {
+		// GIVEN a consumer with generous worker, cluster load and FSM write limits but a tight global concurrency cap
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		copier := replicationtest.NewFakeCopier()
+		var running, peak atomic.Int32
+		copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		}
+		const maxConcurrentOps = 3
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 16,
+			replication.WithClusterLoadThrottling(&fakeClusterLoadProvider{}, 100, time.Millisecond),
+			replication.WithMaxConcurrentFSMWrites(16),
+			replication.WithMaxConcurrentOps(maxConcurrentOps))
+
+		const opsCount = 40
+		opsChan := make(chan replication.ShardReplicationOp, opsCount)
+		for i := 1; i <= opsCount; i++ {
+			opsChan <- replication.NewShardReplicationOp(uint64(i), "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", i))
+		}
+		close(opsChan)
+
+		// WHEN the ops are consumed while the worker limit is raised further
+		consumeErr := make(chan error, 1)
+		go func() {
+			consumeErr <- consumer.Consume(context.Background(), opsChan)
+		}()
+		require.NoError(t, consumer.SetMaxWorkers(64))
+
+		// THEN every op completes without more ops than the cap ever being processed concurrently
+		require.NoError(t, <-consumeErr)
+		require.Len(t, copier.Calls(), opsCount)
+		require.Equal(t, int32(maxConcurrentOps), peak.Load(), "the cap should be reached but never exceeded")
+		for id := uint64(1); id <= opsCount; id++ {
+			state, _ := fsmUpdater.State(id)
+			require.Equal(t, api.READY, state)
+		}
+	})
+
+	t.Run("staged copy is verified then promoted", func(t *testing.T) {
+		// GIVEN a consumer copying replicas into a staging area, verified by comparing object counts
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		copier := &stagedReplicaCopier{counts: map[string]int64{"node1": 10, "node2": 10}}
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 1, replication.WithStagedCopy(), replication.WithCopyVerification())
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN the replica is staged, verified and promoted, without copying into the live replica
+		require.Equal(t, []string{"stage node1/TestCollection/shard1", "count node1", "count node2", "promote TestCollection/shard1"}, copier.events)
+		require.Zero(t, copier.liveCopies)
+		state, _ := fsmUpdater.State(1)
+		require.Equal(t, api.READY, state)
+	})
+
+	t.Run("staged copy is discarded on failure", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			copier   *stagedReplicaCopier
+			expected []string
+		}{
+			{
+				name:     "staging fails",
+				copier:   &stagedReplicaCopier{stageErr: errors.New("source unreachable")},
+				expected: []string{"stage node1/TestCollection/shard1", "discard TestCollection/shard1"},
+			},
+			{
+				name:     "verification fails",
+				copier:   &stagedReplicaCopier{counts: map[string]int64{"node1": 10, "node2": 7}},
+				expected: []string{"stage node1/TestCollection/shard1", "count node1", "count node2", "discard TestCollection/shard1"},
+			},
+			{
+				name:   "promotion fails",
+				copier: &stagedReplicaCopier{counts: map[string]int64{"node1": 10, "node2": 10}, promoteErr: errors.New("swap failed")},
+				expected: []string{"stage node1/TestCollection/shard1", "count node1", "count node2", "promote TestCollection/shard1",
+					"discard TestCollection/shard1"},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN a consumer copying replicas into a staging area without retrying failed copies
+				logger, _ := logrustest.NewNullLogger()
+				fsmUpdater := replicationtest.NewFakeFSMUpdater()
+				consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, tt.copier, replication.RealTimeProvider{}, "node2",
+					func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 1, replication.WithStagedCopy(), replication.WithCopyVerification())
+
+				opsChan := make(chan replication.ShardReplicationOp, 1)
+				opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+				close(opsChan)
+
+				// WHEN
+				require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+				// THEN the staged replica is discarded and never made live
+				require.Equal(t, tt.expected, tt.copier.events)
+				require.Zero(t, tt.copier.liveCopies)
+				state, _ := fsmUpdater.State(1)
+				require.NotEqual(t, api.READY, state)
+			})
+		}
+	})
+
+	t.Run("staged copy not supported by the replica copier", func(t *testing.T) {
+		// GIVEN a consumer requiring staged copies with a replica copier unable to stage replicas
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		copier := replicationtest.NewFakeCopier()
+		var outcome bytes.Buffer
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3) }, time.Minute, 1,
+			replication.WithStagedCopy(), replication.WithOpOutcomeWriter(&outcome))
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN the op fails without copying into the live replica
+		require.Empty(t, copier.Calls())
+		var record replication.OpOutcomeRecord
+		require.NoError(t, json.Unmarshal(outcome.Bytes(), &record))
+		require.Contains(t, record.Error, replication.ErrStagedCopyUnsupported.Error())
+	})
+
+	t.Run("staged copy not supported over an encrypted transport", func(t *testing.T) {
+		// GIVEN a consumer requiring staged copies over an encrypted transport, with a replica copier supporting both
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		copier := &encryptedStagedReplicaCopier{stagedReplicaCopier: &stagedReplicaCopier{counts: map[string]int64{"node1": 10, "node2": 10}}}
+		var outcome bytes.Buffer
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3) }, time.Minute, 1, replication.WithStagedCopy(),
+			replication.WithEncryptedTransport(&tls.Config{MinVersion: tls.VersionTLS13}), replication.WithOpOutcomeWriter(&outcome))
+
+		opsChan := make(chan replication.ShardReplicationOp, 1)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN the op fails rather than copying into the live replica without staging
+		require.Empty(t, copier.events)
+		require.Zero(t, copier.liveCopies)
+		require.Zero(t, copier.encryptedCopies.Load())
+		var record replication.OpOutcomeRecord
+		require.NoError(t, json.Unmarshal(outcome.Bytes(), &record))
+		require.Contains(t, record.Error, replication.ErrStagedCopyUnsupported.Error())
+	})
+
+	t.Run("completion callbacks are invoked once ops are READY", func(t *testing.T) {
+		// GIVEN a consumer with a single worker, an op completing and an op failing, a completion callback panicking
+		// and another one recording the completions
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		copier := replicationtest.NewFakeCopier()
+		secondCopyStarted := make(chan struct{})
+		copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+			if shard == "shard2" {
+				close(secondCopyStarted)
+				return errors.New("copy failed")
+			}
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		}
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 1)
+
+		type completion struct {
+			id            uint64
+			duration      time.Duration
+			state         api.ShardReplicationState
+			tokenReleased bool
+		}
+		var completions []completion
+		consumer.OnOpComplete(func(replication.ShardReplicationOp, time.Duration) {
+			panic("callback failure")
+		})
+		consumer.OnOpComplete(func(op replication.ShardReplicationOp, duration time.Duration) {
+			state, _ := fsmUpdater.State(op.ID)
+			c := completion{id: op.ID, duration: duration, state: state}
+			// With a single worker, the next op only starts once the token of the completed op is released
+			select {
+			case <-secondCopyStarted:
+				c.tokenReleased = true
+			case <-time.After(5 * time.Second):
+			}
+			completions = append(completions, c)
+		})
+
+		opsChan := make(chan replication.ShardReplicationOp, 2)
+		opsChan <- replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		opsChan <- replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2")
+		close(opsChan)
+
+		// WHEN
+		require.NoError(t, consumer.Consume(context.Background(), opsChan))
+
+		// THEN only the completed op is reported, once READY and after its worker token was released, despite the
+		// panicking callback
+		require.Len(t, completions, 1)
+		require.Equal(t, uint64(1), completions[0].id)
+		require.Equal(t, api.READY, completions[0].state)
+		require.GreaterOrEqual(t, completions[0].duration, 5*time.Millisecond)
+		require.True(t, completions[0].tokenReleased)
+	})
+
+	t.Run("late retry racing a completion completes the op exactly once", func(t *testing.T) {
+		// GIVEN the same op dispatched twice to two workers, the first copy failing late, once the other worker is
+		// marking the op READY
+		logger, _ := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		readyStarted := make(chan struct{})
+		allowReady := make(chan struct{})
+		var readyUpdates atomic.Int32
+		fsmUpdater.UpdateStatusFunc = func(id uint64, state api.ShardReplicationState) error {
+			if state == api.READY && readyUpdates.Add(1) == 1 {
+				close(readyStarted)
+				<-allowReady
+			}
+			return nil
+		}
+		copier := replicationtest.NewFakeCopier()
+		releaseLate := make(chan struct{})
+		var copies atomic.Int32
+		copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+			if copies.Add(1) == 1 {
+				<-releaseLate
+				return errors.New("transient copy failure")
+			}
+			return nil
+		}
+		duplicates := make(chan replication.ShardReplicationOp, 2)
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3) }, time.Minute, 2,
+			replication.WithDuplicateCompletionHandler(func(op replication.ShardReplicationOp) { duplicates <- op }))
+		var completions atomic.Int32
+		consumer.OnOpComplete(func(replication.ShardReplicationOp, time.Duration) {
+			completions.Add(1)
+		})
+
+		opsChan := make(chan replication.ShardReplicationOp, 2)
+		op := replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		opsChan <- op
+		opsChan <- op
+		close(opsChan)
+		consumeErr := make(chan error, 1)
+		go func() {
+			consumeErr <- consumer.Consume(context.Background(), opsChan)
+		}()
+
+		// WHEN the late copy fails and is retried while the op is being marked READY
+		<-readyStarted
+		close(releaseLate)
+
+		// THEN the late retry is suppressed as a duplicate completion
+		select {
+		case duplicate := <-duplicates:
+			require.Equal(t, uint64(1), duplicate.ID)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "the late retry should be reported as a duplicate completion")
+		}
+		close(allowReady)
+		require.NoError(t, <-consumeErr)
+
+		// THEN the op is marked READY and reported complete exactly once, and never leaves READY
+		require.Equal(t, int32(1), readyUpdates.Load())
+		require.Equal(t, int32(1), completions.Load())
+		require.Empty(t, duplicates)
+		history := fsmUpdater.StateHistory(1)
+		require.Equal(t, api.READY, history[len(history)-1])
+	})
+}
+
+// fakeClusterLoadProvider reports a configurable cluster load and counts how many times it has been queried.
+type fakeClusterLoadProvider struct {
+	load    atomic.Int64
+	queries atomic.Int64
+}
+
+func (p *fakeClusterLoadProvider) ClusterInFlightOps() int {
+	p.queries.Add(1)
+	return int(p.load.Load())
+}
+
+// fakeSourceQueryLoadProvider reports a high query load on a single shard until it is cooled down.
+type fakeSourceQueryLoadProvider struct {
+	busyShard string
+	cooled    atomic.Bool
+}
+
+func (p *fakeSourceQueryLoadProvider) ShardQueryLoad(_, _, shard string) float64 {
+	if shard == p.busyShard && !p.cooled.Load() {
+		return 1000
+	}
+	return 1
+}
+
+// fakeNodeScheduleProvider reports a single node under maintenance until the end of its window, as measured by the
+// clock.
+type fakeNodeScheduleProvider struct {
+	clock *replicationtest.FakeClock
+	node  string
+	end   time.Time
+}
+
+func (p *fakeNodeScheduleProvider) MaintenanceWindow(node string) (time.Time, bool) {
+	if node == p.node && p.clock.Now().Before(p.end) {
+		return p.end, true
+	}
+	return time.Time{}, false
+}
+
+// countingBackOff wraps a backoff policy and counts how many retries it has been asked for.
+type countingBackOff struct {
+	backoff.BackOff
+	mu      sync.Mutex
+	retries int
+}
+
+func (b *countingBackOff) NextBackOff() time.Duration {
+	b.mu.Lock()
+	b.retries++
+	b.mu.Unlock()
+	return b.BackOff.NextBackOff()
+}
+
+func (b *countingBackOff) Retries() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.retries
+}
+
+// fakeSizedReplicaCopier is a types.SizedReplicaCopier reporting a fixed size per copied shard.
+type fakeSizedReplicaCopier struct {
+	sizes map[string]int64
+}
+
+func (c *fakeSizedReplicaCopier) CopyReplica(ctx context.Context, sourceNode, sourceCollection, sourceShard string) error {
+	_, err := c.CopyReplicaWithSize(ctx, sourceNode, sourceCollection, sourceShard)
+	return err
+}
+
+func (c *fakeSizedReplicaCopier) CopyReplicaWithSize(_ context.Context, _, _, sourceShard string) (int64, error) {
+	return c.sizes[sourceShard], nil
+}
+
+// recordingBackOff is a backoff.BackOff recording every computed retry interval.
+type recordingBackOff struct {
+	backoff.BackOff
+	mu        sync.Mutex
+	intervals []time.Duration
+}
+
+func (b *recordingBackOff) NextBackOff() time.Duration {
+	interval := b.BackOff.NextBackOff()
+	b.mu.Lock()
+	b.intervals = append(b.intervals, interval)
+	b.mu.Unlock()
+	return interval
+}
+
+func (b *recordingBackOff) Intervals() []time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]time.Duration(nil), b.intervals...)
+}
+
+type copyResult struct {
+	bytes int64
+	err   error
+}
+
+// scriptedSizedReplicaCopier is a types.SizedReplicaCopier returning the given results in order, one per copy.
+type scriptedSizedReplicaCopier struct {
+	results []copyResult
+	calls   int
+}
+
+func (c *scriptedSizedReplicaCopier) CopyReplica(ctx context.Context, sourceNode, sourceCollection, sourceShard string) error {
+	_, err := c.CopyReplicaWithSize(ctx, sourceNode, sourceCollection, sourceShard)
+	return err
+}
+
+func (c *scriptedSizedReplicaCopier) CopyReplicaWithSize(context.Context, string, string, string) (int64, error) {
+	result := c.results[c.calls]
+	c.calls++
+	return result.bytes, result.err
+}
+
+// shardScriptedReplicaCopier is a types.SizedReplicaCopier returning, for each shard, the given results in order, one
+// per copy. The first copy of each shard waits for the first copy of every other shard to start, so that the copies
+// of the shards run concurrently.
+type shardScriptedReplicaCopier struct {
+	mu      sync.Mutex
+	results map[string][]copyResult
+	started map[string]bool
+	barrier sync.WaitGroup
+}
+
+func newShardScriptedReplicaCopier(results map[string][]copyResult) *shardScriptedReplicaCopier {
+	c := &shardScriptedReplicaCopier{results: results, started: make(map[string]bool)}
+	c.barrier.Add(len(results))
+	return c
+}
+
+func (c *shardScriptedReplicaCopier) CopyReplica(ctx context.Context, sourceNode, sourceCollection, sourceShard string) error {
+	_, err := c.CopyReplicaWithSize(ctx, sourceNode, sourceCollection, sourceShard)
+	return err
+}
+
+func (c *shardScriptedReplicaCopier) CopyReplicaWithSize(_ context.Context, _, _, shard string) (int64, error) {
+	c.mu.Lock()
+	first := !c.started[shard]
+	c.started[shard] = true
+	result := c.results[shard][0]
+	c.results[shard] = c.results[shard][1:]
+	c.mu.Unlock()
+
+	if first {
+		c.barrier.Done()
+		c.barrier.Wait()
+	}
+	return result.bytes, result.err
+}
+
+// objectCountingReplicaCopier is a types.ObjectCountingReplicaCopier whose copies always succeed and reporting, for
+// each node, the given object counts in order, one per count. The last count of a node is repeated once exhausted.
+type objectCountingReplicaCopier struct {
+	counts map[string][]int64
+	copies int
+}
+
+func (c *objectCountingReplicaCopier) CopyReplica(context.Context, string, string, string) error {
+	c.copies++
+	return nil
+}
+
+func (c *objectCountingReplicaCopier) CountObjects(_ context.Context, node, _, _ string) (int64, error) {
+	counts := c.counts[node]
+	count := counts[0]
+	if len(counts) > 1 {
+		c.counts[node] = counts[1:]
+	}
+	return count, nil
+}
+
+// shardingStateFSMUpdater is a types.FSMUpdater also implementing types.ShardingStateReader, reporting the given
+// replicas keyed by collection/shard.
+type shardingStateFSMUpdater struct {
+	*types.MockFSMUpdater
+	replicas map[string][]string
+}
+
+func (u *shardingStateFSMUpdater) ShardReplicas(collection, shard string) ([]string, error) {
+	return u.replicas[collection+"/"+shard], nil
+}
+
+// checkpointFSMUpdater is a types.FSMUpdater also implementing types.OpCheckpointStore, recording the stored
+// checkpoints.
+type checkpointFSMUpdater struct {
+	*types.MockFSMUpdater
+	mu     sync.Mutex
+	stored []int
+}
+
+func (u *checkpointFSMUpdater) ReplicationStoreOpCheckpoint(_ uint64, committedBatches int) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.stored = append(u.stored, committedBatches)
+	return nil
+}
+
+func (u *checkpointFSMUpdater) checkpoints() []int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]int(nil), u.stored...)
+}
+
+// fakeBatchReplicaCopier is a types.BatchReplicaCopier copying a fixed number of batches, failing each batch as many
+// times as configured in failures.
+type fakeBatchReplicaCopier struct {
+	batches  int
+	mu       sync.Mutex
+	failures map[int]int
+	copied   []int
+}
+
+func (c *fakeBatchReplicaCopier) CopyReplica(context.Context, string, string, string) error {
+	return errors.New("copying the whole replica is not supported")
+}
+
+func (c *fakeBatchReplicaCopier) CopyReplicaBatch(_ context.Context, _, _, _ string, batch int) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.copied = append(c.copied, batch)
+	if c.failures[batch] > 0 {
+		c.failures[batch]--
+		return false, errors.New("connection reset")
+	}
+	return batch == c.batches-1, nil
+}
+
+func (c *fakeBatchReplicaCopier) copiedBatches() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int(nil), c.copied...)
+}
+
+// encryptedReplicaCopier is a types.EncryptedReplicaCopier recording the TLS configurations of encrypted copies and
+// counting plaintext copies.
+type encryptedReplicaCopier struct {
+	mu              sync.Mutex
+	encryptedCopies []*tls.Config
+	plaintextCopies int
+}
+
+func (c *encryptedReplicaCopier) CopyReplica(context.Context, string, string, string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.plaintextCopies++
+	return nil
+}
+
+func (c *encryptedReplicaCopier) CopyReplicaEncrypted(_ context.Context, _, _, _ string, tlsConfig *tls.Config) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.encryptedCopies = append(c.encryptedCopies, tlsConfig)
+	return nil
+}
+
+// stagedReplicaCopier is a types.StagedReplicaCopier and types.ObjectCountingReplicaCopier recording the staging,
+// counting, promotion and discarding events in order, and counting copies into the live replica.
+// encryptedStagedReplicaCopier is a stagedReplicaCopier also able to copy replicas over an encrypted transport.
+type encryptedStagedReplicaCopier struct {
+	*stagedReplicaCopier
+	encryptedCopies atomic.Int32
+}
+
+func (c *encryptedStagedReplicaCopier) CopyReplicaEncrypted(_ context.Context, _, _, _ string, _ *tls.Config) error {
+	c.encryptedCopies.Add(1)
+	return nil
+}
+
+type stagedReplicaCopier struct {
+	counts     map[string]int64
+	stageErr   error
+	promoteErr error
+
+	mu         sync.Mutex
+	events     []string
+	liveCopies int
+}
+
+func (c *stagedReplicaCopier) record(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+}
+
+func (c *stagedReplicaCopier) CopyReplica(context.Context, string, string, string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.liveCopies++
+	return nil
+}
+
+func (c *stagedReplicaCopier) StageReplica(_ context.Context, sourceNode, collection, shard string) error {
+	c.record(fmt.Sprintf("stage %s/%s/%s", sourceNode, collection, shard))
+	return c.stageErr
+}
+
+func (c *stagedReplicaCopier) PromoteStagedReplica(_ context.Context, collection, shard string) error {
+	c.record(fmt.Sprintf("promote %s/%s", collection, shard))
+	return c.promoteErr
+}
+
+func (c *stagedReplicaCopier) DiscardStagedReplica(_ context.Context, collection, shard string) error {
+	c.record(fmt.Sprintf("discard %s/%s", collection, shard))
+	return nil
+}
+
+func (c *stagedReplicaCopier) CountObjects(_ context.Context, node, _, _ string) (int64, error) {
+	c.record("count " + node)
+	return c.counts[node], nil
+}
+
+// tokenEvent is a token acquisition or release recorded by recordingTokenObserver.
+type tokenEvent struct {
+	opID        uint64
+	acquired    bool
+	utilization replication.TokenPoolUtilization
+}
+
+// recordingTokenObserver records the token acquisitions and releases it is notified of.
+type recordingTokenObserver struct {
+	mu     sync.Mutex
+	events []tokenEvent
+}
+
+func (o *recordingTokenObserver) OnTokenAcquired(opID uint64, utilization replication.TokenPoolUtilization) {
+	o.record(tokenEvent{opID: opID, acquired: true, utilization: utilization})
+}
+
+func (o *recordingTokenObserver) OnTokenReleased(opID uint64, utilization replication.TokenPoolUtilization) {
+	o.record(tokenEvent{opID: opID, utilization: utilization})
+}
+
+func (o *recordingTokenObserver) record(event tokenEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, event)
+}
+
+func (o *recordingTokenObserver) snapshot() []tokenEvent {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]tokenEvent(nil), o.events...)
+}
+
+// verificationRecordingCopier is a types.ObjectCountingReplicaCopier recording the shards whose copy is verified.
+type verificationRecordingCopier struct {
+	mu       sync.Mutex
+	verified map[string]bool
+}
+
+func (c *verificationRecordingCopier) CopyReplica(context.Context, string, string, string) error {
+	return nil
+}
+
+func (c *verificationRecordingCopier) CountObjects(_ context.Context, _, _, shard string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.verified[shard] = true
+	return 1, nil
+}
+
+func (c *verificationRecordingCopier) verifiedShards() map[string]bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.verified)
+}
+
+// shardDeletingFSMUpdater is a replicationtest.FakeFSMUpdater also implementing types.ShardingStateReader, reporting
+// every shard as held by node1 until it is deleted.
+type shardDeletingFSMUpdater struct {
+	*replicationtest.FakeFSMUpdater
+	deleted atomic.Bool
+}
+
+func (u *shardDeletingFSMUpdater) ShardReplicas(collection, shard string) ([]string, error) {
+	if u.deleted.Load() {
+		return nil, fmt.Errorf("%w: %s/%s", types.ErrShardNotFound, collection, shard)
+	}
+	return []string{"node1"}, nil
+}
+
+// quorumFSMUpdater is a replicationtest.FakeFSMUpdater also implementing types.ReplicaQuorumWaiter, timing out the
+// given number of times before acknowledging the replica, and recording the op state on every wait.
+type quorumFSMUpdater struct {
+	*replicationtest.FakeFSMUpdater
+	mu       sync.Mutex
+	timeouts int
+	states   []api.ShardReplicationState
+}
+
+func (u *quorumFSMUpdater) WaitForReplicaQuorum(ctx context.Context, _, _, _ string) error {
+	state, _ := u.State(1)
+	u.mu.Lock()
+	u.states = append(u.states, state)
+	timeout := len(u.states) <= u.timeouts
+	u.mu.Unlock()
+
+	if timeout {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (u *quorumFSMUpdater) waitCount() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.states)
+}
+
+func (u *quorumFSMUpdater) statesWhileWaiting() []api.ShardReplicationState {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return slices.Clone(u.states)
+}
+
+// outcomeChanWriter is an io.Writer sending a copy of every record written by the consumer to the channel.
+type outcomeChanWriter chan []byte
+
+func (w outcomeChanWriter) Write(p []byte) (int, error) {
+	w <- slices.Clone(p)
+	return len(p), nil
+}
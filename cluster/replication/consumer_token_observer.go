@@ -0,0 +1,51 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+// TokenPoolUtilization is the utilization of the consumer worker token pool.
+type TokenPoolUtilization struct {
+	// InUse is the number of tokens held by operations being processed.
+	InUse int
+	// Limit is the current number of workers, i.e. the maximum number of tokens held by operations at once. It is
+	// lower than the maximum number of workers while adaptive worker scaling reserves tokens.
+	Limit int
+}
+
+// TokenObserver observes the acquisition and release of the consumer worker tokens. Its methods are called
+// synchronously, possibly concurrently by different workers, and should not block.
+type TokenObserver interface {
+	// OnTokenAcquired is called once a token is acquired to process the given operation, with the pool utilization
+	// including that token.
+	OnTokenAcquired(opID uint64, utilization TokenPoolUtilization)
+	// OnTokenReleased is called once the token held to process the given operation is released, with the pool
+	// utilization excluding that token.
+	OnTokenReleased(opID uint64, utilization TokenPoolUtilization)
+}
+
+// tokenPoolUtilization returns the current utilization of the worker token pool.
+func (c *CopyOpConsumer) tokenPoolUtilization() TokenPoolUtilization {
+	return TokenPoolUtilization{InUse: c.BusyWorkers(), Limit: c.Workers()}
+}
+
+// notifyTokenAcquired notifies the token observer, if any, that a token was acquired to process the operation.
+func (c *CopyOpConsumer) notifyTokenAcquired(opID uint64) {
+	if c.tokenObserver != nil {
+		c.tokenObserver.OnTokenAcquired(opID, c.tokenPoolUtilization())
+	}
+}
+
+// notifyTokenReleased notifies the token observer, if any, that the token of the operation was released.
+func (c *CopyOpConsumer) notifyTokenReleased(opID uint64) {
+	if c.tokenObserver != nil {
+		c.tokenObserver.OnTokenReleased(opID, c.tokenPoolUtilization())
+	}
+}
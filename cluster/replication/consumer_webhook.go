@@ -0,0 +1,138 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/sirupsen/logrus"
+	enterrors "github.com/weaviate/weaviate/entities/errors"
+)
+
+// maxPendingWebhookPosts bounds the number of outcome records being posted to the completion webhook at once, so
+// that an unreachable webhook does not accumulate goroutines.
+const maxPendingWebhookPosts = 64
+
+// webhookRetryInterval is the initial interval between attempts to post an outcome record to the completion webhook.
+const webhookRetryInterval = 100 * time.Millisecond
+
+// opCompletionWebhook posts outcome records to an HTTP endpoint.
+type opCompletionWebhook struct {
+	url        string
+	client     *http.Client
+	maxRetries int
+	// pending holds a token for every record being posted.
+	pending chan struct{}
+
+	// ctx and wg are those of the running Consume call, see bind. Posts are aborted once ctx is done, and Consume
+	// waits for them through wg before returning.
+	mu  sync.Mutex
+	ctx context.Context
+	wg  *sync.WaitGroup
+}
+
+func newOpCompletionWebhook(url string, timeout time.Duration, maxRetries int) *opCompletionWebhook {
+	return &opCompletionWebhook{
+		url:        url,
+		client:     &http.Client{Timeout: timeout},
+		maxRetries: max(0, maxRetries),
+		pending:    make(chan struct{}, maxPendingWebhookPosts),
+	}
+}
+
+// bind ties the posts started from now on to the lifecycle of a Consume call, whose context and wait group are given.
+func (w *opCompletionWebhook) bind(ctx context.Context, wg *sync.WaitGroup) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ctx, w.wg = ctx, wg
+}
+
+// lifecycle returns the context and wait group of the running Consume call, see bind.
+func (w *opCompletionWebhook) lifecycle() (context.Context, *sync.WaitGroup) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ctx, w.wg
+}
+
+// post posts the record once, returning an error if the request fails or the webhook does not respond with a 2xx
+// status code.
+func (w *opCompletionWebhook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// postOpOutcome asynchronously posts the outcome record to the completion webhook, if any, retrying failed attempts.
+// Posting is aborted once the consumer stops, and the consumer waits for pending posts before returning from Consume.
+func (c *CopyOpConsumer) postOpOutcome(record OpOutcomeRecord) {
+	if c.webhook == nil {
+		return
+	}
+
+	logger := c.logger.WithFields(logrus.Fields{"consumer": c, "op": record.OpID})
+	ctx, wg := c.webhook.lifecycle()
+	if ctx == nil {
+		logger.Warn("replication operation consumer not running, dropping outcome for the completion webhook")
+		return
+	}
+	body, err := json.Marshal(record)
+	if err != nil {
+		logger.WithError(err).Warn("failed to encode replication operation outcome for the completion webhook")
+		return
+	}
+
+	select {
+	case c.webhook.pending <- struct{}{}:
+	default:
+		logger.Warn("too many replication operation outcomes being posted to the completion webhook, dropping it")
+		return
+	}
+	wg.Add(1)
+	c.goroutines.Add(1)
+	enterrors.GoWrapper(func() {
+		defer func() {
+			<-c.webhook.pending
+			c.goroutines.Add(-1)
+			wg.Done()
+		}()
+
+		policy := backoff.NewExponentialBackOff()
+		policy.InitialInterval = webhookRetryInterval
+		err := backoff.Retry(func() error {
+			return c.webhook.post(ctx, body)
+		}, backoff.WithContext(backoff.WithMaxRetries(policy, uint64(c.webhook.maxRetries)), ctx))
+		if err != nil {
+			logger.WithError(err).Warn("failed to post replication operation outcome to the completion webhook")
+		}
+	}, c.logger)
+}
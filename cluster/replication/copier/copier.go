@@ -53,26 +53,35 @@ func New(t types.RemoteIndex, nodeSelector cluster.NodeSelector, rootPath string
 
 // CopyReplica copies a shard replica from the source node to this node.
 func (c *Copier) CopyReplica(ctx context.Context, srcNodeId, collectionName, shardName string) error {
+	_, err := c.CopyReplicaWithSize(ctx, srcNodeId, collectionName, shardName)
+	return err
+}
+
+// CopyReplicaWithSize copies a shard replica from the source node to this node and returns the number of
+// bytes downloaded, including on failure. Files already matching the remote ones are not downloaded and not
+// accounted for.
+func (c *Copier) CopyReplicaWithSize(ctx context.Context, srcNodeId, collectionName, shardName string) (int64, error) {
 	sourceNodeHostname, ok := c.nodeSelector.NodeHostname(srcNodeId)
 	if !ok {
-		return fmt.Errorf("source node address not found in cluster membership for node %s", srcNodeId)
+		return 0, fmt.Errorf("source node address not found in cluster membership for node %s", srcNodeId)
 	}
 
 	err := c.remoteIndex.PauseFileActivity(ctx, sourceNodeHostname, collectionName, shardName)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer c.remoteIndex.ResumeFileActivity(ctx, sourceNodeHostname, collectionName, shardName)
 
 	relativeFilePaths, err := c.remoteIndex.ListFiles(ctx, sourceNodeHostname, collectionName, shardName)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
+	var copiedBytes int64
 	for _, relativeFilePath := range relativeFilePaths {
 		md, err := c.remoteIndex.GetFileMetadata(ctx, sourceNodeHostname, collectionName, shardName, relativeFilePath)
 		if err != nil {
-			return err
+			return copiedBytes, err
 		}
 
 		finalLocalPath := filepath.Join(c.rootDataPath, relativeFilePath)
@@ -80,7 +89,7 @@ func (c *Copier) CopyReplica(ctx context.Context, srcNodeId, collectionName, sha
 		_, checksum, err := integrity.CRC32(finalLocalPath)
 		if err != nil {
 			if !errors.Is(err, os.ErrNotExist) {
-				return err
+				return copiedBytes, err
 			}
 		} else if checksum == md.CRC32 {
 			// local file matches remote one, no need to download it
@@ -89,13 +98,13 @@ func (c *Copier) CopyReplica(ctx context.Context, srcNodeId, collectionName, sha
 
 		reader, err := c.remoteIndex.GetFile(ctx, sourceNodeHostname, collectionName, shardName, relativeFilePath)
 		if err != nil {
-			return err
+			return copiedBytes, err
 		}
 		defer reader.Close()
 
 		dir := path.Dir(finalLocalPath)
 		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
-			return fmt.Errorf("create parent folder for %s: %w", relativeFilePath, err)
+			return copiedBytes, fmt.Errorf("create parent folder for %s: %w", relativeFilePath, err)
 		}
 
 		err = func() error {
@@ -105,7 +114,8 @@ func (c *Copier) CopyReplica(ctx context.Context, srcNodeId, collectionName, sha
 			}
 			defer f.Close()
 
-			_, err = io.Copy(f, reader)
+			n, err := io.Copy(f, reader)
+			copiedBytes += n
 			if err != nil {
 				return err
 			}
@@ -127,14 +137,14 @@ func (c *Copier) CopyReplica(ctx context.Context, srcNodeId, collectionName, sha
 			return nil
 		}()
 		if err != nil {
-			return err
+			return copiedBytes, err
 		}
 	}
 
 	err = c.indexGetter.GetIndex(schema.ClassName(collectionName)).LoadLocalShard(ctx, shardName)
 	if err != nil {
-		return err
+		return copiedBytes, err
 	}
 
-	return nil
+	return copiedBytes, nil
 }
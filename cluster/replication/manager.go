@@ -32,7 +32,7 @@ type Manager struct {
 }
 
 func NewManager(logger *logrus.Logger, schemaReader schema.SchemaReader, replicaCopier types.ReplicaCopier, reg prometheus.Registerer) *Manager {
-	replicationFSM := newShardReplicationFSM(reg)
+	replicationFSM := newShardReplicationFSM(schemaReader, reg)
 	return &Manager{
 		replicationFSM: replicationFSM,
 		schemaReader:   schemaReader,
@@ -67,6 +67,37 @@ func (m *Manager) UpdateReplicateOpState(c *cmd.ApplyRequest) error {
 	return m.replicationFSM.UpdateReplicationOpStatus(req)
 }
 
+func (m *Manager) PurgeReplicationOps(c *cmd.ApplyRequest) error {
+	req := &cmd.ReplicationPurgeOpsRequest{}
+	if err := json.Unmarshal(c.SubCommand, req); err != nil {
+		return fmt.Errorf("%w: %w", ErrBadRequest, err)
+	}
+
+	// Delete from the FSM the terminal shard replication ops
+	return m.replicationFSM.PurgeReplicationOps(req)
+}
+
+func (m *Manager) ForceReplicateOpState(c *cmd.ApplyRequest) error {
+	req := &cmd.ReplicationForceOpStateRequest{}
+	if err := json.Unmarshal(c.SubCommand, req); err != nil {
+		return fmt.Errorf("%w: %w", ErrBadRequest, err)
+	}
+
+	// Force in the FSM the state of the shard replication op
+	return m.replicationFSM.ForceReplicationOpState(req)
+}
+
+// ValidateDeleteReplica validates that the replica removal requested by c can be applied while keeping at least the
+// requested number of READY replicas of the shard, see ValidateReplicationDeleteReplica.
+func (m *Manager) ValidateDeleteReplica(c *cmd.ApplyRequest) error {
+	req := &cmd.DeleteReplicaFromShardRequest{}
+	if err := json.Unmarshal(c.SubCommand, req); err != nil {
+		return fmt.Errorf("%w: %w", ErrBadRequest, err)
+	}
+
+	return ValidateReplicationDeleteReplica(m.schemaReader, m.replicationFSM, req.MinReadyReplicas, req.Replica, req.Class, req.Shard)
+}
+
 func (m *Manager) GetReplicationDetailsByReplicationId(c *cmd.QueryRequest) ([]byte, error) {
 	subCommand := cmd.ReplicationDetailsRequest{}
 	if err := json.Unmarshal(c.SubCommand, &subCommand); err != nil {
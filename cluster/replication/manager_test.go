@@ -164,6 +164,152 @@ func TestManager_Replicate(t *testing.T) {
 	}
 }
 
+func TestShardReplicationFSM_ValidateOp(t *testing.T) {
+	addTestCollection := func(t *testing.T, s *schema.SchemaManager) {
+		require.NoError(t, s.AddClass(
+			buildApplyRequest("TestCollection", api.ApplyRequest_TYPE_ADD_CLASS, api.AddClassRequest{
+				Class: &models.Class{Class: "TestCollection", MultiTenancyConfig: &models.MultiTenancyConfig{Enabled: false}},
+				State: &sharding.State{
+					Physical: map[string]sharding.Physical{"shard1": {BelongsToNodes: []string{"node1"}}},
+				},
+			}), "node1", true, false))
+	}
+
+	tests := []struct {
+		name          string
+		schemaSetup   func(*testing.T, *schema.SchemaManager)
+		registered    *api.ReplicationReplicateShardRequest
+		op            replication.ShardReplicationOp
+		expectedError error
+	}{
+		{
+			name:        "valid op",
+			schemaSetup: addTestCollection,
+			op:          replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"),
+		},
+		{
+			name:          "collection not found",
+			schemaSetup:   addTestCollection,
+			op:            replication.NewShardReplicationOp(1, "node1", "node2", "NonExistentCollection", "shard1"),
+			expectedError: replication.ErrClassNotFound,
+		},
+		{
+			name:          "source shard not found",
+			schemaSetup:   addTestCollection,
+			op:            replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "NonExistentShard"),
+			expectedError: schema.ErrShardNotFound,
+		},
+		{
+			name:          "source node does not hold the shard",
+			schemaSetup:   addTestCollection,
+			op:            replication.NewShardReplicationOp(1, "node4", "node2", "TestCollection", "shard1"),
+			expectedError: replication.ErrNodeNotFound,
+		},
+		{
+			name:          "target node already holds the shard",
+			schemaSetup:   addTestCollection,
+			op:            replication.NewShardReplicationOp(1, "node1", "node1", "TestCollection", "shard1"),
+			expectedError: replication.ErrAlreadyExists,
+		},
+		{
+			name:        "target replica already being replicated",
+			schemaSetup: addTestCollection,
+			registered: &api.ReplicationReplicateShardRequest{
+				SourceCollection: "TestCollection",
+				SourceShard:      "shard1",
+				SourceNode:       "node1",
+				TargetNode:       "node2",
+			},
+			op:            replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard1"),
+			expectedError: replication.ErrShardAlreadyReplicating,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			parser := fakes.NewMockParser()
+			parser.On("ParseClass", mock.Anything).Return(nil)
+			schemaManager := schema.NewSchemaManager("test-node", nil, parser, prometheus.NewPedanticRegistry(), logrus.New())
+			fsm := replication.NewManager(logrus.New(), schemaManager.NewSchemaReader(), nil, prometheus.NewPedanticRegistry()).GetReplicationFSM()
+			tt.schemaSetup(t, schemaManager)
+			if tt.registered != nil {
+				require.NoError(t, fsm.Replicate(1, tt.registered))
+			}
+			before, _ := fsm.ListOps(0, 10)
+
+			// WHEN
+			err := fsm.ValidateOp(tt.op)
+
+			// THEN
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			after, _ := fsm.ListOps(0, 10)
+			require.Equal(t, before, after, "validating an op should not mutate the FSM")
+		})
+	}
+}
+
+func TestManager_ValidateDeleteReplica(t *testing.T) {
+	// GIVEN a shard on node1 being replicated to node2, whose replica was added to the sharding state while finalizing
+	parser := fakes.NewMockParser()
+	parser.On("ParseClass", mock.Anything).Return(nil)
+	schemaManager := schema.NewSchemaManager("test-node", nil, parser, prometheus.NewPedanticRegistry(), logrus.New())
+	schemaReader := schemaManager.NewSchemaReader()
+	manager := replication.NewManager(logrus.New(), schemaReader, nil, prometheus.NewPedanticRegistry())
+	require.NoError(t, schemaManager.AddClass(buildApplyRequest("TestCollection", api.ApplyRequest_TYPE_ADD_CLASS, api.AddClassRequest{
+		Class: &models.Class{Class: "TestCollection", MultiTenancyConfig: &models.MultiTenancyConfig{Enabled: false}},
+		State: &sharding.State{
+			Physical: map[string]sharding.Physical{"shard1": {BelongsToNodes: []string{"node1"}}},
+		},
+	}), "node1", true, false))
+	require.NoError(t, manager.Replicate(0, buildApplyRequest("TestCollection", api.ApplyRequest_TYPE_REPLICATION_REPLICATE, api.ReplicationReplicateShardRequest{
+		SourceCollection: "TestCollection",
+		SourceShard:      "shard1",
+		SourceNode:       "node1",
+		TargetNode:       "node2",
+	})))
+	updateState := func(state api.ShardReplicationState) {
+		require.NoError(t, manager.UpdateReplicateOpState(buildApplyRequest("", api.ApplyRequest_TYPE_REPLICATION_REPLICATE_UPDATE_STATE,
+			api.ReplicationUpdateOpStateRequest{Id: 0, State: state})))
+	}
+	updateState(api.HYDRATING)
+	updateState(api.FINALIZING)
+	require.NoError(t, schemaManager.AddReplicaToShard(buildApplyRequest("TestCollection", api.ApplyRequest_TYPE_ADD_REPLICA_TO_SHARD,
+		api.AddReplicaToShardRequest{Class: "TestCollection", Shard: "shard1", Replica: "node2"}), true))
+
+	validate := func(minReadyReplicas int, node, collection string) error {
+		return manager.ValidateDeleteReplica(buildApplyRequest(collection, api.ApplyRequest_TYPE_REPLICATION_REPLICA_DELETE,
+			api.DeleteReplicaFromShardRequest{Class: collection, Shard: "shard1", Replica: node, MinReadyReplicas: minReadyReplicas}))
+	}
+
+	t.Run("removal of an unknown replica is rejected", func(t *testing.T) {
+		require.ErrorIs(t, validate(1, "node3", "TestCollection"), replication.ErrNodeNotFound)
+		require.ErrorIs(t, validate(1, "node1", "OtherCollection"), replication.ErrClassNotFound)
+	})
+
+	t.Run("removal of the source is rejected while the new replica is not ready", func(t *testing.T) {
+		require.ErrorIs(t, validate(1, "node1", "TestCollection"), replication.ErrNotEnoughReadyReplicas)
+		require.NoError(t, validate(0, "node1", "TestCollection"), "the check should be disabled")
+	})
+
+	t.Run("removal of the source is accepted once the new replica is ready", func(t *testing.T) {
+		updateState(api.READY)
+
+		require.NoError(t, validate(1, "node1", "TestCollection"))
+		require.ErrorIs(t, validate(2, "node1", "TestCollection"), replication.ErrNotEnoughReadyReplicas)
+
+		require.NoError(t, schemaManager.DeleteReplicaFromShard(buildApplyRequest("TestCollection", api.ApplyRequest_TYPE_REPLICATION_REPLICA_DELETE,
+			api.DeleteReplicaFromShardRequest{Class: "TestCollection", Shard: "shard1", Replica: "node1"}), true))
+		replicas, err := schemaReader.ShardReplicas("TestCollection", "shard1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"node2"}, replicas)
+	})
+}
+
 func TestManager_MetricsTracking(t *testing.T) {
 	const metricName = "weaviate_replication_operation_fsm_ops_by_state"
 	t.Run("one replication operation with two state transitions", func(t *testing.T) {
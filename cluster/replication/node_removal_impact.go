@@ -0,0 +1,89 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// ShardRef identifies a shard of a collection.
+type ShardRef struct {
+	Collection string
+	Shard      string
+}
+
+// NodeRemovalImpact describes the replication operations which would not complete if a node was removed from the
+// cluster. Operations already READY or ABORTED are not affected.
+type NodeRemovalImpact struct {
+	Node string
+	// SourceOps are the IDs of the unfinished operations copying a replica from the node, in ascending order.
+	SourceOps []uint64
+	// TargetOps are the IDs of the unfinished operations copying a replica to the node, in ascending order.
+	TargetOps []uint64
+	// AffectedShards are the shards whose replica being created by an unfinished operation would never become
+	// available, sorted by collection and shard.
+	AffectedShards []ShardRef
+}
+
+// ImpactOfRemovingNode returns the unfinished replication operations sourcing from or targeting the given node,
+// together with the shards they replicate. It only reads the FSM state.
+func (s *ShardReplicationFSM) ImpactOfRemovingNode(node string) NodeRemovalImpact {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+
+	impact := NodeRemovalImpact{Node: node}
+	affectedShards := make(map[ShardRef]struct{})
+	for op, status := range s.opsStatus {
+		if status.state == api.READY || status.state == api.ABORTED {
+			continue
+		}
+
+		affected := false
+		if op.sourceShard.nodeId == node {
+			impact.SourceOps = append(impact.SourceOps, op.ID)
+			affected = true
+		}
+		if op.targetShard.nodeId == node {
+			impact.TargetOps = append(impact.TargetOps, op.ID)
+			affected = true
+		}
+		if affected {
+			affectedShards[ShardRef{Collection: op.targetShard.collectionId, Shard: op.targetShard.shardId}] = struct{}{}
+		}
+	}
+
+	slices.Sort(impact.SourceOps)
+	slices.Sort(impact.TargetOps)
+	for shard := range affectedShards {
+		impact.AffectedShards = append(impact.AffectedShards, shard)
+	}
+	slices.SortFunc(impact.AffectedShards, func(a, b ShardRef) int {
+		return cmp.Or(cmp.Compare(a.Collection, b.Collection), cmp.Compare(a.Shard, b.Shard))
+	})
+	return impact
+}
+
+// ImpactOfRemovingNode is a dry-run reporting the replication operations which would not complete if the given node
+// was removed from the cluster, see ShardReplicationFSM.ImpactOfRemovingNode. It requires the replication FSM set
+// with WithReplicationFSM and otherwise reports no impact.
+func (e *ShardReplicationEngine) ImpactOfRemovingNode(node string) NodeRemovalImpact {
+	if e.fsm == nil {
+		e.logger.WithFields(logrus.Fields{"engine": e, "node": node}).Warn("replication engine has no replication FSM, node removal impact unknown")
+		return NodeRemovalImpact{Node: node}
+	}
+	return e.fsm.ImpactOfRemovingNode(node)
+}
@@ -0,0 +1,133 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"sync"
+	"time"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// TimelineEventType identifies the kind of event recorded in a replication operation timeline.
+type TimelineEventType string
+
+const (
+	// TimelineQueued is recorded when the engine receives the operation, before adding it to the op buffer.
+	TimelineQueued TimelineEventType = "queued"
+	// TimelineDequeued is recorded when the consumer takes the operation from the engine op buffer.
+	TimelineDequeued TimelineEventType = "dequeued"
+	// TimelineStateChanged is recorded when a state transition of the operation is applied to the FSM.
+	TimelineStateChanged TimelineEventType = "state_changed"
+	// TimelineRetried is recorded when the consumer retries a failed step of the operation.
+	TimelineRetried TimelineEventType = "retried"
+	// TimelineCompleted is recorded when the consumer completes the operation.
+	TimelineCompleted TimelineEventType = "completed"
+	// TimelineFailed is recorded when the consumer gives up on the operation.
+	TimelineFailed TimelineEventType = "failed"
+)
+
+// TimelineEvent is a single event in the timeline of a replication operation.
+type TimelineEvent struct {
+	Time time.Time
+	Type TimelineEventType
+	// State is the state the operation transitioned to, set for TimelineStateChanged events only.
+	State api.ShardReplicationState
+	// Detail optionally describes the event, e.g. the error causing a retry.
+	Detail string
+}
+
+// opTimeline records the timeline of the most recent replication operations. Recording on a nil opTimeline is a
+// no-op, so that components can record events regardless of whether timelines are enabled.
+type opTimeline struct {
+	mu           sync.Mutex
+	timeProvider TimeProvider
+	maxOps       int
+	// events stores the events of each tracked op, order stores the tracked op IDs by first event to evict the
+	// oldest op once maxOps ops are tracked.
+	events map[uint64][]TimelineEvent
+	order  []uint64
+	// trace, when set, is written every recorded event, see WithEventTrace
+	trace *opTraceWriter
+	// stream, when set, is written every recorded event, see WithStreamSink
+	stream *opStreamWriter
+}
+
+func newOpTimeline(maxOps int, timeProvider TimeProvider) *opTimeline {
+	return &opTimeline{
+		timeProvider: timeProvider,
+		maxOps:       maxOps,
+		events:       make(map[uint64][]TimelineEvent),
+	}
+}
+
+func (t *opTimeline) record(id uint64, eventType TimelineEventType, state api.ShardReplicationState, detail string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.trace != nil {
+		t.trace.write(id, eventType, state, detail)
+	}
+	if t.stream != nil {
+		t.stream.write(id, eventType, state, detail)
+	}
+	if t.maxOps <= 0 {
+		// Only tracing events, timelines are not kept
+		return
+	}
+
+	if _, ok := t.events[id]; !ok {
+		if len(t.order) >= t.maxOps {
+			delete(t.events, t.order[0])
+			t.order = t.order[1:]
+		}
+		t.order = append(t.order, id)
+	}
+	t.events[id] = append(t.events[id], TimelineEvent{
+		Time:   t.timeProvider.Now(),
+		Type:   eventType,
+		State:  state,
+		Detail: detail,
+	})
+}
+
+// get returns a copy of the recorded events of the given op, in the order they were recorded.
+func (t *opTimeline) get(id uint64) []TimelineEvent {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TimelineEvent(nil), t.events[id]...)
+}
+
+// timelineRecorder is implemented by consumers able to record events in the operation timelines.
+type timelineRecorder interface {
+	recordTimelineTo(timeline *opTimeline)
+}
+
+func (c *CopyOpConsumer) recordTimelineTo(timeline *opTimeline) {
+	c.timeline = timeline
+}
+
+// OpTimeline returns the chronological list of events recorded for the replication operation with the given ID:
+// queueing, dequeueing, state transitions, retries and completion. Timelines are only recorded when the engine is
+// created with WithOpTimeline, and state transitions only when a replication FSM is set with WithReplicationFSM.
+// It returns nil for an unknown operation, or an operation whose timeline was evicted.
+func (e *ShardReplicationEngine) OpTimeline(id uint64) []TimelineEvent {
+	return e.timeline.get(id)
+}
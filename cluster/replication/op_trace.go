@@ -0,0 +1,58 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// TraceRecord is a replication engine event written as a single JSON line by engines configured with
+// WithEventTrace. The trace records the same events as the operation timelines, in the order they occur, so that a
+// scenario can be replayed, e.g. in a simulator.
+type TraceRecord struct {
+	// Time is the time of the event, as measured by the clock given to WithEventTrace.
+	Time  time.Time         `json:"time"`
+	OpID  uint64            `json:"op_id"`
+	Event TimelineEventType `json:"event"`
+	// State is the state the operation transitioned to, set for TimelineStateChanged events only.
+	State api.ShardReplicationState `json:"state,omitempty"`
+	// Detail optionally describes the event, e.g. the error causing a retry.
+	Detail string `json:"detail,omitempty"`
+}
+
+// opTraceWriter serializes the TraceRecord lines written concurrently by the engine and the consumer workers.
+type opTraceWriter struct {
+	mu           sync.Mutex
+	enc          *json.Encoder
+	timeProvider TimeProvider
+	logger       logrus.FieldLogger
+}
+
+func newOpTraceWriter(w io.Writer, timeProvider TimeProvider) *opTraceWriter {
+	return &opTraceWriter{enc: json.NewEncoder(w), timeProvider: timeProvider}
+}
+
+func (w *opTraceWriter) write(id uint64, eventType TimelineEventType, state api.ShardReplicationState, detail string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	record := TraceRecord{Time: w.timeProvider.Now(), OpID: id, Event: eventType, State: state, Detail: detail}
+	if err := w.enc.Encode(record); err != nil && w.logger != nil {
+		w.logger.WithField("op", id).WithError(err).Warn("failed to write replication event trace record")
+	}
+}
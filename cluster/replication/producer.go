@@ -12,11 +12,17 @@
 package replication
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	enterrors "github.com/weaviate/weaviate/entities/errors"
 )
 
 // OpProducer is an interface for producing replication operations.
@@ -27,6 +33,49 @@ type OpProducer interface {
 	Produce(ctx context.Context, out chan<- ShardReplicationOp) error
 }
 
+// OpAcknowledger is optionally implemented by producers reading operations from a source requiring acknowledgments,
+// e.g. an external queue, so that operations are only removed from the source once successfully processed and failed
+// operations are redelivered. The replication engine acknowledges every operation its consumer successfully
+// processed, provided the consumer reports successes, as CopyOpConsumer does.
+type OpAcknowledger interface {
+	// Ack acknowledges the successful processing of the given operation. It is called by the consumer workers,
+	// possibly concurrently, and should not block.
+	Ack(op ShardReplicationOp)
+}
+
+// opSuccessObserver is implemented by consumers able to notify when an operation is successfully processed.
+type opSuccessObserver interface {
+	observeOpSuccess(onOpSucceeded func(op ShardReplicationOp))
+}
+
+func (c *CopyOpConsumer) observeOpSuccess(onOpSucceeded func(op ShardReplicationOp)) {
+	c.onOpSucceeded = onOpSucceeded
+}
+
+// defaultPollingInterval is the interval at which the FSM is polled for operations when none is configured.
+const defaultPollingInterval = 5 * time.Second
+
+// pollIntervalConfigurer is implemented by producers polling for operations at a configurable interval.
+type pollIntervalConfigurer interface {
+	// setPollInterval sets the polling interval, or the default one if the interval is lower than or equal to zero.
+	// It must be called before producing operations.
+	setPollInterval(interval time.Duration)
+	// pollInterval returns the polling interval.
+	pollInterval() time.Duration
+}
+
+func (p *FSMOpProducer) setPollInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultPollingInterval
+	}
+	p.pollingInterval = interval
+	p.logger = p.logger.WithField("polling_interval", interval)
+}
+
+func (p *FSMOpProducer) pollInterval() time.Duration {
+	return p.pollingInterval
+}
+
 // FSMOpProducer is an implementation of the OpProducer interface that reads replication
 // operations from a ShardReplicationFSM, which tracks the state of replication operations.
 type FSMOpProducer struct {
@@ -34,6 +83,14 @@ type FSMOpProducer struct {
 	fsm             *ShardReplicationFSM
 	pollingInterval time.Duration
 	nodeId          string
+	timeProvider    TimeProvider
+
+	// scanWorkers and scanTimeCap bound the concurrency and the duration of the scan of the FSM on every poll.
+	scanWorkers int
+	scanTimeCap time.Duration
+	// scanOffset is the position, in the operations of the node, the next scan starts from when the previous one
+	// was interrupted by the time cap.
+	scanOffset int
 }
 
 // String returns a string representation of the FSMOpProducer,
@@ -48,16 +105,22 @@ func (p *FSMOpProducer) String() string {
 // NewFSMOpProducer creates a new FSMOpProducer instance, which periodically polls the
 // ShardReplicationFSM for operations assigned to the given node and pushes them to
 // a channel for consumption by the replication engine.The polling interval controls
-// how often the FSM is queried for replication operations.
+// how often the FSM is queried for replication operations. A polling interval lower than
+// or equal to zero falls back to a default interval.
 //
 // Additional configuration can be applied using optional FSMProducerOption functions.
-func NewFSMOpProducer(logger *logrus.Logger, fsm *ShardReplicationFSM, pollingInterval time.Duration, nodeId string) *FSMOpProducer {
-	return &FSMOpProducer{
-		logger:          logger.WithFields(logrus.Fields{"component": "replication_producer", "action": replicationEngineLogAction, "node": nodeId, "polling_interval": pollingInterval}),
-		fsm:             fsm,
-		pollingInterval: pollingInterval,
-		nodeId:          nodeId,
+func NewFSMOpProducer(logger *logrus.Logger, fsm *ShardReplicationFSM, pollingInterval time.Duration, nodeId string, opts ...FSMProducerOption) *FSMOpProducer {
+	p := &FSMOpProducer{
+		logger:       logger.WithFields(logrus.Fields{"component": "replication_producer", "action": replicationEngineLogAction, "node": nodeId}),
+		fsm:          fsm,
+		nodeId:       nodeId,
+		timeProvider: RealTimeProvider{},
 	}
+	p.setPollInterval(pollingInterval)
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 // Produce implements the OpProducer interface and starts producing operations for the given node.
@@ -74,6 +137,9 @@ func NewFSMOpProducer(logger *logrus.Logger, fsm *ShardReplicationFSM, pollingIn
 // This behavior is intentional: the producer only generates new work when the system has capacity
 // to process it. Missing some ticks during backpressure is acceptable and avoids accumulating
 // unprocessed work or overloading the system.
+//
+// The operations fetched by a poll are emitted by decreasing priority, operations with the same priority being
+// emitted in the order they are stored in the FSM.
 func (p *FSMOpProducer) Produce(ctx context.Context, out chan<- ShardReplicationOp) error {
 	p.logger.WithField("producer", p).Info("starting replication engine FSM producer")
 
@@ -87,6 +153,7 @@ func (p *FSMOpProducer) Produce(ctx context.Context, out chan<- ShardReplication
 			return ctx.Err()
 		case <-ticker.C:
 			ops := p.allOpsForNode(p.nodeId)
+			sortByPriority(ops)
 			if len(ops) > 0 {
 				p.logger.WithFields(logrus.Fields{"producer": p, "number_of_ops": len(ops)}).Debug("preparing op replication")
 
@@ -109,39 +176,109 @@ func (p *FSMOpProducer) Produce(ctx context.Context, out chan<- ShardReplication
 // 1. Pull Model: Each node is responsible for pulling data TO itself FROM other nodes
 //
 // 2. Node Responsibility:
-//   - Target node: Handles all replication operations which are in REGISTERED or HYDRATING
+//   - Target node: Handles all replication operations which are in REGISTERED, HYDRATING or FINALIZING
 //   - Source node: Only handles DEHYDRATING operations as that state needs data to be deleted
 //
 // 3. Operation States:
 //   - REGISTERED: Initial state, operation waiting to start
 //   - HYDRATING: Operation in progress, target node is pulling data
+//   - FINALIZING: Data copied, target node still needs to update the sharding state
 //   - DEHYDRATING: The only state handled by source node, for cleanup after successful replication
 //   - **all other states**: Not reprocessed, require a new operation
 //
-// Returns only operations that should be actively processed by this node.
+// Operations are scanned concurrently and for a limited time if configured with WithScanConcurrency and
+// WithScanTimeCap. Returns only operations that should be actively processed by this node, in the order they are
+// stored in the FSM starting from where the previous scan stopped.
 func (p *FSMOpProducer) allOpsForNode(nodeId string) []ShardReplicationOp {
 	allNodeOps := p.fsm.GetOpsForNode(nodeId)
+	if len(allNodeOps) == 0 {
+		return nil
+	}
+
+	start := p.scanOffset % len(allNodeOps)
+	var deadline time.Time
+	if p.scanTimeCap > 0 {
+		deadline = p.timeProvider.Now().Add(p.scanTimeCap)
+	}
 
-	nodeOpsSubset := make([]ShardReplicationOp, 0, len(allNodeOps))
-	for _, op := range allNodeOps {
-		opState := p.fsm.GetOpState(op)
-
-		if opState.ShouldRestartOp() {
-			nodeOpsSubset = append(nodeOpsSubset, ShardReplicationOp{
-				ID: op.ID,
-				sourceShard: shardFQDN{
-					nodeId:       op.sourceShard.nodeId,
-					collectionId: op.sourceShard.collectionId,
-					shardId:      op.sourceShard.shardId,
-				},
-				targetShard: shardFQDN{
-					nodeId:       op.targetShard.nodeId,
-					collectionId: op.targetShard.collectionId,
-					shardId:      op.targetShard.shardId,
-				},
-			})
+	// Workers take the next position to scan until every operation is scanned or the deadline is reached, hence the
+	// scanned positions are always the first ones
+	eligible := make([]ShardReplicationOp, len(allNodeOps))
+	found := make([]bool, len(allNodeOps))
+	var next atomic.Int64
+	scan := func() {
+		for {
+			if !deadline.IsZero() && !p.timeProvider.Now().Before(deadline) {
+				return
+			}
+			i := int(next.Add(1) - 1)
+			if i >= len(allNodeOps) {
+				return
+			}
+			eligible[i], found[i] = p.opToProcess(allNodeOps[(start+i)%len(allNodeOps)])
 		}
 	}
 
+	if p.scanWorkers <= 1 {
+		scan()
+	} else {
+		var wg sync.WaitGroup
+		for range p.scanWorkers {
+			wg.Add(1)
+			enterrors.GoWrapper(func() {
+				defer wg.Done()
+				scan()
+			}, p.logger)
+		}
+		wg.Wait()
+	}
+
+	scanned := min(int(next.Load()), len(allNodeOps))
+	if scanned < len(allNodeOps) {
+		p.logger.WithFields(logrus.Fields{"producer": p, "scanned_ops": scanned, "number_of_ops": len(allNodeOps)}).Debug("replication operations scan interrupted by the time cap")
+	}
+	p.scanOffset = (start + scanned) % len(allNodeOps)
+
+	nodeOpsSubset := make([]ShardReplicationOp, 0, scanned)
+	for i := range scanned {
+		if found[i] {
+			nodeOpsSubset = append(nodeOpsSubset, eligible[i])
+		}
+	}
 	return nodeOpsSubset
 }
+
+// opToProcess returns the operation to emit for the given operation stored in the FSM, with its current state, and
+// whether it should be processed.
+func (p *FSMOpProducer) opToProcess(op ShardReplicationOp) (ShardReplicationOp, bool) {
+	opState := p.fsm.GetOpState(op)
+	if !opState.ShouldRestartOp() {
+		return ShardReplicationOp{}, false
+	}
+	return ShardReplicationOp{
+		ID:         op.ID,
+		CostCenter: op.CostCenter,
+		CampaignID: op.CampaignID,
+		Priority:   op.Priority,
+		Deadline:   op.Deadline,
+		sourceShard: shardFQDN{
+			nodeId:       op.sourceShard.nodeId,
+			collectionId: op.sourceShard.collectionId,
+			shardId:      op.sourceShard.shardId,
+		},
+		targetShard: shardFQDN{
+			nodeId:       op.targetShard.nodeId,
+			collectionId: op.targetShard.collectionId,
+			shardId:      op.targetShard.shardId,
+		},
+		startState:       opState.state,
+		committedBatches: opState.committedBatches,
+	}, true
+}
+
+// sortByPriority sorts the operations by decreasing priority, keeping the order of operations with the same priority.
+func sortByPriority(ops []ShardReplicationOp) {
+	slices.SortStableFunc(ops, func(a, b ShardReplicationOp) int {
+		return cmp.Compare(b.Priority, a.Priority)
+	})
+}
@@ -0,0 +1,42 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import "time"
+
+// FSMProducerOption configures optional behavior of an FSMOpProducer.
+type FSMProducerOption func(*FSMOpProducer)
+
+// WithScanConcurrency makes the producer filter the operations of its node using up to workers goroutines on every
+// poll, so that scanning a large FSM does not delay the poll loop. Operations are still emitted in the order they are
+// stored in the FSM. A value lower than or equal to 1 scans the operations sequentially.
+func WithScanConcurrency(workers int) FSMProducerOption {
+	return func(p *FSMOpProducer) {
+		p.scanWorkers = workers
+	}
+}
+
+// WithScanTimeCap caps the time spent scanning the operations of the node on every poll. Operations not scanned once
+// the cap is reached are not emitted by this poll, and the next poll resumes the scan from the first of them, so that
+// every operation is eventually emitted. A cap lower than or equal to zero disables it.
+func WithScanTimeCap(maxDuration time.Duration) FSMProducerOption {
+	return func(p *FSMOpProducer) {
+		p.scanTimeCap = maxDuration
+	}
+}
+
+// WithProducerTimeProvider sets the clock used by the producer to measure the scan duration, see WithScanTimeCap.
+func WithProducerTimeProvider(timeProvider TimeProvider) FSMProducerOption {
+	return func(p *FSMOpProducer) {
+		p.timeProvider = timeProvider
+	}
+}
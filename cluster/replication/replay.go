@@ -0,0 +1,232 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+	"github.com/weaviate/weaviate/cluster/replication/types"
+)
+
+// ErrReplayDiverged is returned by Replay when the outcome of a replayed operation differs from the recorded one.
+var ErrReplayDiverged = errors.New("replayed operation outcomes diverged from the trace")
+
+// replayNodeId is the node the engine replaying a trace runs on.
+const replayNodeId = "replay"
+
+// ReplayHandlers provides Replay with what it needs to feed a recorded trace through the replication engine.
+type ReplayHandlers struct {
+	// Op returns the traced operation with the given ID, as trace records only hold operation IDs. It is required.
+	Op func(id uint64) (ShardReplicationOp, bool)
+	// FSMUpdater receives the state transitions of the replayed operations. Transitions are accepted and discarded
+	// when nil.
+	FSMUpdater types.FSMUpdater
+	// OnEvent, when set, is called with every event recorded while replaying the trace, in the order they occur.
+	// Events are timestamped with the time of the first record of the trace.
+	OnEvent func(record TraceRecord)
+	// Logger logs the replay, logs are discarded when nil.
+	Logger *logrus.Logger
+}
+
+// replayedOp is an operation of a trace along with the scripted outcome of each of its copy attempts.
+type replayedOp struct {
+	id uint64
+	// retries holds the detail of every recorded retry, replayed as a failed copy attempt.
+	retries []string
+	// outcome is the recorded final event of the operation, either TimelineCompleted or TimelineFailed.
+	outcome TimelineEventType
+	detail  string
+	// attempt is the number of copy attempts replayed so far.
+	attempt int
+}
+
+// Replay feeds a trace recorded with WithEventTrace through the replication engine, running a CopyOpConsumer with a
+// single worker and a copier scripted from the trace, so that a scenario captured in production can be reproduced
+// deterministically, e.g. in a test.
+//
+// Operations are produced in the order they were first queued in the trace. Every recorded retry of an operation is
+// replayed as a failed copy attempt, whatever step of the operation was retried, and its last attempt succeeds or
+// fails permanently depending on the recorded outcome. Operations without a recorded outcome, e.g. still in progress
+// when the trace was recorded, are not replayed. State transitions are not replayed from the trace but sent to the
+// FSM updater of the handlers as the consumer processes the operations.
+//
+// It returns an error wrapping ErrReplayDiverged listing the operations whose replayed outcome differs from the
+// recorded one, or an error if the trace is malformed or references an operation unknown to the handlers.
+func Replay(trace io.Reader, handlers ReplayHandlers) error {
+	if handlers.Op == nil {
+		return errors.New("replay trace: no operation handler")
+	}
+
+	var start time.Time
+	var order []uint64
+	recorded := make(map[uint64]*replayedOp)
+	decoder := json.NewDecoder(trace)
+	for decoder.More() {
+		var record TraceRecord
+		if err := decoder.Decode(&record); err != nil {
+			return fmt.Errorf("replay trace: decode record: %w", err)
+		}
+		if start.IsZero() {
+			start = record.Time
+		}
+		op, ok := recorded[record.OpID]
+		if !ok {
+			op = &replayedOp{id: record.OpID}
+			recorded[record.OpID] = op
+			order = append(order, record.OpID)
+		}
+		switch record.Event {
+		case TimelineRetried:
+			op.retries = append(op.retries, record.Detail)
+		case TimelineCompleted, TimelineFailed:
+			op.outcome, op.detail = record.Event, record.Detail
+		}
+	}
+
+	copier := &replayCopier{scripts: make(map[string][]*replayedOp)}
+	var ops []ShardReplicationOp
+	for _, id := range order {
+		if recorded[id].outcome == "" {
+			continue
+		}
+		op, ok := handlers.Op(id)
+		if !ok {
+			return fmt.Errorf("replay trace: operation %d not found", id)
+		}
+		key := replayCopyKey(op.sourceShard.nodeId, op.sourceShard.collectionId, op.sourceShard.shardId)
+		copier.scripts[key] = append(copier.scripts[key], recorded[id])
+		ops = append(ops, op)
+	}
+
+	logger := handlers.Logger
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+	}
+	fsmUpdater := handlers.FSMUpdater
+	if fsmUpdater == nil {
+		fsmUpdater = replayFSMUpdater{}
+	}
+	var replayed bytes.Buffer
+	consumer := NewCopyOpConsumer(logger, fsmUpdater, copier, RealTimeProvider{}, replayNodeId, func() backoff.BackOff { return &backoff.ZeroBackOff{} },
+		time.Minute, 1)
+	engine := NewShardReplicationEngine(logger, replayNodeId, &replayProducer{ops: ops}, consumer, 1, 1, time.Minute,
+		WithEventTrace(&replayed, fixedTimeProvider(start)))
+	if err := engine.Start(context.Background()); err != nil {
+		return fmt.Errorf("replay trace: %w", err)
+	}
+
+	outcomes := make(map[uint64]TimelineEventType)
+	decoder = json.NewDecoder(&replayed)
+	for decoder.More() {
+		var record TraceRecord
+		if err := decoder.Decode(&record); err != nil {
+			return fmt.Errorf("replay trace: decode replayed record: %w", err)
+		}
+		if record.Event == TimelineCompleted || record.Event == TimelineFailed {
+			outcomes[record.OpID] = record.Event
+		}
+		if handlers.OnEvent != nil {
+			handlers.OnEvent(record)
+		}
+	}
+
+	var diverged []uint64
+	for _, op := range ops {
+		if outcomes[op.ID] != recorded[op.ID].outcome {
+			diverged = append(diverged, op.ID)
+		}
+	}
+	if len(diverged) > 0 {
+		return fmt.Errorf("%w: operations %v", ErrReplayDiverged, diverged)
+	}
+	return nil
+}
+
+// fixedTimeProvider always returns the same time.
+type fixedTimeProvider time.Time
+
+func (p fixedTimeProvider) Now() time.Time {
+	return time.Time(p)
+}
+
+// replayProducer produces the operations of a trace once, then finishes.
+type replayProducer struct {
+	ops []ShardReplicationOp
+}
+
+func (p *replayProducer) Produce(ctx context.Context, out chan<- ShardReplicationOp) error {
+	for _, op := range p.ops {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case out <- op:
+		}
+	}
+	return nil
+}
+
+// replayCopier replays the recorded copy attempts of the operations of a trace, in order, for every source replica.
+type replayCopier struct {
+	mu      sync.Mutex
+	scripts map[string][]*replayedOp
+}
+
+func replayCopyKey(sourceNode, collection, shard string) string {
+	return sourceNode + "/" + collection + "/" + shard
+}
+
+func (c *replayCopier) CopyReplica(_ context.Context, sourceNode, collection, shard string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := replayCopyKey(sourceNode, collection, shard)
+	if len(c.scripts[key]) == 0 {
+		return backoff.Permanent(fmt.Errorf("replay trace: unexpected copy of %s", key))
+	}
+	op := c.scripts[key][0]
+	attempt := op.attempt
+	op.attempt++
+	if attempt < len(op.retries) {
+		return errors.New(op.retries[attempt])
+	}
+
+	// Last attempt of the operation, the next copy of the replica belongs to the next operation
+	c.scripts[key] = slices.Delete(c.scripts[key], 0, 1)
+	if op.outcome == TimelineFailed {
+		return backoff.Permanent(errors.New(op.detail))
+	}
+	return nil
+}
+
+// replayFSMUpdater accepts and discards every update.
+type replayFSMUpdater struct{}
+
+func (replayFSMUpdater) AddReplicaToShard(context.Context, string, string, string) (uint64, error) {
+	return 0, nil
+}
+
+func (replayFSMUpdater) ReplicationUpdateReplicaOpStatus(uint64, api.ShardReplicationState) error {
+	return nil
+}
@@ -0,0 +1,186 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replicationtest
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/weaviate/weaviate/cluster/replication"
+)
+
+var (
+	_ replication.TimeProvider = (*FakeClock)(nil)
+	_ replication.Timer        = (*FakeClock)(nil)
+)
+
+// FakeClock is a manually advanced clock implementing replication.TimeProvider and replication.Timer, together with
+// the usual time functions (After, NewTicker and Sleep), for deterministic simulations of time-based features.
+//
+// Time only moves forward when Advance is called, which fires the pending timers, tickers and sleepers whose deadline
+// is reached, one at a time in deadline order, with Now returning the deadline of each one as it fires. Timers sharing
+// a deadline fire in the order they were created.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	seq     uint64
+	waiters []*clockWaiter
+}
+
+// clockWaiter is a pending timer, ticker or sleeper, firing at its deadline.
+type clockWaiter struct {
+	deadline time.Time
+	seq      uint64
+	// period reschedules the waiter after firing, for tickers
+	period time.Duration
+	fire   func(now time.Time)
+}
+
+// NewFakeClock returns a FakeClock whose current time is now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now implements replication.TimeProvider.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc implements replication.Timer. Unlike time.AfterFunc, fn is called synchronously by Advance. Stopping the
+// returned timer before fn is called prevents fn from being called, and reports whether it did so like time.Timer.
+func (c *FakeClock) AfterFunc(d time.Duration, fn func()) *time.Timer {
+	// The returned timer never fires by itself and is only used to track whether it was stopped: the first Stop
+	// reports true, either called by the caller to cancel fn or by the clock to claim the timer before calling fn.
+	timer := time.AfterFunc(math.MaxInt64, func() {})
+	c.schedule(d, 0, func(time.Time) {
+		if timer.Stop() {
+			fn()
+		}
+	})
+	return timer
+}
+
+// After returns a channel receiving the current time once the clock advanced by d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.schedule(d, 0, func(now time.Time) {
+		ch <- now
+	})
+	return ch
+}
+
+// Sleep blocks until the clock advanced by d.
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// FakeTicker delivers the current time of a FakeClock every period, dropping ticks for slow receivers like
+// time.Ticker.
+type FakeTicker struct {
+	C      <-chan time.Time
+	clock  *FakeClock
+	waiter *clockWaiter
+}
+
+// NewTicker returns a FakeTicker ticking every d once the clock advanced by d.
+func (c *FakeClock) NewTicker(d time.Duration) *FakeTicker {
+	ch := make(chan time.Time, 1)
+	waiter := c.schedule(d, d, func(now time.Time) {
+		select {
+		case ch <- now:
+		default:
+		}
+	})
+	return &FakeTicker{C: ch, clock: c, waiter: waiter}
+}
+
+// Stop stops the ticker. No more ticks are delivered.
+func (t *FakeTicker) Stop() {
+	t.clock.remove(t.waiter)
+}
+
+// Advance moves the clock forward by d, firing every waiter whose deadline is reached in deadline order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		if len(c.waiters) == 0 || c.waiters[0].deadline.After(target) {
+			c.now = target
+			c.mu.Unlock()
+			return
+		}
+		waiter := c.waiters[0]
+		c.waiters = c.waiters[1:]
+		c.now = waiter.deadline
+		if waiter.period > 0 {
+			c.seq++
+			waiter.deadline = waiter.deadline.Add(waiter.period)
+			waiter.seq = c.seq
+			c.insert(waiter)
+		}
+		now := c.now
+		c.mu.Unlock()
+
+		// Firing without holding the lock lets waiters call back into the clock, e.g. to schedule a new timer
+		waiter.fire(now)
+	}
+}
+
+// Waiters returns the number of pending timers, tickers and sleepers, allowing tests to wait for a goroutine to
+// start waiting on the clock before advancing it.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+func (c *FakeClock) schedule(d, period time.Duration, fire func(time.Time)) *clockWaiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	waiter := &clockWaiter{deadline: c.now.Add(d), seq: c.seq, period: period, fire: fire}
+	c.insert(waiter)
+	return waiter
+}
+
+// insert adds the waiter keeping the waiters sorted by deadline and creation order. It must be called holding the
+// lock.
+func (c *FakeClock) insert(waiter *clockWaiter) {
+	i := sort.Search(len(c.waiters), func(i int) bool {
+		other := c.waiters[i]
+		if !other.deadline.Equal(waiter.deadline) {
+			return other.deadline.After(waiter.deadline)
+		}
+		return other.seq > waiter.seq
+	})
+	c.waiters = append(c.waiters, nil)
+	copy(c.waiters[i+1:], c.waiters[i:])
+	c.waiters[i] = waiter
+}
+
+func (c *FakeClock) remove(waiter *clockWaiter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, w := range c.waiters {
+		if w == waiter {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,147 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replicationtest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/weaviate/weaviate/cluster/replication/replicationtest"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("advancing fires pending timers in deadline order", func(t *testing.T) {
+		// GIVEN
+		clock := replicationtest.NewFakeClock(start)
+		var fired []string
+		var firedAt []time.Duration
+		record := func(name string) func() {
+			return func() {
+				fired = append(fired, name)
+				firedAt = append(firedAt, clock.Now().Sub(start))
+			}
+		}
+		clock.AfterFunc(3*time.Second, record("third"))
+		clock.AfterFunc(time.Second, record("first"))
+		clock.AfterFunc(2*time.Second, record("second"))
+		clock.AfterFunc(2*time.Second, record("second, created later"))
+		clock.AfterFunc(time.Minute, record("later"))
+
+		// WHEN
+		clock.Advance(5 * time.Second)
+
+		// THEN
+		require.Equal(t, []string{"first", "second", "second, created later", "third"}, fired)
+		require.Equal(t, []time.Duration{time.Second, 2 * time.Second, 2 * time.Second, 3 * time.Second}, firedAt)
+		require.Equal(t, start.Add(5*time.Second), clock.Now())
+		require.Equal(t, 1, clock.Waiters())
+	})
+
+	t.Run("stopped timer does not fire", func(t *testing.T) {
+		// GIVEN
+		clock := replicationtest.NewFakeClock(start)
+		called := false
+		timer := clock.AfterFunc(time.Second, func() { called = true })
+
+		// WHEN
+		stopped := timer.Stop()
+		clock.Advance(time.Second)
+
+		// THEN
+		require.True(t, stopped)
+		require.False(t, called)
+	})
+
+	t.Run("timers scheduled while firing fire within the same advance", func(t *testing.T) {
+		// GIVEN
+		clock := replicationtest.NewFakeClock(start)
+		var firedAt []time.Time
+		var schedule func()
+		schedule = func() {
+			clock.AfterFunc(time.Second, func() {
+				firedAt = append(firedAt, clock.Now())
+				schedule()
+			})
+		}
+		schedule()
+
+		// WHEN
+		clock.Advance(3 * time.Second)
+
+		// THEN
+		require.Equal(t, []time.Time{start.Add(time.Second), start.Add(2 * time.Second), start.Add(3 * time.Second)}, firedAt)
+	})
+
+	t.Run("ticker ticks every period until stopped", func(t *testing.T) {
+		// GIVEN
+		clock := replicationtest.NewFakeClock(start)
+		ticker := clock.NewTicker(time.Second)
+
+		// WHEN
+		clock.Advance(time.Second)
+
+		// THEN
+		require.Equal(t, start.Add(time.Second), <-ticker.C)
+
+		// WHEN the ticks are not received
+		clock.Advance(3 * time.Second)
+
+		// THEN they are dropped
+		require.Equal(t, start.Add(2*time.Second), <-ticker.C)
+		require.Empty(t, ticker.C)
+
+		// WHEN
+		ticker.Stop()
+		clock.Advance(time.Second)
+
+		// THEN
+		require.Empty(t, ticker.C)
+		require.Zero(t, clock.Waiters())
+	})
+
+	t.Run("after and sleep wait for the clock to advance", func(t *testing.T) {
+		// GIVEN
+		clock := replicationtest.NewFakeClock(start)
+		after := clock.After(time.Second)
+		slept := make(chan struct{})
+		go func() {
+			clock.Sleep(2 * time.Second)
+			close(slept)
+		}()
+		require.Eventually(t, func() bool { return clock.Waiters() == 2 }, 5*time.Second, time.Millisecond)
+
+		// WHEN
+		clock.Advance(time.Second)
+
+		// THEN
+		require.Equal(t, start.Add(time.Second), <-after)
+		select {
+		case <-slept:
+			t.Fatal("sleep should not return before the clock advanced enough")
+		default:
+		}
+
+		// WHEN
+		clock.Advance(time.Second)
+
+		// THEN
+		select {
+		case <-slept:
+		case <-time.After(5 * time.Second):
+			t.Fatal("sleep should return once the clock advanced enough")
+		}
+	})
+}
@@ -0,0 +1,78 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+// Package replicationtest provides in-memory fakes and helpers to run the real shard replication engine in
+// end-to-end tests without hand-wiring mocks.
+package replicationtest
+
+import (
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/weaviate/weaviate/cluster/replication"
+)
+
+const (
+	defaultBufferSize      = 64
+	defaultMaxWorkers      = 4
+	defaultOpTimeout       = 10 * time.Second
+	defaultShutdownTimeout = 10 * time.Second
+)
+
+// InMemoryEngine bundles a real ShardReplicationEngine and CopyOpConsumer with the in-memory fakes they
+// are wired to, so that tests can drive operations and inspect their outcome.
+type InMemoryEngine struct {
+	Engine     *replication.ShardReplicationEngine
+	Consumer   *replication.CopyOpConsumer
+	Producer   *FakeProducer
+	Copier     *FakeCopier
+	FSMUpdater *FakeFSMUpdater
+}
+
+// NewInMemoryEngine assembles a real replication engine for the given node, backed by a FakeProducer,
+// a FakeCopier and a FakeFSMUpdater. Failed operations are not retried. Consumer options are forwarded
+// to the underlying CopyOpConsumer.
+func NewInMemoryEngine(logger *logrus.Logger, nodeId string, opts ...replication.CopyOpConsumerOption) *InMemoryEngine {
+	producer := NewFakeProducer(defaultBufferSize)
+	copier := NewFakeCopier()
+	fsmUpdater := NewFakeFSMUpdater()
+
+	consumer := replication.NewCopyOpConsumer(
+		logger,
+		fsmUpdater,
+		copier,
+		replication.RealTimeProvider{},
+		nodeId,
+		func() backoff.BackOff { return &backoff.StopBackOff{} },
+		defaultOpTimeout,
+		defaultMaxWorkers,
+		opts...,
+	)
+	engine := replication.NewShardReplicationEngine(
+		logger,
+		nodeId,
+		producer,
+		consumer,
+		defaultBufferSize,
+		defaultMaxWorkers,
+		defaultShutdownTimeout,
+	)
+
+	return &InMemoryEngine{
+		Engine:     engine,
+		Consumer:   consumer,
+		Producer:   producer,
+		Copier:     copier,
+		FSMUpdater: fsmUpdater,
+	}
+}
@@ -0,0 +1,62 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replicationtest_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+	"github.com/weaviate/weaviate/cluster/replication"
+	"github.com/weaviate/weaviate/cluster/replication/replicationtest"
+)
+
+func TestInMemoryEngine(t *testing.T) {
+	t.Run("op goes through its full lifecycle", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = inMemory.Engine.Start(context.Background())
+		}()
+
+		// WHEN
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := inMemory.FSMUpdater.WaitFor(ctx, func(f *replicationtest.FakeFSMUpdater) bool {
+			state, _ := f.State(1)
+			return state == api.READY
+		})
+		require.NoError(t, err, "op should complete")
+
+		inMemory.Engine.Stop()
+		wg.Wait()
+
+		// THEN
+		require.NoError(t, engineStartErr)
+		require.Equal(t, []api.ShardReplicationState{api.HYDRATING, api.FINALIZING, api.READY}, inMemory.FSMUpdater.StateHistory(1))
+		require.Equal(t, []replicationtest.CopyCall{{SourceNode: "node1", Collection: "TestCollection", Shard: "shard1"}}, inMemory.Copier.Calls())
+		require.Equal(t, []replicationtest.AddReplicaCall{{Collection: "TestCollection", Shard: "shard1", Node: "node2"}}, inMemory.FSMUpdater.AddedReplicas())
+	})
+}
@@ -0,0 +1,222 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replicationtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+	"github.com/weaviate/weaviate/cluster/replication"
+)
+
+// CopyCall records the arguments of a single FakeCopier.CopyReplica invocation.
+type CopyCall struct {
+	SourceNode string
+	Collection string
+	Shard      string
+}
+
+// FakeCopier is an in-memory types.ReplicaCopier recording every copy request.
+// CopyFunc, when set, decides the outcome of each copy.
+type FakeCopier struct {
+	mu       sync.Mutex
+	calls    []CopyCall
+	CopyFunc func(ctx context.Context, sourceNode, collection, shard string) error
+}
+
+// NewFakeCopier returns a FakeCopier where every copy succeeds.
+func NewFakeCopier() *FakeCopier {
+	return &FakeCopier{}
+}
+
+// CopyReplica implements types.ReplicaCopier.
+func (c *FakeCopier) CopyReplica(ctx context.Context, sourceNode, collection, shard string) error {
+	c.mu.Lock()
+	c.calls = append(c.calls, CopyCall{SourceNode: sourceNode, Collection: collection, Shard: shard})
+	copyFunc := c.CopyFunc
+	c.mu.Unlock()
+
+	if copyFunc != nil {
+		return copyFunc(ctx, sourceNode, collection, shard)
+	}
+	return nil
+}
+
+// Calls returns a copy of the recorded copy requests in invocation order.
+func (c *FakeCopier) Calls() []CopyCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]CopyCall(nil), c.calls...)
+}
+
+// AddReplicaCall records the arguments of a single FakeFSMUpdater.AddReplicaToShard invocation.
+type AddReplicaCall struct {
+	Collection string
+	Shard      string
+	Node       string
+}
+
+// FakeFSMUpdater is an in-memory types.FSMUpdater keeping track of the last known state of every
+// operation and of the replicas added to shards.
+type FakeFSMUpdater struct {
+	mu          sync.Mutex
+	states      map[uint64]api.ShardReplicationState
+	history     map[uint64][]api.ShardReplicationState
+	addReplicas []AddReplicaCall
+	changed     chan struct{}
+
+	// AddReplicaFunc and UpdateStatusFunc, when set, decide the outcome of the respective calls.
+	AddReplicaFunc   func(ctx context.Context, collection, shard, node string) error
+	UpdateStatusFunc func(id uint64, state api.ShardReplicationState) error
+	// PingFunc, when set, decides the outcome of Ping.
+	PingFunc func(ctx context.Context) error
+}
+
+// NewFakeFSMUpdater returns a FakeFSMUpdater where every update succeeds.
+func NewFakeFSMUpdater() *FakeFSMUpdater {
+	return &FakeFSMUpdater{
+		states:  make(map[uint64]api.ShardReplicationState),
+		history: make(map[uint64][]api.ShardReplicationState),
+		changed: make(chan struct{}),
+	}
+}
+
+// AddReplicaToShard implements types.FSMUpdater.
+func (f *FakeFSMUpdater) AddReplicaToShard(ctx context.Context, collection, shard, node string) (uint64, error) {
+	f.mu.Lock()
+	addReplicaFunc := f.AddReplicaFunc
+	f.mu.Unlock()
+
+	if addReplicaFunc != nil {
+		if err := addReplicaFunc(ctx, collection, shard, node); err != nil {
+			return 0, err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.addReplicas = append(f.addReplicas, AddReplicaCall{Collection: collection, Shard: shard, Node: node})
+	f.notifyLocked()
+	return uint64(len(f.addReplicas)), nil
+}
+
+// ReplicationUpdateReplicaOpStatus implements types.FSMUpdater.
+func (f *FakeFSMUpdater) ReplicationUpdateReplicaOpStatus(id uint64, state api.ShardReplicationState) error {
+	f.mu.Lock()
+	updateStatusFunc := f.UpdateStatusFunc
+	f.mu.Unlock()
+
+	if updateStatusFunc != nil {
+		if err := updateStatusFunc(id, state); err != nil {
+			return err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states[id] = state
+	f.history[id] = append(f.history[id], state)
+	f.notifyLocked()
+	return nil
+}
+
+// Ping implements types.LeaderPinger.
+func (f *FakeFSMUpdater) Ping(ctx context.Context) error {
+	f.mu.Lock()
+	pingFunc := f.PingFunc
+	f.mu.Unlock()
+
+	if pingFunc != nil {
+		return pingFunc(ctx)
+	}
+	return nil
+}
+
+// notifyLocked wakes up every goroutine waiting for a change. It must be called with the lock held.
+func (f *FakeFSMUpdater) notifyLocked() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// State returns the last state recorded for the given operation.
+func (f *FakeFSMUpdater) State(id uint64) (api.ShardReplicationState, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, ok := f.states[id]
+	return state, ok
+}
+
+// StateHistory returns every state recorded for the given operation, in order.
+func (f *FakeFSMUpdater) StateHistory(id uint64) []api.ShardReplicationState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]api.ShardReplicationState(nil), f.history[id]...)
+}
+
+// AddedReplicas returns the replicas added to shards, in invocation order.
+func (f *FakeFSMUpdater) AddedReplicas() []AddReplicaCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]AddReplicaCall(nil), f.addReplicas...)
+}
+
+// WaitFor blocks until cond returns true or the context is done. The condition is evaluated each time
+// the fake records a change.
+func (f *FakeFSMUpdater) WaitFor(ctx context.Context, cond func(f *FakeFSMUpdater) bool) error {
+	for {
+		f.mu.Lock()
+		changed := f.changed
+		f.mu.Unlock()
+
+		if cond(f) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// FakeProducer is an in-memory replication.OpProducer emitting the operations submitted to it.
+// Operations submitted while the engine is stopped are emitted once it starts again.
+type FakeProducer struct {
+	queue chan replication.ShardReplicationOp
+}
+
+// NewFakeProducer returns a FakeProducer able to hold up to capacity submitted operations.
+func NewFakeProducer(capacity int) *FakeProducer {
+	return &FakeProducer{queue: make(chan replication.ShardReplicationOp, capacity)}
+}
+
+// Submit enqueues an operation to be emitted by the producer. It blocks when the producer is full.
+func (p *FakeProducer) Submit(op replication.ShardReplicationOp) {
+	p.queue <- op
+}
+
+// Produce implements replication.OpProducer.
+func (p *FakeProducer) Produce(ctx context.Context, out chan<- replication.ShardReplicationOp) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case op := <-p.queue:
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case out <- op:
+			}
+		}
+	}
+}
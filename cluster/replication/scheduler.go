@@ -0,0 +1,332 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import "slices"
+
+// Scheduler holds the replication operations queued in the replication engine and decides the order in which they
+// are handed to the consumer.
+//
+// The engine bounds the number of operations queued in the scheduler, applying its overflow policy, hence
+// schedulers do not need to bound their size. A scheduler is only used by the engine goroutine dispatching
+// operations and does not need to be safe for concurrent use.
+type Scheduler interface {
+	// Enqueue adds an operation to the scheduler.
+	Enqueue(op ShardReplicationOp)
+	// Dequeue removes and returns the next operation to process, or false if the scheduler is empty.
+	Dequeue() (ShardReplicationOp, bool)
+	// Snapshot returns the queued operations, in the order they would be dequeued, without removing them.
+	Snapshot() []ShardReplicationOp
+	// Remove removes the given queued operation, keeping the order of the other ones, and returns false if it is not
+	// queued.
+	Remove(op ShardReplicationOp) bool
+}
+
+// removeOp removes the first occurrence of the given operation from ops, reporting whether it was found.
+func removeOp(ops []ShardReplicationOp, op ShardReplicationOp) ([]ShardReplicationOp, bool) {
+	i := slices.Index(ops, op)
+	if i < 0 {
+		return ops, false
+	}
+	return slices.Delete(ops, i, i+1), true
+}
+
+// FIFOScheduler dispatches operations in the order they were queued. It is the default scheduler.
+type FIFOScheduler struct {
+	ops []ShardReplicationOp
+}
+
+// NewFIFOScheduler returns an empty FIFOScheduler.
+func NewFIFOScheduler() *FIFOScheduler {
+	return &FIFOScheduler{}
+}
+
+// Enqueue implements Scheduler.
+func (s *FIFOScheduler) Enqueue(op ShardReplicationOp) {
+	s.ops = append(s.ops, op)
+}
+
+// Dequeue implements Scheduler.
+func (s *FIFOScheduler) Dequeue() (ShardReplicationOp, bool) {
+	if len(s.ops) == 0 {
+		return ShardReplicationOp{}, false
+	}
+	op := s.ops[0]
+	s.ops[0] = ShardReplicationOp{}
+	s.ops = s.ops[1:]
+	return op, true
+}
+
+// Snapshot implements Scheduler.
+func (s *FIFOScheduler) Snapshot() []ShardReplicationOp {
+	return slices.Clone(s.ops)
+}
+
+// Remove implements Scheduler.
+func (s *FIFOScheduler) Remove(op ShardReplicationOp) bool {
+	var ok bool
+	s.ops, ok = removeOp(s.ops, op)
+	return ok
+}
+
+// LIFOScheduler dispatches the most recently queued operation first.
+type LIFOScheduler struct {
+	ops []ShardReplicationOp
+}
+
+// NewLIFOScheduler returns an empty LIFOScheduler.
+func NewLIFOScheduler() *LIFOScheduler {
+	return &LIFOScheduler{}
+}
+
+// Enqueue implements Scheduler.
+func (s *LIFOScheduler) Enqueue(op ShardReplicationOp) {
+	s.ops = append(s.ops, op)
+}
+
+// Dequeue implements Scheduler.
+func (s *LIFOScheduler) Dequeue() (ShardReplicationOp, bool) {
+	if len(s.ops) == 0 {
+		return ShardReplicationOp{}, false
+	}
+	op := s.ops[len(s.ops)-1]
+	s.ops = s.ops[:len(s.ops)-1]
+	return op, true
+}
+
+// Snapshot implements Scheduler.
+func (s *LIFOScheduler) Snapshot() []ShardReplicationOp {
+	ops := slices.Clone(s.ops)
+	slices.Reverse(ops)
+	return ops
+}
+
+// Remove implements Scheduler.
+func (s *LIFOScheduler) Remove(op ShardReplicationOp) bool {
+	var ok bool
+	s.ops, ok = removeOp(s.ops, op)
+	return ok
+}
+
+// RoundRobinScheduler dispatches operations fairly across their target nodes, taking one operation per target node
+// per round, so that a target node with many queued operations does not starve the operations of other target
+// nodes. Operations targeting the same node are dispatched in the order they were queued, and target nodes are cycled
+// through in the order their first queued operation was queued.
+type RoundRobinScheduler struct {
+	ops map[string][]ShardReplicationOp
+	// nodes holds the target nodes with queued operations, in round order
+	nodes []string
+	// next is the index in nodes of the target node to dispatch an operation from next
+	next int
+}
+
+// NewRoundRobinScheduler returns an empty RoundRobinScheduler.
+func NewRoundRobinScheduler() *RoundRobinScheduler {
+	return &RoundRobinScheduler{ops: make(map[string][]ShardReplicationOp)}
+}
+
+// Enqueue implements Scheduler.
+func (s *RoundRobinScheduler) Enqueue(op ShardReplicationOp) {
+	node := op.targetShard.nodeId
+	if _, ok := s.ops[node]; !ok {
+		s.nodes = append(s.nodes, node)
+	}
+	s.ops[node] = append(s.ops[node], op)
+}
+
+// Dequeue implements Scheduler.
+func (s *RoundRobinScheduler) Dequeue() (ShardReplicationOp, bool) {
+	if len(s.nodes) == 0 {
+		return ShardReplicationOp{}, false
+	}
+	if s.next >= len(s.nodes) {
+		s.next = 0
+	}
+
+	node := s.nodes[s.next]
+	ops := s.ops[node]
+	op := ops[0]
+	if len(ops) == 1 {
+		// The next target node moves to the current index once this one is removed
+		delete(s.ops, node)
+		s.nodes = slices.Delete(s.nodes, s.next, s.next+1)
+	} else {
+		ops[0] = ShardReplicationOp{}
+		s.ops[node] = ops[1:]
+		s.next++
+	}
+	return op, true
+}
+
+// Snapshot implements Scheduler.
+func (s *RoundRobinScheduler) Snapshot() []ShardReplicationOp {
+	clone := &RoundRobinScheduler{ops: make(map[string][]ShardReplicationOp, len(s.ops)), nodes: slices.Clone(s.nodes), next: s.next}
+	queued := 0
+	for node, nodeOps := range s.ops {
+		clone.ops[node] = slices.Clone(nodeOps)
+		queued += len(nodeOps)
+	}
+	ops := make([]ShardReplicationOp, 0, queued)
+	for op, ok := clone.Dequeue(); ok; op, ok = clone.Dequeue() {
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// Remove implements Scheduler. The round position is kept, hence the next dequeued operation is still taken from
+// the same target node, unless it was the last queued operation of that node.
+func (s *RoundRobinScheduler) Remove(op ShardReplicationOp) bool {
+	node := op.targetShard.nodeId
+	ops, ok := removeOp(s.ops[node], op)
+	if !ok {
+		return false
+	}
+	if len(ops) > 0 {
+		s.ops[node] = ops
+		return true
+	}
+
+	delete(s.ops, node)
+	i := slices.Index(s.nodes, node)
+	s.nodes = slices.Delete(s.nodes, i, i+1)
+	if i < s.next {
+		s.next--
+	}
+	return true
+}
+
+// PriorityScheduler dispatches the queued operation with the highest ShardReplicationOp.Priority first. Operations
+// with the same priority are dispatched in the order they were queued.
+type PriorityScheduler struct {
+	// ops holds the queued operations sorted by decreasing priority, in queuing order within a priority
+	ops []ShardReplicationOp
+}
+
+// NewPriorityScheduler returns an empty PriorityScheduler.
+func NewPriorityScheduler() *PriorityScheduler {
+	return &PriorityScheduler{}
+}
+
+// Enqueue implements Scheduler.
+func (s *PriorityScheduler) Enqueue(op ShardReplicationOp) {
+	// Insert after all the operations with a greater or equal priority to keep the queuing order within a priority
+	i, _ := slices.BinarySearchFunc(s.ops, op.Priority, func(queued ShardReplicationOp, priority int) int {
+		if queued.Priority >= priority {
+			return -1
+		}
+		return 1
+	})
+	s.ops = slices.Insert(s.ops, i, op)
+}
+
+// Dequeue implements Scheduler.
+func (s *PriorityScheduler) Dequeue() (ShardReplicationOp, bool) {
+	if len(s.ops) == 0 {
+		return ShardReplicationOp{}, false
+	}
+	op := s.ops[0]
+	s.ops[0] = ShardReplicationOp{}
+	s.ops = s.ops[1:]
+	return op, true
+}
+
+// Snapshot implements Scheduler.
+func (s *PriorityScheduler) Snapshot() []ShardReplicationOp {
+	return slices.Clone(s.ops)
+}
+
+// Remove implements Scheduler.
+func (s *PriorityScheduler) Remove(op ShardReplicationOp) bool {
+	var ok bool
+	s.ops, ok = removeOp(s.ops, op)
+	return ok
+}
+
+// LocalityScheduler dispatches operations in batches of up to window operations, taken in the order they were
+// queued and reordered so that the operations copying from the same source node are dispatched one after the other,
+// rather than interleaving them. This improves cache efficiency on the source disks. Within a batch, source nodes are
+// ordered by their first queued operation. A window lower than or equal to 1 dispatches operations in the order they
+// were queued.
+type LocalityScheduler struct {
+	window int
+	// ops holds the queued operations not part of a batch yet, in queuing order
+	ops []ShardReplicationOp
+	// batch holds the remaining operations of the batch being dispatched
+	batch []ShardReplicationOp
+}
+
+// NewLocalityScheduler returns an empty LocalityScheduler grouping up to window queued operations by source node.
+func NewLocalityScheduler(window int) *LocalityScheduler {
+	return &LocalityScheduler{window: max(1, window)}
+}
+
+// Enqueue implements Scheduler.
+func (s *LocalityScheduler) Enqueue(op ShardReplicationOp) {
+	s.ops = append(s.ops, op)
+}
+
+// Dequeue implements Scheduler.
+func (s *LocalityScheduler) Dequeue() (ShardReplicationOp, bool) {
+	if len(s.batch) == 0 {
+		if len(s.ops) == 0 {
+			return ShardReplicationOp{}, false
+		}
+		n := min(s.window, len(s.ops))
+		s.batch = groupBySourceNode(s.ops[:n])
+		clear(s.ops[:n])
+		s.ops = s.ops[n:]
+	}
+	op := s.batch[0]
+	s.batch[0] = ShardReplicationOp{}
+	s.batch = s.batch[1:]
+	return op, true
+}
+
+// Snapshot implements Scheduler.
+func (s *LocalityScheduler) Snapshot() []ShardReplicationOp {
+	clone := &LocalityScheduler{window: s.window, ops: slices.Clone(s.ops), batch: slices.Clone(s.batch)}
+	ops := make([]ShardReplicationOp, 0, len(s.ops)+len(s.batch))
+	for op, ok := clone.Dequeue(); ok; op, ok = clone.Dequeue() {
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// Remove implements Scheduler.
+func (s *LocalityScheduler) Remove(op ShardReplicationOp) bool {
+	var ok bool
+	if s.batch, ok = removeOp(s.batch, op); ok {
+		return true
+	}
+	s.ops, ok = removeOp(s.ops, op)
+	return ok
+}
+
+// groupBySourceNode stably reorders the given operations so that operations sharing the same source node are
+// adjacent. Source nodes are ordered by their first appearance in the input.
+func groupBySourceNode(ops []ShardReplicationOp) []ShardReplicationOp {
+	sourceOrder := make([]string, 0, len(ops))
+	opsBySource := make(map[string][]ShardReplicationOp, len(ops))
+	for _, op := range ops {
+		source := op.sourceShard.nodeId
+		if _, ok := opsBySource[source]; !ok {
+			sourceOrder = append(sourceOrder, source)
+		}
+		opsBySource[source] = append(opsBySource[source], op)
+	}
+
+	grouped := make([]ShardReplicationOp, 0, len(ops))
+	for _, source := range sourceOrder {
+		grouped = append(grouped, opsBySource[source]...)
+	}
+	return grouped
+}
@@ -23,20 +23,33 @@ import (
 var (
 	ErrShardAlreadyReplicating = errors.New("target shard is already being replicated")
 	ErrReplicationOpNotFound   = errors.New("could not find the replication op")
+	ErrTransitionVetoed        = errors.New("replication op state transition vetoed")
 )
 
 func (s *ShardReplicationFSM) Replicate(id uint64, c *api.ReplicationReplicateShardRequest) error {
+	if err := s.replicate(id, c); err != nil {
+		return err
+	}
+	s.notifyTransition(id, "", api.REGISTERED)
+	return nil
+}
+
+func (s *ShardReplicationFSM) replicate(id uint64, c *api.ReplicationReplicateShardRequest) error {
 	s.opsLock.Lock()
 	defer s.opsLock.Unlock()
 
 	srcFQDN := newShardFQDN(c.SourceNode, c.SourceCollection, c.SourceShard)
 	targetFQDN := newShardFQDN(c.TargetNode, c.SourceCollection, c.SourceShard)
-	if _, ok := s.opsByTargetFQDN[targetFQDN]; ok {
-		return ErrShardAlreadyReplicating
+	if err := s.checkTargetNotReplicating(targetFQDN); err != nil {
+		return err
 	}
 
 	op := ShardReplicationOp{
 		ID:          id,
+		CostCenter:  c.CostCenter,
+		CampaignID:  c.CampaignID,
+		Priority:    c.Priority,
+		Deadline:    c.Deadline,
 		sourceShard: srcFQDN,
 		targetShard: targetFQDN,
 	}
@@ -44,31 +57,84 @@ func (s *ShardReplicationFSM) Replicate(id uint64, c *api.ReplicationReplicateSh
 	s.opsByShard[c.SourceShard] = append(s.opsByShard[c.SourceShard], op)
 	s.opsByCollection[c.SourceCollection] = append(s.opsByCollection[c.SourceCollection], op)
 	s.opsByTargetFQDN[targetFQDN] = op
+	s.opsBySourceFQDN[srcFQDN] = append(s.opsBySourceFQDN[srcFQDN], op)
 	s.opsById[op.ID] = op
-	s.opsStatus[op] = shardReplicationOpStatus{state: api.REGISTERED}
+	s.opsStatus[op] = shardReplicationOpStatus{state: api.REGISTERED, enteredAt: s.timeProvider.Now()}
 
 	s.opsByStateGauge.WithLabelValues(s.opsStatus[op].state.String()).Inc()
 
 	return nil
 }
 
+// checkTargetNotReplicating returns ErrShardAlreadyReplicating if an op is already replicating to the given target
+// replica. It must be called while holding the ops lock.
+func (s *ShardReplicationFSM) checkTargetNotReplicating(targetFQDN shardFQDN) error {
+	if _, ok := s.opsByTargetFQDN[targetFQDN]; ok {
+		return ErrShardAlreadyReplicating
+	}
+	return nil
+}
+
+// ValidateOp reports whether the op would be accepted if it was registered now, e.g. to pre-validate a replication
+// plan, without mutating the FSM. It runs the same validations as the registration of an op: the collection must
+// exist in the schema, the source node must hold a replica of the shard while the target node must not, and no other
+// op may be replicating the shard to the target node.
+func (s *ShardReplicationFSM) ValidateOp(op ShardReplicationOp) error {
+	if err := ValidateReplicationReplicateShard(s.schemaReader, &api.ReplicationReplicateShardRequest{
+		SourceNode:       op.sourceShard.nodeId,
+		SourceCollection: op.sourceShard.collectionId,
+		SourceShard:      op.sourceShard.shardId,
+		TargetNode:       op.targetShard.nodeId,
+	}); err != nil {
+		return err
+	}
+
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	return s.checkTargetNotReplicating(op.targetShard)
+}
+
 func (s *ShardReplicationFSM) UpdateReplicationOpStatus(c *api.ReplicationUpdateOpStateRequest) error {
+	from, err := s.updateReplicationOpStatus(c)
+	if err != nil {
+		return err
+	}
+	if from != c.State {
+		s.notifyTransition(c.Id, from, c.State)
+	}
+	return nil
+}
+
+// updateReplicationOpStatus applies the state change and returns the state the op was in before. An update keeping
+// the op in its current state, e.g. to record a batched copy checkpoint, is not a transition.
+func (s *ShardReplicationFSM) updateReplicationOpStatus(c *api.ReplicationUpdateOpStateRequest) (api.ShardReplicationState, error) {
 	s.opsLock.Lock()
 	defer s.opsLock.Unlock()
 
 	op, ok := s.opsById[c.Id]
 	if !ok {
-		return ErrReplicationOpNotFound
+		return "", ErrReplicationOpNotFound
 	}
-	s.opsByStateGauge.WithLabelValues(s.opsStatus[op].state.String()).Dec()
-	s.opsStatus[op] = shardReplicationOpStatus{state: c.State}
+	from := s.opsStatus[op].state
+	status := shardReplicationOpStatus{state: c.State, enteredAt: s.timeProvider.Now()}
+	if from == c.State {
+		// Staying in the same state, e.g. to record a batched copy checkpoint, keeps the state entry time and progress
+		status = s.opsStatus[op]
+	}
+	if c.CommittedBatches != nil {
+		status.committedBatches = *c.CommittedBatches
+	}
+	s.opsByStateGauge.WithLabelValues(from.String()).Dec()
+	s.opsStatus[op] = status
 	s.opsByStateGauge.WithLabelValues(s.opsStatus[op].state.String()).Inc()
 
-	return nil
+	return from, nil
 }
 
 func (s *ShardReplicationFSM) DeleteReplicationOp(c *api.ReplicationDeleteOpRequest) error {
-	return s.deleteShardReplicationOp(c.Id)
+	err := s.deleteShardReplicationOp(c.Id)
+	s.notifyRemoval(c.Id)
+	return err
 }
 
 // TODO: Improve the error handling in that function
@@ -76,19 +142,26 @@ func (s *ShardReplicationFSM) deleteShardReplicationOp(id uint64) error {
 	s.opsLock.Lock()
 	defer s.opsLock.Unlock()
 
-	var err error
 	op, ok := s.opsById[id]
 	if !ok {
 		return ErrReplicationOpNotFound
 	}
+	return s.deleteShardReplicationOpLocked(op)
+}
+
+// deleteShardReplicationOpLocked removes the op from every index of the FSM. It must be called while holding the ops
+// lock.
+func (s *ShardReplicationFSM) deleteShardReplicationOpLocked(op ShardReplicationOp) error {
+	var err error
 
-	ops, ok := s.opsByNode[op.sourceShard.nodeId]
+	// Ops are indexed by their target node, see replicate
+	ops, ok := s.opsByNode[op.targetShard.nodeId]
 	if !ok {
 		err = multierror.Append(err, fmt.Errorf("could not find op in ops by node, this should not happen"))
 	}
 	opsReplace, ok := findAndDeleteOp(op.ID, ops)
 	if ok {
-		s.opsByNode[op.sourceShard.nodeId] = opsReplace
+		s.opsByNode[op.targetShard.nodeId] = opsReplace
 	}
 
 	ops, ok = s.opsByCollection[op.sourceShard.collectionId]
@@ -109,6 +182,17 @@ func (s *ShardReplicationFSM) deleteShardReplicationOp(id uint64) error {
 		s.opsByShard[op.sourceShard.shardId] = opsReplace
 	}
 
+	ops, ok = s.opsBySourceFQDN[op.sourceShard]
+	if !ok {
+		err = multierror.Append(err, fmt.Errorf("could not find op in ops by source FQDN, this should not happen"))
+	}
+	opsReplace = slices.DeleteFunc(ops, func(o ShardReplicationOp) bool { return o.ID == op.ID })
+	if len(opsReplace) > 0 {
+		s.opsBySourceFQDN[op.sourceShard] = opsReplace
+	} else {
+		delete(s.opsBySourceFQDN, op.sourceShard)
+	}
+
 	s.opsByStateGauge.WithLabelValues(s.opsStatus[op].state.String()).Dec()
 
 	delete(s.opsByTargetFQDN, op.targetShard)
@@ -130,7 +214,8 @@ func findAndDeleteOp(id uint64, ops []ShardReplicationOp) ([]ShardReplicationOp,
 		}
 	}
 	if ok {
-		ops = slices.Delete(ops, indexToDelete, indexToDelete)
+		// Delete from a copy, as the slice may still be held by callers of GetOpsForNode
+		ops = slices.Delete(slices.Clone(ops), indexToDelete, indexToDelete+1)
 	}
 	return ops, ok
 }
@@ -0,0 +1,96 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// ShardAvailability counts the replicas of a shard being created by replication operations, by availability.
+type ShardAvailability struct {
+	Shard string
+	// Ready is the number of replicas whose operation completed, available for reads and writes. Replicas whose
+	// operation is DEHYDRATING the source replica after completing are counted as ready.
+	Ready int
+	// Finalizing is the number of replicas whose copy completed, available for writes only.
+	Finalizing int
+	// InProgress is the number of replicas whose operation is REGISTERED or HYDRATING, not available yet.
+	InProgress int
+}
+
+// CollectionAvailabilityReport summarizes, per shard of a collection, the availability of the replicas being created
+// by replication operations. Replicas of aborted operations and replicas not created by a replication operation
+// tracked in the FSM are not counted.
+type CollectionAvailabilityReport struct {
+	Collection string
+	// Shards are the shards of the collection with at least one counted replica, sorted by shard.
+	Shards []ShardAvailability
+}
+
+// CollectionAvailability returns the availability of the replicas created by replication operations for each shard
+// of the given collection. It only reads the FSM state.
+func (s *ShardReplicationFSM) CollectionAvailability(collection string) CollectionAvailabilityReport {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+
+	shards := make(map[string]*ShardAvailability)
+	for _, op := range s.opsByCollection[collection] {
+		state := s.opsStatus[op].state
+		if state == api.ABORTED {
+			continue
+		}
+
+		shard, ok := shards[op.targetShard.shardId]
+		if !ok {
+			shard = &ShardAvailability{Shard: op.targetShard.shardId}
+			shards[op.targetShard.shardId] = shard
+		}
+		switch state {
+		case api.READY, api.DEHYDRATING:
+			shard.Ready++
+		case api.FINALIZING:
+			shard.Finalizing++
+		default:
+			shard.InProgress++
+		}
+	}
+
+	report := CollectionAvailabilityReport{Collection: collection}
+	for _, shard := range shards {
+		report.Shards = append(report.Shards, *shard)
+	}
+	slices.SortFunc(report.Shards, func(a, b ShardAvailability) int {
+		return cmp.Compare(a.Shard, b.Shard)
+	})
+	return report
+}
+
+// isReplicaReady reports whether the replica of the shard on node is READY, that is not being created by an operation
+// which did not complete yet.
+func (s *ShardReplicationFSM) isReplicaReady(node, collection, shard string) bool {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+
+	op, ok := s.opsByTargetFQDN[newShardFQDN(node, collection, shard)]
+	if !ok {
+		return true
+	}
+	switch s.opsStatus[op].state {
+	case api.REGISTERED, api.HYDRATING, api.FINALIZING:
+		return false
+	default:
+		return true
+	}
+}
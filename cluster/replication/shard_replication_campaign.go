@@ -0,0 +1,55 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import "github.com/weaviate/weaviate/cluster/proto/api"
+
+// CampaignStatusReport aggregates the states of the replication operations of a campaign.
+type CampaignStatusReport struct {
+	CampaignID string
+	// Total is the number of operations of the campaign tracked in the FSM.
+	Total int
+	// Done is the number of operations which completed, i.e. READY or DEHYDRATING the source replica.
+	Done int
+	// Failed is the number of ABORTED operations.
+	Failed int
+	// InProgress is the number of operations REGISTERED, HYDRATING or FINALIZING.
+	InProgress int
+}
+
+// CampaignStatus returns the aggregate status of the replication operations of the given campaign, see
+// ShardReplicationOp.CampaignID. Operations deleted from the FSM, e.g. purged, are not counted. It only reads the FSM
+// state.
+func (s *ShardReplicationFSM) CampaignStatus(id string) CampaignStatusReport {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+
+	report := CampaignStatusReport{CampaignID: id}
+	if id == "" {
+		return report
+	}
+	for _, op := range s.opsById {
+		if op.CampaignID != id {
+			continue
+		}
+		report.Total++
+		switch s.opsStatus[op].state {
+		case api.READY, api.DEHYDRATING:
+			report.Done++
+		case api.ABORTED:
+			report.Failed++
+		default:
+			report.InProgress++
+		}
+	}
+	return report
+}
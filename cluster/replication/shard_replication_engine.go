@@ -19,8 +19,12 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/sirupsen/logrus"
-	enterrors "github.com/weaviate/weaviate/entities/errors"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+	"github.com/weaviate/weaviate/cluster/replication/types"
 )
 
 const (
@@ -40,6 +44,19 @@ func (p RealTimeProvider) Now() time.Time {
 	return time.Now()
 }
 
+// Timer abstracts the scheduling of delayed functions to enable testing without time dependencies.
+type Timer interface {
+	AfterFunc(duration time.Duration, fn func()) *time.Timer
+}
+
+// RealTimer implements the Timer interface using the standard time package
+type RealTimer struct{}
+
+// AfterFunc calls fn in its own goroutine after the duration elapsed
+func (t RealTimer) AfterFunc(duration time.Duration, fn func()) *time.Timer {
+	return time.AfterFunc(duration, fn)
+}
+
 // ShardReplicationEngine coordinates the replication of shard data between nodes in a distributed system.
 //
 // It uses a producer-consumer pattern where replication operations are pulled from a source (e.g., FSM)
@@ -50,7 +67,7 @@ func (p RealTimeProvider) Now() time.Time {
 // provides mechanisms for graceful shutdown and error handling. The replication engine is responsible for managing the
 // lifecycle of both producer and consumer goroutines that work together to execute replication tasks.
 //
-// Key responsibilities of this engine include managing buffered channels for backpressure, starting and stopping
+// Key responsibilities of this engine include managing a bounded op queue for backpressure, starting and stopping
 // the replication operation lifecycle, and ensuring that the engine handles concurrent workers without resource exhaustion.
 //
 // This engine is expected to run in a single node within a cluster, where it processes replication operations relevant
@@ -70,7 +87,7 @@ type ShardReplicationEngine struct {
 
 	// producer is responsible for generating replication operations that this node should execute.
 	// These operations are typically retrieved from the cluster’s FSM stored in RAFT.
-	// The producer pulls operations from the source and sends them to the opsChan for the consumer to process.
+	// The producer pulls operations from the source and sends them to the engine for the consumer to process.
 	producer OpProducer
 
 	// consumer handles the execution of replication operations by processing them with a pool of workers.
@@ -78,15 +95,19 @@ type ShardReplicationEngine struct {
 	// The consumer listens on the opsChan and processes operations as they arrive.
 	consumer OpConsumer
 
-	// opBufferSize determines the size of the buffered channel between the producer and consumer.
+	// opBufferSize determines the number of operations queued between the producer and consumer.
 	// It controls how many operations can be in-flight or waiting for processing, enabling backpressure.
-	// If the channel is full, the producer will be blocked until space is available, resulting in proparagting
+	// If the queue is full, the producer will be blocked until space is available, resulting in proparagting
 	// backpressure from the consumer up to the producer.
 	opBufferSize int
 
-	// opsChan is the buffered channel used to pass operations from the producer to the consumer.
-	// A bounded channel ensures that backpressure is applied when the consumer is overwhelmed or when
-	// a certain number of concurrent workers are already busy processing replication operations.
+	// scheduler holds the queued operations and decides the order in which they are passed to the consumer.
+	scheduler Scheduler
+
+	// opsChan is the channel used to pass the operations taken from the scheduler to the consumer.
+	// Operations are queued in the scheduler, bounded by opBufferSize, ensuring that backpressure is applied when
+	// the consumer is overwhelmed or when a certain number of concurrent workers are already busy processing
+	// replication operations.
 	opsChan chan ShardReplicationOp
 
 	// stopChan is a signal-only channel used to trigger graceful shutdown of the engine.
@@ -94,6 +115,10 @@ type ShardReplicationEngine struct {
 	// This allows for a controlled and graceful shutdown of all active components.
 	stopChan chan struct{}
 
+	// drainChan is closed by StopWithDrain to stop pulling operations from the producer while letting the consumer
+	// complete the operations it already holds.
+	drainChan chan struct{}
+
 	// isRunning is a flag that indicates whether the engine is currently running.
 	// It prevents concurrent starts (multiple instances of the replication engine running simultaneously) or stops.
 	// Ensures that the engine runs only once per each node.
@@ -104,12 +129,33 @@ type ShardReplicationEngine struct {
 	// The wait group helps ensure that the engine doesn't terminate prematurely before all goroutines have finished.
 	wg sync.WaitGroup
 
+	// goroutines is the number of goroutines currently run by the engine, see Diagnostics.
+	goroutines atomic.Int64
+
 	// cancel is a function that cancels the context associated with the replication engine's main execution loop.
 	// It is used to gracefully stop the operation of the engine by canceling the context passed to the producer
 	// and consumer goroutines. The context cancellation triggers the shutdown sequence for the engine, allowing
 	// the producer and consumer to stop gracefully.
 	cancel context.CancelFunc
 
+	// successRateWindow, minSuccessRate and throttleProbeInterval configure the producer throttling, enabled when the
+	// window is greater than zero and the consumer implements successRateTracker.
+	successRateWindow     int
+	minSuccessRate        float64
+	throttleProbeInterval time.Duration
+	successRateTracker    successRateTracker
+	producerThrottled     atomic.Bool
+
+	// errorAggregation decides which errors Start returns when both the producer and the consumer fail.
+	errorAggregation ErrorAggregation
+
+	// done is closed by Start once the engine completed its shutdown, including discarding the queued operations.
+	done chan struct{}
+
+	// lifecycleLock guards stopChan, drainChan, cancel and done, which are replaced on every start, against concurrent calls
+	// to Start and Stop.
+	lifecycleLock sync.Mutex
+
 	// maxWorkers controls the maximum number of concurrent workers in the consumer pool.
 	// It is used to limit the parallelism of replication operations, preventing the system from being overwhelmed by
 	// too many concurrent tasks performing replication operations.
@@ -119,6 +165,111 @@ type ShardReplicationEngine struct {
 	// If the engine takes longer than this timeout to shut down, a warning is logged, and the process is forcibly stopped.
 	// This ensures that the system doesn't hang indefinitely during shutdown.
 	shutdownTimeout time.Duration
+
+	// overflowPolicy defines how operations produced while the op buffer is full are handled.
+	// By default, the producer blocks until the consumer frees space in the buffer.
+	overflowPolicy OverflowPolicy
+
+	// dropSelector chooses the operation to discard while the op buffer is full with the DropSelected policy.
+	dropSelector DropSelector
+
+	// interceptors are applied in order to the produced operations before they are queued.
+	interceptors []OpInterceptor
+
+	// resourceReserver, when set, reserves the resources needed by the operations on their target node before
+	// queueing them. reservations holds the reservations of the queued and running operations.
+	resourceReserver types.ResourceReserver
+	reservations     *opReservations
+
+	// exportRequests receives the requests to take the queued operations out of the engine, see ExportPendingOps.
+	exportRequests chan chan []ShardReplicationOp
+
+	// registerer is used to register the replication engine metrics. When nil, metrics are still collected
+	// but not registered.
+	registerer prometheus.Registerer
+
+	// opsDropped counts the operations discarded by the overflow policy, labeled by policy.
+	opsDropped *prometheus.CounterVec
+
+	// fsm is the replication FSM used to track the state of submitted operations. It is optional.
+	fsm *ShardReplicationFSM
+
+	// submitLock guards the channel and context used to submit operations while the engine is running,
+	// preventing submissions on a channel being closed by the engine shutdown.
+	submitLock sync.RWMutex
+
+	// submitChan is the channel submitted operations are written to, the same the producer writes to. It is nil
+	// while the engine is not running.
+	submitChan chan<- ShardReplicationOp
+
+	// submitCtx is the context of the goroutine dispatching operations, done when the engine shuts down or drains.
+	submitCtx context.Context
+
+	// submittedOps tracks the operations enqueued with Submit until the engine is done with them, whether processed
+	// by the consumer or discarded, so that their handles stop waiting for operations unknown to the FSM.
+	submittedOps *inFlightOps
+
+	// halted is set by an emergency stop and prevents the engine from running until it is reset.
+	halted atomic.Bool
+
+	// fatalErr is the fatal error latched by the engine, preventing it from running until it is cleared.
+	fatalErr  error
+	fatalLock sync.Mutex
+
+	// maxQueuedOps caps the number of operations queued in the op buffer, when greater than zero.
+	maxQueuedOps int
+
+	// queuedOps is the number of queued operations, queuedOpsSize the estimated memory they hold.
+	queuedOps     atomic.Int64
+	queuedOpsSize atomic.Int64
+
+	// stats counts the operations since the engine was last started, see Stats.
+	stats engineStats
+
+	// queuedOpIDs counts the queued operations by ID, an operation being possibly queued more than once.
+	queuedOpIDs     map[uint64]int
+	queuedOpIDsLock sync.Mutex
+
+	// queuedOpsBytes reports the estimated memory held by the operations queued in the op buffer.
+	queuedOpsBytes prometheus.GaugeFunc
+	// throughput reports the bytes copied per second by the consumer, see CurrentThroughput.
+	throughput prometheus.GaugeFunc
+
+	// timeline records the timeline of the most recent operations. It is nil unless enabled with WithOpTimeline.
+	timeline *opTimeline
+
+	// trace writes every engine event to a trace file. It is nil unless enabled with WithEventTrace.
+	trace *opTraceWriter
+	// stream appends every engine event to a stream sink. It is nil unless enabled with WithStreamSink.
+	stream *opStreamWriter
+
+	// finalizationHealingTicks triggers the finalization healing runs, when enabled with WithFinalizationHealing.
+	finalizationHealingTicks <-chan time.Time
+
+	// producerPollInterval, when set with WithProducerPollInterval, overrides the polling interval of the producer.
+	producerPollInterval *time.Duration
+
+	// flagProvider, when set with WithReplicationFlag, pauses the engine while replication is disabled, as checked on
+	// every tick received from flagTicks.
+	flagProvider types.FlagProvider
+	flagTicks    <-chan time.Time
+	// flagPaused is set while the engine is paused by the flag provider, and flagChanged signals it changed.
+	flagPaused  atomic.Bool
+	flagChanged chan struct{}
+
+	// restartCoordinator limits the number of engines restarting at once across the cluster when running supervised.
+	restartCoordinator types.RestartCoordinator
+	// timer schedules the restarts of the engine when running supervised, and the probes of a throttled producer.
+	timer Timer
+
+	// idempotencyKeys deduplicates the operations submitted with an idempotency key, retained for
+	// idempotencyKeyRetention once their operation is terminal.
+	idempotencyKeys         idempotencyKeys
+	idempotencyKeyRetention time.Duration
+
+	// canceledChanged signals operations were canceled with CancelOpsForSourceFQDN, so that they are discarded from
+	// the queue.
+	canceledChanged chan struct{}
 }
 
 // NewShardReplicationEngine creates a new replication engine
@@ -130,100 +281,388 @@ func NewShardReplicationEngine(
 	opBufferSize int,
 	maxWorkers int,
 	shutdownTimeout time.Duration,
+	opts ...ShardReplicationEngineOption,
 ) *ShardReplicationEngine {
-	return &ShardReplicationEngine{
+	e := &ShardReplicationEngine{
 		nodeId:          nodeId,
 		logger:          logger.WithFields(logrus.Fields{"action": replicationEngineLogAction, "node": nodeId}),
 		producer:        producer,
 		consumer:        consumer,
 		opBufferSize:    opBufferSize,
+		scheduler:       NewFIFOScheduler(),
+		queuedOpIDs:     make(map[uint64]int),
+		submittedOps:    newInFlightOps(),
 		maxWorkers:      maxWorkers,
 		shutdownTimeout: shutdownTimeout,
 		stopChan:        make(chan struct{}),
+		exportRequests:  make(chan chan []ShardReplicationOp),
+		flagChanged:     make(chan struct{}, 1),
+		canceledChanged: make(chan struct{}, 1),
+		reservations:    newOpReservations(),
+		timer:           RealTimer{},
+
+		idempotencyKeyRetention: defaultIdempotencyKeyRetention,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if e.producerPollInterval != nil {
+		if configurer, ok := e.producer.(pollIntervalConfigurer); ok {
+			configurer.setPollInterval(*e.producerPollInterval)
+		} else {
+			e.logger.WithFields(logrus.Fields{"engine": e}).Warn("replication engine producer does not poll for operations, ignoring the producer poll interval")
+		}
+	}
+	if tracker, ok := e.consumer.(successRateTracker); ok && e.successRateWindow > 0 {
+		tracker.trackSuccessRate(e.successRateWindow, func() { e.updateProducerThrottling() })
+		e.successRateTracker = tracker
+	}
+	if observer, ok := e.consumer.(queueDepthObserver); ok {
+		observer.observeQueueDepth(e.OpChannelLen)
+	}
+	if observer, ok := e.consumer.(opEndObserver); ok {
+		observer.observeOpEnd(e.endOp)
+	}
+	e.holdPausedOps()
+	if checker, ok := e.consumer.(transitionChecker); ok && e.fsm != nil {
+		checker.checkTransitionsWith(e.fsm.CheckTransition)
+	}
+	if observer, ok := e.consumer.(opOutcomeObserver); ok {
+		observer.observeOpOutcome(e.stats.recordOutcome)
+	}
+	if acknowledger, ok := e.producer.(OpAcknowledger); ok {
+		if observer, ok := e.consumer.(opSuccessObserver); ok {
+			observer.observeOpSuccess(acknowledger.Ack)
+		} else {
+			e.logger.WithFields(logrus.Fields{"engine": e}).Warn("replication engine consumer does not report successes, produced operations are never acknowledged")
+		}
+	}
+	if e.trace != nil {
+		e.trace.logger = e.logger
+		if e.timeline == nil {
+			// Events are recorded by the timeline, which only traces them unless enabled with WithOpTimeline
+			e.timeline = newOpTimeline(0, RealTimeProvider{})
+		}
+		e.timeline.trace = e.trace
+	}
+	if e.stream != nil {
+		e.stream.logger = e.logger
+		e.stream.now = e.now
+		if e.fsm != nil {
+			e.stream.resolveKey = e.fsm.opStreamKey
+		}
+		if e.timeline == nil {
+			// Events are recorded by the timeline, which only streams them unless enabled with WithOpTimeline
+			e.timeline = newOpTimeline(0, RealTimeProvider{})
+		}
+		e.timeline.stream = e.stream
+	}
+	if e.timeline != nil {
+		if recorder, ok := e.consumer.(timelineRecorder); ok {
+			recorder.recordTimelineTo(e.timeline)
+		}
+		if e.fsm != nil {
+			e.fsm.OnTransition(func(id uint64, _, to api.ShardReplicationState) {
+				e.timeline.record(id, TimelineStateChanged, to, "")
+			})
+		}
 	}
+
+	e.opsDropped = promauto.With(e.registerer).NewCounterVec(prometheus.CounterOpts{
+		Namespace: "weaviate",
+		Name:      "replication_ops_dropped_total",
+		Help:      "Number of replication operations discarded by the replication engine overflow policy",
+	}, []string{"policy"})
+	e.queuedOpsBytes = promauto.With(e.registerer).NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "weaviate",
+		Name:      "replication_engine_queued_ops_bytes",
+		Help:      "Estimated memory held by the replication operations queued in the replication engine op buffer",
+	}, func() float64 {
+		return float64(e.queuedOpsSize.Load())
+	})
+	e.throughput = promauto.With(e.registerer).NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "weaviate",
+		Name:      "replication_throughput_bytes_per_second",
+		Help:      "Number of bytes copied per second by the replication engine consumer over its recent throughput window",
+	}, e.CurrentThroughput)
+
+	return e
 }
 
 // Start runs the replication engine's main loop, including the operation producer and consumer.
 //
 // It starts two goroutines: one for the OpProducer and one for the OpConsumer. These goroutines
-// communicate through a buffered channel, and the engine coordinates their lifecycle. This method
+// communicate through a bounded queue ordered by the scheduler, and the engine coordinates their lifecycle. This method
 // is safe to call only once; if the engine is already running, it logs a warning and returns.
 //
-// It returns an error if either the producer or consumer fails unexpectedly, or if the context is cancelled.
+// It returns an error if either the producer or consumer fails unexpectedly, or if the context is cancelled. A
+// producer closing the channel it writes operations to while the engine is running fails with an error wrapping
+// ErrOpChannelClosed, rather than being treated as a clean shutdown.
+//
+// A producer returning nil while the engine is running finished emitting operations. The engine then keeps handing
+// the queued operations to the consumer, closes the channel the consumer reads from once the queue is drained, and
+// returns nil once the consumer returns nil, as it is expected to do once its channel is closed.
+// It returns ErrReplicationEngineHalted without starting if the engine is halted by an emergency stop.
+//
+// A failure wrapping ErrFatal is latched: Start then returns an error wrapping it without starting until ClearFatal is
+// called.
 //
 // It is, safe to restart the replication engin using this method, after it has been stopped.
 func (e *ShardReplicationEngine) Start(ctx context.Context) error {
+	return e.start(ctx, func() {})
+}
+
+// start implements Start, calling started once the producer and the consumer are running, or as soon as the engine
+// does not start.
+func (e *ShardReplicationEngine) start(ctx context.Context, started func()) error {
+	if e.halted.Load() {
+		started()
+		e.logger.WithField("engine", e).Warn("replication engine halted by an emergency stop, not starting")
+		return ErrReplicationEngineHalted
+	}
+	if err := e.latchedFatal(); err != nil {
+		started()
+		e.logger.WithField("engine", e).WithError(err).Warn("replication engine fatal error latched, not starting")
+		return err
+	}
+	e.lifecycleLock.Lock()
 	if !e.isRunning.CompareAndSwap(false, true) {
+		e.lifecycleLock.Unlock()
+		started()
 		e.logger.Warnf("replication engine already running: %v", e)
 		return nil
 	}
+	e.stats.reset()
 
-	// Channels are creating while starting the replication engine to allow start/stop.
-	e.opsChan = make(chan ShardReplicationOp, e.opBufferSize)
-	e.stopChan = make(chan struct{})
+	// Channels are creating while starting the replication engine to allow start/stop. They are captured locally
+	// as the fields are replaced when the engine is restarted.
+	opsChan := make(chan ShardReplicationOp)
+	stopChan := make(chan struct{})
+	drainChan := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
 
 	engineCtx, engineCancel := context.WithCancel(ctx)
+	e.opsChan = opsChan
+	e.stopChan = stopChan
+	e.drainChan = drainChan
 	e.cancel = engineCancel
+	e.done = done
+	e.lifecycleLock.Unlock()
+
 	e.logger.WithFields(logrus.Fields{"engine": e}).Info("starting replication engine")
 
 	// Channels for error reporting used by producer and consumer.
 	producerErrChan := make(chan error, 1)
 	consumerErrChan := make(chan error, 1)
 
+	// The producer writes to an intake channel and operations are queued in the scheduler applying the overflow
+	// policy and the queued operations cap, then dispatched to the consumer through the ops channel.
+	producerChan := make(chan ShardReplicationOp)
+	dispatchErrChan := make(chan error, 1)
+	producerDone := make(chan struct{})
+	drained := make(chan struct{})
+	// The producer and the dispatching of operations are stopped on their own when draining, while the consumer
+	// completes the operations it holds.
+	producerCtx, producerCancel := context.WithCancel(engineCtx)
+	defer producerCancel()
+	dispatchCtx, dispatchCancel := context.WithCancel(engineCtx)
+	defer dispatchCancel()
+	dispatchDone := make(chan struct{})
+	e.goTracked(func() {
+		defer close(dispatchDone)
+		err := e.dispatchOps(dispatchCtx, producerChan, opsChan, producerDone)
+		switch {
+		case errors.Is(err, errOpsDrained):
+			close(drained)
+		case err != nil:
+			e.logger.WithField("producer", e.producer).WithError(err).Error("producer closed the operation channel while the engine is running")
+			dispatchErrChan <- err
+		}
+	})
+
+	e.submitLock.Lock()
+	e.submitChan = producerChan
+	e.submitCtx = dispatchCtx
+	e.submitLock.Unlock()
+
+	if e.finalizationHealingTicks != nil {
+		e.goTracked(func() {
+			e.healFinalizations(engineCtx, e.finalizationHealingTicks)
+		})
+	}
+	if e.flagProvider != nil {
+		e.goTracked(func() {
+			e.watchReplicationFlag(engineCtx, e.flagTicks)
+		})
+	}
+	if e.stream != nil {
+		e.goTracked(func() {
+			e.stream.run(engineCtx)
+		})
+	}
+
 	// Start one replication operations producer.
-	e.wg.Add(1)
-	enterrors.GoWrapper(func() {
-		defer e.wg.Done()
+	e.goTracked(func() {
 		e.logger.WithField("producer", e.producer).Info("starting replication engine producer")
-		err := e.producer.Produce(engineCtx, e.opsChan)
+		err := e.producer.Produce(producerCtx, producerChan)
+		if err == nil && producerCtx.Err() == nil {
+			e.logger.WithField("producer", e.producer).Info("producer finished emitting operations, draining queued operations")
+			close(producerDone)
+		}
 		if err != nil && !errors.Is(err, context.Canceled) {
 			e.logger.WithField("producer", e.producer).WithError(err).Error("stopping producer after failure")
 			producerErrChan <- err
 		}
 		e.logger.WithField("producer", e.producer).Info("replication engine producer stopped")
-	}, e.logger)
+	})
 
 	// Start one replication operations consumer.
-	e.wg.Add(1)
-	enterrors.GoWrapper(func() {
-		defer e.wg.Done()
+	consumerDone := make(chan struct{})
+	e.goTracked(func() {
+		defer close(consumerDone)
 		e.logger.WithField("consumer", e.consumer).Info("starting replication engine consumer")
-		err := e.consumer.Consume(engineCtx, e.opsChan)
+		err := e.consumer.Consume(engineCtx, opsChan)
 		if err != nil && !errors.Is(err, context.Canceled) {
 			e.logger.WithField("consumer", e.consumer).WithError(err).Error("stopping consumer after failure")
 			consumerErrChan <- err
 		}
 		e.logger.WithField("consumer", e.consumer).Info("replication engine consumer stopped")
-	}, e.logger)
+	})
+
+	started()
 
 	// Coordinate replication engine execution with producer and consumer lifecycle.
 	var err error
-	select {
-	case <-ctx.Done():
-		e.logger.WithField("engine", e).Info("replication engine cancel request, shutting down")
-		err = ctx.Err()
-	case <-e.stopChan:
-		e.logger.WithField("engine", e).Info("replication engine stop request, shutting down")
-		// Graceful shutdown executed when stopping the replication engine
-	case producerErr := <-producerErrChan:
-		if !errors.Is(producerErr, context.Canceled) {
-			e.logger.WithField("engine", e).WithError(producerErr).Error("stopping replication engine producer after failure")
-			err = fmt.Errorf("replication engine producer failed with: %w", producerErr)
+	opsChanClosed := false
+	// consumerFinished is only watched once the consumer channel is closed, as the consumer is not expected to
+	// return before
+	var consumerFinished <-chan struct{}
+	for running := true; running; {
+		select {
+		case <-ctx.Done():
+			e.logger.WithField("engine", e).Info("replication engine cancel request, shutting down")
+			err = ctx.Err()
+			running = false
+		case <-stopChan:
+			e.logger.WithField("engine", e).Info("replication engine stop request, shutting down")
+			// Graceful shutdown executed when stopping the replication engine
+			running = false
+		case producerErr := <-producerErrChan:
+			if !errors.Is(producerErr, context.Canceled) {
+				e.logger.WithField("engine", e).WithError(producerErr).Error("stopping replication engine producer after failure")
+				err = producerFailure(producerErr)
+			}
+			running = false
+		case dispatchErr := <-dispatchErrChan:
+			// The producer closing its channel is a producer failure rather than a clean shutdown
+			e.logger.WithField("engine", e).WithError(dispatchErr).Error("stopping replication engine after the operation channel was closed")
+			err = producerFailure(dispatchErr)
+			running = false
+		case consumerErr := <-consumerErrChan:
+			e.logger.WithField("engine", e).WithError(consumerErr).Error("stopping replication engine consumer after failure")
+			err = consumerFailure(consumerErr)
+			running = false
+		case <-drainChan:
+			producerCancel()
+			dispatchCancel()
+			// The ops channel is only closed once no more operation is dispatched to it
+			<-dispatchDone
+			e.submitLock.Lock()
+			e.submitChan = nil
+			e.submitLock.Unlock()
+			e.logger.WithField("engine", e).Info("replication engine drain request, completing the operations held by the consumer")
+			if !opsChanClosed {
+				// Already closed if the queue was drained after the producer finished
+				close(opsChan)
+				opsChanClosed = true
+			}
+			drainChan = nil
+			drained = nil
+			consumerFinished = consumerDone
+		case <-drained:
+			e.logger.WithField("engine", e).Info("replication engine queue drained after the producer finished, waiting for the consumer")
+			close(opsChan)
+			opsChanClosed = true
+			drained = nil
+			consumerFinished = consumerDone
+		case <-consumerFinished:
+			// A consumer failure is sent before the consumer is done
+			select {
+			case consumerErr := <-consumerErrChan:
+				e.logger.WithField("engine", e).WithError(consumerErr).Error("stopping replication engine consumer after failure")
+				err = consumerFailure(consumerErr)
+			default:
+				e.logger.WithField("engine", e).Info("replication engine consumer finished, shutting down")
+			}
+			running = false
 		}
-	case consumerErr := <-consumerErrChan:
-		e.logger.WithField("engine", e).WithError(consumerErr).Error("stopping replication engine consumer after failure")
-		err = fmt.Errorf("replication engine consumer failed with: %w", consumerErr)
 	}
 
 	// Always cancel the replication engine context and wait for the producer and consumers to terminate to gracefully
 	// shut down the replication engine the both the producer and consumer.
 	engineCancel()
 	e.wg.Wait()
-	close(e.opsChan)
+	if err != nil && e.errorAggregation == JoinedErrors {
+		// The failure of the other component, if any, has been reported by now as both terminated
+		err = joinRemainingFailures(err, producerErrChan, consumerErrChan)
+	}
+	if discarded := e.discardQueuedOps(); discarded > 0 {
+		e.logger.WithFields(logrus.Fields{"engine": e, "discarded_ops": discarded}).Info("discarded queued replication operations on shutdown")
+	}
+	e.submitLock.Lock()
+	e.submitChan = nil
+	e.submitCtx = nil
+	if !opsChanClosed {
+		close(opsChan)
+	}
+	e.submitLock.Unlock()
+	e.latchFatal(err)
 	e.isRunning.Store(false)
 	return err
 }
 
+// producerFailure and consumerFailure wrap the error a producer or a consumer failed with, as returned by Start.
+func producerFailure(err error) error {
+	return fmt.Errorf("replication engine producer failed with: %w", err)
+}
+
+func consumerFailure(err error) error {
+	return fmt.Errorf("replication engine consumer failed with: %w", err)
+}
+
+// joinRemainingFailures joins the given error with the producer and consumer failures not yet received from their
+// error channels.
+func joinRemainingFailures(err error, producerErrChan, consumerErrChan <-chan error) error {
+	errs := []error{err}
+	select {
+	case producerErr := <-producerErrChan:
+		errs = append(errs, producerFailure(producerErr))
+	default:
+	}
+	select {
+	case consumerErr := <-consumerErrChan:
+		errs = append(errs, consumerFailure(consumerErr))
+	default:
+	}
+	return errors.Join(errs...)
+}
+
+// dropOp records an operation discarded by the overflow policy.
+//
+// Dropped operations are not removed from the FSM, which means a producer reading from the FSM will emit them again
+// once there is capacity in the op buffer.
+func (e *ShardReplicationEngine) dropOp(op ShardReplicationOp) {
+	e.endOp(op.ID)
+	e.opsDropped.WithLabelValues(e.overflowPolicy.String()).Inc()
+	e.logger.WithFields(logrus.Fields{
+		"engine": e,
+		"op":     op.ID,
+		"policy": e.overflowPolicy,
+	}).Warn("replication engine op buffer full, dropping replication operation")
+}
+
 // Stop signals the replication engine to shut down gracefully.
 //
 // It safely transitions the engine's running state to false and closes the internal stop channel,
@@ -232,34 +671,34 @@ func (e *ShardReplicationEngine) Start(ctx context.Context) error {
 // Note that the ops channel is closed in the Start method after waiting for both the producer and consumers to
 // terminate.
 func (e *ShardReplicationEngine) Stop() {
-	if !e.isRunning.Load() {
+	e.lifecycleLock.Lock()
+	if !e.isRunning.Load() || e.stopChan == nil {
+		e.lifecycleLock.Unlock()
 		return
 	}
+	stopChan, cancel, done := e.stopChan, e.cancel, e.done
+	// Clearing the stop channel ensures only the first of concurrent Stop calls closes it
+	e.stopChan = nil
+	e.lifecycleLock.Unlock()
 
 	// Closing the stop channel notifies both the producer and consumer to shut down gracefully coordinating with the
 	// replication engine.
-	close(e.stopChan)
-	e.cancel()
+	close(stopChan)
+	cancel()
 
 	// We use a timeout mechanism to wait for the replication engine to shut down and prevent it from running
-	// indefinitely.
+	// indefinitely. Waiting for Start to complete its shutdown, rather than only for the producer and consumer to
+	// terminate, ensures a restart does not overlap with the previous run.
 	timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), e.shutdownTimeout)
 	defer timeoutCancel()
 
-	done := make(chan struct{})
-	enterrors.GoWrapper(func() {
-		e.wg.Wait()
-		close(done)
-	}, e.logger)
-
 	select {
 	case <-done:
 		e.logger.WithField("engine", e).Info("replication engine shutdown completed successfully")
 	case <-timeoutCtx.Done():
 		e.logger.WithField("engine", e).WithField("timeout", e.shutdownTimeout).Warn("replication engine shutdown timed out")
+		e.isRunning.Store(false)
 	}
-
-	e.isRunning.Store(false)
 }
 
 // IsRunning reports whether the replication engine is currently running.
@@ -269,19 +708,28 @@ func (e *ShardReplicationEngine) IsRunning() bool {
 	return e.isRunning.Load()
 }
 
-// OpChannelCap returns the capacity of the internal operation channel.
+// OpChannelCap returns the number of operations the engine queues between the producer and the consumer.
 //
 // This reflects the total number of replication operations the channel can queue
 // before blocking the producer implementing a backpressure mechanism.
 func (e *ShardReplicationEngine) OpChannelCap() int {
-	return cap(e.opsChan)
+	return e.opBufferSize
+}
+
+// ProducerPollInterval returns the interval at which the producer polls for new operations, see
+// WithProducerPollInterval. It returns 0 if the producer does not poll for operations.
+func (e *ShardReplicationEngine) ProducerPollInterval() time.Duration {
+	if configurer, ok := e.producer.(pollIntervalConfigurer); ok {
+		return configurer.pollInterval()
+	}
+	return 0
 }
 
-// OpChannelLen returns the current number of operations buffered in the internal channel.
+// OpChannelLen returns the current number of operations queued between the producer and the consumer.
 //
 // This can be used to monitor the backpressure between the producer and the consumer.
 func (e *ShardReplicationEngine) OpChannelLen() int {
-	return len(e.opsChan)
+	return int(e.queuedOps.Load())
 }
 
 // String returns a string representation of the ShardReplicationEngine,
@@ -0,0 +1,35 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import "github.com/sirupsen/logrus"
+
+// opBlockReasoner is implemented by consumers able to explain why an operation is not progressing.
+type opBlockReasoner interface {
+	OpBlockReason(id uint64) (string, bool)
+}
+
+// OpBlockReason returns a human-readable reason for which the replication operation with the given ID is not
+// progressing, either because it is still queued in the engine or because the engine consumer is holding it back,
+// see CopyOpConsumer.OpBlockReason. It reports false if the operation is progressing, unknown, or if the engine
+// consumer does not report why operations are blocked.
+func (e *ShardReplicationEngine) OpBlockReason(id uint64) (string, bool) {
+	if e.isQueued(id) {
+		return opBlockedQueued, true
+	}
+	reasoner, ok := e.consumer.(opBlockReasoner)
+	if !ok {
+		e.logger.WithFields(logrus.Fields{"engine": e, "op": id}).Debug("replication engine consumer does not report why operations are blocked")
+		return "", false
+	}
+	return reasoner.OpBlockReason(id)
+}
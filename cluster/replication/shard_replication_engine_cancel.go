@@ -0,0 +1,109 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// opAborter is implemented by consumers able to abort replication operations through the FSM.
+type opAborter interface {
+	// abortOp marks the operation ABORTED unless the consumer already completed it.
+	abortOp(ctx context.Context, op ShardReplicationOp) error
+}
+
+func (c *CopyOpConsumer) abortOp(ctx context.Context, op ShardReplicationOp) error {
+	return c.markOpTerminal(ctx, op, api.ABORTED)
+}
+
+// isOpAborted reports whether the operation is ABORTED according to the replication FSM, if any.
+func (e *ShardReplicationEngine) isOpAborted(id uint64) bool {
+	if e.fsm == nil {
+		return false
+	}
+	state, ok := e.fsm.getOpStateByID(id)
+	return ok && state == api.ABORTED
+}
+
+// nextDispatchableOp returns the operation to hand to the consumer next, the given held operation if any, or the
+// next one taken from the scheduler otherwise. ABORTED operations, e.g. canceled with CancelOpsForSourceFQDN, are
+// discarded on the way. It must only be called while dispatching operations.
+func (e *ShardReplicationEngine) nextDispatchableOp(next ShardReplicationOp, hasNext bool) (ShardReplicationOp, bool) {
+	for {
+		if !hasNext {
+			next, hasNext = e.scheduler.Dequeue()
+		}
+		if !hasNext || !e.isOpAborted(next.ID) {
+			return next, hasNext
+		}
+		e.logger.WithFields(logrus.Fields{"engine": e, "op": next.ID}).Debug("discarding aborted replication operation")
+		e.trackQueued(next, -1)
+		e.endOp(next.ID)
+		hasNext = false
+	}
+}
+
+// CancelOpsForSourceFQDN cancels the unfinished replication operations copying the given source replica to the node
+// of the engine, e.g. once it is discovered corrupt, and returns how many were canceled. Operations copying it to
+// other nodes are canceled by the engines of these nodes. It requires the replication FSM set with
+// WithReplicationFSM, which lists the operations of the source replica, and a consumer able to abort operations,
+// such as CopyOpConsumer, and otherwise cancels nothing.
+//
+// Canceled operations are marked ABORTED through the FSM, hence the cancellation is durable and the producer no
+// longer emits them. Those queued in the engine are discarded instead of being handed to the consumer, and those
+// already handed to the consumer are paused, see CopyOpConsumer.PauseOp, so that they are not retried once their
+// current attempt ends. Operations of other source replicas, including other shards of the same node, are not
+// affected.
+func (e *ShardReplicationEngine) CancelOpsForSourceFQDN(node, collection, shard string) int {
+	logger := e.logger.WithFields(logrus.Fields{"engine": e, "source_node": node, "collection": collection, "shard": shard})
+	if e.fsm == nil {
+		logger.Warn("replication engine has no replication FSM, no replication operation to cancel")
+		return 0
+	}
+	aborter, ok := e.consumer.(opAborter)
+	if !ok {
+		logger.Warn("replication consumer cannot abort replication operations, no replication operation canceled")
+		return 0
+	}
+
+	pauser, _ := e.consumer.(opPauser)
+	canceled := 0
+	for _, op := range e.fsm.GetOpsForSourceFQDN(node, collection, shard) {
+		if op.targetShard.nodeId != e.nodeId {
+			continue
+		}
+		state, ok := e.fsm.getOpStateByID(op.ID)
+		if !ok || state == api.READY || state == api.ABORTED {
+			continue
+		}
+		if err := aborter.abortOp(context.Background(), op); err != nil {
+			logger.WithField("op", op.ID).WithError(err).Warn("failed to abort replication operation")
+			continue
+		}
+		if pauser != nil {
+			pauser.PauseOp(op.ID)
+		}
+		canceled++
+	}
+
+	select {
+	case e.canceledChanged <- struct{}{}:
+	default:
+		// A signal is already pending and will also discard these operations
+	}
+	logger.WithField("canceled_ops", canceled).Info("canceled replication operations of the source replica")
+	return canceled
+}
@@ -0,0 +1,105 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/weaviate/weaviate/cluster/replication/types"
+)
+
+// ErrReplicaComparisonUnsupported is returned when comparing replicas using a replica copier without any verification
+// primitive.
+var ErrReplicaComparisonUnsupported = errors.New("replica copier cannot compare replicas")
+
+// ConsistencyReport is the outcome of the comparison of two replicas of a shard.
+type ConsistencyReport struct {
+	NodeA      string
+	NodeB      string
+	Collection string
+	Shard      string
+
+	// ObjectsCompared reports whether the object counts were compared, ObjectCountA and ObjectCountB being the number
+	// of objects held by the replicas on NodeA and NodeB respectively.
+	ObjectsCompared bool
+	ObjectCountA    int64
+	ObjectCountB    int64
+
+	// ChecksumsCompared reports whether the checksums were compared, ChecksumA and ChecksumB being the checksums of
+	// the replicas on NodeA and NodeB respectively.
+	ChecksumsCompared bool
+	ChecksumA         string
+	ChecksumB         string
+
+	// Consistent reports whether every compared property of the two replicas matches.
+	Consistent bool
+}
+
+// replicaComparer is implemented by consumers able to compare replicas using their replica copier.
+type replicaComparer interface {
+	compareReplicas(ctx context.Context, nodeA, nodeB, collection, shard string) (ConsistencyReport, error)
+}
+
+// compareReplicas compares the object counts of the replicas if the replica copier implements
+// types.ObjectCountingReplicaCopier, and their checksums if it implements types.ChecksummingReplicaCopier.
+func (c *CopyOpConsumer) compareReplicas(ctx context.Context, nodeA, nodeB, collection, shard string) (ConsistencyReport, error) {
+	report := ConsistencyReport{NodeA: nodeA, NodeB: nodeB, Collection: collection, Shard: shard, Consistent: true}
+
+	counter, canCount := c.replicaCopier.(types.ObjectCountingReplicaCopier)
+	checksummer, canChecksum := c.replicaCopier.(types.ChecksummingReplicaCopier)
+	if !canCount && !canChecksum {
+		return report, ErrReplicaComparisonUnsupported
+	}
+
+	if canCount {
+		var err error
+		if report.ObjectCountA, err = counter.CountObjects(ctx, nodeA, collection, shard); err != nil {
+			return report, fmt.Errorf("counting objects on node %s: %w", nodeA, err)
+		}
+		if report.ObjectCountB, err = counter.CountObjects(ctx, nodeB, collection, shard); err != nil {
+			return report, fmt.Errorf("counting objects on node %s: %w", nodeB, err)
+		}
+		report.ObjectsCompared = true
+		report.Consistent = report.ObjectCountA == report.ObjectCountB
+	}
+
+	if canChecksum {
+		var err error
+		if report.ChecksumA, err = checksummer.ReplicaChecksum(ctx, nodeA, collection, shard); err != nil {
+			return report, fmt.Errorf("computing checksum on node %s: %w", nodeA, err)
+		}
+		if report.ChecksumB, err = checksummer.ReplicaChecksum(ctx, nodeB, collection, shard); err != nil {
+			return report, fmt.Errorf("computing checksum on node %s: %w", nodeB, err)
+		}
+		report.ChecksumsCompared = true
+		report.Consistent = report.Consistent && report.ChecksumA == report.ChecksumB
+	}
+
+	return report, nil
+}
+
+// CompareReplicas reports whether the replicas of a shard held by nodeA and nodeB are consistent, using the
+// verification primitives of the consumer replica copier: object counts and checksums. It does not require a
+// replication operation between the two nodes and can be used to audit any pair of replicas.
+//
+// It returns an error wrapping ErrReplicaComparisonUnsupported if the consumer or its replica copier cannot compare
+// replicas.
+func (e *ShardReplicationEngine) CompareReplicas(ctx context.Context, nodeA, nodeB, collection, shard string) (ConsistencyReport, error) {
+	comparer, ok := e.consumer.(replicaComparer)
+	if !ok {
+		return ConsistencyReport{NodeA: nodeA, NodeB: nodeB, Collection: collection, Shard: shard},
+			fmt.Errorf("%w: consumer %s", ErrReplicaComparisonUnsupported, e.consumer)
+	}
+	return comparer.compareReplicas(ctx, nodeA, nodeB, collection, shard)
+}
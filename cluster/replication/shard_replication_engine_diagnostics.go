@@ -0,0 +1,62 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import enterrors "github.com/weaviate/weaviate/entities/errors"
+
+// Diagnostics is a snapshot of the resources held by the replication engine, to be compared over time, e.g. across
+// many start and stop cycles, to detect leaks.
+type Diagnostics struct {
+	// Goroutines is the number of goroutines run by the engine and by its consumer to process operations.
+	Goroutines int
+	// TokensInUse is the number of worker tokens held by operations being processed by the consumer.
+	TokensInUse int
+	// InFlightOps is the number of operations currently held by a consumer worker.
+	InFlightOps int
+}
+
+// diagnosticsReporter is implemented by consumers able to report the resources they hold.
+type diagnosticsReporter interface {
+	diagnostics() (goroutines, tokensInUse int)
+}
+
+func (c *CopyOpConsumer) diagnostics() (int, int) {
+	// Tokens reserved to lower the worker limit are not held by operations
+	return int(c.goroutines.Load()), len(c.tokens) - int(c.reservedTokens.Load())
+}
+
+// Diagnostics returns a snapshot of the resources currently held by the engine and its consumer. Once the engine is
+// stopped, every resource is expected to be released, the consumer resources being only reported by consumers able
+// to, as CopyOpConsumer is.
+func (e *ShardReplicationEngine) Diagnostics() Diagnostics {
+	diagnostics := Diagnostics{
+		Goroutines:  int(e.goroutines.Load()),
+		InFlightOps: len(e.InFlightOps()),
+	}
+	if reporter, ok := e.consumer.(diagnosticsReporter); ok {
+		goroutines, tokensInUse := reporter.diagnostics()
+		diagnostics.Goroutines += goroutines
+		diagnostics.TokensInUse = tokensInUse
+	}
+	return diagnostics
+}
+
+// goTracked runs fn in a new goroutine accounted for in the engine goroutines and waited for on shutdown.
+func (e *ShardReplicationEngine) goTracked(fn func()) {
+	e.wg.Add(1)
+	e.goroutines.Add(1)
+	enterrors.GoWrapper(func() {
+		defer e.wg.Done()
+		defer e.goroutines.Add(-1)
+		fn()
+	}, e.logger)
+}
@@ -0,0 +1,88 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"sync"
+	"time"
+)
+
+// throughputWindow is the number of most recent operation completions used to measure the consumer throughput.
+const throughputWindow = 32
+
+// opCompletions keeps the times of the most recent operation completions.
+type opCompletions struct {
+	mu    sync.Mutex
+	times []time.Time
+}
+
+func (o *opCompletions) record(at time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.times) == throughputWindow {
+		o.times = o.times[1:]
+	}
+	o.times = append(o.times, at)
+}
+
+// throughput returns the number of operations completed per second since the oldest recorded completion until now,
+// and false if fewer than two completions were recorded.
+func (o *opCompletions) throughput(now time.Time) (float64, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.times) < 2 {
+		return 0, false
+	}
+	elapsed := now.Sub(o.times[0])
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return float64(len(o.times)-1) / elapsed.Seconds(), true
+}
+
+// throughputReporter is implemented by consumers measuring their recent throughput.
+type throughputReporter interface {
+	// recentThroughput returns the number of operations completed per second recently, and false without enough
+	// history.
+	recentThroughput() (float64, bool)
+	// pendingOps returns the number of operations received and not completed yet.
+	pendingOps() int
+}
+
+func (c *CopyOpConsumer) recentThroughput() (float64, bool) {
+	return c.completions.throughput(c.timeProvider.Now())
+}
+
+func (c *CopyOpConsumer) pendingOps() int {
+	return int(c.pending.Load())
+}
+
+// EstimatedDrainTime estimates how long the engine needs to process its current backlog, made of the operations
+// queued in the engine and the ones received by the consumer but not completed yet, given the throughput of the
+// consumer over its recent operations. It returns false if the consumer does not measure its throughput or has not
+// completed enough operations yet, unless the backlog is empty.
+func (e *ShardReplicationEngine) EstimatedDrainTime() (time.Duration, bool) {
+	reporter, ok := e.consumer.(throughputReporter)
+	if !ok {
+		return 0, false
+	}
+
+	backlog := e.OpChannelLen() + reporter.pendingOps()
+	if backlog == 0 {
+		return 0, true
+	}
+	throughput, ok := reporter.recentThroughput()
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(float64(backlog) / throughput * float64(time.Second)), true
+}
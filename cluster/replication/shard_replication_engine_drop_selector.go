@@ -0,0 +1,68 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+// DropSelector chooses which operation to discard while the op buffer is full, see WithDropSelector. It is given the
+// buffered operations, in the order they would be handed to the consumer, followed by the newly produced operation,
+// and returns the index of the one to discard. An index out of range discards the newly produced operation.
+//
+// It is called by the goroutine dispatching operations, hence it must not block, and it must not modify the given
+// operations.
+type DropSelector func(ops []ShardReplicationOp) int
+
+// dequeueAll empties the scheduler, appending its operations to the given ones in the order they were dequeued. It
+// does not account for the operations leaving the queue and must only be called while dispatching operations.
+func (e *ShardReplicationEngine) dequeueAll(ops []ShardReplicationOp) []ShardReplicationOp {
+	for {
+		op, ok := e.scheduler.Dequeue()
+		if !ok {
+			return ops
+		}
+		ops = append(ops, op)
+	}
+}
+
+// dropSelectedOp discards the operation chosen by the drop selector among the given held operation, if any, the
+// operations held by the scheduler and the newly produced operation, not yet accounted for as queued, which is queued
+// unless discarded. The other operations stay in the scheduler in their order. It returns the held operation left, if
+// any, and must only be called while dispatching operations.
+func (e *ShardReplicationEngine) dropSelectedOp(next ShardReplicationOp, hasNext bool, op ShardReplicationOp) (ShardReplicationOp, bool) {
+	var candidates []ShardReplicationOp
+	if hasNext {
+		candidates = append(candidates, next)
+	}
+	candidates = append(candidates, e.scheduler.Snapshot()...)
+	candidates = append(candidates, op)
+
+	newest := len(candidates) - 1
+	dropped := newest
+	if e.dropSelector != nil {
+		if i := e.dropSelector(candidates); i >= 0 && i < len(candidates) {
+			dropped = i
+		}
+	}
+
+	switch {
+	case dropped == newest:
+		e.dropOp(op)
+		return next, hasNext
+	case hasNext && dropped == 0:
+		next, hasNext = ShardReplicationOp{}, false
+	default:
+		e.scheduler.Remove(candidates[dropped])
+	}
+	e.trackQueued(candidates[dropped], -1)
+	e.dropOp(candidates[dropped])
+	e.scheduler.Enqueue(op)
+	e.trackQueued(op, 1)
+	return next, hasNext
+}
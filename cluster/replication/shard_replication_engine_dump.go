@@ -0,0 +1,138 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// dumpWriter writes formatted lines, keeping the first write error and ignoring the writes following it.
+type dumpWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (d *dumpWriter) section(name string) {
+	d.line("== %s ==", name)
+}
+
+func (d *dumpWriter) line(format string, args ...any) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, format+"\n", args...)
+}
+
+// DumpState writes a human-readable dump of the engine configuration, limits, queued and in-flight operations and,
+// if the engine tracks operations in a replication FSM, the state of every operation of the FSM, e.g. to attach to a
+// bug report. Sections and their lines are written in a stable order, operations being sorted by ID, so that dumps
+// of engines in the same state are identical.
+func (e *ShardReplicationEngine) DumpState(w io.Writer) error {
+	d := &dumpWriter{w: w}
+
+	d.section("engine")
+	d.line("node: %s", e.nodeId)
+	d.line("running: %t", e.IsRunning())
+	d.line("halted: %t", e.IsHalted())
+	d.line("scheduler: %T", e.scheduler)
+	d.line("shutdown_timeout: %s", e.shutdownTimeout)
+	d.line("producer: %T", e.producer)
+	d.line("consumer: %T", e.consumer)
+
+	limits := e.LimitsSnapshot()
+	d.section("limits")
+	d.line("op_buffer_size: %d", limits.OpBufferSize)
+	d.line("max_queued_ops: %d", limits.MaxQueuedOps)
+	d.line("overflow_policy: %s", limits.OverflowPolicy)
+	d.line("max_workers: %d", limits.MaxWorkers)
+	d.line("workers: %d", limits.Workers)
+	d.line("min_workers: %d", limits.MinWorkers)
+	d.line("max_cluster_in_flight_ops: %d", limits.MaxClusterInFlightOps)
+
+	d.section("queue")
+	queued := e.queuedOpCounts()
+	d.line("queued_ops: %d", e.OpChannelLen())
+	for _, id := range slices.Sorted(maps.Keys(queued)) {
+		d.line("op %d: queued %d time(s)", id, queued[id])
+	}
+
+	d.section("in-flight")
+	if lister, ok := e.consumer.(inFlightOpsLister); ok {
+		for _, id := range lister.InFlightOps() {
+			reason, blocked := e.OpBlockReason(id)
+			if !blocked {
+				reason = "processing"
+			}
+			d.line("op %d: %s", id, reason)
+		}
+	} else {
+		d.line("not tracked by the consumer")
+	}
+
+	d.section("fsm")
+	if e.fsm == nil {
+		d.line("no replication FSM")
+	} else {
+		for _, op := range e.fsm.dumpOps() {
+			d.line("op %d: %s -> %s %s since %s", op.id, op.source, op.target, op.state, op.enteredAt.UTC().Format(time.RFC3339))
+		}
+	}
+
+	return d.err
+}
+
+// queuedOpCounts returns a copy of the number of times each operation is queued in the engine.
+func (e *ShardReplicationEngine) queuedOpCounts() map[uint64]int {
+	e.queuedOpIDsLock.Lock()
+	defer e.queuedOpIDsLock.Unlock()
+	counts := make(map[uint64]int, len(e.queuedOpIDs))
+	for id, count := range e.queuedOpIDs {
+		counts[id] = count
+	}
+	return counts
+}
+
+// dumpedOp describes an operation of the FSM in a state dump.
+type dumpedOp struct {
+	id             uint64
+	source, target string
+	state          api.ShardReplicationState
+	enteredAt      time.Time
+}
+
+// dumpOps returns every operation of the FSM, sorted by ID.
+func (s *ShardReplicationFSM) dumpOps() []dumpedOp {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	ops := make([]dumpedOp, 0, len(s.opsById))
+	for id, op := range s.opsById {
+		status := s.opsStatus[op]
+		ops = append(ops, dumpedOp{
+			id:        id,
+			source:    op.sourceShard.String(),
+			target:    op.targetShard.String(),
+			state:     status.state,
+			enteredAt: status.enteredAt,
+		})
+	}
+	slices.SortFunc(ops, func(a, b dumpedOp) int {
+		return cmp.Compare(a.id, b.id)
+	})
+	return ops
+}
@@ -0,0 +1,51 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import "github.com/sirupsen/logrus"
+
+// recentFailuresTaker is implemented by consumers keeping the history of failed operations.
+type recentFailuresTaker interface {
+	takeRecentFailures(n int) []ShardReplicationOp
+}
+
+func (c *CopyOpConsumer) takeRecentFailures(n int) []ShardReplicationOp {
+	return c.failedOps.takeRecent(n)
+}
+
+// ReplayRecentFailures hands the n most recently failed replication operations back to the running engine for
+// processing and returns how many were requeued. Failed operations are kept by consumers configured with
+// WithFailedOpsHistory and are removed from the history once replayed.
+//
+// It returns 0 if the engine is not running or if its consumer does not keep the history of failed operations.
+// Operations taken from the history while the engine is stopping are not requeued; like any unfinished operation,
+// they are emitted again by the producer once the engine starts.
+func (e *ShardReplicationEngine) ReplayRecentFailures(n int) int {
+	taker, ok := e.consumer.(recentFailuresTaker)
+	if !ok {
+		e.logger.WithFields(logrus.Fields{"engine": e}).Warn("replication engine consumer does not keep failed operations")
+		return 0
+	}
+	if !e.IsRunning() || e.halted.Load() {
+		e.logger.WithFields(logrus.Fields{"engine": e}).Warn("replication engine not running, not replaying failed operations")
+		return 0
+	}
+
+	requeued := 0
+	for _, op := range taker.takeRecentFailures(n) {
+		if e.enqueue(op) == nil {
+			requeued++
+		}
+	}
+	e.logger.WithFields(logrus.Fields{"engine": e, "requeued_ops": requeued}).Info("replayed recently failed replication operations")
+	return requeued
+}
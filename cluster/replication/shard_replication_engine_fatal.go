@@ -0,0 +1,62 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrFatal marks unrecoverable conditions, e.g. a corrupt local state, that must not be retried by restarting the
+// engine. Producers and consumers wrap it in the error they fail with to latch the failure on the engine.
+var ErrFatal = errors.New("replication engine fatal error")
+
+// latchFatal latches err if it is fatal, making the engine refuse to start until ClearFatal is called.
+func (e *ShardReplicationEngine) latchFatal(err error) {
+	if !errors.Is(err, ErrFatal) {
+		return
+	}
+	e.fatalLock.Lock()
+	defer e.fatalLock.Unlock()
+	if e.fatalErr == nil {
+		e.fatalErr = err
+		e.logger.WithField("engine", e).WithError(err).Error("replication engine hit a fatal error, refusing to start until cleared")
+	}
+}
+
+// latchedFatal returns the error the engine refuses to start with, if a fatal error is latched.
+func (e *ShardReplicationEngine) latchedFatal() error {
+	e.fatalLock.Lock()
+	defer e.fatalLock.Unlock()
+	if e.fatalErr == nil {
+		return nil
+	}
+	return fmt.Errorf("replication engine not started after a fatal error: %w", e.fatalErr)
+}
+
+// FatalError returns the fatal error latched by the engine, or nil if none is latched.
+func (e *ShardReplicationEngine) FatalError() error {
+	e.fatalLock.Lock()
+	defer e.fatalLock.Unlock()
+	return e.fatalErr
+}
+
+// ClearFatal clears the latched fatal error, if any, allowing the engine to be started again. It is expected to be
+// called once the unrecoverable condition has been dealt with, e.g. the corrupt local state repaired.
+func (e *ShardReplicationEngine) ClearFatal() {
+	e.fatalLock.Lock()
+	defer e.fatalLock.Unlock()
+	if e.fatalErr != nil {
+		e.logger.WithField("engine", e).WithError(e.fatalErr).Info("replication engine fatal error cleared")
+		e.fatalErr = nil
+	}
+}
@@ -0,0 +1,66 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// finalizationHealer is implemented by consumers able to retry finalizing an operation outside of their workers.
+type finalizationHealer interface {
+	healFinalization(ctx context.Context, op ShardReplicationOp) (bool, error)
+}
+
+// healFinalizations retries finalizing the operations stuck in FINALIZING on every tick, until the context is done.
+func (e *ShardReplicationEngine) healFinalizations(ctx context.Context, ticks <-chan time.Time) {
+	healer, ok := e.consumer.(finalizationHealer)
+	if !ok || e.fsm == nil {
+		e.logger.WithFields(logrus.Fields{"engine": e}).Warn("replication engine consumer does not support finalization healing or no replication FSM set, not healing finalizations")
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticks:
+			e.healFinalizationsOnce(ctx, healer)
+		}
+	}
+}
+
+// healFinalizationsOnce makes a single finalization attempt for each operation targeting the engine node that is
+// stuck in FINALIZING and not queued in the engine.
+func (e *ShardReplicationEngine) healFinalizationsOnce(ctx context.Context, healer finalizationHealer) {
+	healed, failed := 0, 0
+	for _, op := range e.fsm.finalizingOps(e.nodeId) {
+		if ctx.Err() != nil {
+			return
+		}
+		if e.isQueued(op.ID) {
+			continue
+		}
+		attempted, err := healer.healFinalization(ctx, op)
+		switch {
+		case err != nil:
+			failed++
+		case attempted:
+			healed++
+		}
+	}
+	if healed > 0 || failed > 0 {
+		e.logger.WithFields(logrus.Fields{"engine": e, "healed_ops": healed, "failed_ops": failed}).Info("healed replication operations stuck finalizing")
+	}
+}
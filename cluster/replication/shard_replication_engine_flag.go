@@ -0,0 +1,55 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// watchReplicationFlag pauses the engine while the flag provider reports replication as disabled, checking the flag
+// when started and on every tick, until the context is done.
+func (e *ShardReplicationEngine) watchReplicationFlag(ctx context.Context, ticks <-chan time.Time) {
+	for {
+		e.applyReplicationFlag(e.flagProvider.ReplicationEnabled())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticks:
+		}
+	}
+}
+
+// applyReplicationFlag pauses or resumes the engine according to the replication flag, and wakes up the goroutine
+// dispatching operations when it changes.
+func (e *ShardReplicationEngine) applyReplicationFlag(enabled bool) {
+	if !e.flagPaused.CompareAndSwap(enabled, !enabled) {
+		return
+	}
+	if enabled {
+		e.logger.WithFields(logrus.Fields{"engine": e}).Info("replication enabled by the feature flag, resuming replication engine")
+	} else {
+		e.logger.WithFields(logrus.Fields{"engine": e}).Warn("replication disabled by the feature flag, pausing replication engine")
+	}
+	select {
+	case e.flagChanged <- struct{}{}:
+	default:
+	}
+}
+
+// IsPaused reports whether the engine is paused because the flag provider set with WithReplicationFlag reports
+// replication as disabled.
+func (e *ShardReplicationEngine) IsPaused() bool {
+	return e.flagPaused.Load()
+}
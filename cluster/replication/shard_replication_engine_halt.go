@@ -0,0 +1,50 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"errors"
+)
+
+// ErrReplicationEngineHalted is returned when starting a replication engine halted by an emergency stop, or when
+// submitting an operation to it.
+var ErrReplicationEngineHalted = errors.New("replication engine halted by an emergency stop")
+
+// EmergencyStop immediately halts all replication on this node, e.g. when data corruption is detected and must not
+// spread through replication.
+//
+// It stops the engine, canceling the operations being processed instead of waiting for them to complete, and
+// discards the queued operations. The engine is then halted: it refuses to start and rejects submitted operations
+// until Reset is called. Operations are not removed from the FSM, hence the interrupted and discarded ones are
+// emitted again by the producer once the engine is reset and restarted.
+func (e *ShardReplicationEngine) EmergencyStop() {
+	e.halted.Store(true)
+	e.logger.WithField("engine", e).Warn("replication engine emergency stop requested, halting replication")
+
+	// Stopping cancels the engine context, which in turn cancels the context of every in-flight operation, and
+	// discards the queued operations
+	e.Stop()
+
+	e.logger.WithField("engine", e).Warn("replication engine halted")
+}
+
+// Reset lifts the halt set by EmergencyStop, allowing the engine to be started again.
+func (e *ShardReplicationEngine) Reset() {
+	if e.halted.CompareAndSwap(true, false) {
+		e.logger.WithField("engine", e).Info("replication engine reset after emergency stop")
+	}
+}
+
+// IsHalted reports whether the engine is halted by an emergency stop and not reset yet.
+func (e *ShardReplicationEngine) IsHalted() bool {
+	return e.halted.Load()
+}
@@ -0,0 +1,72 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import "github.com/sirupsen/logrus"
+
+// takeQueuedOps empties the scheduler, appending its operations to the given ones already taken out of it, and
+// accounts for all of them leaving the queue. It must only be called while dispatching operations.
+func (e *ShardReplicationEngine) takeQueuedOps(ops []ShardReplicationOp) []ShardReplicationOp {
+	ops = e.dequeueAll(ops)
+	for _, op := range ops {
+		e.trackQueued(op, -1)
+		e.endOp(op.ID)
+	}
+	return ops
+}
+
+// ExportPendingOps takes the operations queued in the running engine out of it and returns them in the order they
+// would have been handed to the consumer, so that they can be handed over to another engine with ImportPendingOps,
+// e.g. when the node is being replaced, without waiting for the producer of the other engine to emit them.
+//
+// Operations already handed to the consumer are not exported, and the resources reserved for the exported operations
+// are released. It returns nil if the engine is not running.
+func (e *ShardReplicationEngine) ExportPendingOps() []ShardReplicationOp {
+	e.submitLock.RLock()
+	ctx := e.submitCtx
+	e.submitLock.RUnlock()
+	if ctx == nil {
+		e.logger.WithFields(logrus.Fields{"engine": e}).Warn("replication engine not running, no pending operations to export")
+		return nil
+	}
+
+	// The scheduler is only accessed by the goroutine dispatching operations, hence the operations are taken out of
+	// it by that goroutine, which always replies once it received the request.
+	reply := make(chan []ShardReplicationOp, 1)
+	select {
+	case e.exportRequests <- reply:
+	case <-ctx.Done():
+		return nil
+	}
+	ops := <-reply
+	e.logger.WithFields(logrus.Fields{"engine": e, "exported_ops": len(ops)}).Info("exported pending replication operations")
+	return ops
+}
+
+// ImportPendingOps hands operations exported from another engine with ExportPendingOps to the running engine and
+// returns how many were enqueued. Operations are imported with all their attributes, including the state and the
+// copy progress they were emitted with, and go through the interceptors and the overflow policy like produced
+// operations.
+//
+// It returns 0 if the engine is not running. The imported operations are still tracked in the FSM, hence operations
+// not enqueued are emitted again by the producer reading from the FSM.
+func (e *ShardReplicationEngine) ImportPendingOps(ops []ShardReplicationOp) int {
+	imported := 0
+	for _, op := range ops {
+		if e.enqueue(op) != nil {
+			break
+		}
+		imported++
+	}
+	e.logger.WithFields(logrus.Fields{"engine": e, "imported_ops": imported}).Info("imported pending replication operations")
+	return imported
+}
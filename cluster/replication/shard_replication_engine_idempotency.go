@@ -0,0 +1,112 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"sync"
+	"time"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// defaultIdempotencyKeyRetention is how long the idempotency key of a terminal operation is retained by default.
+const defaultIdempotencyKeyRetention = time.Hour
+
+// idempotentSubmission is the operation submitted with an idempotency key.
+type idempotentSubmission struct {
+	id          uint64
+	submittedAt time.Time
+}
+
+// idempotencyKeys maps the idempotency keys of submitted operations to the operation they were first submitted with.
+type idempotencyKeys struct {
+	mu   sync.Mutex
+	keys map[string]idempotentSubmission
+}
+
+// submittedOp returns the ID of the operation already submitted with the given key, or records the given operation
+// as submitted with the key at the given time if there is none. Keys for which retained returns false are dropped
+// first. It reports whether an operation was already submitted with the key.
+func (k *idempotencyKeys) submittedOp(key string, id uint64, now time.Time, retained func(idempotentSubmission) bool) (uint64, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.keys == nil {
+		k.keys = make(map[string]idempotentSubmission)
+	}
+	for existingKey, submission := range k.keys {
+		if !retained(submission) {
+			delete(k.keys, existingKey)
+		}
+	}
+	if submission, ok := k.keys[key]; ok {
+		return submission.id, true
+	}
+	k.keys[key] = idempotentSubmission{id: id, submittedAt: now}
+	return id, false
+}
+
+// forget drops the given key if it was recorded for the given operation, e.g. once its submission failed. It is a
+// no-op for an empty key.
+func (k *idempotencyKeys) forget(key string, id uint64) {
+	if key == "" {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if submission, ok := k.keys[key]; ok && submission.id == id {
+		delete(k.keys, key)
+	}
+}
+
+// isKeyRetained reports whether the idempotency key of the given submission must still be retained at the given time.
+// Keys are retained while the operation is not terminal, and for the idempotency key retention once it is READY or
+// ABORTED. Without replication FSM, or while the operation is not known to it, keys are retained for the idempotency
+// key retention from the submission.
+func (e *ShardReplicationEngine) isKeyRetained(submission idempotentSubmission, now time.Time) bool {
+	retention := e.idempotencyKeyRetention
+	if e.fsm == nil {
+		return now.Sub(submission.submittedAt) < retention
+	}
+
+	status, ok := e.fsm.opStatusByID(submission.id)
+	switch {
+	case !ok:
+		return now.Sub(submission.submittedAt) < retention
+	case status.state == api.READY || status.state == api.ABORTED:
+		return now.Sub(status.enteredAt) < retention
+	default:
+		return true
+	}
+}
+
+// now returns the current time as measured by the clock of the replication FSM, if any, as used to timestamp the
+// operation state transitions.
+func (e *ShardReplicationEngine) now() time.Time {
+	if e.fsm == nil {
+		return time.Now()
+	}
+	e.fsm.opsLock.RLock()
+	defer e.fsm.opsLock.RUnlock()
+	return e.fsm.timeProvider.Now()
+}
+
+// opStatusByID returns the status of the op with the given ID and whether the op exists.
+func (s *ShardReplicationFSM) opStatusByID(id uint64) (shardReplicationOpStatus, bool) {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	op, ok := s.opsById[id]
+	if !ok {
+		return shardReplicationOpStatus{}, false
+	}
+	return s.opsStatus[op], true
+}
@@ -0,0 +1,30 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import "github.com/sirupsen/logrus"
+
+// inFlightOpsLister is implemented by consumers tracking the operations held by their workers.
+type inFlightOpsLister interface {
+	InFlightOps() []uint64
+}
+
+// InFlightOps returns the IDs of the replication operations currently being processed by the engine consumer,
+// see CopyOpConsumer.InFlightOps. It returns nil if the engine consumer does not track in-flight operations.
+func (e *ShardReplicationEngine) InFlightOps() []uint64 {
+	lister, ok := e.consumer.(inFlightOpsLister)
+	if !ok {
+		e.logger.WithFields(logrus.Fields{"engine": e}).Warn("replication engine consumer does not track in-flight operations")
+		return nil
+	}
+	return lister.InFlightOps()
+}
@@ -0,0 +1,32 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import "github.com/sirupsen/logrus"
+
+// OpInterceptor is invoked on every operation produced onto the engine, before it is queued, e.g. to enforce
+// policies. It returns the operation to queue, possibly modified (e.g. tagged with a cost center), and whether to
+// queue it at all, operations rejected by an interceptor being discarded.
+type OpInterceptor func(op ShardReplicationOp) (ShardReplicationOp, bool)
+
+// interceptOp applies the interceptors to a produced operation in order, stopping at the first rejecting it. It
+// returns the operation to queue and whether it was accepted by every interceptor.
+func (e *ShardReplicationEngine) interceptOp(op ShardReplicationOp) (ShardReplicationOp, bool) {
+	for _, intercept := range e.interceptors {
+		var accepted bool
+		if op, accepted = intercept(op); !accepted {
+			e.logger.WithFields(logrus.Fields{"engine": e, "op": op.ID}).Debug("replication operation rejected by interceptor")
+			return op, false
+		}
+	}
+	return op, true
+}
@@ -0,0 +1,60 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/weaviate/weaviate/cluster/replication/types"
+)
+
+// leaderPingTimeout bounds the time spent checking the connectivity to the leader.
+const leaderPingTimeout = 5 * time.Second
+
+// leaderClientPinger is implemented by consumers able to check the connectivity of their leader client.
+type leaderClientPinger interface {
+	// pingLeaderClient returns an error if the leader client cannot reach the leader, and false if the leader client
+	// cannot be pinged.
+	pingLeaderClient(ctx context.Context) (bool, error)
+}
+
+func (c *CopyOpConsumer) pingLeaderClient(ctx context.Context) (bool, error) {
+	pinger, ok := c.leaderClient.(types.LeaderPinger)
+	if !ok {
+		return false, nil
+	}
+	return true, pinger.Ping(ctx)
+}
+
+// LeaderClientHealthy reports whether the client used by the consumer to update the state of the replication
+// operations can reach the leader, so that operators can tell replication stalls caused by leader connectivity apart
+// from copy problems. The leader client is reported healthy when its health cannot be checked, i.e. when the consumer
+// or its leader client do not support it.
+func (e *ShardReplicationEngine) LeaderClientHealthy() bool {
+	pinger, ok := e.consumer.(leaderClientPinger)
+	if !ok {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), leaderPingTimeout)
+	defer cancel()
+	pinged, err := pinger.pingLeaderClient(ctx)
+	if !pinged {
+		return true
+	}
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{"engine": e}).WithError(err).Warn("replication engine leader client unhealthy")
+		return false
+	}
+	return true
+}
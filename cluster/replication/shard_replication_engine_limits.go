@@ -0,0 +1,71 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+// LimitsView is a snapshot of the effective limits gating the progress of replication operations on a node.
+// Limits which are not configured are zero.
+type LimitsView struct {
+	// OpBufferSize is the number of operations the engine queues between the producer and the consumer.
+	OpBufferSize int
+	// MaxQueuedOps caps the number of queued operations below OpBufferSize, see WithMaxQueuedOps.
+	MaxQueuedOps int
+	// OverflowPolicy is the policy applied to operations produced while the queue is full.
+	OverflowPolicy OverflowPolicy
+
+	// MaxWorkers is the maximum number of operations processed concurrently.
+	MaxWorkers int
+	// Workers is the current worker limit, lower than MaxWorkers while adaptive worker scaling shrank it.
+	Workers int
+	// MinWorkers is the lower bound of adaptive worker scaling, see WithAdaptiveWorkers.
+	MinWorkers int
+
+	// MaxClusterInFlightOps is the cluster-wide load above which operations are not started, see
+	// WithClusterLoadThrottling.
+	MaxClusterInFlightOps int
+	// MaxConcurrentOps is the global cap on the number of operations processed concurrently, see
+	// WithMaxConcurrentOps.
+	MaxConcurrentOps int
+}
+
+// limitsReporter is implemented by consumers reporting the limits they enforce.
+type limitsReporter interface {
+	reportLimits(view *LimitsView)
+}
+
+func (c *CopyOpConsumer) reportLimits(view *LimitsView) {
+	view.MaxWorkers = int(c.maxWorkers.Load())
+	view.Workers = c.Workers()
+	if c.scalingPolicy != nil {
+		view.MinWorkers = c.minWorkers
+	}
+	if c.clusterLoadProvider != nil {
+		view.MaxClusterInFlightOps = c.maxClusterInFlightOps
+	}
+	view.MaxConcurrentOps = cap(c.opSlots)
+}
+
+// LimitsSnapshot returns the limits currently enforced by the engine and its consumer, including the ones changing
+// at runtime such as the worker limit of adaptive worker scaling. If the consumer does not report its limits, the
+// worker limits are the engine maxWorkers.
+func (e *ShardReplicationEngine) LimitsSnapshot() LimitsView {
+	view := LimitsView{
+		OpBufferSize:   e.opBufferSize,
+		MaxQueuedOps:   e.maxQueuedOps,
+		OverflowPolicy: e.overflowPolicy,
+		MaxWorkers:     e.maxWorkers,
+		Workers:        e.maxWorkers,
+	}
+	if reporter, ok := e.consumer.(limitsReporter); ok {
+		reporter.reportLimits(&view)
+	}
+	return view
+}
@@ -0,0 +1,249 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/weaviate/weaviate/cluster/replication/types"
+)
+
+// ShardReplicationEngineOption configures optional behavior of a ShardReplicationEngine.
+type ShardReplicationEngineOption func(*ShardReplicationEngine)
+
+// OverflowPolicy defines how the replication engine handles operations produced while the op buffer is full, or
+// holds as many operations as allowed by WithMaxQueuedOps.
+type OverflowPolicy int
+
+const (
+	// BlockOnOverflow blocks the producer until the consumer dequeues operations from the op buffer.
+	BlockOnOverflow OverflowPolicy = iota
+	// DropOldest discards the buffered operation queued first to make room for the newly produced one, whichever
+	// order the scheduler dispatches the buffered operations in.
+	DropOldest
+	// DropNewest discards the newly produced operation and keeps the buffered ones.
+	DropNewest
+	// DropSelected discards the operation chosen by the drop selector set with WithDropSelector among the buffered
+	// ones and the newly produced one. Without a drop selector, it discards the newly produced operation.
+	DropSelected
+)
+
+// String returns the name of the overflow policy, as used in logs and metric labels.
+func (p OverflowPolicy) String() string {
+	switch p {
+	case DropOldest:
+		return "drop_oldest"
+	case DropNewest:
+		return "drop_newest"
+	case DropSelected:
+		return "drop_selected"
+	default:
+		return "block"
+	}
+}
+
+// ErrorAggregation defines which errors the replication engine returns from Start when both the producer and the
+// consumer fail.
+type ErrorAggregation int
+
+const (
+	// FirstError returns the error of whichever of the producer and the consumer failed first.
+	FirstError ErrorAggregation = iota
+	// JoinedErrors returns the errors of both the producer and the consumer, combined with errors.Join.
+	JoinedErrors
+)
+
+// WithErrorAggregation sets which errors Start returns when both the producer and the consumer fail.
+func WithErrorAggregation(aggregation ErrorAggregation) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.errorAggregation = aggregation
+	}
+}
+
+// WithOverflowPolicy sets the policy applied to operations produced while the op buffer is full.
+func WithOverflowPolicy(policy OverflowPolicy) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.overflowPolicy = policy
+	}
+}
+
+// WithDropSelector sets the DropSelected overflow policy with the given selector choosing which operation to discard
+// while the op buffer is full, e.g. the lowest-priority or the largest one.
+func WithDropSelector(selector DropSelector) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.overflowPolicy = DropSelected
+		e.dropSelector = selector
+	}
+}
+
+// WithEngineRegisterer sets the prometheus registerer used to register the replication engine metrics.
+func WithEngineRegisterer(reg prometheus.Registerer) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.registerer = reg
+	}
+}
+
+// WithReplicationFSM sets the replication FSM the engine uses to track the state of submitted operations.
+func WithReplicationFSM(fsm *ShardReplicationFSM) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.fsm = fsm
+	}
+}
+
+// WithOpTimeline makes the engine record the timeline of the most recent maxOps operations, see
+// ShardReplicationEngine.OpTimeline. State transitions are recorded when a replication FSM is set with
+// WithReplicationFSM.
+func WithOpTimeline(maxOps int) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.timeline = newOpTimeline(maxOps, RealTimeProvider{})
+	}
+}
+
+// WithMaxQueuedOps caps the number of operations queued in the op buffer, independently of its capacity, to bound
+// the memory held by queued operations when the producer is much faster than the consumer. Operations produced
+// while the cap is reached are handled according to the overflow policy. A cap lower than or equal to zero
+// disables it.
+func WithMaxQueuedOps(maxQueuedOps int) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.maxQueuedOps = maxQueuedOps
+	}
+}
+
+// WithScheduler sets the scheduler deciding the order in which queued operations are handed to the consumer. The
+// default scheduler hands them in the order they were produced.
+func WithScheduler(scheduler Scheduler) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.scheduler = scheduler
+	}
+}
+
+// WithProducerThrottling throttles the producer while the consumer fails most operations, e.g. because a downstream
+// dependency is broken, as producing more operations is then pointless. Once the success rate of the last window
+// operations processed by the consumer drops below minSuccessRate, the engine accepts a single produced operation per
+// probeInterval, until the success rate recovers. The consumer must track its success rate, as CopyOpConsumer does.
+func WithProducerThrottling(window int, minSuccessRate float64, probeInterval time.Duration) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.successRateWindow = window
+		e.minSuccessRate = minSuccessRate
+		e.throttleProbeInterval = probeInterval
+	}
+}
+
+// WithFinalizationHealing makes the engine periodically retry finalizing the operations targeting its node that are
+// stuck in FINALIZING, e.g. because the sharding state update kept failing after the copy completed until the
+// consumer gave up. On every tick received from ticks, typically the channel of a time.Ticker owned by the caller,
+// each such operation not currently processed nor queued gets a single attempt to add its replica to the sharding
+// state and to be marked READY, without copying the replica again. A replication FSM must be set with
+// WithReplicationFSM, and the consumer must support finalization healing, as CopyOpConsumer does.
+func WithFinalizationHealing(ticks <-chan time.Time) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.finalizationHealingTicks = ticks
+	}
+}
+
+// WithProducerPollInterval sets the interval at which the producer polls for new operations to emit, trading the
+// latency to start new operations against the load of polling, if the producer polls for operations, as
+// FSMOpProducer does. An interval lower than or equal to zero falls back to the default interval of the producer.
+//
+// Polling is paused while the producer is blocked emitting operations because the op buffer is full, see
+// ShardReplicationEngine.OpChannelCap, and resumes once the consumer catches up. Hence a shorter interval only lowers
+// the latency while the op buffer has room, and polls do not pile up under backpressure. An FSM without operations to
+// emit is polled once per interval and never continuously, even with a very short interval.
+func WithProducerPollInterval(interval time.Duration) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.producerPollInterval = &interval
+	}
+}
+
+// WithReplicationFlag makes the engine honor the replication flag of an external control plane, checked when the
+// engine starts and on every tick received from ticks, typically the channel of a time.Ticker owned by the caller.
+// While replication is disabled, the engine is paused: operations are still produced and queued, but not handed to
+// the consumer until replication is enabled again. Operations already handed to the consumer are not interrupted.
+func WithReplicationFlag(provider types.FlagProvider, ticks <-chan time.Time) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.flagProvider = provider
+		e.flagTicks = ticks
+	}
+}
+
+// WithIdempotencyKeyRetention sets how long the idempotency key of an operation submitted with Submit is retained
+// once the operation is READY or ABORTED, one hour by default. Submitting an operation with a retained key returns
+// the handle of the operation first submitted with it rather than submitting a duplicate operation.
+func WithIdempotencyKeyRetention(retention time.Duration) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.idempotencyKeyRetention = retention
+	}
+}
+
+// WithRestartCoordinator sets the coordinator from which the engine acquires a restart slot before being restarted
+// after a failure by RunSupervised, limiting the number of engines restarting at once across the cluster.
+func WithRestartCoordinator(coordinator types.RestartCoordinator) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.restartCoordinator = coordinator
+	}
+}
+
+// WithEngineTimer sets the timer used by RunSupervised to delay the restarts of the engine, and to schedule the
+// probes of a throttled producer, see WithProducerThrottling, e.g. a fake clock in tests.
+func WithEngineTimer(timer Timer) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.timer = timer
+	}
+}
+
+// WithStreamSink makes the engine append every event of the replication operations to sink as a StreamRecord keyed by
+// the replicated shard, e.g. to feed data pipelines. The streamed events are the ones recorded in the operation
+// timelines, see ShardReplicationEngine.OpTimeline, whether or not timelines are kept with WithOpTimeline. State
+// transitions are streamed when a replication FSM is set with WithReplicationFSM.
+//
+// Records are appended in order by a dedicated goroutine while the engine is running, so that a slow sink never
+// delays processing operations. Records failing to be appended are retried after retryInterval and the following
+// ones are buffered meanwhile, up to bufferSize records, beyond which the oldest buffered records are dropped and
+// logged.
+func WithStreamSink(sink StreamSink, bufferSize int, retryInterval time.Duration) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.stream = newOpStreamWriter(sink, bufferSize, retryInterval)
+	}
+}
+
+// WithEventTrace makes the engine write every event of the replication operations to w as a TraceRecord JSON line,
+// timestamped using timeProvider, to reproduce issues by replaying the trace. The traced events are the ones recorded
+// in the operation timelines, see ShardReplicationEngine.OpTimeline, whether or not timelines are kept with
+// WithOpTimeline. State transitions are traced when a replication FSM is set with WithReplicationFSM.
+func WithEventTrace(w io.Writer, timeProvider TimeProvider) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.trace = newOpTraceWriter(w, timeProvider)
+	}
+}
+
+// WithOpInterceptors adds interceptors invoked on every produced operation before it is queued, allowing to modify
+// or reject it. Interceptors are applied in the order they are added, each receiving the operation returned by the
+// previous one, until one rejects it.
+func WithOpInterceptors(interceptors ...OpInterceptor) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.interceptors = append(e.interceptors, interceptors...)
+	}
+}
+
+// WithResourceReserver makes the engine reserve the resources needed by every operation on its target node before
+// queueing it, so that operations which can never run, e.g. because the target disk is too small, are rejected
+// upfront rather than failing later. Operations submitted with Submit are rejected with an error wrapping
+// ErrResourceReservationFailed, reported by the returned handle, while produced operations are rejected and logged.
+// Reservations are released once the consumer is done with the operation, or when the operation is discarded.
+func WithResourceReserver(reserver types.ResourceReserver) ShardReplicationEngineOption {
+	return func(e *ShardReplicationEngine) {
+		e.resourceReserver = reserver
+	}
+}
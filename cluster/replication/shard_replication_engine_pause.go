@@ -0,0 +1,87 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// opPauser is implemented by consumers supporting pausing individual replication operations.
+type opPauser interface {
+	PauseOp(id uint64)
+	ResumeOp(id uint64)
+}
+
+// pausedOpsHolder is implemented by consumers holding paused operations, which the engine is done with until they
+// are resumed.
+type pausedOpsHolder interface {
+	// observeOpResume registers the function called before dispatching a resumed operation.
+	observeOpResume(onOpResume func(op ShardReplicationOp) error)
+	// forgetPausedOp drops the pause state of an operation which will never be processed.
+	forgetPausedOp(id uint64)
+}
+
+func (c *CopyOpConsumer) observeOpResume(onOpResume func(op ShardReplicationOp) error) {
+	c.onOpResume = onOpResume
+}
+
+// holdPausedOps makes the engine reserve again the resources of the operations resumed by the consumer, and makes the
+// consumer forget the paused operations removed from the replication FSM or aborted.
+func (e *ShardReplicationEngine) holdPausedOps() {
+	holder, ok := e.consumer.(pausedOpsHolder)
+	if !ok {
+		return
+	}
+	holder.observeOpResume(func(op ShardReplicationOp) error {
+		if err := e.reserveOp(context.Background(), op); err != nil {
+			e.rejectUnreservedOp(op, err)
+			return err
+		}
+		return nil
+	})
+	if e.fsm != nil {
+		e.fsm.OnRemoval(holder.forgetPausedOp)
+		e.fsm.OnTransition(func(id uint64, _, to api.ShardReplicationState) {
+			if to == api.ABORTED {
+				holder.forgetPausedOp(id)
+			}
+		})
+	}
+}
+
+// PauseOp pauses the replication operation with the given ID, see CopyOpConsumer.PauseOp.
+// It reports false if the engine consumer does not support pausing operations.
+func (e *ShardReplicationEngine) PauseOp(id uint64) bool {
+	pauser, ok := e.consumer.(opPauser)
+	if !ok {
+		e.logger.WithFields(logrus.Fields{"engine": e, "op": id}).Warn("replication engine consumer does not support pausing operations")
+		return false
+	}
+	pauser.PauseOp(id)
+	return true
+}
+
+// ResumeOp resumes a paused replication operation, see CopyOpConsumer.ResumeOp.
+// It reports false if the engine consumer does not support pausing operations.
+func (e *ShardReplicationEngine) ResumeOp(id uint64) bool {
+	pauser, ok := e.consumer.(opPauser)
+	if !ok {
+		e.logger.WithFields(logrus.Fields{"engine": e, "op": id}).Warn("replication engine consumer does not support resuming operations")
+		return false
+	}
+	pauser.ResumeOp(id)
+	return true
+}
@@ -0,0 +1,237 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unsafe"
+
+	"github.com/sirupsen/logrus"
+)
+
+// estimatedSize returns an estimate of the memory held by the operation, including its strings.
+func (op ShardReplicationOp) estimatedSize() int64 {
+	return int64(unsafe.Sizeof(op)) +
+		int64(len(op.CostCenter)+len(op.IdempotencyKey)+len(op.CampaignID)) +
+		int64(len(op.sourceShard.nodeId)+len(op.sourceShard.collectionId)+len(op.sourceShard.shardId)) +
+		int64(len(op.targetShard.nodeId)+len(op.targetShard.collectionId)+len(op.targetShard.shardId))
+}
+
+// queueFull reports whether the engine holds as many queued operations as allowed by the op buffer size and by
+// WithMaxQueuedOps. An op buffer size lower than one allows a single queued operation.
+func (e *ShardReplicationEngine) queueFull() bool {
+	queued := int(e.queuedOps.Load())
+	if e.maxQueuedOps > 0 && queued >= e.maxQueuedOps {
+		return true
+	}
+	return queued >= max(1, e.opBufferSize)
+}
+
+// trackQueued accounts for an operation entering (delta 1) or leaving (delta -1) the queue.
+func (e *ShardReplicationEngine) trackQueued(op ShardReplicationOp, delta int64) {
+	e.queuedOps.Add(delta)
+	e.queuedOpsSize.Add(delta * op.estimatedSize())
+
+	e.queuedOpIDsLock.Lock()
+	defer e.queuedOpIDsLock.Unlock()
+	e.queuedOpIDs[op.ID] += int(delta)
+	if e.queuedOpIDs[op.ID] <= 0 {
+		delete(e.queuedOpIDs, op.ID)
+	}
+}
+
+// isQueued reports whether the operation with the given ID is queued in the engine.
+func (e *ShardReplicationEngine) isQueued(id uint64) bool {
+	e.queuedOpIDsLock.Lock()
+	defer e.queuedOpIDsLock.Unlock()
+	return e.queuedOpIDs[id] > 0
+}
+
+// ErrOpChannelClosed is returned when the channel the producer writes operations to is closed while the replication
+// engine is running, which the engine was not asked for.
+var ErrOpChannelClosed = errors.New("replication operation channel closed unexpectedly")
+
+// errOpsDrained is returned by dispatchOps once the producer finished and every queued operation was handed to the
+// consumer.
+var errOpsDrained = errors.New("replication operations drained")
+
+// dispatchOps queues the operations received from the producer intake channel in the scheduler and hands them to
+// the consumer, in the order decided by the scheduler, until the context is canceled. The intake channel is only
+// closed by the engine once stopped, hence it returns ErrOpChannelClosed if the intake channel is closed before.
+//
+// Once producerDone is closed, as the producer finished emitting operations, it returns errOpsDrained as soon as the
+// queue is empty. Operations submitted in the meantime are still queued and handed to the consumer.
+//
+// The next operation to hand to the consumer is taken from the scheduler as soon as there is one and held until
+// the consumer receives it. It is still accounted for as queued. While the queue is full, the configured overflow
+// policy decides whether to stop receiving from the producer or which operation to discard. No operation is handed
+// to the consumer while the engine is paused by the replication flag, and ABORTED operations, e.g. canceled with
+// CancelOpsForSourceFQDN, are discarded instead of being queued or handed to the consumer.
+func (e *ShardReplicationEngine) dispatchOps(ctx context.Context, in <-chan ShardReplicationOp, out chan<- ShardReplicationOp, producerDone <-chan struct{}) error {
+	var next ShardReplicationOp
+	hasNext := false
+	producerFinished := false
+	// queueSeq records the order in which operations are queued, as the scheduler may dispatch them in another one
+	var queueSeq uint64
+
+	// While the producer is throttled, a single operation is accepted every probe interval
+	var probeTimer *time.Timer
+	var probeDue <-chan struct{}
+	probeReady := false
+	defer func() {
+		if probeTimer != nil {
+			probeTimer.Stop()
+		}
+	}()
+
+	for {
+		next, hasNext = e.nextDispatchableOp(next, hasNext)
+		if producerFinished && !hasNext {
+			return errOpsDrained
+		}
+
+		var dispatch chan<- ShardReplicationOp
+		if hasNext && !e.flagPaused.Load() {
+			dispatch = out
+		}
+		intake := in
+		if e.overflowPolicy == BlockOnOverflow && e.queueFull() {
+			// Blocking the producer until the consumer receives an operation
+			intake = nil
+		}
+		if e.updateProducerThrottling() && !probeReady {
+			intake = nil
+			if probeDue == nil {
+				due := make(chan struct{})
+				probeDue = due
+				probeTimer = e.timer.AfterFunc(e.throttleProbeInterval, func() { close(due) })
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-e.flagChanged:
+
+		case <-e.canceledChanged:
+
+		case <-probeDue:
+			probeTimer, probeDue = nil, nil
+			probeReady = true
+
+		case <-producerDone:
+			producerFinished = true
+			producerDone = nil
+
+		case dispatch <- next:
+			e.trackQueued(next, -1)
+			e.stats.consumed.Add(1)
+			hasNext = false
+
+		case reply := <-e.exportRequests:
+			var exported []ShardReplicationOp
+			if hasNext {
+				exported = append(exported, next)
+				hasNext = false
+			}
+			reply <- e.takeQueuedOps(exported)
+
+		case op, ok := <-intake:
+			if !ok {
+				return ErrOpChannelClosed
+			}
+			probeReady = false
+			e.stats.produced.Add(1)
+			if op, ok = e.interceptOp(op); !ok {
+				e.endOp(op.ID)
+				continue
+			}
+			if e.isOpAborted(op.ID) {
+				e.logger.WithFields(logrus.Fields{"engine": e, "op": op.ID}).Debug("discarding aborted replication operation")
+				e.endOp(op.ID)
+				continue
+			}
+			if err := e.reserveOp(ctx, op); err != nil {
+				e.rejectUnreservedOp(op, err)
+				continue
+			}
+			op.queuedAt = e.now()
+			queueSeq++
+			op.queueSeq = queueSeq
+			if e.stream != nil {
+				e.stream.track(op)
+			}
+			e.timeline.record(op.ID, TimelineQueued, "", "")
+			if e.queueFull() {
+				switch e.overflowPolicy {
+				case DropNewest:
+					e.dropOp(op)
+					continue
+				case DropOldest:
+					next, hasNext = e.dropOldestOp(next, hasNext)
+				case DropSelected:
+					next, hasNext = e.dropSelectedOp(next, hasNext, op)
+					continue
+				}
+			}
+			e.scheduler.Enqueue(op)
+			e.trackQueued(op, 1)
+		}
+	}
+}
+
+// dropOldestOp discards the operation queued first among the given held operation, if any, and the operations held
+// by the scheduler, whichever order the scheduler dispatches them in. It returns the held operation left, if any, and
+// must only be called while dispatching operations.
+func (e *ShardReplicationEngine) dropOldestOp(next ShardReplicationOp, hasNext bool) (ShardReplicationOp, bool) {
+	oldest, found := next, hasNext
+	for _, op := range e.scheduler.Snapshot() {
+		if !found || op.queueSeq < oldest.queueSeq {
+			oldest, found = op, true
+		}
+	}
+	if !found {
+		return next, hasNext
+	}
+
+	if hasNext && oldest == next {
+		next, hasNext = ShardReplicationOp{}, false
+	} else {
+		e.scheduler.Remove(oldest)
+	}
+	e.trackQueued(oldest, -1)
+	e.dropOp(oldest)
+	return next, hasNext
+}
+
+// discardQueuedOps empties the scheduler and returns the number of discarded operations. It must not be called while
+// operations are being dispatched.
+func (e *ShardReplicationEngine) discardQueuedOps() int {
+	discarded := 0
+	for {
+		op, ok := e.scheduler.Dequeue()
+		if !ok {
+			break
+		}
+		e.endOp(op.ID)
+		discarded++
+	}
+	e.queuedOps.Store(0)
+	e.queuedOpsSize.Store(0)
+	e.queuedOpIDsLock.Lock()
+	clear(e.queuedOpIDs)
+	e.queuedOpIDsLock.Unlock()
+	return discarded
+}
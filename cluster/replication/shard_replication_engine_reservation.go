@@ -0,0 +1,101 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrResourceReservationFailed is returned when the resources needed by a replication operation on its target node
+// cannot be reserved before queueing it, see WithResourceReserver.
+var ErrResourceReservationFailed = errors.New("replication operation resource reservation failed")
+
+// opReservations holds the release functions of the resources reserved for the queued and running operations.
+type opReservations struct {
+	mu       sync.Mutex
+	releases map[uint64]func()
+}
+
+func newOpReservations() *opReservations {
+	return &opReservations{releases: make(map[uint64]func())}
+}
+
+// reserveOp reserves the resources needed by the operation on its target node, unless already reserved, e.g. by
+// Submit before the operation reaches the intake. It is a no-op without resource reserver.
+func (e *ShardReplicationEngine) reserveOp(ctx context.Context, op ShardReplicationOp) error {
+	if e.resourceReserver == nil {
+		return nil
+	}
+	e.reservations.mu.Lock()
+	_, reserved := e.reservations.releases[op.ID]
+	e.reservations.mu.Unlock()
+	if reserved {
+		return nil
+	}
+
+	release, err := e.resourceReserver.ReserveReplica(ctx, op.targetShard.nodeId, op.targetShard.collectionId, op.targetShard.shardId)
+	if err != nil {
+		return fmt.Errorf("%w: op %d on node %s: %w", ErrResourceReservationFailed, op.ID, op.targetShard.nodeId, err)
+	}
+
+	e.reservations.mu.Lock()
+	defer e.reservations.mu.Unlock()
+	if _, reserved := e.reservations.releases[op.ID]; reserved {
+		// Reserved concurrently, the operation only holds a single reservation
+		release()
+		return nil
+	}
+	e.reservations.releases[op.ID] = release
+	return nil
+}
+
+// releaseOp releases the resources reserved for the operation with the given ID, if any. It is called by endOp once
+// the operation processing ends, or when the operation is discarded without being processed.
+func (e *ShardReplicationEngine) releaseOp(id uint64) {
+	if e.resourceReserver == nil {
+		return
+	}
+	e.reservations.mu.Lock()
+	release, ok := e.reservations.releases[id]
+	delete(e.reservations.releases, id)
+	e.reservations.mu.Unlock()
+	if ok {
+		release()
+	}
+}
+
+// rejectUnreservedOp logs an operation rejected because its resources could not be reserved.
+func (e *ShardReplicationEngine) rejectUnreservedOp(op ShardReplicationOp, err error) {
+	e.logger.WithFields(logrus.Fields{"engine": e, "op": op.ID}).WithError(err).Warn("replication operation rejected, resources could not be reserved")
+}
+
+// opEndObserver is implemented by consumers able to notify when they are done with an operation, whether processed,
+// failed or skipped.
+type opEndObserver interface {
+	observeOpEnd(onOpEnd func(id uint64))
+}
+
+func (c *CopyOpConsumer) observeOpEnd(onOpEnd func(id uint64)) {
+	c.onOpEnd = onOpEnd
+}
+
+// endOp notifies the engine, if any, that the consumer is done with the operation.
+func (c *CopyOpConsumer) endOp(id uint64) {
+	if c.onOpEnd != nil {
+		c.onOpEnd(id)
+	}
+}
@@ -0,0 +1,76 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import "sync/atomic"
+
+// Stats is a snapshot of the operations counted by the replication engine since it was last started.
+type Stats struct {
+	// Produced is the number of operations received from the producer, including the submitted ones.
+	Produced int64
+	// InFlight is the number of operations currently queued between the producer and the consumer, as returned by
+	// OpChannelLen.
+	InFlight int64
+	// Consumed is the number of operations handed to the consumer.
+	Consumed int64
+	// Completed is the number of operations the consumer processed successfully.
+	Completed int64
+	// Failed is the number of operations the consumer failed to process.
+	Failed int64
+}
+
+// engineStats holds the counters reported by Stats.
+type engineStats struct {
+	produced  atomic.Int64
+	consumed  atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+}
+
+func (s *engineStats) reset() {
+	s.produced.Store(0)
+	s.consumed.Store(0)
+	s.completed.Store(0)
+	s.failed.Store(0)
+}
+
+// recordOutcome counts an operation processed by the consumer, successfully if err is nil.
+func (s *engineStats) recordOutcome(_ ShardReplicationOp, err error) {
+	if err != nil {
+		s.failed.Add(1)
+	} else {
+		s.completed.Add(1)
+	}
+}
+
+// opOutcomeObserver is implemented by consumers able to notify when they are done processing an operation, with
+// the error it failed with, if any. Paused and canceled operations are not reported.
+type opOutcomeObserver interface {
+	observeOpOutcome(onOpOutcome func(op ShardReplicationOp, err error))
+}
+
+func (c *CopyOpConsumer) observeOpOutcome(onOpOutcome func(op ShardReplicationOp, err error)) {
+	c.onOpOutcome = onOpOutcome
+}
+
+// Stats returns the number of operations produced, queued, consumed, completed and failed since the engine was last
+// started. It is safe to call while the engine is running. Completed and failed operations are only counted for
+// consumers able to report them, as CopyOpConsumer is.
+func (e *ShardReplicationEngine) Stats() Stats {
+	return Stats{
+		Produced:  e.stats.produced.Load(),
+		InFlight:  int64(e.OpChannelLen()),
+		Consumed:  e.stats.consumed.Load(),
+		Completed: e.stats.completed.Load(),
+		Failed:    e.stats.failed.Load(),
+	}
+}
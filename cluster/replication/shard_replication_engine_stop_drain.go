@@ -0,0 +1,63 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrDrainTimeout is returned by StopWithDrain when operations are still in progress once its context is done.
+var ErrDrainTimeout = errors.New("replication engine drain timed out")
+
+// StopWithDrain stops the replication engine gracefully, e.g. during a rolling restart, so that replica copies in
+// progress are not aborted midway.
+//
+// The producer is stopped and no more operations are accepted nor handed to the consumer, while the operations the
+// consumer already holds run to completion. The operations still queued in the engine are discarded, to be emitted
+// again by the producer once the engine is restarted. It blocks until the consumer completed its operations and the
+// engine shut down, or until ctx is done, in which case the engine is stopped as with Stop, canceling the operations
+// in progress, and an error wrapping ErrDrainTimeout listing their IDs is returned.
+//
+// It returns nil right away if the engine is not running. Stop remains the way to stop the engine without waiting for
+// the operations in progress.
+func (e *ShardReplicationEngine) StopWithDrain(ctx context.Context) error {
+	e.lifecycleLock.Lock()
+	if !e.isRunning.Load() || e.stopChan == nil {
+		e.lifecycleLock.Unlock()
+		return nil
+	}
+	drainChan, done := e.drainChan, e.done
+	// Clearing the drain channel ensures only the first of concurrent StopWithDrain calls closes it
+	e.drainChan = nil
+	e.lifecycleLock.Unlock()
+
+	if drainChan != nil {
+		e.logger.WithField("engine", e).Info("replication engine drain requested")
+		close(drainChan)
+	}
+
+	select {
+	case <-done:
+		e.logger.WithField("engine", e).Info("replication engine drained and stopped")
+		return nil
+	case <-ctx.Done():
+		inProgress := e.InFlightOps()
+		e.logger.WithFields(logrus.Fields{"engine": e, "ops_in_progress": inProgress}).WithError(ctx.Err()).
+			Warn("replication engine drain timed out, canceling the operations in progress")
+		e.Stop()
+		return fmt.Errorf("%w: operations %v still in progress: %w", ErrDrainTimeout, inProgress, ctx.Err())
+	}
+}
@@ -0,0 +1,179 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// StreamRecord is a replication operation lifecycle event appended to a StreamSink by engines configured with
+// WithStreamSink. The records are the events recorded in the operation timelines, see ShardReplicationEngine.OpTimeline.
+type StreamRecord struct {
+	// Key is the partition key of the record, formatted as collection/shard, so that the records of the operations
+	// replicating the same shard are kept in order by the sink. It is empty if the shard of the operation is unknown.
+	Key string `json:"key"`
+	// Time is the time of the event, as measured by the clock of the engine.
+	Time  time.Time         `json:"time"`
+	OpID  uint64            `json:"op_id"`
+	Event TimelineEventType `json:"event"`
+	// State is the state the operation transitioned to, set for TimelineStateChanged events only.
+	State api.ShardReplicationState `json:"state,omitempty"`
+	// Detail optionally describes the event, e.g. the error causing a retry.
+	Detail string `json:"detail,omitempty"`
+}
+
+// StreamSink is an append-only stream of StreamRecord, typically an adapter producing to a Kafka or Pulsar topic
+// partitioned by the record key.
+type StreamSink interface {
+	// Append appends a single record to the stream. Records are appended one at a time, in the order the events
+	// occurred. A record failing to be appended is appended again, hence the sink must tolerate duplicates.
+	Append(ctx context.Context, record StreamRecord) error
+}
+
+// opStreamWriter buffers the records to append to a stream sink, and appends them in order from a single goroutine.
+type opStreamWriter struct {
+	sink          StreamSink
+	bufferSize    int
+	retryInterval time.Duration
+	now           func() time.Time
+	logger        logrus.FieldLogger
+	// resolveKey returns the partition key of the operations whose key is not tracked.
+	resolveKey func(id uint64) (string, bool)
+
+	mu sync.Mutex
+	// keys holds the partition keys of the operations queued by the engine.
+	keys map[uint64]string
+	// pending holds the records not appended yet, and pendingSignal signals new pending records.
+	pending       []StreamRecord
+	pendingSignal chan struct{}
+	dropped       int
+}
+
+func newOpStreamWriter(sink StreamSink, bufferSize int, retryInterval time.Duration) *opStreamWriter {
+	return &opStreamWriter{
+		sink:          sink,
+		bufferSize:    max(1, bufferSize),
+		retryInterval: retryInterval,
+		now:           time.Now,
+		resolveKey:    func(uint64) (string, bool) { return "", false },
+		keys:          make(map[uint64]string),
+		pendingSignal: make(chan struct{}, 1),
+	}
+}
+
+// track records the partition key of an operation, so that it is known for all its subsequent events.
+func (w *opStreamWriter) track(op ShardReplicationOp) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.keys[op.ID] = op.targetShard.collectionId + "/" + op.targetShard.shardId
+}
+
+// write buffers the record of an event to be appended. The oldest buffered record is dropped while the buffer is full.
+func (w *opStreamWriter) write(id uint64, eventType TimelineEventType, state api.ShardReplicationState, detail string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key, ok := w.keys[id]
+	if !ok {
+		key, _ = w.resolveKey(id)
+	}
+	if len(w.pending) >= w.bufferSize {
+		w.pending = w.pending[1:]
+		w.dropped++
+	}
+	w.pending = append(w.pending, StreamRecord{
+		Key:    key,
+		Time:   w.now(),
+		OpID:   id,
+		Event:  eventType,
+		State:  state,
+		Detail: detail,
+	})
+	select {
+	case w.pendingSignal <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the oldest buffered record, and the number of records dropped since the last call.
+func (w *opStreamWriter) next() (StreamRecord, int, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dropped := w.dropped
+	w.dropped = 0
+	if len(w.pending) == 0 {
+		return StreamRecord{}, dropped, false
+	}
+	return w.pending[0], dropped, true
+}
+
+// appended removes the given record from the buffer once appended, unless it was already dropped meanwhile.
+func (w *opStreamWriter) appended(record StreamRecord) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) > 0 && w.pending[0] == record {
+		w.pending = w.pending[1:]
+	}
+	if record.Event == TimelineCompleted || record.Event == TimelineFailed {
+		delete(w.keys, record.OpID)
+	}
+}
+
+// run appends the buffered records to the sink in order until the context is done. A record failing to be appended
+// is kept and appended again after the retry interval, hence records are buffered while the sink is unavailable.
+// Records not appended when the context is done are kept, and appended once run is called again.
+func (w *opStreamWriter) run(ctx context.Context) {
+	for {
+		record, dropped, ok := w.next()
+		if dropped > 0 {
+			w.logger.WithField("dropped", dropped).Warn("replication stream sink buffer full, dropped the oldest records")
+		}
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.pendingSignal:
+				continue
+			}
+		}
+
+		if err := w.sink.Append(ctx, record); err != nil {
+			w.logger.WithFields(logrus.Fields{"op": record.OpID, "key": record.Key}).WithError(err).
+				Warn("failed to append replication record to the stream sink, retrying")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.retryInterval):
+			}
+			continue
+		}
+		w.appended(record)
+	}
+}
+
+// opStreamKey returns the partition key of the operation with the given ID, formatted as collection/shard, and whether
+// the operation exists.
+func (s *ShardReplicationFSM) opStreamKey(id uint64) (string, bool) {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	op, ok := s.opsById[id]
+	if !ok {
+		return "", false
+	}
+	return op.targetShard.collectionId + "/" + op.targetShard.shardId, true
+}
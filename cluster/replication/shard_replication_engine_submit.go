@@ -0,0 +1,187 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+var (
+	// ErrReplicationOpAborted is returned when waiting for a replication operation which ended up ABORTED.
+	ErrReplicationOpAborted = errors.New("replication operation aborted")
+	// ErrNoReplicationFSM is returned when tracking a submitted operation on an engine without a replication FSM.
+	ErrNoReplicationFSM = errors.New("replication engine has no replication FSM to track operations")
+	// ErrReplicationEngineNotRunning is returned when submitting an operation to a replication engine not running.
+	ErrReplicationEngineNotRunning = errors.New("replication engine not running")
+)
+
+// OpHandle tracks a replication operation submitted to the replication engine, allowing callers to await its
+// completion. Its state is read from the replication FSM.
+type OpHandle struct {
+	id  uint64
+	fsm *ShardReplicationFSM
+	// submitted tracks the operations the engine is not done with yet, so that an operation unknown to the FSM is
+	// only waited for while the engine may still process it.
+	submitted *inFlightOps
+	// err is the error the operation was rejected with when submitted, if any.
+	err error
+}
+
+// Err returns the error the replication operation was rejected with when submitted, e.g. an error wrapping
+// ErrResourceReservationFailed or ErrReplicationEngineNotRunning, or nil if it was not rejected.
+func (h OpHandle) Err() error {
+	return h.err
+}
+
+// ID returns the ID of the tracked replication operation.
+func (h OpHandle) ID() uint64 {
+	return h.id
+}
+
+// State returns the current state of the tracked replication operation, or an empty state if the operation is not
+// (or no longer) known to the replication FSM.
+func (h OpHandle) State() api.ShardReplicationState {
+	if h.fsm == nil {
+		return ""
+	}
+	state, _ := h.fsm.getOpStateByID(h.id)
+	return state
+}
+
+// Wait blocks until the tracked replication operation reaches a terminal state or the context is done.
+//
+// It returns nil once the operation is READY, ErrReplicationOpAborted if it is ABORTED and
+// ErrReplicationOpNotFound if the operation is deleted from the FSM while waiting. An operation not yet known to
+// the FSM (e.g. its registration is still being replicated) is waited for as long as it is queued or processed by the
+// engine, ErrReplicationOpNotFound being returned once the engine is done with it. The error an operation was
+// rejected with when submitted is returned at once.
+func (h OpHandle) Wait(ctx context.Context) error {
+	if h.err != nil {
+		return h.err
+	}
+	if h.fsm == nil {
+		return ErrNoReplicationFSM
+	}
+
+	seen := false
+	for {
+		// Watch before reading the state to avoid missing a transition happening in between
+		changed := h.fsm.watchOp(h.id)
+
+		state, ok := h.fsm.getOpStateByID(h.id)
+		switch {
+		case !ok && (seen || !h.isSubmitted()):
+			return fmt.Errorf("%w: %d", ErrReplicationOpNotFound, h.id)
+		case state == api.READY:
+			return nil
+		case state == api.ABORTED:
+			return fmt.Errorf("%w: %d", ErrReplicationOpAborted, h.id)
+		}
+		seen = seen || ok
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// isSubmitted reports whether the engine may still process the tracked operation.
+func (h OpHandle) isSubmitted() bool {
+	return h.submitted != nil && h.submitted.contains(h.id)
+}
+
+// Submit hands a replication operation to the running engine for processing, in the same way as operations
+// emitted by the producer, and returns a handle to await its completion. It blocks while the op buffer is full.
+//
+// If the engine is not running or is halted by an emergency stop, the operation is not enqueued and the returned
+// handle reports an error wrapping ErrReplicationEngineNotRunning or ErrReplicationEngineHalted. Operations stored in
+// the FSM are still emitted by the producer once the engine starts. The returned handle tracks the operation state
+// in the replication FSM configured with WithReplicationFSM.
+//
+// An operation submitted with the same idempotency key as a previously submitted operation is not enqueued, and the
+// handle of the previously submitted operation is returned instead, as long as the key is retained, see
+// WithIdempotencyKeyRetention. The key is only retained once the operation is enqueued, hence an operation rejected
+// at submission can be submitted again with the same key.
+//
+// With a resource reserver set with WithResourceReserver, the operation is only enqueued if its resources can be
+// reserved, otherwise the returned handle reports an error wrapping ErrResourceReservationFailed.
+func (e *ShardReplicationEngine) Submit(op ShardReplicationOp) OpHandle {
+	if op.IdempotencyKey != "" {
+		now := e.now()
+		id, duplicate := e.idempotencyKeys.submittedOp(op.IdempotencyKey, op.ID, now, func(submission idempotentSubmission) bool {
+			return e.isKeyRetained(submission, now)
+		})
+		if duplicate {
+			e.logger.WithFields(logrus.Fields{"engine": e, "op": op.ID, "existing_op": id, "idempotency_key": op.IdempotencyKey}).Info("replication operation already submitted with the same idempotency key, not enqueued")
+			return OpHandle{id: id, fsm: e.fsm, submitted: e.submittedOps}
+		}
+	}
+	if err := e.reserveOp(context.Background(), op); err != nil {
+		e.idempotencyKeys.forget(op.IdempotencyKey, op.ID)
+		e.rejectUnreservedOp(op, err)
+		return OpHandle{id: op.ID, fsm: e.fsm, err: err}
+	}
+	// Tracked before being enqueued, as the engine may be done with the operation before enqueue returns
+	e.submittedOps.add(op.ID)
+	if err := e.enqueue(op); err != nil {
+		e.idempotencyKeys.forget(op.IdempotencyKey, op.ID)
+		e.endOp(op.ID)
+		return OpHandle{id: op.ID, fsm: e.fsm, err: fmt.Errorf("op %d: %w", op.ID, err)}
+	}
+	return OpHandle{id: op.ID, fsm: e.fsm, submitted: e.submittedOps}
+}
+
+// endOp is called once the engine is done with the operation with the given ID, whether processed by the consumer
+// or discarded without being processed. It releases the resources reserved for the operation and wakes up the
+// handles of the operation if it was submitted, so that they stop waiting for it if it is unknown to the FSM.
+func (e *ShardReplicationEngine) endOp(id uint64) {
+	e.releaseOp(id)
+	if e.submittedOps.contains(id) {
+		e.submittedOps.remove(id)
+		if e.fsm != nil {
+			e.fsm.notifyOpWatchers(id)
+		}
+	}
+}
+
+// enqueue hands the operation to the running engine, returning an error wrapping ErrReplicationEngineHalted or
+// ErrReplicationEngineNotRunning if it was not enqueued.
+func (e *ShardReplicationEngine) enqueue(op ShardReplicationOp) error {
+	if e.halted.Load() {
+		e.logger.WithFields(logrus.Fields{"engine": e, "op": op.ID}).Warn("replication engine halted by an emergency stop, operation rejected")
+		return ErrReplicationEngineHalted
+	}
+
+	e.submitLock.RLock()
+	defer e.submitLock.RUnlock()
+
+	if e.submitChan == nil {
+		e.logger.WithFields(logrus.Fields{"engine": e, "op": op.ID}).Warn("replication engine not running, operation not enqueued")
+		return ErrReplicationEngineNotRunning
+	}
+
+	select {
+	case e.submitChan <- op:
+		return nil
+	case <-e.submitCtx.Done():
+		e.logger.WithFields(logrus.Fields{"engine": e, "op": op.ID}).Warn("replication engine stopped while submitting operation")
+		return fmt.Errorf("%w: stopped while submitting", ErrReplicationEngineNotRunning)
+	}
+}
@@ -0,0 +1,60 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RunSupervised runs the replication engine like Start, automatically restarting it restartDelay after its producer
+// or its consumer failed, as scheduled by the timer set with WithEngineTimer, until the context is done, the engine is stopped with Stop while running, it is halted
+// by an emergency stop, or it failed with a fatal error.
+//
+// Before every restart, a restart slot is acquired from the restart coordinator set with WithRestartCoordinator, if
+// any, and released once the producer and the consumer are running again, so that only a limited number of engines
+// restart at once across the cluster.
+func (e *ShardReplicationEngine) RunSupervised(ctx context.Context, restartDelay time.Duration) error {
+	release := func() {}
+	for {
+		err := e.start(ctx, release)
+		if err == nil || ctx.Err() != nil || errors.Is(err, ErrReplicationEngineHalted) || errors.Is(err, ErrFatal) {
+			return err
+		}
+		e.logger.WithFields(logrus.Fields{"engine": e, "restart_delay": restartDelay}).WithError(err).Warn("replication engine failed, restarting")
+
+		restart := make(chan struct{})
+		timer := e.timer.AfterFunc(restartDelay, func() { close(restart) })
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-restart:
+		}
+
+		if release, err = e.acquireRestart(ctx); err != nil {
+			return fmt.Errorf("acquire replication engine restart: %w", err)
+		}
+	}
+}
+
+// acquireRestart acquires a restart slot from the restart coordinator, if any, and returns the function releasing it.
+func (e *ShardReplicationEngine) acquireRestart(ctx context.Context) (func(), error) {
+	if e.restartCoordinator == nil {
+		return func() {}, nil
+	}
+	return e.restartCoordinator.AcquireRestart(ctx, e.nodeId)
+}
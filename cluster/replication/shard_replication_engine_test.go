@@ -12,17 +12,30 @@
 package replication_test
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/weaviate/weaviate/cluster/proto/api"
 	"github.com/weaviate/weaviate/cluster/replication"
+	"github.com/weaviate/weaviate/cluster/replication/replicationtest"
+	"github.com/weaviate/weaviate/cluster/replication/types"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
 	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -1120,6 +1133,668 @@ func TestShardReplicationEngine(t *testing.T) {
 	})
 }
 
+func TestShardReplicationEngineOverflowPolicy(t *testing.T) {
+	t.Run("drop oldest discards buffered ops and records them", func(t *testing.T) {
+		// GIVEN
+		const metricName = "weaviate_replication_ops_dropped_total"
+		reg := prometheus.NewPedanticRegistry()
+		logger, hook := logrustest.NewNullLogger()
+
+		mockProducer := replication.NewMockOpProducer(t)
+		mockConsumer := replication.NewMockOpConsumer(t)
+
+		producedChan := make(chan struct{})
+		consumedChan := make(chan []uint64, 1)
+
+		mockProducer.On("Produce", mock.Anything, mock.Anything).Run(
+			func(args mock.Arguments) {
+				ctx := args.Get(0).(context.Context)
+				opsChan := args.Get(1).(chan<- replication.ShardReplicationOp)
+				for id := uint64(1); id <= 4; id++ {
+					opsChan <- replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", "shard1")
+				}
+				close(producedChan)
+				<-ctx.Done()
+			}).Once().Return(context.Canceled)
+
+		mockConsumer.On("Consume", mock.Anything, mock.Anything).Run(
+			func(args mock.Arguments) {
+				ctx := args.Get(0).(context.Context)
+				opsChan := args.Get(1).(<-chan replication.ShardReplicationOp)
+				<-producedChan
+				// The last produced op might still be in the process of being forwarded to the op buffer
+				require.Eventually(t, func() bool {
+					return len(droppedOpIds(hook)) == 2
+				}, 5*time.Second, 10*time.Millisecond)
+				consumed := make([]uint64, 0, 2)
+				for i := 0; i < 2; i++ {
+					op := <-opsChan
+					consumed = append(consumed, op.ID)
+				}
+				consumedChan <- consumed
+				<-ctx.Done()
+			}).Once().Return(context.Canceled)
+
+		engine := replication.NewShardReplicationEngine(
+			logger,
+			"node2",
+			mockProducer,
+			mockConsumer,
+			2,
+			1,
+			1*time.Minute,
+			replication.WithOverflowPolicy(replication.DropOldest),
+			replication.WithEngineRegisterer(reg),
+		)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+
+		// WHEN
+		consumed := <-consumedChan
+		engine.Stop()
+		wg.Wait()
+
+		// THEN
+		require.NoError(t, engineStartErr)
+		require.Equal(t, []uint64{3, 4}, consumed, "only the newest ops should be left in the buffer")
+
+		err := testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+# HELP %s Number of replication operations discarded by the replication engine overflow policy
+# TYPE %s counter
+%s{policy="drop_oldest"} 2
+`, metricName, metricName, metricName)), metricName)
+		require.NoError(t, err)
+
+		require.Equal(t, []uint64{1, 2}, droppedOpIds(hook), "each dropped op should be logged with its ID")
+	})
+
+	t.Run("drop oldest discards the ops queued first whatever the scheduler order", func(t *testing.T) {
+		// GIVEN
+		logger, hook := logrustest.NewNullLogger()
+
+		mockProducer := replication.NewMockOpProducer(t)
+		mockConsumer := replication.NewMockOpConsumer(t)
+
+		producedChan := make(chan struct{})
+		consumedChan := make(chan []uint64, 1)
+
+		mockProducer.On("Produce", mock.Anything, mock.Anything).Run(
+			func(args mock.Arguments) {
+				ctx := args.Get(0).(context.Context)
+				opsChan := args.Get(1).(chan<- replication.ShardReplicationOp)
+				for id := uint64(1); id <= 4; id++ {
+					opsChan <- replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))
+				}
+				close(producedChan)
+				<-ctx.Done()
+			}).Once().Return(context.Canceled)
+
+		mockConsumer.On("Consume", mock.Anything, mock.Anything).Run(
+			func(args mock.Arguments) {
+				ctx := args.Get(0).(context.Context)
+				opsChan := args.Get(1).(<-chan replication.ShardReplicationOp)
+				<-producedChan
+				require.Eventually(t, func() bool {
+					return len(droppedOpIds(hook)) == 2
+				}, 5*time.Second, 10*time.Millisecond)
+				consumed := make([]uint64, 0, 2)
+				for i := 0; i < 2; i++ {
+					op := <-opsChan
+					consumed = append(consumed, op.ID)
+				}
+				consumedChan <- consumed
+				<-ctx.Done()
+			}).Once().Return(context.Canceled)
+
+		engine := replication.NewShardReplicationEngine(
+			logger,
+			"node2",
+			mockProducer,
+			mockConsumer,
+			2,
+			1,
+			1*time.Minute,
+			replication.WithOverflowPolicy(replication.DropOldest),
+			replication.WithScheduler(replication.NewLIFOScheduler()),
+		)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+
+		// WHEN
+		consumed := <-consumedChan
+		engine.Stop()
+		wg.Wait()
+
+		// THEN the LIFO scheduler dispatches the newest op first, yet the oldest ones are dropped
+		require.NoError(t, engineStartErr)
+		require.Equal(t, []uint64{1, 2}, droppedOpIds(hook))
+		require.Equal(t, []uint64{3, 4}, consumed)
+	})
+
+	t.Run("queued ops cap below the buffer capacity drops newest ops", func(t *testing.T) {
+		// GIVEN
+		const metricName = "weaviate_replication_engine_queued_ops_bytes"
+		reg := prometheus.NewPedanticRegistry()
+		logger, hook := logrustest.NewNullLogger()
+
+		mockProducer := replication.NewMockOpProducer(t)
+		mockConsumer := replication.NewMockOpConsumer(t)
+
+		producedChan := make(chan struct{})
+		consumedChan := make(chan []uint64, 1)
+		var queuedBytes float64
+		var engine *replication.ShardReplicationEngine
+
+		mockProducer.On("Produce", mock.Anything, mock.Anything).Run(
+			func(args mock.Arguments) {
+				ctx := args.Get(0).(context.Context)
+				opsChan := args.Get(1).(chan<- replication.ShardReplicationOp)
+				for id := uint64(1); id <= 5; id++ {
+					opsChan <- replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", "shard1")
+				}
+				close(producedChan)
+				<-ctx.Done()
+			}).Once().Return(context.Canceled)
+
+		mockConsumer.On("Consume", mock.Anything, mock.Anything).Run(
+			func(args mock.Arguments) {
+				ctx := args.Get(0).(context.Context)
+				opsChan := args.Get(1).(<-chan replication.ShardReplicationOp)
+				<-producedChan
+				// The last produced op might still be in the process of being forwarded to the op buffer
+				require.Eventually(t, func() bool {
+					return len(droppedOpIds(hook)) == 3
+				}, 5*time.Second, 10*time.Millisecond)
+				require.Equal(t, 2, engine.OpChannelLen(), "engine should not queue more ops than the cap")
+				queuedBytes = gatheredGaugeValue(t, reg, metricName)
+
+				consumed := make([]uint64, 0, 2)
+				for i := 0; i < 2; i++ {
+					op := <-opsChan
+					consumed = append(consumed, op.ID)
+				}
+				require.Eventually(t, func() bool {
+					return gatheredGaugeValue(t, reg, metricName) == 0
+				}, 5*time.Second, 10*time.Millisecond, "dequeued ops should no longer be accounted for")
+				consumedChan <- consumed
+				<-ctx.Done()
+			}).Once().Return(context.Canceled)
+
+		engine = replication.NewShardReplicationEngine(
+			logger,
+			"node2",
+			mockProducer,
+			mockConsumer,
+			10,
+			1,
+			1*time.Minute,
+			replication.WithOverflowPolicy(replication.DropNewest),
+			replication.WithMaxQueuedOps(2),
+			replication.WithEngineRegisterer(reg),
+		)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+
+		// WHEN
+		consumed := <-consumedChan
+		engine.Stop()
+		wg.Wait()
+
+		// THEN
+		require.NoError(t, engineStartErr)
+		require.Equal(t, 10, engine.OpChannelCap(), "cap should not change the op buffer capacity")
+		require.Equal(t, []uint64{1, 2}, consumed, "only the oldest ops within the cap should be queued")
+		require.Equal(t, []uint64{3, 4, 5}, droppedOpIds(hook), "ops beyond the cap should be dropped")
+		require.Positive(t, queuedBytes, "queued ops memory should be estimated")
+	})
+
+	t.Run("queued ops cap blocks the producer with the blocking policy", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+
+		mockProducer := replication.NewMockOpProducer(t)
+		mockConsumer := replication.NewMockOpConsumer(t)
+
+		var produced atomic.Int32
+		consumedChan := make(chan []uint64, 1)
+		var engine *replication.ShardReplicationEngine
+
+		mockProducer.On("Produce", mock.Anything, mock.Anything).Run(
+			func(args mock.Arguments) {
+				ctx := args.Get(0).(context.Context)
+				opsChan := args.Get(1).(chan<- replication.ShardReplicationOp)
+				for id := uint64(1); id <= 4; id++ {
+					opsChan <- replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", "shard1")
+					produced.Add(1)
+				}
+				<-ctx.Done()
+			}).Once().Return(context.Canceled)
+
+		mockConsumer.On("Consume", mock.Anything, mock.Anything).Run(
+			func(args mock.Arguments) {
+				ctx := args.Get(0).(context.Context)
+				opsChan := args.Get(1).(<-chan replication.ShardReplicationOp)
+				require.Eventually(t, func() bool {
+					return engine.OpChannelLen() == 2
+				}, 5*time.Second, 10*time.Millisecond)
+				// Two ops are queued while the producer is blocked sending the third one
+				time.Sleep(50 * time.Millisecond)
+				require.Equal(t, 2, engine.OpChannelLen(), "engine should not queue more ops than the cap")
+				require.Equal(t, int32(2), produced.Load(), "producer should be blocked by the cap")
+
+				consumed := make([]uint64, 0, 4)
+				for i := 0; i < 4; i++ {
+					op := <-opsChan
+					consumed = append(consumed, op.ID)
+				}
+				consumedChan <- consumed
+				<-ctx.Done()
+			}).Once().Return(context.Canceled)
+
+		engine = replication.NewShardReplicationEngine(
+			logger,
+			"node2",
+			mockProducer,
+			mockConsumer,
+			10,
+			1,
+			1*time.Minute,
+			replication.WithMaxQueuedOps(2),
+		)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+
+		// WHEN
+		consumed := <-consumedChan
+		engine.Stop()
+		wg.Wait()
+
+		// THEN
+		require.NoError(t, engineStartErr)
+		require.Equal(t, []uint64{1, 2, 3, 4}, consumed, "blocked ops should be queued once the consumer catches up")
+	})
+
+	t.Run("drop selector discards the lowest-priority op", func(t *testing.T) {
+		// GIVEN ops whose priority depends on their collection, and a selector dropping the first lowest-priority one
+		const metricName = "weaviate_replication_ops_dropped_total"
+		reg := prometheus.NewPedanticRegistry()
+		logger, hook := logrustest.NewNullLogger()
+
+		priorities := map[string]int{"Low": 1, "Medium": 2, "High": 3}
+		lowestPriority := func(ops []replication.ShardReplicationOp) int {
+			lowest := 0
+			for i, op := range ops {
+				if priorities[op.Collection()] < priorities[ops[lowest].Collection()] {
+					lowest = i
+				}
+			}
+			return lowest
+		}
+
+		mockProducer := replication.NewMockOpProducer(t)
+		mockConsumer := replication.NewMockOpConsumer(t)
+
+		producedChan := make(chan struct{})
+		consumedChan := make(chan []uint64, 1)
+
+		mockProducer.On("Produce", mock.Anything, mock.Anything).Run(
+			func(args mock.Arguments) {
+				ctx := args.Get(0).(context.Context)
+				opsChan := args.Get(1).(chan<- replication.ShardReplicationOp)
+				for id, collection := range []string{"Medium", "Low", "High", "High", "Low"} {
+					opsChan <- replication.NewShardReplicationOp(uint64(id+1), "node1", "node2", collection, "shard1")
+				}
+				close(producedChan)
+				<-ctx.Done()
+			}).Once().Return(context.Canceled)
+
+		mockConsumer.On("Consume", mock.Anything, mock.Anything).Run(
+			func(args mock.Arguments) {
+				ctx := args.Get(0).(context.Context)
+				opsChan := args.Get(1).(<-chan replication.ShardReplicationOp)
+				<-producedChan
+				// The last produced op might still be in the process of being forwarded to the op buffer
+				require.Eventually(t, func() bool {
+					return len(droppedOpIds(hook)) == 2
+				}, 5*time.Second, 10*time.Millisecond)
+				consumed := make([]uint64, 0, 3)
+				for i := 0; i < 3; i++ {
+					op := <-opsChan
+					consumed = append(consumed, op.ID)
+				}
+				consumedChan <- consumed
+				<-ctx.Done()
+			}).Once().Return(context.Canceled)
+
+		engine := replication.NewShardReplicationEngine(
+			logger,
+			"node2",
+			mockProducer,
+			mockConsumer,
+			3,
+			1,
+			1*time.Minute,
+			replication.WithDropSelector(lowestPriority),
+			replication.WithEngineRegisterer(reg),
+		)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+
+		// WHEN
+		consumed := <-consumedChan
+		engine.Stop()
+		wg.Wait()
+
+		// THEN the queued low-priority op is evicted for the fourth op, and the fifth op is evicted itself
+		require.NoError(t, engineStartErr)
+		require.Equal(t, []uint64{1, 3, 4}, consumed, "the remaining ops should be consumed in order")
+		require.Equal(t, []uint64{2, 5}, droppedOpIds(hook), "the lowest-priority ops should be dropped")
+
+		err := testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+# HELP %s Number of replication operations discarded by the replication engine overflow policy
+# TYPE %s counter
+%s{policy="drop_selected"} 2
+`, metricName, metricName, metricName)), metricName)
+		require.NoError(t, err)
+	})
+
+	t.Run("drop selector keeps the order of a LIFO queue", func(t *testing.T) {
+		// GIVEN a selector dropping the first low-priority op
+		logger, hook := logrustest.NewNullLogger()
+
+		firstLow := func(ops []replication.ShardReplicationOp) int {
+			return slices.IndexFunc(ops, func(op replication.ShardReplicationOp) bool { return op.Collection() == "Low" })
+		}
+
+		mockProducer := replication.NewMockOpProducer(t)
+		mockConsumer := replication.NewMockOpConsumer(t)
+
+		producedChan := make(chan struct{})
+		consumedChan := make(chan []uint64, 1)
+
+		mockProducer.On("Produce", mock.Anything, mock.Anything).Run(
+			func(args mock.Arguments) {
+				ctx := args.Get(0).(context.Context)
+				opsChan := args.Get(1).(chan<- replication.ShardReplicationOp)
+				for id, collection := range []string{"High", "Low", "High", "High", "Low"} {
+					opsChan <- replication.NewShardReplicationOp(uint64(id+1), "node1", "node2", collection, fmt.Sprintf("shard%d", id+1))
+				}
+				close(producedChan)
+				<-ctx.Done()
+			}).Once().Return(context.Canceled)
+
+		mockConsumer.On("Consume", mock.Anything, mock.Anything).Run(
+			func(args mock.Arguments) {
+				ctx := args.Get(0).(context.Context)
+				opsChan := args.Get(1).(<-chan replication.ShardReplicationOp)
+				<-producedChan
+				require.Eventually(t, func() bool {
+					return len(droppedOpIds(hook)) == 2
+				}, 5*time.Second, 10*time.Millisecond)
+				consumed := make([]uint64, 0, 3)
+				for i := 0; i < 3; i++ {
+					op := <-opsChan
+					consumed = append(consumed, op.ID)
+				}
+				consumedChan <- consumed
+				<-ctx.Done()
+			}).Once().Return(context.Canceled)
+
+		engine := replication.NewShardReplicationEngine(
+			logger,
+			"node2",
+			mockProducer,
+			mockConsumer,
+			3,
+			1,
+			1*time.Minute,
+			replication.WithOverflowPolicy(replication.DropSelected),
+			replication.WithDropSelector(firstLow),
+			replication.WithScheduler(replication.NewLIFOScheduler()),
+		)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+
+		// WHEN
+		consumed := <-consumedChan
+		engine.Stop()
+		wg.Wait()
+
+		// THEN the op held for the consumer is handed first, then the remaining ops are handed newest first
+		require.NoError(t, engineStartErr)
+		require.Equal(t, []uint64{2, 5}, droppedOpIds(hook))
+		require.Equal(t, []uint64{1, 4, 3}, consumed)
+	})
+}
+
+func gatheredGaugeValue(t *testing.T, reg *prometheus.Registry, name string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	require.Failf(t, "metric not found", "metric %s is not registered", name)
+	return 0
+}
+
+func droppedOpIds(hook *logrustest.Hook) []uint64 {
+	var droppedOps []uint64
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == logrus.WarnLevel && entry.Message == "replication engine op buffer full, dropping replication operation" {
+			droppedOps = append(droppedOps, entry.Data["op"].(uint64))
+		}
+	}
+	return droppedOps
+}
+
+func TestShardReplicationEngine_PauseOp(t *testing.T) {
+	t.Run("paused queued op is not processed until resumed", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+		require.True(t, inMemory.Engine.PauseOp(1))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = inMemory.Engine.Start(context.Background())
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// WHEN
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+
+		// THEN the op which is not paused completes while the paused one is held
+		require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(2, api.READY)))
+		_, ok := inMemory.FSMUpdater.State(1)
+		require.False(t, ok, "paused op should not be processed")
+		require.Len(t, inMemory.Copier.Calls(), 1)
+
+		// WHEN
+		require.True(t, inMemory.Engine.ResumeOp(1))
+
+		// THEN
+		require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(1, api.READY)), "resumed op should be processed")
+		require.Len(t, inMemory.Copier.Calls(), 2)
+
+		inMemory.Engine.Stop()
+		wg.Wait()
+		require.NoError(t, engineStartErr)
+	})
+
+	t.Run("paused op is released to the engine until resumed and forgotten once deleted", func(t *testing.T) {
+		// GIVEN an engine reserving resources on a target node able to hold a single replica copy
+		logger, _ := logrustest.NewNullLogger()
+		fsm := newTestFSM(t)
+		for id := uint64(1); id <= 4; id++ {
+			require.NoError(t, fsm.Replicate(id, replicateRequest("node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))))
+		}
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		fsmUpdater.UpdateStatusFunc = func(id uint64, state api.ShardReplicationState) error {
+			return fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: id, State: state})
+		}
+		copier := replicationtest.NewFakeCopier()
+		reserver := &capacityReserver{capacity: 1}
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier,
+			replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 2)
+		producer := replicationtest.NewFakeProducer(1)
+		engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 3, 2,
+			10*time.Second, replication.WithReplicationFSM(fsm), replication.WithResourceReserver(reserver))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// An op emitted by the producer being processed ensures the engine accepts submissions
+		producer.Submit(replication.NewShardReplicationOp(4, "node1", "node2", "TestCollection", "shard4"))
+		require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(4, api.READY)))
+
+		// WHEN a paused op is submitted
+		require.True(t, engine.PauseOp(1))
+		paused := engine.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+		require.NoError(t, paused.Err())
+
+		// THEN its reservation is released while it is held, letting another op be reserved and processed
+		require.Eventually(t, func() bool { return reserver.Reserved() == 0 }, 5*time.Second, time.Millisecond)
+		other := engine.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+		require.NoError(t, other.Err())
+		require.NoError(t, other.Wait(ctx))
+
+		// WHEN the paused op is resumed
+		require.True(t, engine.ResumeOp(1))
+
+		// THEN it is reserved again and processed
+		require.NoError(t, paused.Wait(ctx))
+		require.Len(t, copier.Calls(), 3)
+
+		// WHEN a held op is deleted from the FSM
+		require.True(t, engine.PauseOp(3))
+		require.NoError(t, engine.Submit(replication.NewShardReplicationOp(3, "node1", "node2", "TestCollection", "shard3")).Err())
+		require.Eventually(t, func() bool { return reserver.Reserved() == 0 }, 5*time.Second, time.Millisecond)
+		require.NoError(t, fsm.DeleteReplicationOp(&api.ReplicationDeleteOpRequest{Id: 3}))
+
+		// THEN its pause state is dropped and it is not processed once resumed
+		require.False(t, consumer.IsOpPaused(3))
+		require.True(t, engine.ResumeOp(3))
+		require.Never(t, func() bool { return len(copier.Calls()) > 3 }, 100*time.Millisecond, 10*time.Millisecond)
+
+		engine.Stop()
+		wg.Wait()
+		require.NoError(t, engineStartErr)
+		require.Zero(t, reserver.Reserved())
+	})
+}
+
+func TestShardReplicationEngine_TransitionGuard(t *testing.T) {
+	// GIVEN an engine whose FSM has a guard vetoing the first two attempts to mark the op READY
+	logger, _ := logrustest.NewNullLogger()
+	fsm := newTestFSM(t)
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	var vetoes atomic.Int32
+	fsm.AddTransitionGuard(func(id uint64, from, to api.ShardReplicationState) error {
+		if to == api.READY && vetoes.Add(1) <= 2 {
+			return errors.New("external validation pending")
+		}
+		return nil
+	})
+	var proposed []api.ShardReplicationState
+	var proposedLock sync.Mutex
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	fsmUpdater.UpdateStatusFunc = func(id uint64, state api.ShardReplicationState) error {
+		proposedLock.Lock()
+		proposed = append(proposed, state)
+		proposedLock.Unlock()
+		return fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: id, State: state})
+	}
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, replicationtest.NewFakeCopier(),
+		replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 5) },
+		10*time.Second, 1)
+	producer := replicationtest.NewFakeProducer(1)
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 1, 1,
+		10*time.Second, replication.WithReplicationFSM(fsm))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// WHEN
+	producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+
+	// THEN the vetoed transition is retried until the guard allows it, without proposing the vetoed updates
+	require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(1, api.READY)))
+	require.Equal(t, int32(3), vetoes.Load(), "READY should be requested again after each veto")
+	proposedLock.Lock()
+	require.Equal(t, []api.ShardReplicationState{api.HYDRATING, api.FINALIZING, api.READY}, proposed)
+	proposedLock.Unlock()
+
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+func opInState(id uint64, state api.ShardReplicationState) func(f *replicationtest.FakeFSMUpdater) bool {
+	return func(f *replicationtest.FakeFSMUpdater) bool {
+		current, ok := f.State(id)
+		return ok && current == state
+	}
+}
+
 func randomOpIds(t *testing.T, count int) ([]uint64, error) {
 	t.Helper()
 	startId, err := randInt(t, 1000, 10000)
@@ -1127,23 +1802,3019 @@ func randomOpIds(t *testing.T, count int) ([]uint64, error) {
 		return nil, err
 	}
 
-	opIds := make([]uint64, count)
-	currId := uint64(startId)
+	opIds := make([]uint64, count)
+	currId := uint64(startId)
+
+	for i := 0; i < count; i++ {
+		opIds[i] = currId
+		currId += 1
+	}
+
+	return opIds, nil
+}
+
+func randInt(t *testing.T, min, max int) (int, error) {
+	t.Helper()
+	var randValue [1]byte
+	_, err := rand.Read(randValue[:])
+	if err != nil {
+		return 0, err
+	}
+	return min + int(randValue[0])%(max-min+1), nil
+}
+
+func TestShardReplicationEngine_Submit(t *testing.T) {
+	t.Run("handle wait returns once the submitted op is READY", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		fsm := newTestFSM(t)
+		inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+		engine := replication.NewShardReplicationEngine(logger, "node2", inMemory.Producer, inMemory.Consumer,
+			64, 4, 10*time.Second, replication.WithReplicationFSM(fsm))
+		require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// An op emitted by the producer being processed ensures the engine accepts submissions
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+		require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(2, api.READY)))
+
+		// WHEN
+		handle := engine.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+		require.Equal(t, api.REGISTERED, handle.State())
+
+		go func() {
+			for _, state := range []api.ShardReplicationState{api.HYDRATING, api.FINALIZING, api.READY} {
+				assert.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: state}))
+			}
+		}()
+
+		// THEN
+		require.NoError(t, handle.Wait(ctx))
+		require.Equal(t, api.READY, handle.State())
+		require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(1, api.READY)), "submitted op should be processed by the consumer")
+
+		engine.Stop()
+		wg.Wait()
+		require.NoError(t, engineStartErr)
+	})
+
+	t.Run("handle wait returns an error once the submitted op is ABORTED", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		fsm := newTestFSM(t)
+		inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+		engine := replication.NewShardReplicationEngine(logger, "node2", inMemory.Producer, inMemory.Consumer,
+			64, 4, 10*time.Second, replication.WithReplicationFSM(fsm))
+		require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// An op emitted by the producer being processed ensures the engine accepts submissions
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+		require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(2, api.READY)))
+
+		// WHEN
+		handle := engine.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+		require.NoError(t, handle.Err())
+		go func() {
+			assert.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.ABORTED}))
+		}()
+
+		// THEN
+		require.ErrorIs(t, handle.Wait(ctx), replication.ErrReplicationOpAborted)
+
+		engine.Stop()
+		wg.Wait()
+		require.NoError(t, engineStartErr)
+	})
+
+	t.Run("submitting to an engine not running reports an error", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		fsm := newTestFSM(t)
+		inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+		engine := replication.NewShardReplicationEngine(logger, "node2", inMemory.Producer, inMemory.Consumer,
+			64, 4, 10*time.Second, replication.WithReplicationFSM(fsm))
+		require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+
+		// WHEN
+		handle := engine.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+
+		// THEN the op is not waited for
+		require.ErrorIs(t, handle.Err(), replication.ErrReplicationEngineNotRunning)
+		require.ErrorIs(t, handle.Wait(context.Background()), replication.ErrReplicationEngineNotRunning)
+		require.Equal(t, api.REGISTERED, handle.State(), "the op should still be tracked in the FSM")
+	})
+
+	t.Run("handle wait fails once the engine is done with an op unknown to the FSM", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		fsm := newTestFSM(t)
+		inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+		engine := replication.NewShardReplicationEngine(logger, "node2", inMemory.Producer, inMemory.Consumer,
+			64, 4, 10*time.Second, replication.WithReplicationFSM(fsm))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// An op emitted by the producer being processed ensures the engine accepts submissions
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+		require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(2, api.READY)))
+
+		// WHEN an op never registered in the FSM is submitted
+		handle := engine.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+		require.NoError(t, handle.Err())
+
+		// THEN waiting fails once the op was processed, before the context is done
+		require.ErrorIs(t, handle.Wait(ctx), replication.ErrReplicationOpNotFound)
+		require.NoError(t, ctx.Err())
+
+		engine.Stop()
+		wg.Wait()
+		require.NoError(t, engineStartErr)
+	})
+
+	t.Run("submitting the same idempotency key twice creates a single op", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		fsm := newTestFSM(t)
+		inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+		engine := replication.NewShardReplicationEngine(logger, "node2", inMemory.Producer, inMemory.Consumer,
+			64, 4, 10*time.Second, replication.WithReplicationFSM(fsm))
+		require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// An op emitted by the producer being processed ensures the engine accepts submissions
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+		require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(2, api.READY)))
+
+		// WHEN
+		op := replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+		op.IdempotencyKey = "request-1"
+		first := engine.Submit(op)
+		duplicate := replication.NewShardReplicationOp(3, "node1", "node2", "TestCollection", "shard1")
+		duplicate.IdempotencyKey = "request-1"
+		second := engine.Submit(duplicate)
+
+		// THEN
+		require.Equal(t, uint64(1), first.ID())
+		require.Equal(t, uint64(1), second.ID(), "the duplicate submission should return the handle of the existing op")
+		require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(1, api.READY)))
+
+		engine.Stop()
+		wg.Wait()
+		require.NoError(t, engineStartErr)
+		_, ok := inMemory.FSMUpdater.State(3)
+		require.False(t, ok, "the duplicate op should not be processed")
+		require.Len(t, inMemory.Copier.Calls(), 2)
+	})
+
+	t.Run("idempotency key of a terminal op is released after the retention window", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		fsm := newTestFSM(t)
+		clock := replicationtest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+		fsm.SetClock(clock, clock)
+		inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+		engine := replication.NewShardReplicationEngine(logger, "node2", inMemory.Producer, inMemory.Consumer,
+			64, 4, 10*time.Second, replication.WithReplicationFSM(fsm), replication.WithIdempotencyKeyRetention(time.Hour))
+		require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+		submit := func(id uint64) uint64 {
+			op := replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", "shard1")
+			op.IdempotencyKey = "request-1"
+			return engine.Submit(op).ID()
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// An op emitted by the producer being processed ensures the engine accepts submissions
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(3, "node1", "node2", "TestCollection", "shard3"))
+		require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(3, api.READY)))
+		require.Equal(t, uint64(1), submit(1))
+
+		// WHEN the op is in progress for longer than the retention window
+		clock.Advance(2 * time.Hour)
+
+		// THEN
+		require.Equal(t, uint64(1), submit(2), "the key of an op in progress should be retained")
+
+		// WHEN the op is READY for less than the retention window
+		require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.READY}))
+		clock.Advance(30 * time.Minute)
+
+		// THEN
+		require.Equal(t, uint64(1), submit(2), "the key of a recently terminal op should be retained")
+
+		// WHEN the op is READY for longer than the retention window
+		clock.Advance(time.Hour)
+
+		// THEN
+		require.Equal(t, uint64(2), submit(2), "the key of an op terminal for longer than the retention window should be released")
+
+		engine.Stop()
+		wg.Wait()
+		require.NoError(t, engineStartErr)
+	})
+
+	t.Run("idempotency key of an op which was not enqueued is released", func(t *testing.T) {
+		tests := []struct {
+			name string
+			halt bool
+		}{
+			{name: "engine stopped"},
+			{name: "engine halted", halt: true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN an engine which is not running, possibly halted by an emergency stop
+				logger, _ := logrustest.NewNullLogger()
+				inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+				engine := replication.NewShardReplicationEngine(logger, "node2", inMemory.Producer, inMemory.Consumer,
+					64, 4, 10*time.Second)
+				if tt.halt {
+					engine.EmergencyStop()
+				}
+				op := replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1")
+				op.IdempotencyKey = "request-1"
+				engine.Submit(op)
+
+				// WHEN the engine runs and the op is submitted again with the same key
+				engine.Reset()
+				var wg sync.WaitGroup
+				wg.Add(1)
+				var engineStartErr error
+				go func() {
+					defer wg.Done()
+					engineStartErr = engine.Start(context.Background())
+				}()
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+
+				// An op emitted by the producer being processed ensures the engine accepts submissions
+				inMemory.Producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+				require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(2, api.READY)))
+				engine.Submit(op)
+
+				// THEN the retried op is enqueued and processed
+				require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(1, api.READY)))
+
+				engine.Stop()
+				wg.Wait()
+				require.NoError(t, engineStartErr)
+			})
+		}
+	})
+}
+
+func TestShardReplicationEngine_InFlightOps(t *testing.T) {
+	// GIVEN
+	logger, _ := logrustest.NewNullLogger()
+	inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+
+	release := make(chan struct{})
+	inMemory.Copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		if shard == "shard3" {
+			return nil
+		}
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = inMemory.Engine.Start(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// WHEN
+	inMemory.Producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+	inMemory.Producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+	inMemory.Producer.Submit(replication.NewShardReplicationOp(3, "node1", "node2", "TestCollection", "shard3"))
+
+	// THEN only the ops held by a worker are in flight
+	require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(3, api.READY)))
+	require.Eventually(t, func() bool {
+		return slices.Equal([]uint64{1, 2}, inMemory.Engine.InFlightOps())
+	}, 5*time.Second, 10*time.Millisecond, "ops blocked in their copy should be in flight")
+
+	// WHEN
+	close(release)
+
+	// THEN
+	require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(1, api.READY)))
+	require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(2, api.READY)))
+	require.Eventually(t, func() bool {
+		return len(inMemory.Engine.InFlightOps()) == 0
+	}, 5*time.Second, 10*time.Millisecond, "completed ops should no longer be in flight")
+
+	inMemory.Engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+func TestShardReplicationEngine_EmergencyStop(t *testing.T) {
+	// GIVEN
+	logger, _ := logrustest.NewNullLogger()
+	fsm := newTestFSM(t)
+	inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+	engine := replication.NewShardReplicationEngine(logger, "node2", inMemory.Producer, inMemory.Consumer,
+		64, 4, 10*time.Second, replication.WithReplicationFSM(fsm))
+
+	var canceledCopies atomic.Int32
+	inMemory.Copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		if shard == "shard-after-reset" {
+			return nil
+		}
+		<-ctx.Done()
+		canceledCopies.Add(1)
+		return ctx.Err()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+
+	for i := 1; i <= 6; i++ {
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(uint64(i), "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", i)))
+	}
+	require.Eventually(t, func() bool {
+		return len(engine.InFlightOps()) == 4
+	}, 5*time.Second, 10*time.Millisecond, "every worker should be busy copying")
+
+	// WHEN
+	engine.EmergencyStop()
+	wg.Wait()
+
+	// THEN in-flight ops are canceled and the engine is halted
+	require.NoError(t, engineStartErr)
+	require.Equal(t, int32(4), canceledCopies.Load(), "in-flight copies should be canceled")
+	require.Empty(t, engine.InFlightOps())
+	require.False(t, engine.IsRunning())
+	require.True(t, engine.IsHalted())
+
+	// THEN new work is rejected while halted
+	require.ErrorIs(t, engine.Start(context.Background()), replication.ErrReplicationEngineHalted)
+	require.False(t, engine.IsRunning())
+	require.NoError(t, fsm.Replicate(7, replicateRequest("node1", "node2", "TestCollection", "shard-after-reset")))
+	engine.Submit(replication.NewShardReplicationOp(7, "node1", "node2", "TestCollection", "shard-after-reset"))
+	_, ok := inMemory.FSMUpdater.State(7)
+	require.False(t, ok, "op submitted while halted should not be processed")
+
+	// WHEN
+	engine.Reset()
+	require.False(t, engine.IsHalted())
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+	require.Eventually(t, engine.IsRunning, 5*time.Second, 10*time.Millisecond)
+
+	// THEN new work is accepted again
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	inMemory.Producer.Submit(replication.NewShardReplicationOp(7, "node1", "node2", "TestCollection", "shard-after-reset"))
+	require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(7, api.READY)))
+
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+func TestShardReplicationEngine_OpTimeline(t *testing.T) {
+	// GIVEN
+	logger, _ := logrustest.NewNullLogger()
+	fsm := newTestFSM(t)
+	producer := replicationtest.NewFakeProducer(10)
+	copier := replicationtest.NewFakeCopier()
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	fsmUpdater.UpdateStatusFunc = func(id uint64, state api.ShardReplicationState) error {
+		return fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: id, State: state})
+	}
+
+	var copies atomic.Int32
+	copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		if copies.Add(1) == 1 {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+		func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3) }, 10*time.Second, 1)
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 10, 1, 10*time.Second,
+		replication.WithReplicationFSM(fsm), replication.WithOpTimeline(10))
+	require.Nil(t, engine.OpTimeline(1), "unknown op should have no timeline")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// WHEN
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+	require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(1, api.READY)))
+
+	// THEN
+	type event struct {
+		eventType replication.TimelineEventType
+		state     api.ShardReplicationState
+	}
+	var timeline []event
+	require.Eventually(t, func() bool {
+		events := engine.OpTimeline(1)
+		timeline = timeline[:0]
+		for _, e := range events {
+			timeline = append(timeline, event{eventType: e.Type, state: e.State})
+		}
+		return len(events) > 0 && events[len(events)-1].Type == replication.TimelineCompleted
+	}, 5*time.Second, 10*time.Millisecond, "timeline should end with the op completion")
+	require.Equal(t, []event{
+		{eventType: replication.TimelineStateChanged, state: api.REGISTERED},
+		{eventType: replication.TimelineQueued},
+		{eventType: replication.TimelineDequeued},
+		{eventType: replication.TimelineStateChanged, state: api.HYDRATING},
+		{eventType: replication.TimelineRetried},
+		{eventType: replication.TimelineStateChanged, state: api.FINALIZING},
+		{eventType: replication.TimelineStateChanged, state: api.READY},
+		{eventType: replication.TimelineCompleted},
+	}, timeline)
+
+	events := engine.OpTimeline(1)
+	require.Equal(t, "connection reset", events[4].Detail, "retry should record its cause")
+	for i := 1; i < len(events); i++ {
+		require.False(t, events[i].Time.Before(events[i-1].Time), "events should be chronological")
+	}
+
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+func TestShardReplicationEngine_Scheduler(t *testing.T) {
+	tests := []struct {
+		name      string
+		scheduler replication.Scheduler
+		// sources and targets optionally set the source and target node of each produced op, node1 and node2 by
+		// default
+		sources []string
+		targets []string
+		// priorities optionally sets the priority of each produced op, zero by default
+		priorities []int
+		expected   []uint64
+	}{
+		{
+			name:     "default scheduler dispatches ops in produced order",
+			expected: []uint64{1, 2, 3, 4, 5},
+		},
+		{
+			name:      "FIFO scheduler dispatches ops in produced order",
+			scheduler: replication.NewFIFOScheduler(),
+			expected:  []uint64{1, 2, 3, 4, 5},
+		},
+		{
+			// The first op is taken from the scheduler as soon as it is produced, waiting for the consumer
+			name:      "LIFO scheduler dispatches the most recently produced ops first",
+			scheduler: replication.NewLIFOScheduler(),
+			expected:  []uint64{1, 5, 4, 3, 2},
+		},
+		{
+			// The first op is taken from the scheduler as soon as it is produced, waiting for the consumer
+			name:      "round robin scheduler interleaves ops of a queue skewed toward one target node",
+			scheduler: replication.NewRoundRobinScheduler(),
+			targets:   []string{"node2", "node2", "node2", "node2", "node3"},
+			expected:  []uint64{1, 2, 5, 3, 4},
+		},
+		{
+			// The first op is taken from the scheduler as soon as it is produced, waiting for the consumer
+			name:       "priority scheduler dispatches a high priority op produced after low priority ones first",
+			scheduler:  replication.NewPriorityScheduler(),
+			priorities: []int{0, 0, 1, 0, 5},
+			expected:   []uint64{1, 5, 3, 2, 4},
+		},
+		{
+			// The first op is taken from the scheduler as soon as it is produced, waiting for the consumer
+			name:      "locality scheduler groups the queued ops by source node",
+			scheduler: replication.NewLocalityScheduler(6),
+			sources:   []string{"node1", "node3", "node1", "node3", "node1"},
+			expected:  []uint64{1, 2, 4, 3, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			logger, _ := logrustest.NewNullLogger()
+			mockProducer := replication.NewMockOpProducer(t)
+			mockConsumer := replication.NewMockOpConsumer(t)
+
+			consumedChan := make(chan []uint64, 1)
+			var engine *replication.ShardReplicationEngine
+
+			mockProducer.On("Produce", mock.Anything, mock.Anything).Run(
+				func(args mock.Arguments) {
+					ctx := args.Get(0).(context.Context)
+					opsChan := args.Get(1).(chan<- replication.ShardReplicationOp)
+					for id := uint64(1); id <= 5; id++ {
+						source, target := "node1", "node2"
+						if tt.sources != nil {
+							source = tt.sources[id-1]
+						}
+						if tt.targets != nil {
+							target = tt.targets[id-1]
+						}
+						op := replication.NewShardReplicationOp(id, source, target, "TestCollection", fmt.Sprintf("shard%d", id))
+						if tt.priorities != nil {
+							op.Priority = tt.priorities[id-1]
+						}
+						opsChan <- op
+					}
+					<-ctx.Done()
+				}).Once().Return(context.Canceled)
+
+			mockConsumer.On("Consume", mock.Anything, mock.Anything).Run(
+				func(args mock.Arguments) {
+					ctx := args.Get(0).(context.Context)
+					opsChan := args.Get(1).(<-chan replication.ShardReplicationOp)
+					require.Eventually(t, func() bool {
+						return engine.OpChannelLen() == 5
+					}, 5*time.Second, 10*time.Millisecond, "every op should be queued before consuming")
+
+					consumed := make([]uint64, 0, 5)
+					for i := 0; i < 5; i++ {
+						op := <-opsChan
+						consumed = append(consumed, op.ID)
+					}
+					consumedChan <- consumed
+					<-ctx.Done()
+				}).Once().Return(context.Canceled)
+
+			var opts []replication.ShardReplicationEngineOption
+			if tt.scheduler != nil {
+				opts = append(opts, replication.WithScheduler(tt.scheduler))
+			}
+			engine = replication.NewShardReplicationEngine(logger, "node2", mockProducer, mockConsumer, 10, 1, time.Minute, opts...)
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			var engineStartErr error
+			go func() {
+				defer wg.Done()
+				engineStartErr = engine.Start(context.Background())
+			}()
+
+			// WHEN
+			consumed := <-consumedChan
+			engine.Stop()
+			wg.Wait()
+
+			// THEN
+			require.NoError(t, engineStartErr)
+			require.Equal(t, tt.expected, consumed)
+		})
+	}
+}
+
+func TestRoundRobinScheduler(t *testing.T) {
+	// GIVEN a queue skewed toward one target node
+	scheduler := replication.NewRoundRobinScheduler()
+	targets := []string{"node2", "node2", "node2", "node2", "node3", "node2", "node4", "node3"}
+	for i, target := range targets {
+		id := uint64(i + 1)
+		scheduler.Enqueue(replication.NewShardReplicationOp(id, "node1", target, "TestCollection", fmt.Sprintf("shard%d", id)))
+	}
+
+	// WHEN
+	var dequeued []uint64
+	for op, ok := scheduler.Dequeue(); ok; op, ok = scheduler.Dequeue() {
+		dequeued = append(dequeued, op.ID)
+	}
+
+	// THEN one op per target node is taken per round
+	require.Equal(t, []uint64{1, 5, 7, 2, 8, 3, 4, 6}, dequeued)
+
+	// WHEN ops are queued again after draining the scheduler
+	scheduler.Enqueue(replication.NewShardReplicationOp(9, "node1", "node3", "TestCollection", "shard9"))
+
+	// THEN
+	op, ok := scheduler.Dequeue()
+	require.True(t, ok)
+	require.Equal(t, uint64(9), op.ID)
+}
+
+func TestRoundRobinScheduler_SnapshotAndRemove(t *testing.T) {
+	// GIVEN a queue skewed toward one target node, partially dequeued
+	scheduler := replication.NewRoundRobinScheduler()
+	targets := []string{"node2", "node2", "node2", "node2", "node3", "node2", "node4", "node3"}
+	ops := make([]replication.ShardReplicationOp, 0, len(targets))
+	for i, target := range targets {
+		id := uint64(i + 1)
+		op := replication.NewShardReplicationOp(id, "node1", target, "TestCollection", fmt.Sprintf("shard%d", id))
+		ops = append(ops, op)
+		scheduler.Enqueue(op)
+	}
+	for range 2 {
+		_, ok := scheduler.Dequeue()
+		require.True(t, ok)
+	}
+	opIDs := func(ops []replication.ShardReplicationOp) []uint64 {
+		ids := make([]uint64, 0, len(ops))
+		for _, op := range ops {
+			ids = append(ids, op.ID)
+		}
+		return ids
+	}
+
+	// THEN the snapshot lists the ops in dispatch order without removing them
+	require.Equal(t, []uint64{7, 2, 8, 3, 4, 6}, opIDs(scheduler.Snapshot()))
+	require.Equal(t, []uint64{7, 2, 8, 3, 4, 6}, opIDs(scheduler.Snapshot()))
+
+	// WHEN the last op of a target node already served in the round is removed
+	require.True(t, scheduler.Remove(ops[7]))
+	require.False(t, scheduler.Remove(ops[7]), "the op should not be queued anymore")
+
+	// THEN the round position is kept
+	require.Equal(t, []uint64{7, 2, 3, 4, 6}, opIDs(scheduler.Snapshot()))
+	var dequeued []uint64
+	for op, ok := scheduler.Dequeue(); ok; op, ok = scheduler.Dequeue() {
+		dequeued = append(dequeued, op.ID)
+	}
+	require.Equal(t, []uint64{7, 2, 3, 4, 6}, dequeued)
+}
+
+func TestLocalityScheduler(t *testing.T) {
+	// GIVEN ops interleaving two source nodes, more than fit in a window
+	scheduler := replication.NewLocalityScheduler(6)
+	sources := []string{"node1", "node2", "node1", "node2", "node1", "node2", "node2", "node1"}
+	for i, source := range sources {
+		id := uint64(i + 1)
+		scheduler.Enqueue(replication.NewShardReplicationOp(id, source, "node3", "TestCollection", fmt.Sprintf("shard%d", id)))
+	}
+
+	// WHEN
+	var dequeued []uint64
+	for op, ok := scheduler.Dequeue(); ok; op, ok = scheduler.Dequeue() {
+		dequeued = append(dequeued, op.ID)
+	}
+
+	// THEN the ops of each window are grouped by source node, in order of their first op
+	require.Equal(t, []uint64{1, 3, 5, 2, 4, 6, 7, 8}, dequeued)
+}
+
+func TestShardReplicationEngine_ImpactOfRemovingNode(t *testing.T) {
+	// GIVEN
+	logger, _ := logrustest.NewNullLogger()
+	fsm := newTestFSM(t)
+	engine := replication.NewShardReplicationEngine(logger, "node1", replication.NewMockOpProducer(t), replication.NewMockOpConsumer(t),
+		10, 1, time.Minute, replication.WithReplicationFSM(fsm))
+
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "CollectionA", "shard1")))
+	require.NoError(t, fsm.Replicate(2, replicateRequest("node3", "node1", "CollectionB", "shard2")))
+	require.NoError(t, fsm.Replicate(3, replicateRequest("node1", "node3", "CollectionA", "shard3")))
+	require.NoError(t, fsm.Replicate(4, replicateRequest("node2", "node3", "CollectionA", "shard4")))
+	require.NoError(t, fsm.Replicate(5, replicateRequest("node1", "node4", "CollectionA", "shard5")))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 3, State: api.HYDRATING}))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 5, State: api.READY}))
+
+	// WHEN
+	impact := engine.ImpactOfRemovingNode("node1")
+
+	// THEN
+	require.Equal(t, replication.NodeRemovalImpact{
+		Node:      "node1",
+		SourceOps: []uint64{1, 3},
+		TargetOps: []uint64{2},
+		AffectedShards: []replication.ShardRef{
+			{Collection: "CollectionA", Shard: "shard1"},
+			{Collection: "CollectionA", Shard: "shard3"},
+			{Collection: "CollectionB", Shard: "shard2"},
+		},
+	}, impact, "completed ops and ops not involving the node should not be affected")
+	require.Equal(t, replication.NodeRemovalImpact{Node: "node5"}, engine.ImpactOfRemovingNode("node5"))
+	require.Equal(t, 5, fsm.CountOps(func(replication.ShardReplicationOp, api.ShardReplicationState) bool { return true }),
+		"dry-run should not mutate the FSM")
+}
+
+func TestShardReplicationEngine_CancelOpsForSourceFQDN(t *testing.T) {
+	// GIVEN ops copying the same source replica to the node of the engine and to another node, alongside ops of other
+	// shards and source nodes, while an op of another shard is being copied
+	logger, _ := logrustest.NewNullLogger()
+	fsm := newTestFSM(t)
+	ops := map[uint64]replication.ShardReplicationOp{
+		1: replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"),
+		2: replication.NewShardReplicationOp(2, "node1", "node3", "TestCollection", "shard1"),
+		3: replication.NewShardReplicationOp(3, "node1", "node2", "TestCollection", "shard2"),
+		4: replication.NewShardReplicationOp(4, "node3", "node2", "OtherCollection", "shard1"),
+	}
+	for id := uint64(1); id <= 4; id++ {
+		op := ops[id]
+		require.NoError(t, fsm.Replicate(op.ID, replicateRequest(op.SourceNode(), op.TargetNode(), op.Collection(), op.Shard())))
+	}
+	opState := func(id uint64) api.ShardReplicationState {
+		_, status, ok := fsm.GetOpWithStatus(id)
+		require.True(t, ok)
+		return status.State()
+	}
+
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	fsmUpdater.UpdateStatusFunc = func(id uint64, state api.ShardReplicationState) error {
+		return fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: id, State: state})
+	}
+	copying := make(chan struct{})
+	release := make(chan struct{})
+	copier := replicationtest.NewFakeCopier()
+	copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		if shard == "shard2" {
+			close(copying)
+			<-release
+		}
+		return nil
+	}
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+		func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 1)
+	producer := replicationtest.NewFakeProducer(10)
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 10, 1, time.Minute,
+		replication.WithReplicationFSM(fsm))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	producer.Submit(ops[3])
+	<-copying
+	producer.Submit(ops[1])
+	producer.Submit(ops[4])
+
+	// WHEN
+	canceled := engine.CancelOpsForSourceFQDN("node1", "TestCollection", "shard1")
+	close(release)
+
+	// THEN only the op copying the source replica to the node of the engine is canceled, durably through the FSM
+	require.Equal(t, 1, canceled)
+	require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(3, api.READY)))
+	require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(4, api.READY)))
+	require.Equal(t, api.ABORTED, opState(1))
+	require.Equal(t, api.REGISTERED, opState(2), "ops copying to other nodes are canceled by their own engine")
+	require.Zero(t, engine.CancelOpsForSourceFQDN("node1", "TestCollection", "shard1"), "the ops should only be canceled once")
+
+	// WHEN the canceled op is emitted again
+	producer.Submit(ops[1])
+
+	// THEN it is discarded and never copied
+	require.Eventually(t, func() bool {
+		return engine.Stats().Produced == 4 && engine.OpChannelLen() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	for _, call := range copier.Calls() {
+		require.NotEqual(t, replicationtest.CopyCall{SourceNode: "node1", Collection: "TestCollection", Shard: "shard1"}, call)
+	}
+	require.Equal(t, api.ABORTED, opState(1))
+
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+func TestShardReplicationEngine_OpBlockReason(t *testing.T) {
+	newEngine := func(t *testing.T, copyFunc func(ctx context.Context, sourceNode, collection, shard string) error, opts ...replication.CopyOpConsumerOption) (*replication.ShardReplicationEngine, *replicationtest.FakeProducer, *replicationtest.FakeFSMUpdater, func()) {
+		logger, _ := logrustest.NewNullLogger()
+		producer := replicationtest.NewFakeProducer(16)
+		copier := replicationtest.NewFakeCopier()
+		copier.CopyFunc = copyFunc
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 1, opts...)
+		engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 16, 1, 10*time.Second)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+		require.Eventually(t, engine.IsRunning, 5*time.Second, 10*time.Millisecond, "engine should start")
+		stop := func() {
+			engine.Stop()
+			wg.Wait()
+			require.NoError(t, engineStartErr)
+		}
+		return engine, producer, fsmUpdater, stop
+	}
+
+	blockReason := func(engine *replication.ShardReplicationEngine, id uint64) func() string {
+		return func() string {
+			reason, _ := engine.OpBlockReason(id)
+			return reason
+		}
+	}
+
+	t.Run("ops gated by the worker limit and queued in the engine report why they are blocked", func(t *testing.T) {
+		// GIVEN
+		release := make(chan struct{})
+		engine, producer, fsmUpdater, stop := newEngine(t, func(ctx context.Context, sourceNode, collection, shard string) error {
+			if shard != "shard1" {
+				return nil
+			}
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		defer stop()
+
+		// WHEN
+		producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+		require.Eventually(t, func() bool {
+			return slices.Equal([]uint64{1}, engine.InFlightOps())
+		}, 5*time.Second, 10*time.Millisecond, "op 1 should hold the only worker")
+		producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+		producer.Submit(replication.NewShardReplicationOp(3, "node1", "node2", "TestCollection", "shard3"))
+
+		// THEN
+		require.Eventually(t, func() bool {
+			return blockReason(engine, 2)() == "waiting for a free worker"
+		}, 5*time.Second, 10*time.Millisecond, "op 2 should wait for the busy worker")
+		require.Eventually(t, func() bool {
+			return blockReason(engine, 3)() == "waiting in the replication engine queue"
+		}, 5*time.Second, 10*time.Millisecond, "op 3 should still be queued in the engine")
+		reason, blocked := engine.OpBlockReason(1)
+		require.False(t, blocked, "op 1 is being processed, got reason %q", reason)
+		reason, blocked = engine.OpBlockReason(42)
+		require.False(t, blocked, "unknown op should not be blocked, got reason %q", reason)
+
+		// WHEN
+		close(release)
+
+		// THEN
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		for _, id := range []uint64{1, 2, 3} {
+			require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(id, api.READY)))
+		}
+		require.Eventually(t, func() bool {
+			for _, id := range []uint64{1, 2, 3} {
+				if _, blocked := engine.OpBlockReason(id); blocked {
+					return false
+				}
+			}
+			return true
+		}, 5*time.Second, 10*time.Millisecond, "completed ops should not be reported as blocked")
+	})
+
+	t.Run("paused op reports it is paused until resumed", func(t *testing.T) {
+		// GIVEN
+		engine, producer, fsmUpdater, stop := newEngine(t, nil)
+		defer stop()
+		require.True(t, engine.PauseOp(1))
+
+		// WHEN
+		producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+
+		// THEN
+		require.Eventually(t, func() bool {
+			return blockReason(engine, 1)() == "paused, held until resumed"
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// WHEN
+		require.True(t, engine.ResumeOp(1))
+
+		// THEN
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(1, api.READY)))
+		require.Eventually(t, func() bool {
+			_, blocked := engine.OpBlockReason(1)
+			return !blocked
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("op throttled by the cluster load reports the cluster is saturated", func(t *testing.T) {
+		// GIVEN
+		loadProvider := &fakeClusterLoadProvider{}
+		loadProvider.load.Store(5)
+		engine, producer, fsmUpdater, stop := newEngine(t, nil,
+			replication.WithClusterLoadThrottling(loadProvider, 5, 10*time.Millisecond))
+		defer stop()
+
+		// WHEN
+		producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+
+		// THEN
+		require.Eventually(t, func() bool {
+			return blockReason(engine, 1)() == "waiting for the cluster-wide replication load to drop below the configured maximum"
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// WHEN
+		loadProvider.load.Store(0)
+
+		// THEN
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(1, api.READY)))
+	})
+}
+
+func TestShardReplicationEngine_RapidStopStart(t *testing.T) {
+	// GIVEN
+	logger, _ := logrustest.NewNullLogger()
+	producer := replicationtest.NewFakeProducer(1024)
+	copier := replicationtest.NewFakeCopier()
+	copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		select {
+		case <-time.After(time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), copier,
+		replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 2)
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 4, 2, 10*time.Second)
+
+	nextOpID := uint64(0)
+	for cycle := 0; cycle < 50; cycle++ {
+		for i := 0; i < 8; i++ {
+			nextOpID++
+			producer.Submit(replication.NewShardReplicationOp(nextOpID, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", nextOpID)))
+		}
+
+		// WHEN
+		startErr := make(chan error, 1)
+		go func() {
+			startErr <- engine.Start(context.Background())
+		}()
+		if cycle%2 == 0 {
+			// Letting some ops reach the consumer before stopping
+			require.Eventually(t, engine.IsRunning, 5*time.Second, time.Millisecond)
+			time.Sleep(time.Duration(cycle%5) * time.Millisecond)
+		}
+
+		// Stopping right after starting races with the start itself, hence stopping until the engine has returned
+		stopped := false
+		for !stopped {
+			engine.Stop()
+			select {
+			case err := <-startErr:
+				require.NoError(t, err)
+				stopped = true
+			case <-time.After(time.Millisecond):
+			}
+		}
+
+		// THEN
+		require.False(t, engine.IsRunning(), "cycle %d: engine should not be running after stop", cycle)
+		require.Zero(t, engine.OpChannelLen(), "cycle %d: no op should be left queued", cycle)
+		require.Empty(t, engine.InFlightOps(), "cycle %d: no op should be left in flight", cycle)
+		require.Zero(t, consumer.BusyWorkers(), "cycle %d: every worker token should be released", cycle)
+	}
+}
+
+func TestShardReplicationEngine_ReplayRecentFailures(t *testing.T) {
+	// GIVEN
+	logger, _ := logrustest.NewNullLogger()
+	inMemory := replicationtest.NewInMemoryEngine(logger, "node2", replication.WithFailedOpsHistory(10))
+
+	var failing atomic.Bool
+	failing.Store(true)
+	inMemory.Copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		if failing.Load() {
+			return errors.New("source unreachable")
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = inMemory.Engine.Start(context.Background())
+	}()
+
+	// Failing the ops one at a time for their failure order to be deterministic
+	for id := uint64(1); id <= 4; id++ {
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id)))
+		require.Eventually(t, func() bool {
+			return len(inMemory.Copier.Calls()) == int(id) && len(inMemory.Engine.InFlightOps()) == 0
+		}, 5*time.Second, 10*time.Millisecond, "op %d should fail", id)
+	}
+	failing.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// WHEN
+	requeued := inMemory.Engine.ReplayRecentFailures(2)
+
+	// THEN only the two most recent failures are requeued
+	require.Equal(t, 2, requeued)
+	require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(3, api.READY)))
+	require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(4, api.READY)))
+	for _, id := range []uint64{1, 2} {
+		state, _ := inMemory.FSMUpdater.State(id)
+		require.Equal(t, api.HYDRATING, state, "op %d should not be requeued", id)
+	}
+
+	// WHEN replaying more failures than left
+	requeued = inMemory.Engine.ReplayRecentFailures(5)
+
+	// THEN the remaining failures are requeued and the replayed ones are not replayed again
+	require.Equal(t, 2, requeued)
+	require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(1, api.READY)))
+	require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(2, api.READY)))
+	require.Zero(t, inMemory.Engine.ReplayRecentFailures(5))
+
+	inMemory.Engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+func TestShardReplicationEngine_LimitsSnapshot(t *testing.T) {
+	// GIVEN
+	logger, _ := logrustest.NewNullLogger()
+	producer := replicationtest.NewFakeProducer(16)
+	copier := replicationtest.NewFakeCopier()
+	release := make(chan struct{})
+	copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	ticks := make(chan time.Time)
+	loadProvider := &fakeClusterLoadProvider{}
+	consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), copier,
+		replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 3,
+		replication.WithAdaptiveWorkers(1, replication.QueueDepthScalingPolicy{GrowAboveDepth: 0}, ticks),
+		replication.WithClusterLoadThrottling(loadProvider, 20, time.Second))
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 16, 3, 10*time.Second,
+		replication.WithMaxQueuedOps(8), replication.WithOverflowPolicy(replication.DropNewest))
+
+	// WHEN
+	limits := engine.LimitsSnapshot()
+
+	// THEN the snapshot reflects the configured limits
+	require.Equal(t, replication.LimitsView{
+		OpBufferSize:          16,
+		MaxQueuedOps:          8,
+		OverflowPolicy:        replication.DropNewest,
+		MaxWorkers:            3,
+		Workers:               1,
+		MinWorkers:            1,
+		MaxClusterInFlightOps: 20,
+	}, limits)
+
+	// WHEN the worker limit grows at runtime while ops are queued
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+	for i := 1; i <= 4; i++ {
+		producer.Submit(replication.NewShardReplicationOp(uint64(i), "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", i)))
+	}
+	require.Eventually(t, func() bool {
+		return engine.OpChannelLen() > 0 && len(engine.InFlightOps()) == 1
+	}, 5*time.Second, 10*time.Millisecond, "ops should be waiting for the single worker")
+	ticks <- time.Now()
+
+	// THEN the snapshot reflects the new worker limit
+	require.Eventually(t, func() bool {
+		return engine.LimitsSnapshot().Workers == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	close(release)
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+func TestShardReplicationEngine_ErrorAggregation(t *testing.T) {
+	tests := []struct {
+		name             string
+		aggregation      replication.ErrorAggregation
+		wantBothFailures bool
+	}{
+		{name: "first error only reports one failure", aggregation: replication.FirstError, wantBothFailures: false},
+		{name: "joined errors report both failures", aggregation: replication.JoinedErrors, wantBothFailures: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			mockProducer := replication.NewMockOpProducer(t)
+			mockConsumer := replication.NewMockOpConsumer(t)
+
+			// Both the producer and the consumer fail once both are running
+			var bothStarted sync.WaitGroup
+			bothStarted.Add(2)
+			mockProducer.On("Produce", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+				bothStarted.Done()
+				bothStarted.Wait()
+			}).Return(errors.New("unexpected producer error"))
+			mockConsumer.On("Consume", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+				bothStarted.Done()
+				bothStarted.Wait()
+			}).Return(errors.New("unexpected consumer error"))
+
+			logger, _ := logrustest.NewNullLogger()
+			engine := replication.NewShardReplicationEngine(logger, "node1", mockProducer, mockConsumer, 1, 1, time.Minute,
+				replication.WithErrorAggregation(tt.aggregation))
+
+			// WHEN
+			err := engine.Start(context.Background())
+
+			// THEN
+			require.Error(t, err)
+			producerFailed := strings.Contains(err.Error(), "unexpected producer error")
+			consumerFailed := strings.Contains(err.Error(), "unexpected consumer error")
+			if tt.wantBothFailures {
+				require.True(t, producerFailed && consumerFailed, "both failures should be reported, got: %v", err)
+			} else {
+				require.True(t, producerFailed != consumerFailed, "a single failure should be reported, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestShardReplicationEngine_ProducerThrottling(t *testing.T) {
+	// GIVEN
+	logger, _ := logrustest.NewNullLogger()
+	producer := replicationtest.NewFakeProducer(64)
+	copier := replicationtest.NewFakeCopier()
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+		func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 4)
+	probeInterval := 100 * time.Millisecond
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 64, 4, 10*time.Second,
+		replication.WithProducerThrottling(4, 0.5, probeInterval))
+
+	var failing atomic.Bool
+	failing.Store(true)
+	copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		if failing.Load() {
+			return errors.New("target disk full")
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+
+	// WHEN the consumer fails every operation
+	const opsCount = 40
+	submit := func(from, to uint64) {
+		for id := from; id <= to; id++ {
+			producer.Submit(replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id)))
+		}
+	}
+	submit(1, 4)
+
+	// THEN the producer is throttled and only a single op per probe interval is consumed
+	require.Eventually(t, engine.ProducerThrottled, 5*time.Second, 10*time.Millisecond)
+	submit(5, opsCount)
+	consumedBefore := len(copier.Calls())
+	time.Sleep(5 * probeInterval)
+	consumedWhileThrottled := len(copier.Calls()) - consumedBefore
+	require.LessOrEqual(t, consumedWhileThrottled, 7, "the producer should be throttled")
+	require.Less(t, len(copier.Calls()), opsCount)
+	require.True(t, engine.ProducerThrottled())
+
+	// WHEN the consumer recovers
+	failing.Store(false)
+
+	// THEN the producer is not throttled anymore and every op is consumed
+	require.Eventually(t, func() bool {
+		return len(copier.Calls()) == opsCount && !engine.ProducerThrottled()
+	}, 10*time.Second, 10*time.Millisecond)
+
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+func TestShardReplicationEngine_ProducerThrottlingProbe(t *testing.T) {
+	// GIVEN a throttled engine, as its consumer fails every operation, probing the producer with the engine timer
+	logger, _ := logrustest.NewNullLogger()
+	producer := replicationtest.NewFakeProducer(64)
+	copier := replicationtest.NewFakeCopier()
+	copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		return errors.New("target disk full")
+	}
+	consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), copier, replication.RealTimeProvider{},
+		"node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 4)
+	clock := replicationtest.NewFakeClock(time.Now())
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 64, 4, 10*time.Second,
+		replication.WithProducerThrottling(4, 0.5, time.Minute), replication.WithEngineTimer(clock))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+	submit := func(from, to uint64) {
+		for id := from; id <= to; id++ {
+			producer.Submit(replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id)))
+		}
+	}
+	submit(1, 4)
+	require.Eventually(t, engine.ProducerThrottled, 5*time.Second, time.Millisecond)
+	submit(5, 20)
+	settled := func() bool {
+		stats := engine.Stats()
+		return clock.Waiters() == 1 && stats.Failed == stats.Produced
+	}
+	require.Eventually(t, settled, 5*time.Second, time.Millisecond)
+	processed := engine.Stats().Produced
+
+	// WHEN the probe interval did not elapse
+	clock.Advance(time.Minute - time.Millisecond)
+
+	// THEN no op is accepted
+	require.Never(t, func() bool { return engine.Stats().Produced > processed }, 100*time.Millisecond, time.Millisecond)
+
+	// WHEN
+	clock.Advance(time.Millisecond)
+
+	// THEN a single op is accepted before the next probe
+	require.Eventually(t, func() bool { return settled() && engine.Stats().Produced == processed+1 }, 5*time.Second, time.Millisecond)
+	require.Never(t, func() bool { return engine.Stats().Produced > processed+1 }, 100*time.Millisecond, time.Millisecond)
+
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+func TestShardReplicationEngine_CompareReplicas(t *testing.T) {
+	tests := []struct {
+		name    string
+		copier  types.ReplicaCopier
+		want    replication.ConsistencyReport
+		wantErr error
+	}{
+		{
+			name: "matching replicas",
+			copier: &verifyingReplicaCopier{
+				counts:    map[string]int64{"node1": 100, "node2": 100},
+				checksums: map[string]string{"node1": "a1b2", "node2": "a1b2"},
+			},
+			want: replication.ConsistencyReport{
+				ObjectsCompared: true, ObjectCountA: 100, ObjectCountB: 100,
+				ChecksumsCompared: true, ChecksumA: "a1b2", ChecksumB: "a1b2",
+				Consistent: true,
+			},
+		},
+		{
+			name: "replicas with the same object count but different checksums",
+			copier: &verifyingReplicaCopier{
+				counts:    map[string]int64{"node1": 100, "node2": 100},
+				checksums: map[string]string{"node1": "a1b2", "node2": "c3d4"},
+			},
+			want: replication.ConsistencyReport{
+				ObjectsCompared: true, ObjectCountA: 100, ObjectCountB: 100,
+				ChecksumsCompared: true, ChecksumA: "a1b2", ChecksumB: "c3d4",
+				Consistent: false,
+			},
+		},
+		{
+			name: "replicas with different object counts compared without checksums",
+			copier: &objectCountingReplicaCopier{
+				counts: map[string][]int64{"node1": {100}, "node2": {98}},
+			},
+			want: replication.ConsistencyReport{
+				ObjectsCompared: true, ObjectCountA: 100, ObjectCountB: 98,
+				Consistent: false,
+			},
+		},
+		{
+			name:    "copier without verification primitives",
+			copier:  replicationtest.NewFakeCopier(),
+			wantErr: replication.ErrReplicaComparisonUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			logger, _ := logrustest.NewNullLogger()
+			consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), tt.copier,
+				replication.RealTimeProvider{}, "node3", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 1)
+			engine := replication.NewShardReplicationEngine(logger, "node3", replicationtest.NewFakeProducer(1), consumer,
+				1, 1, 10*time.Second)
+
+			// WHEN
+			report, err := engine.CompareReplicas(context.Background(), "node1", "node2", "TestCollection", "shard1")
+
+			// THEN
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tt.want.NodeA, tt.want.NodeB, tt.want.Collection, tt.want.Shard = "node1", "node2", "TestCollection", "shard1"
+			require.Equal(t, tt.want, report)
+		})
+	}
+}
+
+// verifyingReplicaCopier is a replica copier reporting fixed object counts and checksums per node.
+type verifyingReplicaCopier struct {
+	counts    map[string]int64
+	checksums map[string]string
+}
+
+func (c *verifyingReplicaCopier) CopyReplica(context.Context, string, string, string) error {
+	return nil
+}
+
+func (c *verifyingReplicaCopier) CountObjects(_ context.Context, node, _, _ string) (int64, error) {
+	return c.counts[node], nil
+}
+
+func (c *verifyingReplicaCopier) ReplicaChecksum(_ context.Context, node, _, _ string) (string, error) {
+	return c.checksums[node], nil
+}
+
+func TestShardReplicationEngine_CollectionAllowList(t *testing.T) {
+	// GIVEN
+	logger, _ := logrustest.NewNullLogger()
+	inMemory := replicationtest.NewInMemoryEngine(logger, "node2", replication.WithCollectionAllowList("Allowed", "AlsoAllowed"))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = inMemory.Engine.Start(context.Background())
+	}()
+
+	// WHEN
+	inMemory.Producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "Allowed", "shard1"))
+	inMemory.Producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "Deferred", "shard1"))
+	inMemory.Producer.Submit(replication.NewShardReplicationOp(3, "node1", "node2", "AlsoAllowed", "shard1"))
+	inMemory.Producer.Submit(replication.NewShardReplicationOp(4, "node1", "node2", "Deferred", "shard2"))
+
+	// THEN only the ops of allow-listed collections are processed
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(1, api.READY)))
+	require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(3, api.READY)))
+
+	inMemory.Engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+
+	for _, id := range []uint64{2, 4} {
+		require.Empty(t, inMemory.FSMUpdater.StateHistory(id), "op %d should remain queued", id)
+	}
+	require.ElementsMatch(t, []replicationtest.CopyCall{
+		{SourceNode: "node1", Collection: "Allowed", Shard: "shard1"},
+		{SourceNode: "node1", Collection: "AlsoAllowed", Shard: "shard1"},
+	}, inMemory.Copier.Calls())
+}
+
+func TestShardReplicationEngine_EstimatedDrainTime(t *testing.T) {
+	// GIVEN a consumer completing an op every 10 seconds
+	logger, _ := logrustest.NewNullLogger()
+	clock := replicationtest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	producer := replicationtest.NewFakeProducer(16)
+	copier := replicationtest.NewFakeCopier()
+	release := make(chan struct{})
+	copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		if shard == "blocked" {
+			<-release
+		}
+		clock.Advance(10 * time.Second)
+		return nil
+	}
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, clock, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} },
+		10*time.Second, 1)
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 16, 1, 10*time.Second)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+
+	// WHEN there is no throughput history
+	_, ok := engine.EstimatedDrainTime()
+
+	// THEN
+	require.True(t, ok, "an empty backlog is drained right away")
+	producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "blocked"))
+	require.Eventually(t, func() bool {
+		return len(engine.InFlightOps()) == 1
+	}, 5*time.Second, time.Millisecond)
+	_, ok = engine.EstimatedDrainTime()
+	require.False(t, ok, "the drain time cannot be estimated without throughput history")
+	release <- struct{}{}
+
+	// WHEN the consumer completed a few ops and a backlog builds up
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for id := uint64(2); id <= 5; id++ {
+		producer.Submit(replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id)))
+	}
+	require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(5, api.READY)))
+	for id := uint64(6); id <= 10; id++ {
+		producer.Submit(replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", "blocked"))
+	}
+
+	// THEN the 5 ops of the backlog are estimated to be processed at the rate of an op every 10 seconds
+	require.Eventually(t, func() bool {
+		estimate, ok := engine.EstimatedDrainTime()
+		return ok && estimate == 50*time.Second
+	}, 5*time.Second, time.Millisecond)
+
+	close(release)
+	require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(10, api.READY)))
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+func TestShardReplicationEngine_CurrentThroughput(t *testing.T) {
+	// GIVEN a consumer measuring its throughput over a 10 seconds window of its fake clock
+	const metricName = "weaviate_replication_throughput_bytes_per_second"
+	reg := prometheus.NewPedanticRegistry()
+	logger, _ := logrustest.NewNullLogger()
+	clock := replicationtest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	producer := replicationtest.NewFakeProducer(16)
+	copier := &fakeSizedReplicaCopier{sizes: map[string]int64{"shard1": 1000, "shard2": 3000}}
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, clock, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} },
+		10*time.Second, 1, replication.WithThroughputWindow(10*time.Second))
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 16, 1, 10*time.Second,
+		replication.WithEngineRegisterer(reg))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+
+	// WHEN nothing was copied yet
+	// THEN
+	require.Zero(t, engine.CurrentThroughput())
+
+	// WHEN two copies complete 5 seconds apart
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+	require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(1, api.READY)))
+	clock.Advance(5 * time.Second)
+	producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+	require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(2, api.READY)))
+
+	// THEN the bytes of both copies are averaged over the window
+	require.Equal(t, 400.0, engine.CurrentThroughput())
+	require.Equal(t, 400.0, gatheredGaugeValue(t, reg, metricName))
+
+	// THEN the first copy leaves the window once older than the window
+	clock.Advance(6 * time.Second)
+	require.Equal(t, 300.0, engine.CurrentThroughput())
+	clock.Advance(5 * time.Second)
+	require.Zero(t, engine.CurrentThroughput())
+	require.Zero(t, gatheredGaugeValue(t, reg, metricName))
+
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+func TestShardReplicationEngine_ReplicationFlag(t *testing.T) {
+	// GIVEN an engine honoring a replication flag disabled by the control plane
+	logger, _ := logrustest.NewNullLogger()
+	producer := replicationtest.NewFakeProducer(16)
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, replicationtest.NewFakeCopier(),
+		replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 1)
+	flag := &fakeFlagProvider{}
+	ticks := make(chan time.Time)
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 16, 1, 10*time.Second,
+		replication.WithReplicationFlag(flag, ticks))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+	require.Eventually(t, engine.IsPaused, 5*time.Second, time.Millisecond)
+
+	// WHEN an op is produced while replication is disabled
+	producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+
+	// THEN it is queued but not processed
+	require.Eventually(t, func() bool {
+		return engine.OpChannelLen() == 1
+	}, 5*time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	_, processed := fsmUpdater.State(1)
+	require.False(t, processed, "no op should be processed while the engine is paused")
+
+	// WHEN the control plane enables replication
+	flag.enabled.Store(true)
+	ticks <- time.Now()
+
+	// THEN the engine resumes and processes the queued op
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(1, api.READY)))
+	require.False(t, engine.IsPaused())
+
+	// WHEN the control plane disables replication again
+	flag.enabled.Store(false)
+	ticks <- time.Now()
+
+	// THEN the engine pauses again
+	require.Eventually(t, engine.IsPaused, 5*time.Second, time.Millisecond)
+	producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+	require.Eventually(t, func() bool {
+		return engine.OpChannelLen() == 1
+	}, 5*time.Second, time.Millisecond)
+	_, processed = fsmUpdater.State(2)
+	require.False(t, processed, "no op should be processed while the engine is paused")
+
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+// fakeFlagProvider reports replication as enabled once enabled is set.
+type fakeFlagProvider struct {
+	enabled atomic.Bool
+}
+
+func (p *fakeFlagProvider) ReplicationEnabled() bool {
+	return p.enabled.Load()
+}
+
+func TestShardReplicationEngine_StreamSink(t *testing.T) {
+	// GIVEN an engine streaming to a sink which is unavailable, and tracing the same events
+	logger, _ := logrustest.NewNullLogger()
+	producer := replicationtest.NewFakeProducer(16)
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, replicationtest.NewFakeCopier(),
+		replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 1)
+	sink := &fakeStreamSink{}
+	var trace bytes.Buffer
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 16, 1, 10*time.Second,
+		replication.WithStreamSink(sink, 100, time.Millisecond),
+		replication.WithEventTrace(&trace, replication.RealTimeProvider{}))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+
+	// WHEN ops replicating two shards are processed while the sink is unavailable
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shards := map[uint64]string{1: "shard1", 2: "shard2", 3: "shard1", 4: "shard2"}
+	for id := uint64(1); id <= 4; id++ {
+		producer.Submit(replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", shards[id]))
+	}
+	for id := uint64(1); id <= 4; id++ {
+		require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(id, api.READY)))
+	}
+
+	// THEN the records are buffered
+	require.Eventually(t, func() bool {
+		return sink.failures.Load() > 1
+	}, 5*time.Second, time.Millisecond)
+	require.Empty(t, sink.appended())
+
+	// WHEN the sink becomes available
+	sink.available.Store(true)
+
+	// THEN every buffered record is appended, in the order the events occurred for each shard
+	require.Eventually(t, func() bool {
+		return len(sink.appended()) == 12
+	}, 5*time.Second, time.Millisecond, "each op should stream its queued, dequeued and completed events")
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+
+	want := map[string][]string{}
+	decoder := json.NewDecoder(&trace)
+	for decoder.More() {
+		var record replication.TraceRecord
+		require.NoError(t, decoder.Decode(&record))
+		key := "TestCollection/" + shards[record.OpID]
+		want[key] = append(want[key], fmt.Sprintf("%d %s", record.OpID, record.Event))
+	}
+	got := map[string][]string{}
+	for _, record := range sink.appended() {
+		got[record.Key] = append(got[record.Key], fmt.Sprintf("%d %s", record.OpID, record.Event))
+	}
+	require.Len(t, got, 2)
+	require.Equal(t, want, got)
+}
+
+// fakeStreamSink is a replication.StreamSink failing to append records until available, and recording the appended
+// records otherwise.
+type fakeStreamSink struct {
+	available atomic.Bool
+	failures  atomic.Int32
+	mu        sync.Mutex
+	records   []replication.StreamRecord
+}
+
+func (s *fakeStreamSink) Append(_ context.Context, record replication.StreamRecord) error {
+	if !s.available.Load() {
+		s.failures.Add(1)
+		return errors.New("sink unavailable")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *fakeStreamSink) appended() []replication.StreamRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.records)
+}
+
+func TestShardReplicationEngine_ProducerPollInterval(t *testing.T) {
+	logger, _ := logrustest.NewNullLogger()
+
+	t.Run("poll interval is wired to the FSM producer", func(t *testing.T) {
+		// GIVEN an FSM producer created with a long polling interval, overridden by the engine
+		fsm := newTestFSM(t)
+		producer := replication.NewFSMOpProducer(logger, fsm, time.Hour, "node2")
+		consumer := &recordingConsumer{consumed: make(chan replication.ShardReplicationOp, 1)}
+		engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 16, 1, time.Minute,
+			replication.WithProducerPollInterval(10*time.Millisecond))
+		require.Equal(t, 10*time.Millisecond, engine.ProducerPollInterval())
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+
+		// WHEN an op is registered
+		require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+
+		// THEN it is emitted at the configured interval
+		select {
+		case op := <-consumer.consumed:
+			require.Equal(t, uint64(1), op.ID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the op should be emitted without waiting for the producer polling interval")
+		}
+		engine.Stop()
+		wg.Wait()
+		require.NoError(t, engineStartErr)
+	})
+
+	t.Run("zero poll interval falls back to the default", func(t *testing.T) {
+		// GIVEN
+		producer := replication.NewFSMOpProducer(logger, newTestFSM(t), time.Hour, "node2")
+
+		// WHEN
+		engine := replication.NewShardReplicationEngine(logger, "node2", producer, &recordingConsumer{}, 16, 1,
+			time.Minute, replication.WithProducerPollInterval(0))
+
+		// THEN
+		require.Equal(t, 5*time.Second, engine.ProducerPollInterval())
+	})
+
+	t.Run("poll interval is unknown for producers not polling", func(t *testing.T) {
+		// GIVEN
+		engine := replication.NewShardReplicationEngine(logger, "node2", replicationtest.NewFakeProducer(1),
+			&recordingConsumer{}, 16, 1, time.Minute, replication.WithProducerPollInterval(time.Second))
+
+		// THEN
+		require.Zero(t, engine.ProducerPollInterval())
+	})
+}
+
+func TestShardReplicationEngine_FatalErrorLatch(t *testing.T) {
+	// GIVEN
+	logger, _ := logrustest.NewNullLogger()
+	mockProducer := replication.NewMockOpProducer(t)
+	mockConsumer := replication.NewMockOpConsumer(t)
+	engine := replication.NewShardReplicationEngine(logger, "node1", mockProducer, mockConsumer, 1, 1, 1*time.Minute)
+
+	blockUntilDone := func(args mock.Arguments) {
+		<-args.Get(0).(context.Context).Done()
+	}
+	mockProducer.On("Produce", mock.Anything, mock.Anything).Run(blockUntilDone).Return(context.Canceled)
+	fatalErr := fmt.Errorf("%w: corrupt local state", replication.ErrFatal)
+	mockConsumer.On("Consume", mock.Anything, mock.Anything).Return(fatalErr).Once()
+
+	// WHEN the consumer fails with a fatal error
+	err := engine.Start(context.Background())
+
+	// THEN the fatal error is latched
+	require.ErrorIs(t, err, fatalErr)
+	require.ErrorIs(t, engine.FatalError(), fatalErr)
+
+	// THEN the engine refuses to start without running the consumer again
+	for i := 0; i < 3; i++ {
+		err = engine.Start(context.Background())
+		require.ErrorIs(t, err, replication.ErrFatal)
+		require.ErrorIs(t, err, fatalErr)
+		require.False(t, engine.IsRunning())
+	}
+	mockConsumer.AssertNumberOfCalls(t, "Consume", 1)
+
+	// WHEN the latch is cleared
+	engine.ClearFatal()
+	require.NoError(t, engine.FatalError())
+	mockConsumer.On("Consume", mock.Anything, mock.Anything).Run(blockUntilDone).Return(context.Canceled).Once()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err = engine.Start(context.Background())
+	}()
+
+	// THEN the engine starts again
+	require.Eventually(t, engine.IsRunning, 5*time.Second, 10*time.Millisecond)
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, err)
+	mockConsumer.AssertNumberOfCalls(t, "Consume", 2)
+}
+
+func TestShardReplicationEngine_Stats(t *testing.T) {
+	t.Run("counts produced and consumed ops and resets on start", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		mockProducer := replication.NewMockOpProducer(t)
+		mockConsumer := replication.NewMockOpConsumer(t)
+		engine := replication.NewShardReplicationEngine(logger, "node2", mockProducer, mockConsumer, 16, 1, 1*time.Minute)
+
+		const opsCount = 10
+		mockProducer.On("Produce", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			out := args.Get(1).(chan<- replication.ShardReplicationOp)
+			for i := 1; i <= opsCount; i++ {
+				out <- replication.NewShardReplicationOp(uint64(i), "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", i))
+			}
+			<-ctx.Done()
+		}).Return(context.Canceled).Once()
+		consumed := make(chan replication.ShardReplicationOp, opsCount)
+		mockConsumer.On("Consume", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			in := args.Get(1).(<-chan replication.ShardReplicationOp)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case op := <-in:
+					consumed <- op
+				}
+			}
+		}).Return(context.Canceled).Once()
+
+		// WHEN
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, engine.Start(context.Background()))
+		}()
+		for i := 0; i < opsCount; i++ {
+			<-consumed
+		}
+
+		// THEN every produced op is consumed and none is left in the op channel
+		require.Eventually(t, func() bool {
+			return engine.Stats() == replication.Stats{Produced: opsCount, Consumed: opsCount}
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Equal(t, engine.OpChannelLen(), int(engine.Stats().InFlight))
+
+		engine.Stop()
+		wg.Wait()
+		require.Equal(t, int64(opsCount), engine.Stats().Produced, "stats should be kept once stopped")
+
+		// WHEN the engine is started again
+		mockProducer.On("Produce", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		}).Return(context.Canceled).Once()
+		mockConsumer.On("Consume", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		}).Return(context.Canceled).Once()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, engine.Start(context.Background()))
+		}()
+		require.Eventually(t, engine.IsRunning, 5*time.Second, 10*time.Millisecond)
+
+		// THEN the stats are reset
+		require.Equal(t, replication.Stats{}, engine.Stats())
+		engine.Stop()
+		wg.Wait()
+	})
+
+	t.Run("counts completed and failed ops", func(t *testing.T) {
+		// GIVEN
+		logger, _ := logrustest.NewNullLogger()
+		inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+		engine := replication.NewShardReplicationEngine(logger, "node2", inMemory.Producer, inMemory.Consumer, 16, 4, 10*time.Second)
+		inMemory.Copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+			if shard == "broken" {
+				return errors.New("copy failed")
+			}
+			return nil
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, engine.Start(context.Background()))
+		}()
+
+		// WHEN
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(3, "node1", "node2", "TestCollection", "broken"))
+
+		// THEN
+		require.Eventually(t, func() bool {
+			stats := engine.Stats()
+			return stats.Completed == 2 && stats.Failed == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		stats := engine.Stats()
+		require.Equal(t, int64(3), stats.Produced)
+		require.Equal(t, int64(3), stats.Consumed)
+		require.Zero(t, stats.InFlight)
+
+		engine.Stop()
+		wg.Wait()
+	})
+}
+
+func TestShardReplicationEngine_StopWithDrain(t *testing.T) {
+	// newDrainedEngine returns a running engine with two workers, processing two ops whose copies block until the
+	// given channel is closed or their context is done, a third op dequeued by the consumer waiting for a worker and
+	// a fourth op queued in the engine.
+	newDrainedEngine := func(t *testing.T, release <-chan struct{}) (*replication.ShardReplicationEngine, *logrustest.Hook, *replicationtest.FakeFSMUpdater, chan error) {
+		logger, hook := logrustest.NewNullLogger()
+		fsmUpdater := replicationtest.NewFakeFSMUpdater()
+		copier := replicationtest.NewFakeCopier()
+		copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		producer := replicationtest.NewFakeProducer(4)
+		consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+			func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 2)
+		engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 4, 2, 10*time.Second)
+
+		startErr := make(chan error, 1)
+		go func() {
+			startErr <- engine.Start(context.Background())
+		}()
+		for id := uint64(1); id <= 4; id++ {
+			producer.Submit(replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id)))
+		}
+		require.Eventually(t, func() bool {
+			return slices.Equal(engine.InFlightOps(), []uint64{1, 2}) && engine.OpChannelLen() == 1
+		}, 5*time.Second, time.Millisecond, "both workers should be busy with an op queued")
+		return engine, hook, fsmUpdater, startErr
+	}
+
+	t.Run("in-flight ops complete before returning", func(t *testing.T) {
+		// GIVEN
+		release := make(chan struct{})
+		engine, hook, fsmUpdater, startErr := newDrainedEngine(t, release)
 
-	for i := 0; i < count; i++ {
-		opIds[i] = currId
-		currId += 1
+		// WHEN
+		drainErr := make(chan error, 1)
+		go func() {
+			drainErr <- engine.StopWithDrain(context.Background())
+		}()
+
+		// THEN the engine waits for the in-flight ops and no longer accepts ops
+		require.Eventually(t, func() bool {
+			return slices.ContainsFunc(hook.AllEntries(), func(entry *logrus.Entry) bool {
+				return strings.Contains(entry.Message, "drain request")
+			})
+		}, 5*time.Second, time.Millisecond)
+		engine.Submit(replication.NewShardReplicationOp(5, "node1", "node2", "TestCollection", "shard5"))
+		require.Equal(t, int64(4), engine.Stats().Produced, "submitted op should be rejected while draining")
+		require.Never(t, func() bool { return len(drainErr) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+		require.True(t, engine.IsRunning())
+
+		// WHEN the copies complete
+		close(release)
+
+		// THEN the ops dequeued by the consumer complete and the queued one is not started
+		require.NoError(t, <-drainErr)
+		require.NoError(t, <-startErr)
+		require.False(t, engine.IsRunning())
+		for _, id := range []uint64{1, 2, 3} {
+			state, _ := fsmUpdater.State(id)
+			require.Equal(t, api.READY, state)
+		}
+		_, started := fsmUpdater.State(4)
+		require.False(t, started, "the op queued in the engine should not be started")
+		require.Equal(t, int64(3), engine.Stats().Completed)
+	})
+
+	t.Run("in-flight ops are canceled once the deadline expires", func(t *testing.T) {
+		// GIVEN
+		engine, _, fsmUpdater, startErr := newDrainedEngine(t, nil)
+
+		// WHEN
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := engine.StopWithDrain(ctx)
+
+		// THEN the ops still in progress are reported and the engine is stopped
+		require.ErrorIs(t, err, replication.ErrDrainTimeout)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.ErrorContains(t, err, "operations [1 2] still in progress")
+		require.NoError(t, <-startErr)
+		require.False(t, engine.IsRunning())
+		require.Empty(t, engine.InFlightOps())
+		for _, id := range []uint64{1, 2} {
+			state, _ := fsmUpdater.State(id)
+			require.NotEqual(t, api.READY, state)
+		}
+	})
+}
+
+func TestShardReplicationEngine_DumpState(t *testing.T) {
+	// GIVEN an engine processing an op, with another op waiting for a worker and two more queued
+	logger, _ := logrustest.NewNullLogger()
+	fsm := newTestFSM(t)
+	clock := replicationtest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	fsm.SetClock(clock, clock)
+	for id := uint64(1); id <= 4; id++ {
+		require.NoError(t, fsm.Replicate(id, replicateRequest("node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))))
+	}
+	clock.Advance(time.Minute)
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.HYDRATING}))
+
+	producer := replicationtest.NewFakeProducer(16)
+	copier := replicationtest.NewFakeCopier()
+	release := make(chan struct{})
+	copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		<-release
+		return nil
+	}
+	consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), copier,
+		replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 1)
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 16, 1, 10*time.Second,
+		replication.WithReplicationFSM(fsm), replication.WithMaxQueuedOps(8))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+	for id := uint64(1); id <= 4; id++ {
+		producer.Submit(replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id)))
+	}
+	require.Eventually(t, func() bool {
+		reason, _ := engine.OpBlockReason(2)
+		return engine.OpChannelLen() == 2 && reason == "waiting for a free worker"
+	}, 5*time.Second, time.Millisecond)
+
+	// WHEN
+	var first, second bytes.Buffer
+	require.NoError(t, engine.DumpState(&first))
+	require.NoError(t, engine.DumpState(&second))
+
+	// THEN
+	expected := `== engine ==
+node: node2
+running: true
+halted: false
+scheduler: *replication.FIFOScheduler
+shutdown_timeout: 10s
+producer: *replicationtest.FakeProducer
+consumer: *replication.CopyOpConsumer
+== limits ==
+op_buffer_size: 16
+max_queued_ops: 8
+overflow_policy: block
+max_workers: 1
+workers: 1
+min_workers: 0
+max_cluster_in_flight_ops: 0
+== queue ==
+queued_ops: 2
+op 3: queued 1 time(s)
+op 4: queued 1 time(s)
+== in-flight ==
+op 1: processing
+== fsm ==
+op 1: node1/TestCollection/shard1 -> node2/TestCollection/shard1 HYDRATING since 2025-01-01T00:01:00Z
+op 2: node1/TestCollection/shard2 -> node2/TestCollection/shard2 REGISTERED since 2025-01-01T00:00:00Z
+op 3: node1/TestCollection/shard3 -> node2/TestCollection/shard3 REGISTERED since 2025-01-01T00:00:00Z
+op 4: node1/TestCollection/shard4 -> node2/TestCollection/shard4 REGISTERED since 2025-01-01T00:00:00Z
+`
+	require.Equal(t, expected, first.String())
+	require.Equal(t, first.String(), second.String(), "the dump should be deterministic")
+
+	close(release)
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+}
+
+func TestShardReplicationEngine_FinalizationHealing(t *testing.T) {
+	// GIVEN an op stuck in FINALIZING after its copy completed, whose sharding update fails until the leader recovers
+	logger, _ := logrustest.NewNullLogger()
+	fsm := newTestFSM(t)
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	for _, state := range []api.ShardReplicationState{api.HYDRATING, api.FINALIZING} {
+		require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: state}))
 	}
 
-	return opIds, nil
+	var leaderRecovered atomic.Bool
+	var failedAttempts atomic.Int32
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	fsmUpdater.AddReplicaFunc = func(ctx context.Context, collection, shard, node string) error {
+		if !leaderRecovered.Load() {
+			failedAttempts.Add(1)
+			return errors.New("no leader")
+		}
+		return nil
+	}
+	fsmUpdater.UpdateStatusFunc = func(id uint64, state api.ShardReplicationState) error {
+		return fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: id, State: state})
+	}
+	copier := replicationtest.NewFakeCopier()
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier,
+		replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 1)
+	ticks := make(chan time.Time)
+	engine := replication.NewShardReplicationEngine(logger, "node2", replicationtest.NewFakeProducer(1), consumer, 1, 1, 10*time.Second,
+		replication.WithReplicationFSM(fsm), replication.WithFinalizationHealing(ticks))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+
+	// WHEN healing runs while the leader is unavailable
+	ticks <- time.Now()
+	ticks <- time.Now()
+
+	// THEN the op stays FINALIZING
+	require.Eventually(t, func() bool { return failedAttempts.Load() >= 1 }, 5*time.Second, time.Millisecond)
+	require.Equal(t, 1, fsm.CountOps(func(_ replication.ShardReplicationOp, state api.ShardReplicationState) bool {
+		return state == api.FINALIZING
+	}))
+
+	// WHEN healing runs once the leader recovered
+	leaderRecovered.Store(true)
+	ticks <- time.Now()
+
+	// THEN the sharding update is retried and the op reaches READY without being copied again
+	require.Eventually(t, func() bool {
+		return fsm.CountOps(func(_ replication.ShardReplicationOp, state api.ShardReplicationState) bool {
+			return state == api.READY
+		}) == 1
+	}, 5*time.Second, time.Millisecond)
+	require.Empty(t, copier.Calls())
+	require.Len(t, fsmUpdater.AddedReplicas(), 1)
+	require.Equal(t, []api.ShardReplicationState{api.READY}, fsmUpdater.StateHistory(1))
+
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
 }
 
-func randInt(t *testing.T, min, max int) (int, error) {
-	t.Helper()
-	var randValue [1]byte
-	_, err := rand.Read(randValue[:])
-	if err != nil {
-		return 0, err
+// semaphoreRestartCoordinator is a types.RestartCoordinator allowing a limited number of concurrent restarts.
+type semaphoreRestartCoordinator struct {
+	slots chan struct{}
+
+	mu         sync.Mutex
+	holders    int
+	maxHolders int
+	acquired   []string
+}
+
+func (c *semaphoreRestartCoordinator) AcquireRestart(ctx context.Context, node string) (func(), error) {
+	select {
+	case c.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
-	return min + int(randValue[0])%(max-min+1), nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.holders++
+	c.maxHolders = max(c.maxHolders, c.holders)
+	c.acquired = append(c.acquired, node)
+	return func() {
+		c.mu.Lock()
+		c.holders--
+		c.mu.Unlock()
+		<-c.slots
+	}, nil
+}
+
+func (c *semaphoreRestartCoordinator) stats() (int, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.maxHolders, slices.Clone(c.acquired)
+}
+
+// failingOnceConsumer fails the first time it consumes and then consumes until the context is done.
+type failingOnceConsumer struct {
+	runs atomic.Int32
+}
+
+func (c *failingOnceConsumer) Consume(ctx context.Context, in <-chan replication.ShardReplicationOp) error {
+	if c.runs.Add(1) == 1 {
+		return errors.New("consumer failure")
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestShardReplicationEngine_RunSupervised(t *testing.T) {
+	// GIVEN engines of several nodes sharing a restart coordinator allowing two concurrent restarts
+	logger, _ := logrustest.NewNullLogger()
+	coordinator := &semaphoreRestartCoordinator{slots: make(chan struct{}, 2)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The test holds every restart slot until all engines failed
+	releaseTestSlots := make([]func(), 0, 2)
+	for range 2 {
+		release, err := coordinator.AcquireRestart(ctx, "test")
+		require.NoError(t, err)
+		releaseTestSlots = append(releaseTestSlots, release)
+	}
+
+	nodes := []string{"node1", "node2", "node3", "node4"}
+	consumers := make([]*failingOnceConsumer, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		consumers[i] = &failingOnceConsumer{}
+		engine := replication.NewShardReplicationEngine(logger, node, replicationtest.NewFakeProducer(1), consumers[i],
+			1, 1, 10*time.Second, replication.WithRestartCoordinator(coordinator))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.ErrorIs(t, engine.RunSupervised(ctx, time.Millisecond), context.Canceled)
+		}()
+	}
+
+	// WHEN every engine failed while no restart slot is available
+	require.Eventually(t, func() bool {
+		for _, consumer := range consumers {
+			if consumer.runs.Load() < 1 {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, time.Millisecond)
+
+	// THEN no engine restarts
+	time.Sleep(50 * time.Millisecond)
+	for _, consumer := range consumers {
+		require.Equal(t, int32(1), consumer.runs.Load(), "an engine restarted without acquiring a restart slot")
+	}
+
+	// WHEN restart slots become available
+	coordinator.mu.Lock()
+	coordinator.maxHolders = 0
+	coordinator.mu.Unlock()
+	for _, release := range releaseTestSlots {
+		release()
+	}
+
+	// THEN every engine restarts, no more than two at once
+	require.Eventually(t, func() bool {
+		for _, consumer := range consumers {
+			if consumer.runs.Load() < 2 {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, time.Millisecond)
+	maxHolders, acquired := coordinator.stats()
+	require.LessOrEqual(t, maxHolders, 2)
+	require.ElementsMatch(t, append([]string{"test", "test"}, nodes...), acquired)
+
+	cancel()
+	wg.Wait()
+}
+
+func TestShardReplicationEngine_RunSupervisedRestartDelay(t *testing.T) {
+	// GIVEN an engine whose consumer fails once, restarted a minute after failing
+	logger, _ := logrustest.NewNullLogger()
+	clock := replicationtest.NewFakeClock(time.Now())
+	consumer := &failingOnceConsumer{}
+	engine := replication.NewShardReplicationEngine(logger, "node2", replicationtest.NewFakeProducer(1), consumer,
+		1, 1, 10*time.Second, replication.WithEngineTimer(clock))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	supervised := make(chan error, 1)
+	go func() { supervised <- engine.RunSupervised(ctx, time.Minute) }()
+
+	// WHEN the consumer failed
+	require.Eventually(t, func() bool { return clock.Waiters() == 1 }, 5*time.Second, time.Millisecond)
+
+	// THEN the engine is not restarted before the restart delay elapsed
+	clock.Advance(time.Minute - time.Millisecond)
+	require.Equal(t, int32(1), consumer.runs.Load())
+
+	// WHEN
+	clock.Advance(time.Millisecond)
+
+	// THEN
+	require.Eventually(t, func() bool { return consumer.runs.Load() == 2 }, 5*time.Second, time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-supervised, context.Canceled)
+}
+
+func TestShardReplicationEngine_EventTrace(t *testing.T) {
+	// GIVEN an engine tracing its events, processing an op whose first copy attempt fails
+	logger, _ := logrustest.NewNullLogger()
+	fsm := newTestFSM(t)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := replicationtest.NewFakeClock(start)
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	fsmUpdater.UpdateStatusFunc = func(id uint64, state api.ShardReplicationState) error {
+		return fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: id, State: state})
+	}
+	copier := replicationtest.NewFakeCopier()
+	var copies atomic.Int32
+	copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		if copies.Add(1) == 1 {
+			return errors.New("copy failure")
+		}
+		return nil
+	}
+	producer := replicationtest.NewFakeProducer(1)
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier,
+		replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.ZeroBackOff{} }, 10*time.Second, 1)
+	var trace bytes.Buffer
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 1, 1, 10*time.Second,
+		replication.WithReplicationFSM(fsm), replication.WithEventTrace(&trace, clock))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+
+	// WHEN
+	producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+	require.Eventually(t, func() bool {
+		return len(engine.InFlightOps()) == 0 && fsm.CountOps(func(_ replication.ShardReplicationOp, state api.ShardReplicationState) bool {
+			return state == api.READY
+		}) == 1
+	}, 5*time.Second, time.Millisecond)
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+
+	// THEN the trace holds the ordered events of the op, timestamped by the injected clock
+	var records []replication.TraceRecord
+	decoder := json.NewDecoder(&trace)
+	for decoder.More() {
+		var record replication.TraceRecord
+		require.NoError(t, decoder.Decode(&record))
+		records = append(records, record)
+	}
+	event := func(eventType replication.TimelineEventType, state api.ShardReplicationState, detail string) replication.TraceRecord {
+		return replication.TraceRecord{Time: start, OpID: 1, Event: eventType, State: state, Detail: detail}
+	}
+	require.Equal(t, []replication.TraceRecord{
+		event(replication.TimelineQueued, "", ""),
+		event(replication.TimelineDequeued, "", ""),
+		event(replication.TimelineStateChanged, api.HYDRATING, ""),
+		event(replication.TimelineRetried, "", "copy failure"),
+		event(replication.TimelineStateChanged, api.FINALIZING, ""),
+		event(replication.TimelineStateChanged, api.READY, ""),
+		event(replication.TimelineCompleted, "", ""),
+	}, records)
+}
+
+func TestShardReplicationEngine_ReplayTrace(t *testing.T) {
+	// GIVEN a traced scenario with an op succeeding, an op succeeding after a retry and an op failing permanently
+	logger, _ := logrustest.NewNullLogger()
+	clock := replicationtest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	copier := replicationtest.NewFakeCopier()
+	var flakyCopies atomic.Int32
+	copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		switch {
+		case shard == "flaky" && flakyCopies.Add(1) == 1:
+			return errors.New("connection reset")
+		case shard == "broken":
+			return errors.New("disk full")
+		}
+		return nil
+	}
+	producer := replicationtest.NewFakeProducer(3)
+	consumer := replication.NewCopyOpConsumer(logger, replicationtest.NewFakeFSMUpdater(), copier,
+		replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2) }, 10*time.Second, 1)
+	var trace bytes.Buffer
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 3, 1, 10*time.Second,
+		replication.WithEventTrace(&trace, clock))
+
+	ops := map[uint64]replication.ShardReplicationOp{
+		1: replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "healthy"),
+		2: replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "flaky"),
+		3: replication.NewShardReplicationOp(3, "node1", "node2", "TestCollection", "broken"),
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, engine.Start(context.Background()))
+	}()
+	for id := uint64(1); id <= 3; id++ {
+		producer.Submit(ops[id])
+	}
+	require.Eventually(t, func() bool {
+		stats := engine.Stats()
+		return stats.Completed+stats.Failed == 3
+	}, 5*time.Second, time.Millisecond)
+	engine.Stop()
+	wg.Wait()
+
+	// outcomes returns the final event and the number of retries of every op of the given records.
+	type opOutcome struct {
+		event   replication.TimelineEventType
+		retries int
+	}
+	outcomes := func(records []replication.TraceRecord) map[uint64]opOutcome {
+		result := make(map[uint64]opOutcome)
+		for _, record := range records {
+			outcome := result[record.OpID]
+			switch record.Event {
+			case replication.TimelineRetried:
+				outcome.retries++
+			case replication.TimelineCompleted, replication.TimelineFailed:
+				outcome.event = record.Event
+			}
+			result[record.OpID] = outcome
+		}
+		return result
+	}
+	var recorded []replication.TraceRecord
+	decoder := json.NewDecoder(bytes.NewReader(trace.Bytes()))
+	for decoder.More() {
+		var record replication.TraceRecord
+		require.NoError(t, decoder.Decode(&record))
+		recorded = append(recorded, record)
+	}
+
+	// WHEN the trace is replayed
+	var replayed []replication.TraceRecord
+	err := replication.Replay(bytes.NewReader(trace.Bytes()), replication.ReplayHandlers{
+		Op: func(id uint64) (replication.ShardReplicationOp, bool) {
+			op, ok := ops[id]
+			return op, ok
+		},
+		FSMUpdater: replicationtest.NewFakeFSMUpdater(),
+		OnEvent: func(record replication.TraceRecord) {
+			replayed = append(replayed, record)
+		},
+	})
+
+	// THEN the replayed ops have the same outcomes as the recorded ones
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]opOutcome{
+		1: {event: replication.TimelineCompleted},
+		2: {event: replication.TimelineCompleted, retries: 1},
+		3: {event: replication.TimelineFailed, retries: 2},
+	}, outcomes(recorded))
+	require.Equal(t, outcomes(recorded), outcomes(replayed))
+
+	// THEN a trace referencing an unknown op is rejected
+	err = replication.Replay(bytes.NewReader(trace.Bytes()), replication.ReplayHandlers{
+		Op: func(id uint64) (replication.ShardReplicationOp, bool) {
+			return replication.ShardReplicationOp{}, false
+		},
+	})
+	require.ErrorContains(t, err, "operation 1 not found")
+}
+
+// closingProducer closes the operation channel it is given instead of producing operations.
+type closingProducer struct{}
+
+func (closingProducer) Produce(ctx context.Context, out chan<- replication.ShardReplicationOp) error {
+	close(out)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestShardReplicationEngine_OpChannelClosedUnexpectedly(t *testing.T) {
+	// GIVEN a producer closing the op channel while the engine is running
+	logger, _ := logrustest.NewNullLogger()
+	inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+	engine := replication.NewShardReplicationEngine(logger, "node2", closingProducer{}, inMemory.Consumer, 1, 1, 10*time.Second)
+
+	// WHEN
+	engineStartErr := make(chan error, 1)
+	go func() {
+		engineStartErr <- engine.Start(context.Background())
+	}()
+
+	// THEN the engine stops with an error rather than a silent clean exit
+	select {
+	case err := <-engineStartErr:
+		require.ErrorIs(t, err, replication.ErrOpChannelClosed)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "engine should stop once the op channel is closed")
+	}
+	require.False(t, engine.IsRunning())
+}
+
+// recordingConsumer records the consumed operations until the context is done.
+type recordingConsumer struct {
+	consumed chan replication.ShardReplicationOp
+}
+
+func (c *recordingConsumer) Consume(ctx context.Context, in <-chan replication.ShardReplicationOp) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case op, ok := <-in:
+			if !ok {
+				return nil
+			}
+			c.consumed <- op
+		}
+	}
+}
+
+func TestShardReplicationEngine_OpInterceptors(t *testing.T) {
+	// GIVEN an engine whose first interceptor tags the ops with a cost center and whose second rejects op 2
+	logger, _ := logrustest.NewNullLogger()
+	producer := replicationtest.NewFakeProducer(3)
+	consumer := &recordingConsumer{consumed: make(chan replication.ShardReplicationOp, 3)}
+	var intercepted []replication.ShardReplicationOp
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 3, 1, 10*time.Second,
+		replication.WithOpInterceptors(func(op replication.ShardReplicationOp) (replication.ShardReplicationOp, bool) {
+			op.CostCenter = "team-a"
+			return op, true
+		}),
+		replication.WithOpInterceptors(func(op replication.ShardReplicationOp) (replication.ShardReplicationOp, bool) {
+			intercepted = append(intercepted, op)
+			return op, op.ID != 2
+		}))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+
+	// WHEN
+	for id := uint64(1); id <= 3; id++ {
+		producer.Submit(replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id)))
+	}
+
+	// THEN the interceptors apply in order, the modified ops are consumed and the rejected one is not
+	var consumed []replication.ShardReplicationOp
+	for range 2 {
+		select {
+		case op := <-consumer.consumed:
+			consumed = append(consumed, op)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "expected accepted ops to be consumed")
+		}
+	}
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+	require.Empty(t, consumer.consumed, "the rejected op should not be consumed")
+	require.Equal(t, []uint64{1, 3}, []uint64{consumed[0].ID, consumed[1].ID})
+	for _, op := range consumed {
+		require.Equal(t, "team-a", op.CostCenter)
+	}
+	require.Len(t, intercepted, 3)
+	for _, op := range intercepted {
+		require.Equal(t, "team-a", op.CostCenter, "the second interceptor should receive the op modified by the first")
+	}
+}
+
+func TestShardReplicationEngine_LeaderClientHealthy(t *testing.T) {
+	// GIVEN an engine whose leader client ping health is toggled
+	logger, _ := logrustest.NewNullLogger()
+	inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+	var leaderReachable atomic.Bool
+	inMemory.FSMUpdater.PingFunc = func(ctx context.Context) error {
+		if !leaderReachable.Load() {
+			return errors.New("leader not found")
+		}
+		return nil
+	}
+
+	// WHEN the leader cannot be reached
+	// THEN
+	require.False(t, inMemory.Engine.LeaderClientHealthy())
+
+	// WHEN the leader can be reached again
+	leaderReachable.Store(true)
+	// THEN
+	require.True(t, inMemory.Engine.LeaderClientHealthy())
+}
+
+func TestShardReplicationEngine_MaxQueueWait(t *testing.T) {
+	// GIVEN an engine whose consumer cancels ops queued for more than a minute, and whose consumer clock is an hour
+	// ahead of the engine one, so that every op is aged when dequeued
+	logger, _ := logrustest.NewNullLogger()
+	reg := prometheus.NewPedanticRegistry()
+	copier := replicationtest.NewFakeCopier()
+	producer := replicationtest.NewFakeProducer(1)
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier,
+		replicationtest.NewFakeClock(time.Now().Add(time.Hour)), "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 1,
+		replication.WithMaxQueueWait(time.Minute), replication.WithConsumerRegisterer(reg))
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 1, 1, 10*time.Second,
+		replication.WithOpTimeline(10))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+
+	// WHEN
+	producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+
+	// THEN the op is canceled with the queue wait reason without being copied
+	require.Eventually(t, func() bool {
+		events := engine.OpTimeline(1)
+		return len(events) > 0 && events[len(events)-1].Type == replication.TimelineFailed
+	}, 5*time.Second, time.Millisecond)
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+
+	events := engine.OpTimeline(1)
+	require.Contains(t, events[len(events)-1].Detail, replication.ErrOpQueueWaitExceeded.Error())
+	require.Empty(t, copier.Calls())
+	state, ok := fsmUpdater.State(1)
+	require.True(t, ok)
+	require.Equal(t, api.ABORTED, state, "the canceled op should be marked ABORTED")
+	const metricName = "weaviate_replication_queue_wait_exceeded_total"
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP weaviate_replication_queue_wait_exceeded_total Number of replication operations canceled because they waited too long in the queue
+# TYPE weaviate_replication_queue_wait_exceeded_total counter
+weaviate_replication_queue_wait_exceeded_total 1
+`), metricName))
+}
+
+// idleConsumer consumes nothing until the context is done.
+type idleConsumer struct{}
+
+func (idleConsumer) Consume(ctx context.Context, _ <-chan replication.ShardReplicationOp) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestShardReplicationEngine_PendingOpsHandover(t *testing.T) {
+	// GIVEN an engine holding queued ops with attributes, as its consumer does not consume them, and another engine
+	logger, _ := logrustest.NewNullLogger()
+	deadline := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	var submitted []replication.ShardReplicationOp
+	for id := uint64(1); id <= 3; id++ {
+		op := replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))
+		op.CostCenter = fmt.Sprintf("team-%d", id)
+		op.Deadline = deadline
+		op.IdempotencyKey = fmt.Sprintf("key-%d", id)
+		submitted = append(submitted, op)
+	}
+
+	fromProducer := replicationtest.NewFakeProducer(3)
+	from := replication.NewShardReplicationEngine(logger, "node2", fromProducer, idleConsumer{}, 3, 1, 10*time.Second)
+	toProducer := replicationtest.NewFakeProducer(1)
+	toConsumer := &recordingConsumer{consumed: make(chan replication.ShardReplicationOp, 3)}
+	to := replication.NewShardReplicationEngine(logger, "node3", toProducer, toConsumer, 3, 1, 10*time.Second)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	var fromStartErr, toStartErr error
+	go func() {
+		defer wg.Done()
+		fromStartErr = from.Start(context.Background())
+	}()
+	go func() {
+		defer wg.Done()
+		toStartErr = to.Start(context.Background())
+	}()
+	for _, op := range submitted {
+		fromProducer.Submit(op)
+	}
+	require.Eventually(t, func() bool { return from.OpChannelLen() == 3 }, 5*time.Second, time.Millisecond)
+	// An op produced by the other engine is consumed once it accepts ops
+	toProducer.Submit(replication.NewShardReplicationOp(100, "node1", "node3", "TestCollection", "shard100"))
+	require.Equal(t, uint64(100), (<-toConsumer.consumed).ID)
+
+	// WHEN
+	exported := from.ExportPendingOps()
+	imported := to.ImportPendingOps(exported)
+
+	// THEN the ops leave the first engine and are consumed by the other one with their attributes
+	attributes := func(op replication.ShardReplicationOp) []any {
+		return []any{op.ID, op.CostCenter, op.Deadline, op.IdempotencyKey, op.Collection()}
+	}
+	require.Len(t, exported, 3)
+	require.Equal(t, 3, imported)
+	require.Zero(t, from.OpChannelLen())
+	for i, op := range submitted {
+		require.Equal(t, attributes(op), attributes(exported[i]))
+		select {
+		case consumed := <-toConsumer.consumed:
+			require.Equal(t, attributes(op), attributes(consumed))
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "expected imported ops to be consumed")
+		}
+	}
+
+	from.Stop()
+	to.Stop()
+	wg.Wait()
+	require.NoError(t, fromStartErr)
+	require.NoError(t, toStartErr)
+}
+
+// capacityReserver reserves a single unit of a node capacity per replica.
+type capacityReserver struct {
+	mu       sync.Mutex
+	capacity int
+	reserved int
+}
+
+func (r *capacityReserver) ReserveReplica(_ context.Context, node, _, _ string) (func(), error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reserved >= r.capacity {
+		return nil, fmt.Errorf("node %s out of capacity", node)
+	}
+	r.reserved++
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.reserved--
+	}, nil
+}
+
+func (r *capacityReserver) Reserved() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reserved
+}
+
+func TestShardReplicationEngine_ResourceReservation(t *testing.T) {
+	// GIVEN an engine reserving resources on a target node able to hold a single replica copy, whose first copy
+	// blocks until released
+	logger, _ := logrustest.NewNullLogger()
+	fsm := newTestFSM(t)
+	for id := uint64(1); id <= 4; id++ {
+		require.NoError(t, fsm.Replicate(id, replicateRequest("node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))))
+	}
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	fsmUpdater.UpdateStatusFunc = func(id uint64, state api.ShardReplicationState) error {
+		return fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: id, State: state})
+	}
+	copier := replicationtest.NewFakeCopier()
+	unblockCopy := make(chan struct{})
+	copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		if shard == "shard1" {
+			<-unblockCopy
+		}
+		return nil
+	}
+	reserver := &capacityReserver{capacity: 1}
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier,
+		replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 2)
+	producer := replicationtest.NewFakeProducer(1)
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 3, 2,
+		10*time.Second, replication.WithReplicationFSM(fsm), replication.WithResourceReserver(reserver))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// An op emitted by the producer being processed ensures the engine accepts submissions
+	producer.Submit(replication.NewShardReplicationOp(4, "node1", "node2", "TestCollection", "shard4"))
+	require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(4, api.READY)))
+	require.Eventually(t, func() bool { return reserver.Reserved() == 0 }, 5*time.Second, time.Millisecond)
+
+	var first replication.OpHandle
+	t.Run("op whose resources are reserved is processed", func(t *testing.T) {
+		// WHEN
+		first = engine.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "shard1"))
+
+		// THEN
+		require.NoError(t, first.Err())
+		require.Eventually(t, func() bool { return len(copier.Calls()) == 2 }, 5*time.Second, time.Millisecond)
+		require.Equal(t, 1, reserver.Reserved())
+	})
+
+	t.Run("op whose resources are insufficient is rejected at submission", func(t *testing.T) {
+		// WHEN
+		second := engine.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2"))
+
+		// THEN
+		require.ErrorIs(t, second.Err(), replication.ErrResourceReservationFailed)
+		require.ErrorIs(t, second.Wait(context.Background()), replication.ErrResourceReservationFailed)
+		require.Len(t, copier.Calls(), 2, "the rejected op should not be copied")
+	})
+
+	t.Run("op rejected at submission can be retried with the same idempotency key", func(t *testing.T) {
+		// GIVEN
+		op := replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "shard2")
+		op.IdempotencyKey = "request-2"
+		require.ErrorIs(t, engine.Submit(op).Err(), replication.ErrResourceReservationFailed)
+
+		// WHEN the resources become available and the op is submitted again
+		reserver.mu.Lock()
+		reserver.capacity++
+		reserver.mu.Unlock()
+		retried := engine.Submit(op)
+
+		// THEN
+		require.NoError(t, retried.Err())
+		require.Eventually(t, func() bool { return len(copier.Calls()) == 3 }, 5*time.Second, time.Millisecond)
+	})
+
+	t.Run("reservation is released once the op completes", func(t *testing.T) {
+		// WHEN
+		close(unblockCopy)
+
+		// THEN
+		require.NoError(t, first.Wait(ctx))
+		require.Eventually(t, func() bool { return reserver.Reserved() == 0 }, 5*time.Second, time.Millisecond)
+		third := engine.Submit(replication.NewShardReplicationOp(3, "node1", "node2", "TestCollection", "shard3"))
+		require.NoError(t, third.Err())
+		require.NoError(t, third.Wait(ctx))
+	})
+
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+	require.Zero(t, reserver.Reserved())
+}
+
+// finiteProducer emits a fixed set of ops and then returns nil.
+type finiteProducer struct {
+	ops []replication.ShardReplicationOp
+}
+
+func (p finiteProducer) Produce(ctx context.Context, out chan<- replication.ShardReplicationOp) error {
+	for _, op := range p.ops {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case out <- op:
+		}
+	}
+	return nil
+}
+
+func TestShardReplicationEngine_ProducerFinished(t *testing.T) {
+	// GIVEN an engine whose producer emits a fixed set of ops then finishes, with a buffer smaller than the set
+	logger, _ := logrustest.NewNullLogger()
+	var ops []replication.ShardReplicationOp
+	for id := uint64(1); id <= 5; id++ {
+		ops = append(ops, replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id)))
+	}
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	copier := replicationtest.NewFakeCopier()
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier,
+		replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 2)
+	engine := replication.NewShardReplicationEngine(logger, "node2", finiteProducer{ops: ops}, consumer, 2, 2, 10*time.Second)
+
+	// WHEN
+	engineStartErr := make(chan error, 1)
+	go func() {
+		engineStartErr <- engine.Start(context.Background())
+	}()
+
+	// THEN the engine exits cleanly without being stopped, once the consumer processed every op
+	select {
+	case err := <-engineStartErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "expected the engine to exit once the producer finished")
+	}
+	require.False(t, engine.IsRunning())
+	require.Len(t, copier.Calls(), len(ops))
+	for _, op := range ops {
+		state, ok := fsmUpdater.State(op.ID)
+		require.True(t, ok)
+		require.Equal(t, api.READY, state)
+	}
+}
+
+func TestShardReplicationEngine_DiagnosticsAcrossCycles(t *testing.T) {
+	// GIVEN an engine processing an op on every start and stop cycle
+	logger, _ := logrustest.NewNullLogger()
+	inMemory := replicationtest.NewInMemoryEngine(logger, "node2")
+	engine := inMemory.Engine
+	baseline := engine.Diagnostics()
+	require.Equal(t, replication.Diagnostics{}, baseline)
+
+	for cycle := range 30 {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var engineStartErr error
+		go func() {
+			defer wg.Done()
+			engineStartErr = engine.Start(context.Background())
+		}()
+
+		// WHEN
+		id := uint64(cycle + 1)
+		inMemory.Producer.Submit(replication.NewShardReplicationOp(id, "node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id)))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		require.NoError(t, inMemory.FSMUpdater.WaitFor(ctx, opInState(id, api.READY)))
+		cancel()
+		require.Positive(t, engine.Diagnostics().Goroutines, "the running engine should report its goroutines")
+		engine.Stop()
+		wg.Wait()
+		require.NoError(t, engineStartErr)
+
+		// THEN every resource is released once stopped
+		require.Equal(t, baseline, engine.Diagnostics(), "resources leaked after cycle %d", cycle)
+	}
+}
+
+// ackingProducer emits the submitted ops and records the acknowledged ones.
+type ackingProducer struct {
+	*replicationtest.FakeProducer
+	mu    sync.Mutex
+	acked []uint64
+}
+
+func (p *ackingProducer) Ack(op replication.ShardReplicationOp) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.acked = append(p.acked, op.ID)
+}
+
+func (p *ackingProducer) Acked() []uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.acked)
+}
+
+func TestShardReplicationEngine_OpAcknowledgment(t *testing.T) {
+	// GIVEN an engine whose producer requires acknowledgments, and whose copies of shard "bad" fail
+	logger, _ := logrustest.NewNullLogger()
+	producer := &ackingProducer{FakeProducer: replicationtest.NewFakeProducer(2)}
+	fsmUpdater := replicationtest.NewFakeFSMUpdater()
+	copier := replicationtest.NewFakeCopier()
+	copier.CopyFunc = func(ctx context.Context, sourceNode, collection, shard string) error {
+		if shard == "bad" {
+			return errors.New("copy failure")
+		}
+		return nil
+	}
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier,
+		replication.RealTimeProvider{}, "node2", func() backoff.BackOff { return &backoff.StopBackOff{} }, 10*time.Second, 2)
+	engine := replication.NewShardReplicationEngine(logger, "node2", producer, consumer, 2, 2, 10*time.Second)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var engineStartErr error
+	go func() {
+		defer wg.Done()
+		engineStartErr = engine.Start(context.Background())
+	}()
+
+	// WHEN
+	producer.Submit(replication.NewShardReplicationOp(1, "node1", "node2", "TestCollection", "bad"))
+	producer.Submit(replication.NewShardReplicationOp(2, "node1", "node2", "TestCollection", "good"))
+
+	// THEN only the successfully processed op is acknowledged
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, fsmUpdater.WaitFor(ctx, opInState(2, api.READY)))
+	require.Eventually(t, func() bool {
+		return len(copier.Calls()) == 2 && len(engine.InFlightOps()) == 0
+	}, 5*time.Second, time.Millisecond)
+	engine.Stop()
+	wg.Wait()
+	require.NoError(t, engineStartErr)
+	require.Equal(t, []uint64{2}, producer.Acked())
 }
@@ -0,0 +1,117 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// opOutcomes keeps the outcome of the most recent operations processed by the consumer, up to a window size.
+type opOutcomes struct {
+	mu        sync.Mutex
+	window    []bool
+	next      int
+	samples   int
+	successes int
+	// onRecord is invoked after every recorded outcome, outside of the lock.
+	onRecord func()
+}
+
+func newOpOutcomes(windowSize int, onRecord func()) *opOutcomes {
+	return &opOutcomes{window: make([]bool, max(1, windowSize)), onRecord: onRecord}
+}
+
+// record adds the outcome of an operation, evicting the oldest outcome once the window is full. It is a no-op on a
+// nil instance.
+func (o *opOutcomes) record(success bool) {
+	if o == nil {
+		return
+	}
+	o.add(success)
+	if o.onRecord != nil {
+		o.onRecord()
+	}
+}
+
+func (o *opOutcomes) add(success bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.samples == len(o.window) {
+		if o.window[o.next] {
+			o.successes--
+		}
+	} else {
+		o.samples++
+	}
+	o.window[o.next] = success
+	if success {
+		o.successes++
+	}
+	o.next = (o.next + 1) % len(o.window)
+}
+
+// successRate returns the ratio of successful operations in the window. It reports false until the window is full.
+func (o *opOutcomes) successRate() (float64, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.samples < len(o.window) {
+		return 0, false
+	}
+	return float64(o.successes) / float64(o.samples), true
+}
+
+// successRateTracker is implemented by consumers able to track the success rate of their recent operations.
+type successRateTracker interface {
+	// trackSuccessRate starts tracking the outcome of the most recent operations, invoking onRecord after each one.
+	trackSuccessRate(windowSize int, onRecord func())
+	recentSuccessRate() (float64, bool)
+}
+
+func (c *CopyOpConsumer) trackSuccessRate(windowSize int, onRecord func()) {
+	c.outcomes = newOpOutcomes(windowSize, onRecord)
+}
+
+func (c *CopyOpConsumer) recentSuccessRate() (float64, bool) {
+	if c.outcomes == nil {
+		return 0, false
+	}
+	return c.outcomes.successRate()
+}
+
+// ProducerThrottled reports whether the engine currently throttles the producer because of the low success rate of
+// the consumer, see WithProducerThrottling. It is re-evaluated every time the consumer records an outcome and every
+// time the dispatch loop accepts or hands out an operation.
+func (e *ShardReplicationEngine) ProducerThrottled() bool {
+	return e.producerThrottled.Load()
+}
+
+// updateProducerThrottling re-evaluates whether the producer must be throttled based on the recent success rate of
+// the consumer, logging every change, and returns the outcome.
+func (e *ShardReplicationEngine) updateProducerThrottling() bool {
+	if e.successRateTracker == nil {
+		return false
+	}
+
+	rate, ok := e.successRateTracker.recentSuccessRate()
+	throttled := ok && rate < e.minSuccessRate
+	if e.producerThrottled.Swap(throttled) != throttled {
+		fields := logrus.Fields{"engine": e, "success_rate": rate, "min_success_rate": e.minSuccessRate}
+		if throttled {
+			e.logger.WithFields(fields).Warn("replication operations mostly failing, throttling the producer")
+		} else {
+			e.logger.WithFields(fields).Info("replication operations success rate recovered, no longer throttling the producer")
+		}
+	}
+	return throttled
+}
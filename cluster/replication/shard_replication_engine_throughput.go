@@ -0,0 +1,85 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultThroughputWindow is the window over which the copy throughput is measured, unless set with
+// WithThroughputWindow.
+const defaultThroughputWindow = time.Minute
+
+// copiedBytesWindow records the bytes copied over a rolling time window.
+type copiedBytesWindow struct {
+	mu     sync.Mutex
+	window time.Duration
+	copies []copiedBytes
+	total  int64
+}
+
+// copiedBytes is the number of bytes copied by a replica copy completed at a given time.
+type copiedBytes struct {
+	at    time.Time
+	bytes int64
+}
+
+func newCopiedBytesWindow(window time.Duration) *copiedBytesWindow {
+	return &copiedBytesWindow{window: window}
+}
+
+// record records the bytes copied by a replica copy completed at the given time.
+func (w *copiedBytesWindow) record(at time.Time, bytes int64) {
+	if bytes <= 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.copies = append(w.copies, copiedBytes{at: at, bytes: bytes})
+	w.total += bytes
+}
+
+// rate returns the number of bytes copied per second over the window ending now.
+func (w *copiedBytesWindow) rate(now time.Time) float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	start := now.Add(-w.window)
+	expired := 0
+	for expired < len(w.copies) && !w.copies[expired].at.After(start) {
+		w.total -= w.copies[expired].bytes
+		expired++
+	}
+	w.copies = w.copies[expired:]
+	return float64(w.total) / w.window.Seconds()
+}
+
+// bytesThroughputReporter is implemented by consumers measuring the bytes they copy.
+type bytesThroughputReporter interface {
+	// bytesThroughput returns the number of bytes copied per second over the recent window.
+	bytesThroughput() float64
+}
+
+func (c *CopyOpConsumer) bytesThroughput() float64 {
+	return c.copiedBytes.rate(c.timeProvider.Now())
+}
+
+// CurrentThroughput returns the number of bytes copied per second by the consumer over its recent throughput window,
+// see WithThroughputWindow, as measured by the consumer clock. It returns 0 if the consumer does not measure the bytes
+// it copies.
+func (e *ShardReplicationEngine) CurrentThroughput() float64 {
+	reporter, ok := e.consumer.(bytesThroughputReporter)
+	if !ok {
+		return 0
+	}
+	return reporter.bytesThroughput()
+}
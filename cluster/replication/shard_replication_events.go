@@ -0,0 +1,102 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+	enterrors "github.com/weaviate/weaviate/entities/errors"
+)
+
+// TransitionEvent is the serializable description of a replication operation state transition applied to the FSM,
+// published to an EventPublisher.
+type TransitionEvent struct {
+	OpID uint64 `json:"op_id"`
+	// From is empty when the operation is registered
+	From api.ShardReplicationState `json:"from,omitempty"`
+	To   api.ShardReplicationState `json:"to"`
+	// Timestamp is the time the operation entered the To state, as measured by the clock of the node applying the
+	// transition
+	Timestamp time.Time `json:"timestamp"`
+	// SourceShard and TargetShard are the FQDNs of the source and target replicas, formatted as node/collection/shard
+	SourceShard string `json:"source_shard"`
+	TargetShard string `json:"target_shard"`
+}
+
+// EventPublisher publishes replication operation state transitions to an external system, such as a message bus.
+type EventPublisher interface {
+	// Publish publishes a single event. It may block, e.g. while waiting for the message bus to acknowledge the event.
+	Publish(ctx context.Context, event TransitionEvent) error
+}
+
+// PublishEvents publishes an event for every state transition applied to the FSM from now on, including the
+// registration of new operations, until ctx is done.
+//
+// Unlike transition observers, the publisher never delays the application of the Raft log: events are buffered, up
+// to bufferSize events, and published one at a time in the order transitions are applied by a dedicated goroutine.
+// Events are dropped while the buffer is full, as are events the publisher fails to publish, and both are counted in
+// the weaviate_replication_fsm_events_dropped_total metric. Publishing failures are also logged using logger.
+func (s *ShardReplicationFSM) PublishEvents(ctx context.Context, logger logrus.FieldLogger, publisher EventPublisher, bufferSize int) {
+	events := make(chan TransitionEvent, max(1, bufferSize))
+
+	s.OnTransition(func(id uint64, from, to api.ShardReplicationState) {
+		if ctx.Err() != nil {
+			return
+		}
+		event, ok := s.transitionEvent(id, from, to)
+		if !ok {
+			return
+		}
+		select {
+		case events <- event:
+		default:
+			s.droppedEvents.WithLabelValues("buffer_full").Inc()
+		}
+	})
+
+	enterrors.GoWrapper(func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-events:
+				if err := publisher.Publish(ctx, event); err != nil {
+					logger.WithError(err).WithField("op", event.OpID).Warn("failed to publish replication operation transition event")
+					s.droppedEvents.WithLabelValues("publish_error").Inc()
+				}
+			}
+		}
+	}, logger)
+}
+
+// transitionEvent returns the event describing the transition of the op with the given ID to its current state,
+// and false if the op does not exist anymore.
+func (s *ShardReplicationFSM) transitionEvent(id uint64, from, to api.ShardReplicationState) (TransitionEvent, bool) {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	op, ok := s.opsById[id]
+	if !ok {
+		return TransitionEvent{}, false
+	}
+	return TransitionEvent{
+		OpID:        id,
+		From:        from,
+		To:          to,
+		Timestamp:   s.opsStatus[op].enteredAt,
+		SourceShard: op.sourceShard.String(),
+		TargetShard: op.targetShard.String(),
+	}, true
+}
@@ -0,0 +1,103 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+	"github.com/weaviate/weaviate/cluster/replication/types"
+)
+
+// ErrForceSetStateDisabled is returned when forcing the state of an op on an FSM without forced state changes enabled.
+var ErrForceSetStateDisabled = errors.New("forced replication operation state changes are disabled")
+
+// EnableForceSetState allows forcing the state of ops with ForceSetState from this node, logging every forced change
+// to logger. It is meant to be called explicitly by operators recovering from a bug, so that ops are not forced by
+// accident.
+func (s *ShardReplicationFSM) EnableForceSetState(logger logrus.FieldLogger) {
+	s.opsLock.Lock()
+	defer s.opsLock.Unlock()
+	s.forceLogger = logger
+}
+
+// ForceSetState sets, through the forcer, the state of the op with the given ID on every node, bypassing the
+// transition guards, as an escape hatch to recover ops left in a wrong state by a bug. The forced state is applied
+// through the Raft log, see ForceReplicationOpState, so that it is not reverted by a leader change or a snapshot
+// restore. The reason is logged on this node and recorded with the state on every node, see ForcedStateReason.
+//
+// It fails with ErrForceSetStateDisabled unless enabled on this node with EnableForceSetState.
+func (s *ShardReplicationFSM) ForceSetState(forcer types.OpStateForcer, id uint64, state api.ShardReplicationState, reason string) error {
+	s.opsLock.RLock()
+	logger := s.forceLogger
+	op, ok := s.opsById[id]
+	from := s.opsStatus[op].state
+	s.opsLock.RUnlock()
+
+	if logger == nil {
+		return ErrForceSetStateDisabled
+	}
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrReplicationOpNotFound, id)
+	}
+
+	logger.WithFields(logrus.Fields{"op": id, "from": from, "to": state, "reason": reason}).
+		Warn("FORCING replication operation state, bypassing transition validation")
+	return forcer.ReplicationForceReplicaOpState(id, state, reason)
+}
+
+// ForceReplicationOpState applies a forced state of an op, bypassing the transition guards and recording the reason
+// until the next transition of the op. Observers and watchers are notified like for any other transition.
+func (s *ShardReplicationFSM) ForceReplicationOpState(c *api.ReplicationForceOpStateRequest) error {
+	from, err := s.forceReplicationOpState(c)
+	if err != nil {
+		return err
+	}
+	s.notifyTransition(c.Id, from, c.State)
+	return nil
+}
+
+func (s *ShardReplicationFSM) forceReplicationOpState(c *api.ReplicationForceOpStateRequest) (api.ShardReplicationState, error) {
+	s.opsLock.Lock()
+	defer s.opsLock.Unlock()
+
+	op, ok := s.opsById[c.Id]
+	if !ok {
+		return "", fmt.Errorf("%w: %d", ErrReplicationOpNotFound, c.Id)
+	}
+
+	status := s.opsStatus[op]
+	from := status.state
+	status.state = c.State
+	status.enteredAt = s.timeProvider.Now()
+	status.forcedReason = c.Reason
+	s.opsByStateGauge.WithLabelValues(from.String()).Dec()
+	s.opsStatus[op] = status
+	s.opsByStateGauge.WithLabelValues(c.State.String()).Inc()
+	return from, nil
+}
+
+// ForcedStateReason returns the reason the current state of the op with the given ID was forced with ForceSetState,
+// and whether it was forced. It is false once the op transitioned again after being forced.
+func (s *ShardReplicationFSM) ForcedStateReason(id uint64) (string, bool) {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	op, ok := s.opsById[id]
+	if !ok {
+		return "", false
+	}
+	status := s.opsStatus[op]
+	return status.forcedReason, status.forcedReason != ""
+}
@@ -12,25 +12,71 @@
 package replication
 
 import (
+	"fmt"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
 
 	"github.com/weaviate/weaviate/cluster/proto/api"
+	"github.com/weaviate/weaviate/cluster/schema"
 )
 
 type shardReplicationOpStatus struct {
 	// state is the current state of the shard replication operation
 	state api.ShardReplicationState
+	// enteredAt is the time, as measured by the clock of the node applying the transition, the operation entered
+	// its current state
+	enteredAt time.Time
+	// committedBatches is the number of object batches already committed to the target replica by a batched copy
+	committedBatches int
+	// forcedReason is the reason the state was forced with ForceSetState, empty unless forced
+	forcedReason string
 }
 
 type ShardReplicationOp struct {
 	ID uint64
 
+	// CostCenter optionally tags the operation so that its replication cost (e.g. bytes copied) can be attributed
+	// to a team or namespace.
+	CostCenter string
+
+	// Deadline optionally sets the time after which the operation is not started anymore. It is registered with the
+	// operation, see api.ReplicationReplicateShardRequest, hence set by another node and compared allowing for the
+	// consumer clock skew tolerance.
+	Deadline time.Time
+
+	// IdempotencyKey optionally identifies the logical replication request the operation was submitted for, so that
+	// the replication engine does not submit the same request twice, see ShardReplicationEngine.Submit.
+	IdempotencyKey string
+
+	// CampaignID optionally groups the operation with other operations submitted together as a campaign, e.g. a
+	// rebalance, so that their aggregate status can be reported, see ShardReplicationFSM.CampaignStatus.
+	CampaignID string
+
+	// Priority optionally orders the operation relative to the other operations: operations with a higher priority
+	// are emitted by the producer first, and dispatched first by the PriorityScheduler. It defaults to zero.
+	Priority int
+
 	// Targeting information of the replication operation
 	sourceShard shardFQDN
 	targetShard shardFQDN
+
+	// startState is the state the operation was in when it was emitted by the producer, used by the consumer to
+	// decide where to resume the operation from. It is not part of the operation stored in the FSM.
+	startState api.ShardReplicationState
+	// committedBatches is the number of object batches the operation copy already committed when it was emitted by
+	// the producer, used by the consumer to resume a batched copy. It is not part of the operation stored in the FSM.
+	committedBatches int
+	// queuedAt is the time the operation was queued by the replication engine, used by the consumer to cancel
+	// operations waiting too long in the queue. It is not part of the operation stored in the FSM.
+	queuedAt time.Time
+	// queueSeq orders the operations queued by the replication engine, a greater value being queued later. It is not
+	// part of the operation stored in the FSM.
+	queueSeq uint64
 }
 
 func NewShardReplicationOp(id uint64, sourceNode, targetNode, collectionId, shardId string) ShardReplicationOp {
@@ -41,6 +87,26 @@ func NewShardReplicationOp(id uint64, sourceNode, targetNode, collectionId, shar
 	}
 }
 
+// SourceNode returns the node the operation copies the shard replica from.
+func (op ShardReplicationOp) SourceNode() string {
+	return op.sourceShard.nodeId
+}
+
+// TargetNode returns the node the operation copies the shard replica to.
+func (op ShardReplicationOp) TargetNode() string {
+	return op.targetShard.nodeId
+}
+
+// Collection returns the collection of the replicated shard.
+func (op ShardReplicationOp) Collection() string {
+	return op.targetShard.collectionId
+}
+
+// Shard returns the replicated shard.
+func (op ShardReplicationOp) Shard() string {
+	return op.targetShard.shardId
+}
+
 type ShardReplicationFSM struct {
 	opsLock sync.RWMutex
 
@@ -52,21 +118,182 @@ type ShardReplicationFSM struct {
 	opsByShard map[string][]ShardReplicationOp
 	// opsByTargetFQDN stores the registered ShardReplicationOp (if any) for each destination replica
 	opsByTargetFQDN map[shardFQDN]ShardReplicationOp
+	// opsBySourceFQDN stores the array of ShardReplicationOp for each source replica
+	opsBySourceFQDN map[shardFQDN][]ShardReplicationOp
 	// opsByShard stores opId -> replicationOp
 	opsById map[uint64]ShardReplicationOp
 	// opsStatus stores op -> opStatus
 	opsStatus       map[ShardReplicationOp]shardReplicationOpStatus
 	opsByStateGauge *prometheus.GaugeVec
+	// droppedEvents counts the transition events not published, labeled by reason, see PublishEvents
+	droppedEvents *prometheus.CounterVec
+
+	// observersLock guards the transition observers, independently of the ops lock so that observers can be
+	// registered while operations are applied.
+	observersLock sync.RWMutex
+	// transitionObservers are notified of every op state transition applied to the FSM
+	transitionObservers []TransitionObserver
+	// removalObservers are notified of every op deleted from the FSM
+	removalObservers []func(id uint64)
+	// opWatchers stores, for each watched op, a channel closed on the next change of the op
+	opWatchers map[uint64]chan struct{}
+	// transitionGuards are consulted before every op state transition and may veto it
+	transitionGuards []TransitionGuard
+
+	// timeProvider and timer are the clock used to timestamp state transitions and to schedule automatic purges
+	timeProvider TimeProvider
+	timer        Timer
+
+	// schemaReader reads the schema the ops are validated against, see ValidateOp
+	schemaReader schema.SchemaReader
+
+	// forceLogger logs the forced op state changes, which are disabled while it is nil, see EnableForceSetState
+	forceLogger logrus.FieldLogger
+}
+
+// TransitionObserver is notified of a replication operation state transition applied to the FSM.
+// The from state is empty when the operation is registered.
+type TransitionObserver func(id uint64, from, to api.ShardReplicationState)
+
+// OnTransition registers an observer notified of every state transition applied to the FSM, including the
+// registration of new operations. Updates keeping an operation in its current state, such as batched copy
+// checkpoints, are not transitions and are not notified. Observers are invoked synchronously after the transition has been applied and
+// the FSM lock released, in the order transitions are applied, so they can be used to build read-model projections
+// of the replication operations. Observers must not block as they delay the application of the Raft log.
+func (s *ShardReplicationFSM) OnTransition(observer TransitionObserver) {
+	s.observersLock.Lock()
+	defer s.observersLock.Unlock()
+	s.transitionObservers = append(s.transitionObservers, observer)
+}
+
+// OnRemoval registers an observer notified of every operation deleted from the FSM, whether deleted or purged. Like
+// transition observers, removal observers are invoked synchronously once the ops lock is released and must not block.
+func (s *ShardReplicationFSM) OnRemoval(observer func(id uint64)) {
+	s.observersLock.Lock()
+	defer s.observersLock.Unlock()
+	s.removalObservers = append(s.removalObservers, observer)
+}
+
+// notifyRemoval invokes the registered removal observers and wakes up the watchers of the op. It must be called
+// without holding the ops lock.
+func (s *ShardReplicationFSM) notifyRemoval(id uint64) {
+	s.notifyOpWatchers(id)
+
+	s.observersLock.RLock()
+	defer s.observersLock.RUnlock()
+	for _, observer := range s.removalObservers {
+		observer(id)
+	}
+}
+
+// notifyTransition invokes the registered transition observers and wakes up the watchers of the op. It must be
+// called without holding the ops lock.
+func (s *ShardReplicationFSM) notifyTransition(id uint64, from, to api.ShardReplicationState) {
+	s.notifyOpWatchers(id)
+
+	s.observersLock.RLock()
+	defer s.observersLock.RUnlock()
+	for _, observer := range s.transitionObservers {
+		observer(id, from, to)
+	}
+}
+
+// TransitionGuard is consulted before a replication operation state transition is proposed. Returning a non-nil error
+// vetoes the transition, leaving the operation in its current state.
+type TransitionGuard func(id uint64, from, to api.ShardReplicationState) error
+
+// AddTransitionGuard registers a guard consulted before every op state transition, e.g. to prevent an operation from
+// being marked READY until an external validation passes. Guards are checked with CheckTransition by the consumer of
+// the engine configured with this FSM, on the node processing the operation, before the update is proposed through
+// the Raft log. A vetoed update fails with an error wrapping ErrTransitionVetoed, which the consumer retries like any
+// other failed status update.
+//
+// Guards are not consulted when applying updates to the FSM, so that every node applies the same updates whatever the
+// guards registered on it. Forced state changes, see ForceSetState, are not checked.
+func (s *ShardReplicationFSM) AddTransitionGuard(guard TransitionGuard) {
+	s.observersLock.Lock()
+	defer s.observersLock.Unlock()
+	s.transitionGuards = append(s.transitionGuards, guard)
+}
+
+// CheckTransition returns an error wrapping ErrTransitionVetoed if a registered guard vetoes moving the op with the
+// given ID from its current state to the given state. Updates keeping the op in its current state, e.g. to record a
+// batched copy checkpoint, are not transitions and are not checked, nor are ops unknown to this FSM.
+func (s *ShardReplicationFSM) CheckTransition(id uint64, to api.ShardReplicationState) error {
+	from, ok := s.getOpStateByID(id)
+	if !ok || from == to {
+		return nil
+	}
+
+	s.observersLock.RLock()
+	guards := slices.Clone(s.transitionGuards)
+	s.observersLock.RUnlock()
+	for _, guard := range guards {
+		if err := guard(id, from, to); err != nil {
+			return fmt.Errorf("%w: op %d from %s to %s: %w", ErrTransitionVetoed, id, from, to, err)
+		}
+	}
+	return nil
+}
+
+// watchOp returns a channel closed on the next change (state transition or deletion) of the op with the given ID.
+func (s *ShardReplicationFSM) watchOp(id uint64) <-chan struct{} {
+	s.observersLock.Lock()
+	defer s.observersLock.Unlock()
+	watcher, ok := s.opWatchers[id]
+	if !ok {
+		watcher = make(chan struct{})
+		s.opWatchers[id] = watcher
+	}
+	return watcher
+}
+
+// notifyOpWatchers wakes up every watcher of the op with the given ID.
+func (s *ShardReplicationFSM) notifyOpWatchers(id uint64) {
+	s.observersLock.Lock()
+	defer s.observersLock.Unlock()
+	if watcher, ok := s.opWatchers[id]; ok {
+		close(watcher)
+		delete(s.opWatchers, id)
+	}
+}
+
+// getOpStateByID returns the state of the op with the given ID and whether the op exists.
+func (s *ShardReplicationFSM) getOpStateByID(id uint64) (api.ShardReplicationState, bool) {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	op, ok := s.opsById[id]
+	if !ok {
+		return "", false
+	}
+	return s.opsStatus[op].state, true
 }
 
-func newShardReplicationFSM(reg prometheus.Registerer) *ShardReplicationFSM {
+// CommittedBatches returns the number of object batches the batched copy of the op with the given ID already committed
+// to the target replica, and whether the op exists.
+func (s *ShardReplicationFSM) CommittedBatches(id uint64) (int, bool) {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	op, ok := s.opsById[id]
+	if !ok {
+		return 0, false
+	}
+	return s.opsStatus[op].committedBatches, true
+}
+
+func newShardReplicationFSM(schemaReader schema.SchemaReader, reg prometheus.Registerer) *ShardReplicationFSM {
 	fsm := &ShardReplicationFSM{
 		opsByNode:       make(map[string][]ShardReplicationOp),
 		opsByCollection: make(map[string][]ShardReplicationOp),
 		opsByShard:      make(map[string][]ShardReplicationOp),
 		opsByTargetFQDN: make(map[shardFQDN]ShardReplicationOp),
+		opsBySourceFQDN: make(map[shardFQDN][]ShardReplicationOp),
 		opsById:         make(map[uint64]ShardReplicationOp),
 		opsStatus:       make(map[ShardReplicationOp]shardReplicationOpStatus),
+		opWatchers:      make(map[uint64]chan struct{}),
+		timeProvider:    RealTimeProvider{},
+		timer:           RealTimer{},
+		schemaReader:    schemaReader,
 	}
 
 	fsm.opsByStateGauge = promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
@@ -74,6 +301,11 @@ func newShardReplicationFSM(reg prometheus.Registerer) *ShardReplicationFSM {
 		Name:      "replication_operation_fsm_ops_by_state",
 		Help:      "Current number of replication operations in each state of the FSM lifecycle",
 	}, []string{"state"})
+	fsm.droppedEvents = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Namespace: "weaviate",
+		Name:      "replication_fsm_events_dropped_total",
+		Help:      "Number of replication operation transition events not published, labeled by reason",
+	}, []string{"reason"})
 
 	return fsm
 }
@@ -84,8 +316,76 @@ func (s *ShardReplicationFSM) GetOpsForNode(node string) []ShardReplicationOp {
 	return s.opsByNode[node]
 }
 
+// GetOpsForCollection returns a copy of the registered operations replicating a shard of the given collection, in
+// registration order. It returns an empty slice for a collection without operations.
+func (s *ShardReplicationFSM) GetOpsForCollection(collection string) []ShardReplicationOp {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	return append([]ShardReplicationOp{}, s.opsByCollection[collection]...)
+}
+
+// GetOpsForShard returns a copy of the registered operations replicating a shard with the given name, in
+// registration order. Operations are indexed by shard name only, hence shards with the same name in different
+// collections are not told apart. It returns an empty slice for a shard without operations.
+func (s *ShardReplicationFSM) GetOpsForShard(shard string) []ShardReplicationOp {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	return append([]ShardReplicationOp{}, s.opsByShard[shard]...)
+}
+
+// GetOpsForSourceFQDN returns, in registration order, the registered operations copying the given source replica,
+// whatever their state.
+func (s *ShardReplicationFSM) GetOpsForSourceFQDN(node, collection, shard string) []ShardReplicationOp {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	return slices.Clone(s.opsBySourceFQDN[newShardFQDN(node, collection, shard)])
+}
+
+// CountOps returns the number of registered operations for which the predicate returns true. The predicate is
+// called with each operation and its current state while holding the FSM read lock, hence it must not call back
+// into the FSM.
+func (s *ShardReplicationFSM) CountOps(pred func(ShardReplicationOp, api.ShardReplicationState) bool) int {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+
+	count := 0
+	for op, status := range s.opsStatus {
+		if pred(op, status.state) {
+			count++
+		}
+	}
+	return count
+}
+
+// ShouldRestartOp reports whether an operation in this state still needs to be processed by the replication engine.
+// Operations in FINALIZING already completed their copy and only need to be finalized.
 func (s shardReplicationOpStatus) ShouldRestartOp() bool {
-	return s.state == api.REGISTERED || s.state == api.HYDRATING
+	return s.state == api.REGISTERED || s.state == api.HYDRATING || s.state == api.FINALIZING
+}
+
+// State returns the current state of the operation.
+func (s shardReplicationOpStatus) State() api.ShardReplicationState {
+	return s.state
+}
+
+// GetOpByID returns the registered operation with the given ID and whether it exists.
+func (s *ShardReplicationFSM) GetOpByID(id uint64) (ShardReplicationOp, bool) {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	op, ok := s.opsById[id]
+	return op, ok
+}
+
+// GetOpWithStatus returns the registered operation with the given ID together with its status, read consistently
+// under the same lock, and whether it exists.
+func (s *ShardReplicationFSM) GetOpWithStatus(id uint64) (ShardReplicationOp, shardReplicationOpStatus, bool) {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+	op, ok := s.opsById[id]
+	if !ok {
+		return ShardReplicationOp{}, shardReplicationOpStatus{}, false
+	}
+	return op, s.opsStatus[op], true
 }
 
 func (s *ShardReplicationFSM) GetOpState(op ShardReplicationOp) shardReplicationOpStatus {
@@ -0,0 +1,916 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+	"github.com/weaviate/weaviate/cluster/replication"
+	"github.com/weaviate/weaviate/cluster/replication/replicationtest"
+	"github.com/weaviate/weaviate/cluster/replication/types"
+	"github.com/weaviate/weaviate/cluster/schema"
+)
+
+type transition struct {
+	id       uint64
+	from, to api.ShardReplicationState
+}
+
+func TestShardReplicationFSM_OnTransition(t *testing.T) {
+	// GIVEN
+	fsm := newTestFSM(t)
+
+	var transitions []transition
+	fsm.OnTransition(func(id uint64, from, to api.ShardReplicationState) {
+		transitions = append(transitions, transition{id: id, from: from, to: to})
+	})
+
+	// WHEN
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.HYDRATING}))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.FINALIZING}))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.READY}))
+	require.ErrorIs(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 2, State: api.READY}), replication.ErrReplicationOpNotFound)
+
+	// THEN
+	require.Equal(t, []transition{
+		{id: 1, from: "", to: api.REGISTERED},
+		{id: 1, from: api.REGISTERED, to: api.HYDRATING},
+		{id: 1, from: api.HYDRATING, to: api.FINALIZING},
+		{id: 1, from: api.FINALIZING, to: api.READY},
+	}, transitions, "observer should receive every applied transition and none for failed ones")
+}
+
+func newTestFSM(t *testing.T) *replication.ShardReplicationFSM {
+	t.Helper()
+	return replication.NewManager(logrus.New(), schema.SchemaReader{}, nil, prometheus.NewPedanticRegistry()).GetReplicationFSM()
+}
+
+func replicateRequest(sourceNode, targetNode, collection, shard string) *api.ReplicationReplicateShardRequest {
+	return &api.ReplicationReplicateShardRequest{
+		SourceNode:       sourceNode,
+		SourceCollection: collection,
+		SourceShard:      shard,
+		TargetNode:       targetNode,
+	}
+}
+
+func TestShardReplicationFSM_CountOps(t *testing.T) {
+	// GIVEN
+	fsm := newTestFSM(t)
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "CollectionA", "shard1")))
+	require.NoError(t, fsm.Replicate(2, replicateRequest("node1", "node3", "CollectionA", "shard2")))
+	require.NoError(t, fsm.Replicate(3, replicateRequest("node2", "node3", "CollectionB", "shard3")))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 2, State: api.HYDRATING}))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 3, State: api.HYDRATING}))
+
+	tests := []struct {
+		name     string
+		pred     func(replication.ShardReplicationOp, api.ShardReplicationState) bool
+		expected int
+	}{
+		{
+			name:     "all ops",
+			pred:     func(replication.ShardReplicationOp, api.ShardReplicationState) bool { return true },
+			expected: 3,
+		},
+		{
+			name: "by state",
+			pred: func(_ replication.ShardReplicationOp, state api.ShardReplicationState) bool {
+				return state == api.HYDRATING
+			},
+			expected: 2,
+		},
+		{
+			name: "by collection",
+			pred: func(op replication.ShardReplicationOp, _ api.ShardReplicationState) bool {
+				return op.Collection() == "CollectionA"
+			},
+			expected: 2,
+		},
+		{
+			name: "by source node",
+			pred: func(op replication.ShardReplicationOp, _ api.ShardReplicationState) bool {
+				return op.SourceNode() == "node2"
+			},
+			expected: 1,
+		},
+		{
+			name: "by target node and state",
+			pred: func(op replication.ShardReplicationOp, state api.ShardReplicationState) bool {
+				return op.TargetNode() == "node3" && state == api.HYDRATING
+			},
+			expected: 2,
+		},
+		{
+			name: "no match",
+			pred: func(op replication.ShardReplicationOp, _ api.ShardReplicationState) bool {
+				return op.Shard() == "unknown"
+			},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			count := fsm.CountOps(tt.pred)
+
+			// THEN
+			require.Equal(t, tt.expected, count)
+		})
+	}
+}
+
+func TestShardReplicationFSM_DeleteReplicationOp(t *testing.T) {
+	// GIVEN an op copying a replica between two distinct nodes, and an op targeting its source node
+	fsm := newTestFSM(t)
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	require.NoError(t, fsm.Replicate(2, replicateRequest("node2", "node1", "TestCollection", "shard2")))
+
+	// WHEN
+	err := fsm.DeleteReplicationOp(&api.ReplicationDeleteOpRequest{Id: 1})
+
+	// THEN the op is found in the index of its target node and deleted without error
+	require.NoError(t, err)
+	require.Empty(t, fsm.GetOpsForNode("node2"))
+	require.Len(t, fsm.GetOpsForNode("node1"), 1, "ops targeting the source node of the deleted op should be kept")
+	require.Zero(t, fsm.CountOps(func(op replication.ShardReplicationOp, _ api.ShardReplicationState) bool { return op.ID == 1 }))
+	require.NoError(t, fsm.Replicate(3, replicateRequest("node1", "node2", "TestCollection", "shard1")),
+		"the target replica of the deleted op should be free again")
+}
+
+func TestShardReplicationFSM_TransitionGuard(t *testing.T) {
+	// GIVEN
+	fsm := newTestFSM(t)
+
+	validated := false
+	fsm.AddTransitionGuard(func(id uint64, from, to api.ShardReplicationState) error {
+		if to == api.READY && !validated {
+			return errors.New("external validation pending")
+		}
+		return nil
+	})
+
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.HYDRATING}))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.FINALIZING}))
+
+	// WHEN the guard vetoes the transition
+	err := fsm.CheckTransition(1, api.READY)
+
+	// THEN
+	require.ErrorIs(t, err, replication.ErrTransitionVetoed)
+	require.NoError(t, fsm.CheckTransition(1, api.FINALIZING), "staying in the same state is not a transition")
+	require.NoError(t, fsm.CheckTransition(2, api.READY), "ops unknown to the FSM are not checked")
+
+	// WHEN the guard allows the transition
+	validated = true
+	err = fsm.CheckTransition(1, api.READY)
+
+	// THEN
+	require.NoError(t, err)
+}
+
+func TestShardReplicationFSM_TransitionGuardNotApplied(t *testing.T) {
+	// GIVEN a guard vetoing every transition
+	fsm := newTestFSM(t)
+	fsm.AddTransitionGuard(func(id uint64, from, to api.ShardReplicationState) error {
+		return errors.New("transitions are frozen")
+	})
+	var transitions []transition
+	fsm.OnTransition(func(id uint64, from, to api.ShardReplicationState) {
+		transitions = append(transitions, transition{id: id, from: from, to: to})
+	})
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+
+	// WHEN an update committed to the Raft log is applied
+	err := fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.HYDRATING})
+
+	// THEN it is applied whatever the guards registered on the node, so that every node keeps the same state
+	require.NoError(t, err)
+	require.Contains(t, transitions, transition{id: 1, from: api.REGISTERED, to: api.HYDRATING})
+}
+
+func TestShardReplicationFSM_DebounceTransitions(t *testing.T) {
+	// GIVEN
+	fsm := newTestFSM(t)
+	mockTimer := replication.NewMockTimer(t)
+
+	var windows []func()
+	mockTimer.On("AfterFunc", 100*time.Millisecond, mock.Anything).Run(func(args mock.Arguments) {
+		windows = append(windows, args.Get(1).(func()))
+	}).Return(nil)
+
+	var notifications []transition
+	fsm.OnTransition(replication.DebounceTransitions(100*time.Millisecond, mockTimer, func(id uint64, from, to api.ShardReplicationState) {
+		notifications = append(notifications, transition{id: id, from: from, to: to})
+	}))
+
+	// WHEN an op changes state rapidly within the window
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.HYDRATING}))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.FINALIZING}))
+	require.NoError(t, fsm.Replicate(2, replicateRequest("node1", "node2", "TestCollection", "shard2")))
+
+	// THEN nothing is delivered before the windows elapse
+	require.Len(t, windows, 2, "one window should be started per op")
+	require.Empty(t, notifications)
+
+	// WHEN the windows elapse
+	windows[0]()
+	windows[1]()
+
+	// THEN a single coalesced notification per op is delivered with the latest state
+	require.Equal(t, []transition{
+		{id: 1, from: "", to: api.FINALIZING},
+		{id: 2, from: "", to: api.REGISTERED},
+	}, notifications)
+
+	// WHEN the op changes state after the window
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.READY}))
+	require.Len(t, windows, 3, "a new window should be started")
+	windows[2]()
+
+	// THEN
+	require.Equal(t, transition{id: 1, from: api.FINALIZING, to: api.READY}, notifications[2])
+}
+
+func TestShardReplicationFSM_StartAutoPurge(t *testing.T) {
+	// GIVEN
+	fsm := newTestFSM(t)
+	mockTimeProvider := replication.NewMockTimeProvider(t)
+	mockTimer := replication.NewMockTimer(t)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mockTimeProvider.EXPECT().Now().RunAndReturn(func() time.Time { return now })
+	var runs []func()
+	mockTimer.On("AfterFunc", time.Minute, mock.Anything).Run(func(args mock.Arguments) {
+		runs = append(runs, args.Get(1).(func()))
+	}).Return(nil)
+	fsm.SetClock(mockTimeProvider, mockTimer)
+
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	require.NoError(t, fsm.Replicate(2, replicateRequest("node1", "node2", "TestCollection", "shard2")))
+	require.NoError(t, fsm.Replicate(3, replicateRequest("node1", "node2", "TestCollection", "shard3")))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.READY}))
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 2, State: api.ABORTED}))
+
+	remaining := func() []uint64 {
+		var ids []uint64
+		for id := uint64(1); id <= 3; id++ {
+			if fsm.CountOps(func(op replication.ShardReplicationOp, _ api.ShardReplicationState) bool { return op.ID == id }) == 1 {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	purger := &fsmPurger{fsm: fsm}
+
+	// WHEN
+	fsm.StartAutoPurge(ctx, purger, time.Minute, time.Hour)
+
+	// THEN the first purge is scheduled
+	require.Len(t, runs, 1)
+
+	// WHEN the first interval elapses before any op is old enough
+	runs[0]()
+
+	// THEN nothing is purged and the next purge is scheduled
+	require.Equal(t, []uint64{1, 2, 3}, remaining())
+	require.Empty(t, purger.purges, "no purge should be submitted without old enough ops")
+	require.Len(t, runs, 2)
+
+	// WHEN the READY op becomes older than the purge age
+	now = now.Add(31 * time.Minute)
+	runs[1]()
+
+	// THEN only the READY op is purged
+	require.Equal(t, []uint64{2, 3}, remaining())
+	require.Equal(t, [][]uint64{{1}}, purger.purges)
+	require.Len(t, runs, 3)
+
+	// WHEN the ABORTED op becomes older than the purge age
+	now = now.Add(30 * time.Minute)
+	runs[2]()
+
+	// THEN it is purged too while the op still in progress is kept
+	require.Equal(t, []uint64{3}, remaining())
+	require.Len(t, runs, 4)
+
+	// WHEN the context is canceled
+	cancel()
+	runs[3]()
+
+	// THEN no further purge is scheduled
+	require.Len(t, runs, 4)
+}
+
+func TestShardReplicationFSM_PurgeReplicationOps(t *testing.T) {
+	// GIVEN
+	fsm := newTestFSM(t)
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	require.NoError(t, fsm.Replicate(2, replicateRequest("node1", "node2", "TestCollection", "shard2")))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.READY}))
+
+	// WHEN purging a terminal op, an op still in progress and an unknown op
+	err := fsm.PurgeReplicationOps(&api.ReplicationPurgeOpsRequest{Ids: []uint64{1, 2, 3}})
+
+	// THEN only the terminal op is deleted, from every index
+	require.NoError(t, err)
+	_, ok := fsm.GetOpByID(1)
+	require.False(t, ok)
+	require.Len(t, fsm.GetOpsForNode("node2"), 1)
+	require.Len(t, fsm.GetOpsForCollection("TestCollection"), 1)
+	require.Equal(t, uint64(2), fsm.GetOpsForCollection("TestCollection")[0].ID)
+}
+
+// fsmPurger is a types.OpPurger applying the purges to the FSM directly, as every node does when applying them from
+// the Raft log, recording the purged IDs.
+type fsmPurger struct {
+	fsm    *replication.ShardReplicationFSM
+	purges [][]uint64
+}
+
+func (p *fsmPurger) ReplicationPurgeOps(ids []uint64) error {
+	p.purges = append(p.purges, ids)
+	return p.fsm.PurgeReplicationOps(&api.ReplicationPurgeOpsRequest{Ids: ids})
+}
+
+func TestShardReplicationFSM_CommittedBatches(t *testing.T) {
+	// GIVEN
+	fsm := newTestFSM(t)
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.HYDRATING}))
+	var transitions atomic.Int32
+	fsm.OnTransition(func(uint64, api.ShardReplicationState, api.ShardReplicationState) { transitions.Add(1) })
+	fsm.AddTransitionGuard(func(_ uint64, from, to api.ShardReplicationState) error {
+		if from == to {
+			return errors.New("not a transition")
+		}
+		return nil
+	})
+
+	// WHEN checkpoints are recorded
+	for _, committed := range []int{1, 2, 3} {
+		require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{
+			Id: 1, State: api.HYDRATING, CommittedBatches: &committed,
+		}))
+	}
+
+	// THEN checkpoints are neither guarded nor notified as transitions
+	require.NoError(t, fsm.CheckTransition(1, api.HYDRATING))
+	require.Zero(t, transitions.Load())
+
+	// THEN the last checkpoint is kept, including by updates not recording a checkpoint
+	committed, ok := fsm.CommittedBatches(1)
+	require.True(t, ok)
+	require.Equal(t, 3, committed)
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.HYDRATING}))
+	committed, _ = fsm.CommittedBatches(1)
+	require.Equal(t, 3, committed)
+
+	// WHEN the op is emitted by the producer and consumed with batch commits
+	logger, _ := logrustest.NewNullLogger()
+	producer := replication.NewFSMOpProducer(logger, fsm, 10*time.Millisecond, "node2")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	produced := make(chan replication.ShardReplicationOp, 1)
+	go producer.Produce(ctx, produced)
+	op := <-produced
+	cancel()
+
+	copier := &fakeBatchReplicaCopier{batches: 5}
+	fsmUpdater := types.NewMockFSMUpdater(t)
+	fsmUpdater.On("ReplicationUpdateReplicaOpStatus", uint64(1), mock.Anything).Return(nil)
+	fsmUpdater.On("AddReplicaToShard", mock.Anything, "TestCollection", "shard1", "node2").Return(uint64(0), nil)
+	consumer := replication.NewCopyOpConsumer(logger, fsmUpdater, copier, replication.RealTimeProvider{}, "node2",
+		func() backoff.BackOff { return &backoff.StopBackOff{} }, time.Minute, 1, replication.WithBatchCommit())
+	ops := make(chan replication.ShardReplicationOp, 1)
+	ops <- op
+	close(ops)
+	require.NoError(t, consumer.Consume(context.Background(), ops))
+
+	// THEN the copy resumes after the last committed batch
+	require.Equal(t, []int{3, 4}, copier.copiedBatches())
+
+	// WHEN the op moves on
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: api.FINALIZING}))
+
+	// THEN the checkpoint is reset
+	committed, _ = fsm.CommittedBatches(1)
+	require.Zero(t, committed)
+}
+
+// recordingPublisher is an EventPublisher sending every published event to a channel, blocking while it is full.
+type recordingPublisher struct {
+	events chan replication.TransitionEvent
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event replication.TransitionEvent) error {
+	select {
+	case p.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestShardReplicationFSM_PublishEvents(t *testing.T) {
+	t.Run("every transition is published", func(t *testing.T) {
+		// GIVEN
+		fsm := newTestFSM(t)
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock := replicationtest.NewFakeClock(start)
+		fsm.SetClock(clock, clock)
+		publisher := &recordingPublisher{events: make(chan replication.TransitionEvent, 10)}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		logger, _ := logrustest.NewNullLogger()
+		fsm.PublishEvents(ctx, logger, publisher, 10)
+
+		// WHEN
+		require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+		for _, state := range []api.ShardReplicationState{api.HYDRATING, api.FINALIZING, api.READY} {
+			clock.Advance(time.Minute)
+			require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 1, State: state}))
+		}
+
+		// THEN
+		event := func(from, to api.ShardReplicationState, at time.Duration) replication.TransitionEvent {
+			return replication.TransitionEvent{
+				OpID: 1, From: from, To: to, Timestamp: start.Add(at),
+				SourceShard: "node1/TestCollection/shard1", TargetShard: "node2/TestCollection/shard1",
+			}
+		}
+		expected := []replication.TransitionEvent{
+			event("", api.REGISTERED, 0),
+			event(api.REGISTERED, api.HYDRATING, time.Minute),
+			event(api.HYDRATING, api.FINALIZING, 2*time.Minute),
+			event(api.FINALIZING, api.READY, 3*time.Minute),
+		}
+		for _, want := range expected {
+			select {
+			case got := <-publisher.events:
+				require.Equal(t, want, got)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("event %s -> %s not published", want.From, want.To)
+			}
+		}
+	})
+
+	t.Run("slow publisher does not block the FSM", func(t *testing.T) {
+		// GIVEN a publisher never done publishing the first event
+		reg := prometheus.NewPedanticRegistry()
+		fsm := replication.NewManager(logrus.New(), schema.SchemaReader{}, nil, reg).GetReplicationFSM()
+		publisher := &recordingPublisher{events: make(chan replication.TransitionEvent)}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		logger, _ := logrustest.NewNullLogger()
+		fsm.PublishEvents(ctx, logger, publisher, 2)
+
+		// WHEN
+		applied := make(chan struct{})
+		go func() {
+			defer close(applied)
+			for id := uint64(1); id <= 10; id++ {
+				require.NoError(t, fsm.Replicate(id, replicateRequest("node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))))
+			}
+		}()
+
+		// THEN every transition is applied and the events not fitting in the buffer are dropped
+		select {
+		case <-applied:
+		case <-time.After(5 * time.Second):
+			t.Fatal("transitions should be applied while the publisher is blocked")
+		}
+		require.Equal(t, 10, fsm.CountOps(func(replication.ShardReplicationOp, api.ShardReplicationState) bool { return true }))
+		// One event is taken by the blocked publisher and two are buffered, unless the publishing goroutine did not
+		// take the first event before the buffer filled up
+		metrics, err := reg.Gather()
+		require.NoError(t, err)
+		var dropped float64
+		for _, family := range metrics {
+			if family.GetName() == "weaviate_replication_fsm_events_dropped_total" {
+				dropped = family.GetMetric()[0].GetCounter().GetValue()
+			}
+		}
+		require.Contains(t, []float64{7, 8}, dropped)
+	})
+}
+
+func TestShardReplicationFSM_CollectionAvailability(t *testing.T) {
+	// GIVEN a collection with several shards whose replicas are in mixed states
+	fsm := newTestFSM(t)
+	ops := []struct {
+		id     uint64
+		target string
+		shard  string
+		state  api.ShardReplicationState
+	}{
+		{id: 1, target: "node2", shard: "shard1", state: api.READY},
+		{id: 2, target: "node3", shard: "shard1", state: api.FINALIZING},
+		{id: 3, target: "node4", shard: "shard1", state: api.HYDRATING},
+		{id: 4, target: "node2", shard: "shard2", state: api.REGISTERED},
+		{id: 5, target: "node3", shard: "shard2", state: api.ABORTED},
+		{id: 6, target: "node2", shard: "shard3", state: api.READY},
+		{id: 7, target: "node3", shard: "shard3", state: api.DEHYDRATING},
+	}
+	for _, op := range ops {
+		require.NoError(t, fsm.Replicate(op.id, replicateRequest("node1", op.target, "CollectionA", op.shard)))
+		if op.state != api.REGISTERED {
+			require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: op.id, State: op.state}))
+		}
+	}
+	require.NoError(t, fsm.Replicate(8, replicateRequest("node1", "node2", "CollectionB", "shard1")))
+
+	// WHEN
+	report := fsm.CollectionAvailability("CollectionA")
+
+	// THEN
+	require.Equal(t, replication.CollectionAvailabilityReport{
+		Collection: "CollectionA",
+		Shards: []replication.ShardAvailability{
+			{Shard: "shard1", Ready: 1, Finalizing: 1, InProgress: 1},
+			{Shard: "shard2", InProgress: 1},
+			{Shard: "shard3", Ready: 2},
+		},
+	}, report)
+	require.Empty(t, fsm.CollectionAvailability("UnknownCollection").Shards)
+}
+
+func TestShardReplicationFSM_ForceSetState(t *testing.T) {
+	// GIVEN an op in a state a guard forbids leaving
+	fsm := newTestFSM(t)
+	fsm.AddTransitionGuard(func(id uint64, from, to api.ShardReplicationState) error {
+		return errors.New("transitions are frozen")
+	})
+	var transitions []transition
+	fsm.OnTransition(func(id uint64, from, to api.ShardReplicationState) {
+		transitions = append(transitions, transition{id: id, from: from, to: to})
+	})
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	require.ErrorIs(t, fsm.CheckTransition(1, api.READY), replication.ErrTransitionVetoed)
+
+	forcer := &fsmForcer{fsm: fsm}
+
+	// WHEN forcing the state without enabling forced state changes
+	err := fsm.ForceSetState(forcer, 1, api.READY, "recover op stuck by a bug")
+
+	// THEN
+	require.ErrorIs(t, err, replication.ErrForceSetStateDisabled)
+	_, forced := fsm.ForcedStateReason(1)
+	require.False(t, forced)
+
+	// WHEN forcing the state once enabled
+	logger, hook := logrustest.NewNullLogger()
+	fsm.EnableForceSetState(logger)
+	err = fsm.ForceSetState(forcer, 1, api.READY, "recover op stuck by a bug")
+
+	// THEN
+	require.NoError(t, err)
+	require.Equal(t, 1, fsm.CountOps(func(_ replication.ShardReplicationOp, state api.ShardReplicationState) bool {
+		return state == api.READY
+	}))
+	reason, forced := fsm.ForcedStateReason(1)
+	require.True(t, forced)
+	require.Equal(t, "recover op stuck by a bug", reason)
+	require.Contains(t, transitions, transition{id: 1, from: api.REGISTERED, to: api.READY})
+	require.NotNil(t, hook.LastEntry())
+	require.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+	require.Equal(t, "recover op stuck by a bug", hook.LastEntry().Data["reason"])
+	require.ErrorIs(t, fsm.ForceSetState(forcer, 2, api.READY, "unknown op"), replication.ErrReplicationOpNotFound)
+	require.Equal(t, 1, forcer.forced, "only the forced state of a known op should be submitted")
+}
+
+// fsmForcer is a types.OpStateForcer applying the forced states to the FSM directly, as every node does when applying
+// them from the Raft log.
+type fsmForcer struct {
+	fsm    *replication.ShardReplicationFSM
+	forced int
+}
+
+func (f *fsmForcer) ReplicationForceReplicaOpState(id uint64, state api.ShardReplicationState, reason string) error {
+	f.forced++
+	return f.fsm.ForceReplicationOpState(&api.ReplicationForceOpStateRequest{Id: id, State: state, Reason: reason})
+}
+
+func TestShardReplicationFSM_ListOps(t *testing.T) {
+	// GIVEN ops registered out of ID order
+	fsm := newTestFSM(t)
+	ids := []uint64{7, 3, 10, 1, 5, 8, 2}
+	for _, id := range ids {
+		require.NoError(t, fsm.Replicate(id, replicateRequest("node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))))
+	}
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 5, State: api.HYDRATING}))
+
+	// WHEN paging through the ops
+	var listed []uint64
+	for offset := 0; ; offset += 3 {
+		page, total := fsm.ListOps(offset, 3)
+
+		// THEN every page reports the total and holds at most the limit
+		require.Equal(t, len(ids), total)
+		require.LessOrEqual(t, len(page), 3)
+		if len(page) == 0 {
+			break
+		}
+		for _, op := range page {
+			listed = append(listed, op.ID)
+			if op.ID == 5 {
+				require.Equal(t, api.HYDRATING, op.State)
+				require.Equal(t, "shard5", op.Shard)
+			}
+		}
+	}
+
+	// THEN the pages do not overlap and cover all ops, ordered by ID
+	require.Equal(t, []uint64{1, 2, 3, 5, 7, 8, 10}, listed)
+
+	page, total := fsm.ListOps(len(ids), 3)
+	require.Empty(t, page)
+	require.Equal(t, len(ids), total)
+	page, _ = fsm.ListOps(0, 0)
+	require.Empty(t, page)
+}
+
+// steppingClock is a TimeProvider whose time advances by step on every reading.
+type steppingClock struct {
+	start time.Time
+	step  time.Duration
+	reads atomic.Int64
+}
+
+func (c *steppingClock) Now() time.Time {
+	return c.start.Add(time.Duration(c.reads.Add(1)) * c.step)
+}
+
+func TestFSMOpProducer_ScanTimeCap(t *testing.T) {
+	// GIVEN a large FSM where half of the ops of the node are eligible, and a scan measured by a clock advancing by a
+	// millisecond on every reading, hence capped to about 200 ops
+	fsm := newTestFSM(t)
+	const numOps = 2000
+	for id := uint64(1); id <= numOps; id++ {
+		require.NoError(t, fsm.Replicate(id, replicateRequest("node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))))
+		if id%2 == 0 {
+			require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: id, State: api.READY}))
+		}
+	}
+	logger, _ := logrustest.NewNullLogger()
+	clock := &steppingClock{start: time.Now(), step: time.Millisecond}
+	producer := replication.NewFSMOpProducer(logger, fsm, 50*time.Millisecond, "node2",
+		replication.WithScanConcurrency(4), replication.WithScanTimeCap(200*time.Millisecond), replication.WithProducerTimeProvider(clock))
+
+	// WHEN
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	produced := make(chan replication.ShardReplicationOp, numOps)
+	go producer.Produce(ctx, produced)
+
+	// THEN the first scan stops at the time cap, still emitting the eligible ops it scanned
+	first := <-produced
+	time.Sleep(10 * time.Millisecond) // Far less than the polling interval, the first scan completed by then
+	firstScan := 1 + len(produced)
+	require.Greater(t, firstScan, 1)
+	require.Less(t, firstScan, numOps/2, "the scan should stop at the time cap")
+	require.Equal(t, uint64(1), first.ID)
+
+	// THEN the following scans resume where the previous stopped until every eligible op is emitted
+	emitted := map[uint64]struct{}{first.ID: {}}
+	require.Eventually(t, func() bool {
+		for {
+			select {
+			case op := <-produced:
+				require.Equal(t, uint64(1), op.ID%2, "only eligible ops should be emitted")
+				emitted[op.ID] = struct{}{}
+			default:
+				return len(emitted) == numOps/2
+			}
+		}
+	}, 10*time.Second, time.Millisecond)
+}
+
+func TestFSMOpProducer_Priority(t *testing.T) {
+	// GIVEN low priority ops registered before a high priority one
+	fsm := newTestFSM(t)
+	for id := uint64(1); id <= 3; id++ {
+		require.NoError(t, fsm.Replicate(id, replicateRequest("node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))))
+	}
+	req := replicateRequest("node1", "node2", "TestCollection", "shard4")
+	req.Priority = 10
+	require.NoError(t, fsm.Replicate(4, req))
+	logger, _ := logrustest.NewNullLogger()
+	producer := replication.NewFSMOpProducer(logger, fsm, 50*time.Millisecond, "node2")
+
+	// WHEN
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	produced := make(chan replication.ShardReplicationOp, 4)
+	go producer.Produce(ctx, produced)
+
+	// THEN the high priority op is emitted first, followed by the low priority ops in registration order
+	ids := make([]uint64, 0, 4)
+	for range 4 {
+		op := <-produced
+		ids = append(ids, op.ID)
+		if op.ID == 4 {
+			require.Equal(t, 10, op.Priority)
+		}
+	}
+	require.Equal(t, []uint64{4, 1, 2, 3}, ids)
+}
+
+func TestFSMOpProducer_Deadline(t *testing.T) {
+	// GIVEN an op registered with a deadline, through a request encoded as in the Raft log, and an op without one
+	fsm := newTestFSM(t)
+	deadline := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	req := replicateRequest("node1", "node2", "TestCollection", "shard1")
+	req.Deadline = deadline
+	encoded, err := json.Marshal(req)
+	require.NoError(t, err)
+	decoded := &api.ReplicationReplicateShardRequest{}
+	require.NoError(t, json.Unmarshal(encoded, decoded))
+	require.NoError(t, fsm.Replicate(1, decoded))
+
+	encoded, err = json.Marshal(replicateRequest("node1", "node2", "TestCollection", "shard2"))
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), "Deadline", "an unset deadline should not be encoded")
+	require.NoError(t, fsm.Replicate(2, replicateRequest("node1", "node2", "TestCollection", "shard2")))
+
+	logger, _ := logrustest.NewNullLogger()
+	producer := replication.NewFSMOpProducer(logger, fsm, 50*time.Millisecond, "node2")
+
+	// WHEN
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	produced := make(chan replication.ShardReplicationOp, 2)
+	go producer.Produce(ctx, produced)
+
+	// THEN the ops are emitted with their deadline, for the consumer to enforce it
+	deadlines := make(map[uint64]time.Time, 2)
+	for range 2 {
+		op := <-produced
+		deadlines[op.ID] = op.Deadline
+	}
+	require.True(t, deadline.Equal(deadlines[1]))
+	require.True(t, deadlines[2].IsZero())
+}
+
+func TestShardReplicationFSM_GetOpByID(t *testing.T) {
+	// GIVEN
+	fsm := newTestFSM(t)
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	require.NoError(t, fsm.Replicate(2, replicateRequest("node1", "node3", "TestCollection", "shard2")))
+	require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: 2, State: api.HYDRATING}))
+
+	// WHEN
+	op, ok := fsm.GetOpByID(2)
+
+	// THEN the op is resolved with its source and target shards
+	require.True(t, ok)
+	require.Equal(t, uint64(2), op.ID)
+	require.Equal(t, "node1", op.SourceNode())
+	require.Equal(t, "node3", op.TargetNode())
+	require.Equal(t, "TestCollection", op.Collection())
+	require.Equal(t, "shard2", op.Shard())
+
+	// WHEN
+	op, status, ok := fsm.GetOpWithStatus(2)
+
+	// THEN the op is returned together with its current state
+	require.True(t, ok)
+	require.Equal(t, uint64(2), op.ID)
+	require.Equal(t, api.HYDRATING, status.State())
+
+	// THEN unknown ops are reported as missing
+	_, ok = fsm.GetOpByID(3)
+	require.False(t, ok)
+	_, _, ok = fsm.GetOpWithStatus(3)
+	require.False(t, ok)
+}
+
+func TestShardReplicationFSM_GetOpsForCollectionAndShard(t *testing.T) {
+	// GIVEN ops of several collections and shards
+	fsm := newTestFSM(t)
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "CollectionA", "shard1")))
+	require.NoError(t, fsm.Replicate(2, replicateRequest("node1", "node2", "CollectionB", "shard1")))
+	require.NoError(t, fsm.Replicate(3, replicateRequest("node1", "node3", "CollectionA", "shard2")))
+
+	ids := func(ops []replication.ShardReplicationOp) []uint64 {
+		ids := make([]uint64, 0, len(ops))
+		for _, op := range ops {
+			ids = append(ids, op.ID)
+		}
+		return ids
+	}
+
+	// WHEN / THEN
+	require.Equal(t, []uint64{1, 3}, ids(fsm.GetOpsForCollection("CollectionA")))
+	require.Equal(t, []uint64{2}, ids(fsm.GetOpsForCollection("CollectionB")))
+	require.Equal(t, []uint64{1, 2}, ids(fsm.GetOpsForShard("shard1")))
+	require.Equal(t, []uint64{3}, ids(fsm.GetOpsForShard("shard2")))
+
+	// THEN unknown collections and shards have no ops
+	require.NotNil(t, fsm.GetOpsForCollection("CollectionC"))
+	require.Empty(t, fsm.GetOpsForCollection("CollectionC"))
+	require.NotNil(t, fsm.GetOpsForShard("shard3"))
+	require.Empty(t, fsm.GetOpsForShard("shard3"))
+
+	// THEN mutating the returned ops does not mutate the FSM
+	ops := fsm.GetOpsForCollection("CollectionA")
+	ops[0] = replication.NewShardReplicationOp(100, "node1", "node2", "CollectionA", "shard1")
+	shardOps := fsm.GetOpsForShard("shard1")
+	shardOps[0] = replication.NewShardReplicationOp(100, "node1", "node2", "CollectionA", "shard1")
+	require.Equal(t, []uint64{1, 3}, ids(fsm.GetOpsForCollection("CollectionA")))
+	require.Equal(t, []uint64{1, 2}, ids(fsm.GetOpsForShard("shard1")))
+}
+
+func TestShardReplicationFSM_GetOpsForSourceFQDN(t *testing.T) {
+	// GIVEN ops copying the same source replica alongside ops of other shards, collections and source nodes
+	fsm := newTestFSM(t)
+	require.NoError(t, fsm.Replicate(1, replicateRequest("node1", "node2", "TestCollection", "shard1")))
+	require.NoError(t, fsm.Replicate(2, replicateRequest("node1", "node2", "TestCollection", "shard2")))
+	require.NoError(t, fsm.Replicate(3, replicateRequest("node1", "node3", "TestCollection", "shard1")))
+	require.NoError(t, fsm.Replicate(4, replicateRequest("node2", "node4", "TestCollection", "shard1")))
+	require.NoError(t, fsm.Replicate(5, replicateRequest("node1", "node2", "OtherCollection", "shard1")))
+
+	ids := func(ops []replication.ShardReplicationOp) []uint64 {
+		ids := make([]uint64, 0, len(ops))
+		for _, op := range ops {
+			ids = append(ids, op.ID)
+		}
+		return ids
+	}
+
+	// WHEN / THEN only the ops copying the source replica are returned
+	require.Equal(t, []uint64{1, 3}, ids(fsm.GetOpsForSourceFQDN("node1", "TestCollection", "shard1")))
+	require.Equal(t, []uint64{4}, ids(fsm.GetOpsForSourceFQDN("node2", "TestCollection", "shard1")))
+	require.Empty(t, fsm.GetOpsForSourceFQDN("node3", "TestCollection", "shard1"))
+
+	// WHEN / THEN deleted ops are removed from the index
+	require.NoError(t, fsm.DeleteReplicationOp(&api.ReplicationDeleteOpRequest{Id: 1}))
+	require.NoError(t, fsm.DeleteReplicationOp(&api.ReplicationDeleteOpRequest{Id: 4}))
+	require.Equal(t, []uint64{3}, ids(fsm.GetOpsForSourceFQDN("node1", "TestCollection", "shard1")))
+	require.Empty(t, fsm.GetOpsForSourceFQDN("node2", "TestCollection", "shard1"))
+}
+
+func TestShardReplicationFSM_CampaignStatus(t *testing.T) {
+	// GIVEN a campaign of ops in mixed states, alongside ops of another campaign and without campaign
+	fsm := newTestFSM(t)
+	campaignStates := []api.ShardReplicationState{api.REGISTERED, api.HYDRATING, api.FINALIZING, api.READY, api.DEHYDRATING, api.ABORTED, api.ABORTED}
+	for i, state := range campaignStates {
+		id := uint64(i + 1)
+		req := replicateRequest("node1", "node2", "TestCollection", fmt.Sprintf("shard%d", id))
+		req.CampaignID = "rebalance-1"
+		require.NoError(t, fsm.Replicate(id, req))
+		if state != api.REGISTERED {
+			require.NoError(t, fsm.UpdateReplicationOpStatus(&api.ReplicationUpdateOpStateRequest{Id: id, State: state}))
+		}
+	}
+	other := replicateRequest("node1", "node3", "TestCollection", "shard1")
+	other.CampaignID = "rebalance-2"
+	require.NoError(t, fsm.Replicate(100, other))
+	require.NoError(t, fsm.Replicate(101, replicateRequest("node1", "node3", "TestCollection", "shard2")))
+
+	// WHEN
+	report := fsm.CampaignStatus("rebalance-1")
+
+	// THEN
+	require.Equal(t, replication.CampaignStatusReport{
+		CampaignID: "rebalance-1",
+		Total:      7,
+		Done:       2,
+		Failed:     2,
+		InProgress: 3,
+	}, report)
+	require.Equal(t, replication.CampaignStatusReport{CampaignID: "rebalance-2", Total: 1, InProgress: 1}, fsm.CampaignStatus("rebalance-2"))
+	require.Equal(t, replication.CampaignStatusReport{CampaignID: "unknown"}, fsm.CampaignStatus("unknown"))
+}
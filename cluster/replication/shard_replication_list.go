@@ -0,0 +1,88 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// OpStatusView is a read-only view of a replication operation and its current status in the FSM.
+type OpStatusView struct {
+	ID         uint64
+	SourceNode string
+	TargetNode string
+	Collection string
+	Shard      string
+	State      api.ShardReplicationState
+	// EnteredAt is the time the operation entered its current state, as measured by the node applying the transition.
+	EnteredAt time.Time
+}
+
+// ListOps returns a page of at most limit operations, skipping the first offset operations, together with the total
+// number of operations. Operations are ordered by ID so that consecutive pages do not overlap while the set of
+// operations is unchanged. The page is empty if offset is beyond the last operation or if limit is not positive.
+func (s *ShardReplicationFSM) ListOps(offset, limit int) ([]OpStatusView, int) {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+
+	total := len(s.opsById)
+	offset = max(offset, 0)
+	if limit <= 0 || offset >= total {
+		return []OpStatusView{}, total
+	}
+
+	ids := make([]uint64, 0, total)
+	for id := range s.opsById {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	ids = ids[offset:min(offset+limit, total)]
+
+	page := make([]OpStatusView, 0, len(ids))
+	for _, id := range ids {
+		op := s.opsById[id]
+		status := s.opsStatus[op]
+		page = append(page, OpStatusView{
+			ID:         op.ID,
+			SourceNode: op.sourceShard.nodeId,
+			TargetNode: op.targetShard.nodeId,
+			Collection: op.targetShard.collectionId,
+			Shard:      op.targetShard.shardId,
+			State:      status.state,
+			EnteredAt:  status.enteredAt,
+		})
+	}
+	return page, total
+}
+
+// finalizingOps returns, in ascending ID order, the operations targeting the given node that are in FINALIZING,
+// with their start state set accordingly.
+func (s *ShardReplicationFSM) finalizingOps(node string) []ShardReplicationOp {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+
+	var ops []ShardReplicationOp
+	for _, op := range s.opsByNode[node] {
+		if s.opsStatus[op].state == api.FINALIZING {
+			op.startState = api.FINALIZING
+			ops = append(ops, op)
+		}
+	}
+	slices.SortFunc(ops, func(a, b ShardReplicationOp) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return ops
+}
@@ -0,0 +1,116 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+	"github.com/weaviate/weaviate/cluster/replication/types"
+)
+
+// SetClock replaces the clock used by the FSM to timestamp op state transitions and to schedule automatic purges.
+// It is meant for tests and must be called before operations are applied.
+func (s *ShardReplicationFSM) SetClock(timeProvider TimeProvider, timer Timer) {
+	s.opsLock.Lock()
+	defer s.opsLock.Unlock()
+	s.timeProvider = timeProvider
+	s.timer = timer
+}
+
+// TerminalOpsOlderThan returns, in ID order, the IDs of the operations that have been READY or ABORTED for longer than
+// olderThan, as measured by the clock of the local node.
+func (s *ShardReplicationFSM) TerminalOpsOlderThan(olderThan time.Duration) []uint64 {
+	s.opsLock.RLock()
+	defer s.opsLock.RUnlock()
+
+	cutoff := s.timeProvider.Now().Add(-olderThan)
+	var ids []uint64
+	for op, status := range s.opsStatus {
+		if isTerminalState(status.state) && status.enteredAt.Before(cutoff) {
+			ids = append(ids, op.ID)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// PurgeReplicationOps deletes the requested operations that are still READY or ABORTED, skipping the others as well
+// as the operations already deleted, so that the purge is applied identically by every node whatever the clock of the
+// node that requested it. Watchers and removal observers of the deleted operations are notified like for any other
+// deletion.
+func (s *ShardReplicationFSM) PurgeReplicationOps(c *api.ReplicationPurgeOpsRequest) error {
+	var purged []uint64
+	s.opsLock.Lock()
+	for _, id := range c.Ids {
+		op, ok := s.opsById[id]
+		if !ok || !isTerminalState(s.opsStatus[op].state) {
+			continue
+		}
+		// The op was found in the ops by ID, so that the other indexes are expected to hold it too
+		_ = s.deleteShardReplicationOpLocked(op)
+		purged = append(purged, id)
+	}
+	s.opsLock.Unlock()
+
+	for _, id := range purged {
+		s.notifyRemoval(id)
+	}
+	return nil
+}
+
+func isTerminalState(state api.ShardReplicationState) bool {
+	return state == api.READY || state == api.ABORTED
+}
+
+// StartAutoPurge purges every interval, through the purger, the operations returned by TerminalOpsOlderThan until the
+// context is done, so that terminal operations older than olderThan do not pile up in the FSM. The purge is applied
+// through the Raft log to keep the FSM of every node identical. A failed purge is retried on the next run. Runs are
+// scheduled using the FSM timer, see SetClock. It returns immediately; the pending run is canceled once the context
+// is done.
+func (s *ShardReplicationFSM) StartAutoPurge(ctx context.Context, purger types.OpPurger, interval, olderThan time.Duration) {
+	s.opsLock.RLock()
+	timer := s.timer
+	s.opsLock.RUnlock()
+
+	var lock sync.Mutex
+	var pending *time.Timer
+	var schedule func()
+	schedule = func() {
+		lock.Lock()
+		defer lock.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		pending = timer.AfterFunc(interval, func() {
+			if ctx.Err() != nil {
+				return
+			}
+			if ids := s.TerminalOpsOlderThan(olderThan); len(ids) > 0 {
+				_ = purger.ReplicationPurgeOps(ids)
+			}
+			schedule()
+		})
+	}
+	schedule()
+
+	context.AfterFunc(ctx, func() {
+		lock.Lock()
+		defer lock.Unlock()
+		if pending != nil {
+			pending.Stop()
+		}
+	})
+}
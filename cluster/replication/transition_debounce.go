@@ -0,0 +1,56 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package replication
+
+import (
+	"sync"
+	"time"
+
+	"github.com/weaviate/weaviate/cluster/proto/api"
+)
+
+// debouncedTransition is a transition of an op waiting for the end of its debounce window.
+type debouncedTransition struct {
+	from api.ShardReplicationState
+	to   api.ShardReplicationState
+}
+
+// DebounceTransitions wraps a transition observer so that the transitions of the same op happening within window
+// are coalesced into a single notification, for observers preferring coarse updates.
+//
+// The first transition of an op starts its window. When the window elapses, the observer is notified once with the
+// state the op was in before the window and the latest state it reached within the window. Notifications are
+// delivered from the goroutine the timer runs fn on, rather than synchronously with the transition.
+func DebounceTransitions(window time.Duration, timer Timer, observer TransitionObserver) TransitionObserver {
+	var mu sync.Mutex
+	pending := make(map[uint64]*debouncedTransition)
+
+	return func(id uint64, from, to api.ShardReplicationState) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if transition, ok := pending[id]; ok {
+			transition.to = to
+			return
+		}
+
+		pending[id] = &debouncedTransition{from: from, to: to}
+		timer.AfterFunc(window, func() {
+			mu.Lock()
+			transition := pending[id]
+			delete(pending, id)
+			mu.Unlock()
+
+			observer(id, transition.from, transition.to)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package types
+
+// ClusterLoadProvider reports the aggregate replication load of the cluster, allowing each node's replication
+// engine to throttle itself when the cluster is globally saturated.
+type ClusterLoadProvider interface {
+	// ClusterInFlightOps returns the total number of replication operations currently in flight across all nodes.
+	ClusterInFlightOps() int
+}
@@ -0,0 +1,19 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package types
+
+// FlagProvider reports feature flags set by an external control plane, allowing replication to be toggled centrally
+// across the cluster without calling each node.
+type FlagProvider interface {
+	// ReplicationEnabled reports whether replication is currently enabled.
+	ReplicationEnabled() bool
+}
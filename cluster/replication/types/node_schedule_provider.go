@@ -0,0 +1,22 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package types
+
+import "time"
+
+// NodeScheduleProvider reports the maintenance windows of the nodes, allowing the replication consumer to defer
+// operations targeting a node under maintenance until the window ends rather than failing them.
+type NodeScheduleProvider interface {
+	// MaintenanceWindow reports whether the given node is currently under maintenance and, if so, when the
+	// maintenance window ends. A zero end time means the end of the window is not known yet.
+	MaintenanceWindow(node string) (end time.Time, inMaintenance bool)
+}
@@ -11,10 +11,64 @@
 
 package types
 
-import "context"
+import (
+	"context"
+	"crypto/tls"
+)
 
 // ReplicaCopier see cluster/replication/copier.Copier
 type ReplicaCopier interface {
 	// CopyReplica see cluster/replication/copier.Copier.CopyReplica
 	CopyReplica(ctx context.Context, sourceNode string, sourceCollection string, sourceShard string) error
 }
+
+// SizedReplicaCopier is implemented by replica copiers able to report the amount of data copied.
+type SizedReplicaCopier interface {
+	// CopyReplicaWithSize behaves like CopyReplica and additionally returns the number of bytes copied.
+	// On failure, the returned number of bytes reports the progress made before failing.
+	CopyReplicaWithSize(ctx context.Context, sourceNode string, sourceCollection string, sourceShard string) (int64, error)
+}
+
+// ObjectCountingReplicaCopier is implemented by replica copiers able to count the objects held by a shard replica,
+// allowing to verify a copy by comparing the object counts of the source and target replicas.
+type ObjectCountingReplicaCopier interface {
+	// CountObjects returns the number of objects held by the replica of the given shard on the given node.
+	CountObjects(ctx context.Context, node string, collection string, shard string) (int64, error)
+}
+
+// BatchReplicaCopier is implemented by replica copiers able to copy a replica incrementally, in batches of objects
+// each committed to the target replica before copying the next one.
+type BatchReplicaCopier interface {
+	// CopyReplicaBatch copies the batch-th batch of objects of the source replica, counting from zero, and commits it
+	// to the local replica. It reports whether the batch was the last one of the replica.
+	CopyReplicaBatch(ctx context.Context, sourceNode string, sourceCollection string, sourceShard string, batch int) (last bool, err error)
+}
+
+// ChecksummingReplicaCopier is implemented by replica copiers able to compute a checksum of the content of a shard
+// replica, allowing to verify that two replicas hold the same objects.
+type ChecksummingReplicaCopier interface {
+	// ReplicaChecksum returns a checksum of the objects held by the replica of the given shard on the given node.
+	// Replicas holding the same objects have the same checksum.
+	ReplicaChecksum(ctx context.Context, node string, collection string, shard string) (string, error)
+}
+
+// EncryptedReplicaCopier is implemented by replica copiers able to copy a replica over an encrypted transport.
+type EncryptedReplicaCopier interface {
+	// CopyReplicaEncrypted behaves like CopyReplica, transferring the replica data over a transport encrypted using
+	// the given TLS configuration.
+	CopyReplicaEncrypted(ctx context.Context, sourceNode string, sourceCollection string, sourceShard string, tlsConfig *tls.Config) error
+}
+
+// StagedReplicaCopier is implemented by replica copiers able to copy a replica into a staging area of the local node,
+// only made the live replica by an atomic swap once the copy is complete, so that a partially copied replica is never
+// served. While a replica is staged, counting the objects of the local replica of the shard or computing its checksum
+// reads the staged replica, so that it can be verified before being promoted.
+type StagedReplicaCopier interface {
+	// StageReplica copies the source replica into the staging area of the local node, replacing any replica already
+	// staged for the shard.
+	StageReplica(ctx context.Context, sourceNode string, sourceCollection string, sourceShard string) error
+	// PromoteStagedReplica atomically swaps the staged replica of the shard in as the live local replica.
+	PromoteStagedReplica(ctx context.Context, collection string, shard string) error
+	// DiscardStagedReplica removes the staged replica of the shard, if any.
+	DiscardStagedReplica(ctx context.Context, collection string, shard string) error
+}
@@ -13,11 +13,61 @@ package types
 
 import (
 	"context"
+	"errors"
 
 	"github.com/weaviate/weaviate/cluster/proto/api"
 )
 
+// ErrShardNotFound is wrapped by the error returned by ShardingStateReader.ShardReplicas when the shard, or its
+// collection, does not exist in the sharding state, e.g. because it was deleted.
+var ErrShardNotFound = errors.New("shard not found")
+
 type FSMUpdater interface {
 	AddReplicaToShard(context.Context, string, string, string) (uint64, error)
 	ReplicationUpdateReplicaOpStatus(id uint64, state api.ShardReplicationState) error
 }
+
+// ShardingStateReader is optionally implemented by FSM updaters able to read the live sharding state, allowing the
+// consumer to verify that an operation is still needed before starting it.
+type ShardingStateReader interface {
+	// ShardReplicas returns the nodes currently holding a replica of the given shard, or an error wrapping
+	// ErrShardNotFound if the shard does not exist.
+	ShardReplicas(collection string, shard string) ([]string, error)
+}
+
+// OpCheckpointStore is optionally implemented by FSM updaters able to persist the progress of a batched replica copy,
+// allowing the copy to resume from the last committed batch after a failure or a restart.
+type OpCheckpointStore interface {
+	// ReplicationStoreOpCheckpoint records that the copy of the op committed the given number of object batches.
+	ReplicationStoreOpCheckpoint(id uint64, committedBatches int) error
+}
+
+// OpPurger is optionally implemented by FSM updaters able to purge terminal ops through the Raft log.
+type OpPurger interface {
+	// ReplicationPurgeOps deletes the given READY or ABORTED ops from the FSM of every node.
+	ReplicationPurgeOps(ids []uint64) error
+}
+
+// OpStateForcer is optionally implemented by FSM updaters able to force the state of an op through the Raft log,
+// bypassing the transition validation. It is not used by the consumer, only by ShardReplicationFSM.ForceSetState for
+// operators recovering ops left in a wrong state by a bug.
+type OpStateForcer interface {
+	// ReplicationForceReplicaOpState sets the state of the op on every node, bypassing the transition guards, and
+	// records the reason it was forced.
+	ReplicationForceReplicaOpState(id uint64, state api.ShardReplicationState, reason string) error
+}
+
+// ReplicaQuorumWaiter is optionally implemented by FSM updaters able to tell when a quorum of the replicas of a shard
+// acknowledged a new replica, allowing the consumer to only mark an operation READY once the new replica is durable.
+type ReplicaQuorumWaiter interface {
+	// WaitForReplicaQuorum blocks until a quorum of the replicas of the given shard acknowledged the replica held by
+	// the given node, returning an error if it is not acknowledged before the context is done.
+	WaitForReplicaQuorum(ctx context.Context, collection, shard, node string) error
+}
+
+// LeaderPinger is optionally implemented by FSM updaters able to cheaply check their connectivity to the cluster
+// leader, allowing to tell replication stalls caused by leader connectivity apart from copy problems.
+type LeaderPinger interface {
+	// Ping returns an error if the leader cannot currently be reached.
+	Ping(ctx context.Context) error
+}
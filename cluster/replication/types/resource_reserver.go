@@ -0,0 +1,22 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package types
+
+import "context"
+
+// ResourceReserver reserves the resources a replica copy is estimated to need on its target node, e.g. disk space,
+// before the replication operation is queued, so that operations which can never run are rejected upfront.
+type ResourceReserver interface {
+	// ReserveReplica reserves the resources needed by a copy of the given shard on the given target node, returning
+	// an error if they are insufficient. The returned function releases the reservation.
+	ReserveReplica(ctx context.Context, node, collection, shard string) (release func(), err error)
+}
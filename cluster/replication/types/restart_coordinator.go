@@ -0,0 +1,22 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package types
+
+import "context"
+
+// RestartCoordinator limits the number of replication engines restarting at once across the cluster, e.g. using a
+// distributed semaphore, so that the engines of many nodes restarting simultaneously do not overwhelm the leader.
+type RestartCoordinator interface {
+	// AcquireRestart blocks until the replication engine of the given node is allowed to restart, or until the
+	// context is done. The returned function releases the restart slot once the engine restarted.
+	AcquireRestart(ctx context.Context, node string) (release func(), err error)
+}
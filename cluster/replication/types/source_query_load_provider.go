@@ -0,0 +1,19 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package types
+
+// SourceQueryLoadProvider reports the query load served by shard replicas, allowing the replication consumer to delay
+// copies reading from a replica busy serving queries, which would otherwise degrade the query latency.
+type SourceQueryLoadProvider interface {
+	// ShardQueryLoad returns the current query load, e.g. in queries per second, of the given shard replica.
+	ShardQueryLoad(node, collection, shard string) float64
+}
@@ -14,6 +14,7 @@ package replication
 import (
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/weaviate/weaviate/cluster/proto/api"
 	"github.com/weaviate/weaviate/cluster/schema"
@@ -25,6 +26,7 @@ var (
 	ErrClassNotFound                = errors.New("class not found")
 	ErrShardNotFound                = errors.New("shard not found")
 	ErrReplicationOperationNotFound = errors.New("replication operation not found")
+	ErrNotEnoughReadyReplicas       = errors.New("not enough ready replicas")
 )
 
 // ValidateReplicationReplicateShard validates that c is valid given the current state of the schema read using schemaReader
@@ -59,3 +61,35 @@ func ValidateReplicationReplicateShard(schemaReader schema.SchemaReader, c *api.
 	}
 	return nil
 }
+
+// ValidateReplicationDeleteReplica validates that the replica of the shard on node can be removed given the current
+// state of the schema read using schemaReader and the replication operations tracked by fsm. Once the replica is
+// removed, the shard must keep at least minReadyReplicas READY replicas, otherwise the removal is rejected and the
+// returned error wraps ErrNotEnoughReadyReplicas. Replicas whose operation did not complete yet are not READY.
+// A minReadyReplicas lower than or equal to zero disables the check of the READY replicas.
+func ValidateReplicationDeleteReplica(schemaReader schema.SchemaReader, fsm *ShardReplicationFSM, minReadyReplicas int, node, collection, shard string) error {
+	classInfo := schemaReader.ClassInfo(collection)
+	if !classInfo.Exists {
+		return fmt.Errorf("collection %s does not exists: %w", collection, ErrClassNotFound)
+	}
+
+	nodes, err := schemaReader.ShardReplicas(collection, shard)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(nodes, node) {
+		return fmt.Errorf("could not find shard %s for collection %s on node %s: %w", shard, collection, node, ErrNodeNotFound)
+	}
+
+	var ready int
+	for _, n := range nodes {
+		if n != node && fsm.isReplicaReady(n, collection, shard) {
+			ready++
+		}
+	}
+	if ready < minReadyReplicas {
+		return fmt.Errorf("shard %s of collection %s would be left with %d ready replicas out of the %d required: %w",
+			shard, collection, ready, minReadyReplicas, ErrNotEnoughReadyReplicas)
+	}
+	return nil
+}
@@ -329,6 +329,24 @@ func (s *SchemaManager) AddReplicaToShard(cmd *command.ApplyRequest, schemaOnly
 	)
 }
 
+// DeleteReplicaFromShard removes the replica from the sharding state of the shard. The data of the replica is left
+// on its node.
+func (s *SchemaManager) DeleteReplicaFromShard(cmd *command.ApplyRequest, schemaOnly bool) error {
+	req := command.DeleteReplicaFromShardRequest{}
+	if err := json.Unmarshal(cmd.SubCommand, &req); err != nil {
+		return fmt.Errorf("%w: %w", ErrBadRequest, err)
+	}
+
+	return s.apply(
+		applyOp{
+			op:           cmd.GetType().String(),
+			updateSchema: func() error { return s.schema.deleteReplicaFromShard(cmd.Class, cmd.Version, req.Shard, req.Replica) },
+			updateStore:  func() error { return nil },
+			schemaOnly:   schemaOnly,
+		},
+	)
+}
+
 func (s *SchemaManager) AddTenants(cmd *command.ApplyRequest, schemaOnly bool) error {
 	req := &command.AddTenantsRequest{}
 	if err := gproto.Unmarshal(cmd.SubCommand, req); err != nil {
@@ -84,7 +84,7 @@ func New(cfg Config, authZController authorization.Controller, snapshotter fsm.S
 		cfg.ReplicaCopier,
 		realTimeProvider,
 		cfg.NodeSelector.LocalName(),
-		&backoff.StopBackOff{},
+		func() backoff.BackOff { return &backoff.StopBackOff{} },
 		replicationOperationTimeout,
 		replicationEngineMaxWorkers,
 	)
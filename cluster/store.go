@@ -161,6 +161,11 @@ type Config struct {
 
 	// ReplicaCopier copies shard replicas between nodes
 	ReplicaCopier replicationTypes.ReplicaCopier
+	// ReplicationMinReadyReplicas is the minimum number of READY replicas a shard must keep when one of its replicas
+	// is removed, the removal being rejected otherwise. A value lower than or equal to zero disables the check.
+	ReplicationMinReadyReplicas int
+	// ReplicationMinReadyReplicasFn is a way to get the overridden value of ReplicationMinReadyReplicas.
+	ReplicationMinReadyReplicasFn func() *int
 
 	// DistributedTasks is the configuration for the distributed task manager.
 	DistributedTasks config.DistributedTasksConfig
@@ -243,6 +243,21 @@ func (st *Store) Apply(l *raft.Log) interface{} {
 		f = func() {
 			ret.Error = st.replicationManager.UpdateReplicateOpState(&cmd)
 		}
+	case api.ApplyRequest_TYPE_REPLICATION_REPLICATE_PURGE:
+		f = func() {
+			ret.Error = st.replicationManager.PurgeReplicationOps(&cmd)
+		}
+	case api.ApplyRequest_TYPE_REPLICATION_REPLICATE_FORCE_STATE:
+		f = func() {
+			ret.Error = st.replicationManager.ForceReplicateOpState(&cmd)
+		}
+	case api.ApplyRequest_TYPE_REPLICATION_REPLICA_DELETE:
+		f = func() {
+			if ret.Error = st.replicationManager.ValidateDeleteReplica(&cmd); ret.Error != nil {
+				return
+			}
+			ret.Error = st.schemaManager.DeleteReplicaFromShard(&cmd, schemaOnly)
+		}
 	case api.ApplyRequest_TYPE_DISTRIBUTED_TASK_ADD:
 		f = func() {
 			ret.Error = st.distributedTasksManager.AddTask(&cmd, l.Index)
@@ -29,6 +29,7 @@ import (
 	"google.golang.org/protobuf/reflect/protoreflect"
 
 	cmd "github.com/weaviate/weaviate/cluster/proto/api"
+	"github.com/weaviate/weaviate/cluster/replication"
 	"github.com/weaviate/weaviate/cluster/schema"
 	"github.com/weaviate/weaviate/entities/models"
 	"github.com/weaviate/weaviate/usecases/cluster/mocks"
@@ -611,6 +612,98 @@ func TestStoreApply(t *testing.T) {
 				})
 			},
 		},
+		{
+			name: "DeleteReplicaFromShard/Success",
+			req: raft.Log{Data: cmdAsBytes("C1", cmd.ApplyRequest_TYPE_REPLICATION_REPLICA_DELETE,
+				cmd.DeleteReplicaFromShardRequest{Class: "C1", Shard: "T1", Replica: "THIS", MinReadyReplicas: 1}, nil)},
+			resp: Response{Error: nil},
+			doBefore: func(m *MockStore) {
+				m.parser.On("ParseClass", mock.Anything).Return(nil)
+				m.indexer.On("TriggerSchemaUpdateCallbacks").Return()
+				m.indexer.On("AddClass", mock.Anything).Return(nil)
+				m.store.Apply(&raft.Log{
+					Data: cmdAsBytes("C1", cmd.ApplyRequest_TYPE_ADD_CLASS, cmd.AddClassRequest{Class: cls, State: ss}, nil),
+				})
+				m.store.Apply(&raft.Log{
+					Data: cmdAsBytes("C1", cmd.ApplyRequest_TYPE_ADD_REPLICA_TO_SHARD, cmd.AddReplicaToShardRequest{Class: "C1", Shard: "T1", Replica: "Node-2"}, nil),
+				})
+			},
+			doAfter: func(ms *MockStore) error {
+				replicas, err := ms.store.SchemaReader().ShardReplicas("C1", "T1")
+				if err != nil {
+					return err
+				}
+				if !reflect.DeepEqual(replicas, []string{"Node-2"}) {
+					return fmt.Errorf("replicas for coll C1 shard T1 got=%v want=[\"Node-2\"]", replicas)
+				}
+				return nil
+			},
+		},
+		{
+			name: "DeleteReplicaFromShard/FailReplicaNotReady",
+			req: raft.Log{Data: cmdAsBytes("C1", cmd.ApplyRequest_TYPE_REPLICATION_REPLICA_DELETE,
+				cmd.DeleteReplicaFromShardRequest{Class: "C1", Shard: "T1", Replica: "THIS", MinReadyReplicas: 1}, nil)},
+			resp: Response{Error: replication.ErrNotEnoughReadyReplicas},
+			doBefore: func(m *MockStore) {
+				m.parser.On("ParseClass", mock.Anything).Return(nil)
+				m.indexer.On("TriggerSchemaUpdateCallbacks").Return()
+				m.indexer.On("AddClass", mock.Anything).Return(nil)
+				m.store.Apply(&raft.Log{
+					Data: cmdAsBytes("C1", cmd.ApplyRequest_TYPE_ADD_CLASS, cmd.AddClassRequest{Class: cls, State: ss}, nil),
+				})
+				// The replica on Node-2 is added by a replication op which is not READY yet
+				m.store.Apply(&raft.Log{
+					Data: cmdAsBytes("C1", cmd.ApplyRequest_TYPE_REPLICATION_REPLICATE, cmd.ReplicationReplicateShardRequest{
+						SourceCollection: "C1", SourceShard: "T1", SourceNode: "THIS", TargetNode: "Node-2",
+					}, nil),
+				})
+				m.store.Apply(&raft.Log{
+					Data: cmdAsBytes("C1", cmd.ApplyRequest_TYPE_ADD_REPLICA_TO_SHARD, cmd.AddReplicaToShardRequest{Class: "C1", Shard: "T1", Replica: "Node-2"}, nil),
+				})
+			},
+			doAfter: func(ms *MockStore) error {
+				replicas, err := ms.store.SchemaReader().ShardReplicas("C1", "T1")
+				if err != nil {
+					return err
+				}
+				if len(replicas) != 2 {
+					return fmt.Errorf("replicas for coll C1 shard T1 should not have been removed got=%v", replicas)
+				}
+				return nil
+			},
+		},
+		{
+			// Both removals pass the check of the proposer but the second one is rejected once the first one applied
+			name: "DeleteReplicaFromShard/FailConcurrentRemoval",
+			req: raft.Log{Data: cmdAsBytes("C1", cmd.ApplyRequest_TYPE_REPLICATION_REPLICA_DELETE,
+				cmd.DeleteReplicaFromShardRequest{Class: "C1", Shard: "T1", Replica: "Node-2", MinReadyReplicas: 1}, nil)},
+			resp: Response{Error: replication.ErrNotEnoughReadyReplicas},
+			doBefore: func(m *MockStore) {
+				m.parser.On("ParseClass", mock.Anything).Return(nil)
+				m.indexer.On("TriggerSchemaUpdateCallbacks").Return()
+				m.indexer.On("AddClass", mock.Anything).Return(nil)
+				m.store.Apply(&raft.Log{
+					Data: cmdAsBytes("C1", cmd.ApplyRequest_TYPE_ADD_CLASS, cmd.AddClassRequest{Class: cls, State: ss}, nil),
+				})
+				m.store.Apply(&raft.Log{
+					Data: cmdAsBytes("C1", cmd.ApplyRequest_TYPE_ADD_REPLICA_TO_SHARD, cmd.AddReplicaToShardRequest{Class: "C1", Shard: "T1", Replica: "Node-2"}, nil),
+				})
+				m.store.Apply(&raft.Log{
+					Data: cmdAsBytes("C1", cmd.ApplyRequest_TYPE_REPLICATION_REPLICA_DELETE,
+						cmd.DeleteReplicaFromShardRequest{Class: "C1", Shard: "T1", Replica: "THIS", MinReadyReplicas: 1}, nil),
+				})
+			},
+			doAfter: func(ms *MockStore) error {
+				replicas, err := ms.store.SchemaReader().ShardReplicas("C1", "T1")
+				if err != nil {
+					return err
+				}
+				if !reflect.DeepEqual(replicas, []string{"Node-2"}) {
+					return fmt.Errorf("replicas for coll C1 shard T1 got=%v want=[\"Node-2\"]", replicas)
+				}
+				return nil
+			},
+		},
 	}
 
 	for _, tc := range tests {
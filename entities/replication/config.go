@@ -23,5 +23,11 @@ type GlobalConfig struct {
 	// forcing them to have replicated classes.
 	MinimumFactor int `json:"minimum_factor" yaml:"minimum_factor"`
 
+	// MinReadyReplicas is the minimum number of READY replicas a shard must keep
+	// when one of its replicas is removed, the removal being rejected otherwise.
+	MinReadyReplicas int `json:"min_ready_replicas" yaml:"min_ready_replicas"`
+	// MinReadyReplicasFn is way to get overridden value for MinReadyReplicas.
+	MinReadyReplicasFn func() *int `json:"-" yaml:"-"`
+
 	DeletionStrategy string `json:"deletion_strategy" yaml:"deletion_strategy"`
 }
@@ -578,6 +578,14 @@ func FromEnv(config *Config) error {
 		return err
 	}
 
+	if err := parseNonNegativeInt(
+		"REPLICATION_MIN_READY_REPLICAS",
+		func(val int) { config.Replication.MinReadyReplicas = val },
+		DefaultReplicationMinReadyReplicas,
+	); err != nil {
+		return err
+	}
+
 	config.Replication.AsyncReplicationDisabled = entcfg.Enabled(os.Getenv("ASYNC_REPLICATION_DISABLED"))
 
 	if v := os.Getenv("REPLICATION_FORCE_DELETION_STRATEGY"); v != "" {
@@ -964,6 +972,7 @@ const (
 	DefaultGRPCPort                            = 50051
 	DefaultGRPCMaxMsgSize                      = 104858000 // 100 * 1024 * 1024 + 400
 	DefaultMinimumReplicationFactor            = 1
+	DefaultReplicationMinReadyReplicas         = 1
 	DefaultMaximumAllowedCollectionsCount      = -1 // unlimited
 )
 
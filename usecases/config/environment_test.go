@@ -639,6 +639,36 @@ func TestEnvironmentMinimumReplicationFactor(t *testing.T) {
 	}
 }
 
+func TestEnvironmentReplicationMinReadyReplicas(t *testing.T) {
+	factors := []struct {
+		name        string
+		value       []string
+		expected    int
+		expectedErr bool
+	}{
+		{"Valid", []string{"2"}, 2, false},
+		{"not given", []string{}, DefaultReplicationMinReadyReplicas, false},
+		{"zero disables the check", []string{"0"}, 0, false},
+		{"negative", []string{"-1"}, -1, true},
+		{"not parsable", []string{"I'm not a number"}, -1, true},
+	}
+	for _, tt := range factors {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.value) == 1 {
+				t.Setenv("REPLICATION_MIN_READY_REPLICAS", tt.value[0])
+			}
+			conf := Config{}
+			err := FromEnv(&conf)
+
+			if tt.expectedErr {
+				require.NotNil(t, err)
+			} else {
+				require.Equal(t, tt.expected, conf.Replication.MinReadyReplicas)
+			}
+		})
+	}
+}
+
 func TestEnvironmentQueryDefaults_Limit(t *testing.T) {
 	factors := []struct {
 		name     string
@@ -31,6 +31,8 @@ type WeaviateRuntimeConfig struct {
 
 	AsyncReplicationDisabled *bool `json:"async_replication_disabled" yaml:"async_replication_disabled"`
 
+	ReplicationMinReadyReplicas *int `json:"replication_min_ready_replicas" yaml:"replication_min_ready_replicas"`
+
 	// config manager that keep the runtime config up to date
 	cm ConfigManager
 }
@@ -62,6 +64,13 @@ func (rc *WeaviateRuntimeConfig) GetAsyncReplicationDisabled() *bool {
 	return nil
 }
 
+func (rc *WeaviateRuntimeConfig) GetReplicationMinReadyReplicas() *int {
+	if cfg, err := rc.cm.Config(); err == nil {
+		return cfg.ReplicationMinReadyReplicas
+	}
+	return nil
+}
+
 func ParseYaml(buf []byte) (*WeaviateRuntimeConfig, error) {
 	var conf WeaviateRuntimeConfig
 
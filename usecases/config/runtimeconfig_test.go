@@ -59,6 +59,21 @@ func TestRuntimeConfig(t *testing.T) {
 		val := rm.GetAsyncReplicationDisabled()
 		require.Nil(t, val)
 	})
+
+	t.Run("setting explicitly value for replication min ready replicas", func(t *testing.T) {
+		n := 2
+
+		cm.c.ReplicationMinReadyReplicas = &n
+		val := rm.GetReplicationMinReadyReplicas()
+		require.NotNil(t, val)
+		require.Equal(t, 2, *val)
+	})
+
+	t.Run("replication min ready replicas not being set should return nil", func(t *testing.T) {
+		cm.c.ReplicationMinReadyReplicas = nil
+		val := rm.GetReplicationMinReadyReplicas()
+		require.Nil(t, val)
+	})
 }
 
 func TestParseYaml(t *testing.T) {
@@ -97,6 +97,31 @@ func (p *Physical) AddReplica(replica string) error {
 	return nil
 }
 
+func (s *State) DeleteReplicaFromShard(shard string, replica string) error {
+	phys, ok := s.Physical[shard]
+	if !ok {
+		return fmt.Errorf("could not find shard %s", shard)
+	}
+	if err := phys.DeleteReplica(replica); err != nil {
+		return err
+	}
+	s.Physical[shard] = phys
+	return nil
+}
+
+// DeleteReplica removes the replica from the replica set, refusing to remove the last replica of the shard.
+func (p *Physical) DeleteReplica(replica string) error {
+	i := slices.Index(p.BelongsToNodes, replica)
+	if i < 0 {
+		return fmt.Errorf("replica %s does not exist", replica)
+	}
+	if len(p.BelongsToNodes) == 1 {
+		return fmt.Errorf("replica %s is the last replica of the shard", replica)
+	}
+	p.BelongsToNodes = slices.Delete(slices.Clone(p.BelongsToNodes), i, i+1)
+	return nil
+}
+
 // AdjustReplicas shrinks or extends the replica set (p.BelongsToNodes)
 func (p *Physical) AdjustReplicas(count int, nodes cluster.NodeSelector) error {
 	if count < 0 {
@@ -298,6 +298,21 @@ func TestAddPartition(t *testing.T) {
 	require.Equal(t, want, s.Physical)
 }
 
+func TestDeleteReplicaFromShard(t *testing.T) {
+	s := &State{Physical: map[string]Physical{
+		"A": {Name: "A", BelongsToNodes: []string{"N", "M"}},
+	}}
+
+	require.Error(t, s.DeleteReplicaFromShard("B", "N"), "unknown shard")
+	require.Error(t, s.DeleteReplicaFromShard("A", "O"), "unknown replica")
+
+	require.NoError(t, s.DeleteReplicaFromShard("A", "N"))
+	require.Equal(t, []string{"M"}, s.Physical["A"].BelongsToNodes)
+
+	require.Error(t, s.DeleteReplicaFromShard("A", "M"), "last replica")
+	require.Equal(t, []string{"M"}, s.Physical["A"].BelongsToNodes)
+}
+
 func TestStateDeepCopy(t *testing.T) {
 	original := State{
 		IndexID: "original",